@@ -0,0 +1,328 @@
+// Package admin provides an optional HTTP handler exposing the loaded query sets
+// as a JSON API and an embedded single-page UI for browsing and searching queries.
+//
+// Example:
+//
+//	http.Handle("/sqlset/", http.StripPrefix("/sqlset", admin.NewHandler(sqlSet)))
+package admin
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/istovpets/sqlset"
+)
+
+//go:embed ui
+var uiFS embed.FS
+
+// Catalog is the interface the handler uses to read query sets.
+// *sqlset.SQLSet implements it.
+type Catalog interface {
+	sqlset.SQLSetsProvider
+	sqlset.SQLQueriesProvider
+}
+
+// Set is the JSON representation of a query set.
+type Set struct {
+	sqlset.QuerySetMeta
+
+	Queries []Query `json:"queries,omitempty"`
+}
+
+// Query is the JSON representation of a single query.
+type Query struct {
+	ID  string `json:"id"`
+	SQL string `json:"sql"`
+	// Source is the location of the query in the files, when the catalog implements Locator.
+	Source *sqlset.Source `json:"source,omitempty"`
+}
+
+// Locator is implemented by catalogs recording the source locations of queries.
+// *sqlset.SQLSet implements it.
+type Locator interface {
+	QuerySource(setID, queryID string) (sqlset.Source, error)
+}
+
+// Describer is implemented by catalogs describing their queries, which returns the source
+// of the template queries that Get rejects with sqlset.ErrTemplateQuery.
+// *sqlset.SQLSet implements it.
+type Describer interface {
+	Describe(setID, queryID string) (sqlset.Query, error)
+}
+
+// Searcher is implemented by catalogs supporting query body search.
+// *sqlset.SQLSet implements it.
+type Searcher interface {
+	Search(pattern string) []sqlset.SearchResult
+	SearchRegexp(re *regexp.Regexp) []sqlset.SearchResult
+}
+
+// StatsProvider is implemented by catalogs reporting their size.
+// *sqlset.SQLSet implements it.
+type StatsProvider interface {
+	Stats() sqlset.Stats
+}
+
+// Inventorier is implemented by catalogs listing their queries for operations tooling.
+// *sqlset.SQLSet implements it.
+type Inventorier interface {
+	Inventory() []sqlset.InventoryEntry
+}
+
+// ModTimer is implemented by catalogs recording the modification time of their files.
+// *sqlset.SQLSet implements it.
+type ModTimer interface {
+	ModTime() time.Time
+}
+
+type handler struct {
+	catalog Catalog
+}
+
+// NewHandler returns an http.Handler serving the UI at "/" and the JSON API at:
+//
+//   - GET /api/sets - metadata of all query sets,
+//   - GET /api/sets/{setID} - metadata and queries of a single set,
+//   - GET /api/search?q={pattern}[&regexp=1] - query body lines matching pattern,
+//     available when the catalog implements Searcher,
+//   - GET /api/stats - number of sets and queries and their estimated memory footprint,
+//     available when the catalog implements StatsProvider,
+//   - GET /api/inventory[?format=openmetrics] - key, hash, tags, size and deprecation
+//     of every query as JSON or in the OpenMetrics text format, available when
+//     the catalog implements Inventorier.
+//
+// When the catalog implements ModTimer, the sets and inventory responses carry
+// a Last-Modified header and If-Modified-Since requests are answered with 304 Not Modified.
+func NewHandler(catalog Catalog) http.Handler {
+	h := &handler{catalog: catalog}
+
+	ui, err := fs.Sub(uiFS, "ui")
+	if err != nil {
+		panic(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/sets", h.lastModified(h.listSets))
+	mux.HandleFunc("GET /api/sets/{setID}", h.lastModified(h.getSet))
+	mux.HandleFunc("GET /api/search", h.search)
+	mux.HandleFunc("GET /api/stats", h.stats)
+	mux.HandleFunc("GET /api/inventory", h.lastModified(h.inventory))
+	mux.Handle("GET /", http.FileServerFS(ui))
+
+	return mux
+}
+
+// lastModified serves the Last-Modified header of the catalog and the conditional requests.
+func (h *handler) lastModified(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if m, ok := h.catalog.(ModTimer); ok {
+			if modTime := m.ModTime(); !modTime.IsZero() {
+				since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+				if err == nil && !modTime.Truncate(time.Second).After(since) {
+					w.WriteHeader(http.StatusNotModified)
+
+					return
+				}
+
+				w.Header().Set("Last-Modified", modTime.UTC().Format(http.TimeFormat))
+			}
+		}
+
+		next(w, r)
+	}
+}
+
+func (h *handler) listSets(w http.ResponseWriter, _ *http.Request) {
+	metas := h.catalog.GetSetsMetas()
+	sort.Slice(metas, func(i, j int) bool {
+		return metas[i].ID < metas[j].ID
+	})
+
+	writeJSON(w, http.StatusOK, metas)
+}
+
+func (h *handler) getSet(w http.ResponseWriter, r *http.Request) {
+	setID := r.PathValue("setID")
+
+	set, err := h.buildSet(setID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, sqlset.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+
+		writeJSON(w, status, map[string]string{"error": err.Error()})
+
+		return
+	}
+
+	writeJSON(w, http.StatusOK, set)
+}
+
+func (h *handler) search(w http.ResponseWriter, r *http.Request) {
+	searcher, ok := h.catalog.(Searcher)
+	if !ok {
+		writeJSON(w, http.StatusNotImplemented, map[string]string{"error": "search is not supported"})
+
+		return
+	}
+
+	pattern := r.URL.Query().Get("q")
+	if pattern == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "q: " + sqlset.ErrArgumentEmpty.Error()})
+
+		return
+	}
+
+	var results []sqlset.SearchResult
+
+	if r.URL.Query().Get("regexp") != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+
+			return
+		}
+
+		results = searcher.SearchRegexp(re)
+	} else {
+		results = searcher.Search(pattern)
+	}
+
+	if results == nil {
+		results = []sqlset.SearchResult{}
+	}
+
+	writeJSON(w, http.StatusOK, results)
+}
+
+func (h *handler) stats(w http.ResponseWriter, _ *http.Request) {
+	provider, ok := h.catalog.(StatsProvider)
+	if !ok {
+		writeJSON(w, http.StatusNotImplemented, map[string]string{"error": "stats are not supported"})
+
+		return
+	}
+
+	writeJSON(w, http.StatusOK, provider.Stats())
+}
+
+func (h *handler) inventory(w http.ResponseWriter, r *http.Request) {
+	inventorier, ok := h.catalog.(Inventorier)
+	if !ok {
+		writeJSON(w, http.StatusNotImplemented, map[string]string{"error": "inventory is not supported"})
+
+		return
+	}
+
+	entries := inventorier.Inventory()
+
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "json":
+		if entries == nil {
+			entries = []sqlset.InventoryEntry{}
+		}
+
+		writeJSON(w, http.StatusOK, entries)
+	case "openmetrics":
+		w.Header().Set("Content-Type", "application/openmetrics-text; version=1.0.0; charset=utf-8")
+		writeOpenMetrics(w, entries)
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unknown format " + format})
+	}
+}
+
+// writeOpenMetrics writes the inventory as an info metric and a size gauge per query.
+func writeOpenMetrics(w io.Writer, entries []sqlset.InventoryEntry) {
+	var sb strings.Builder
+
+	sb.WriteString("# TYPE sqlset_query info\n")
+	sb.WriteString("# HELP sqlset_query Queries of the catalog.\n")
+
+	for _, e := range entries {
+		fmt.Fprintf(&sb, "sqlset_query_info{%s,hash=%q,tags=%q,deprecated=\"%t\"} 1\n",
+			keyLabels(e.Key), e.Hash, strings.Join(e.Tags, ","), e.Deprecated)
+	}
+
+	sb.WriteString("# TYPE sqlset_query_size_bytes gauge\n")
+	sb.WriteString("# UNIT sqlset_query_size_bytes bytes\n")
+	sb.WriteString("# HELP sqlset_query_size_bytes Size of the query body.\n")
+
+	for _, e := range entries {
+		fmt.Fprintf(&sb, "sqlset_query_size_bytes{%s} %d\n", keyLabels(e.Key), e.Size)
+	}
+
+	sb.WriteString("# EOF\n")
+
+	_, _ = io.WriteString(w, sb.String())
+}
+
+func keyLabels(key sqlset.QueryKey) string {
+	return fmt.Sprintf("set=%q,query=%q", key.SetID, key.QueryID)
+}
+
+func (h *handler) buildSet(setID string) (Set, error) {
+	ids, err := h.catalog.GetQueryIDs(setID)
+	if err != nil {
+		return Set{}, err
+	}
+
+	set := Set{Queries: make([]Query, 0, len(ids))}
+
+	for _, meta := range h.catalog.GetSetsMetas() {
+		if meta.ID == setID {
+			set.QuerySetMeta = meta
+			break
+		}
+	}
+
+	for _, id := range ids {
+		q, err := h.querySQL(setID, id)
+		if err != nil {
+			return Set{}, err
+		}
+
+		query := Query{ID: id, SQL: q}
+
+		if locator, ok := h.catalog.(Locator); ok {
+			if src, err := locator.QuerySource(setID, id); err == nil && src.File != "" {
+				query.Source = &src
+			}
+		}
+
+		set.Queries = append(set.Queries, query)
+	}
+
+	return set, nil
+}
+
+// querySQL returns the body of a query, the source of a template query if the catalog describes it.
+func (h *handler) querySQL(setID, queryID string) (string, error) {
+	q, err := h.catalog.Get(setID, queryID)
+
+	describer, ok := h.catalog.(Describer)
+	if !ok || !errors.Is(err, sqlset.ErrTemplateQuery) {
+		return q, err
+	}
+
+	described, err := describer.Describe(setID, queryID)
+
+	return described.SQL, err
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,226 @@
+package admin_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/istovpets/sqlset"
+	"github.com/istovpets/sqlset/admin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestHandler(t *testing.T) http.Handler {
+	t.Helper()
+
+	sqlSet, err := sqlset.New(fstest.MapFS{
+		"users.sql": &fstest.MapFile{Data: []byte(`--META
+{"name": "Users", "description": "User queries"}
+--end
+
+--SQL:GetUserByID
+SELECT id FROM users WHERE id = $1;
+--end`)},
+		"posts.sql": &fstest.MapFile{Data: []byte(`--SQL:GetPostByID
+SELECT id FROM posts WHERE id = $1;
+--end`)},
+	})
+	require.NoError(t, err)
+
+	return admin.NewHandler(sqlSet)
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	h := newTestHandler(t)
+
+	t.Run("list sets", func(t *testing.T) {
+		t.Parallel()
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/sets", nil))
+
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var metas []sqlset.QuerySetMeta
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &metas))
+		assert.Equal(t, []sqlset.QuerySetMeta{
+			{ID: "posts", Name: "posts"},
+			{ID: "users", Name: "Users", Description: "User queries"},
+		}, metas)
+	})
+
+	t.Run("get set", func(t *testing.T) {
+		t.Parallel()
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/sets/users", nil))
+
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var set admin.Set
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &set))
+		assert.Equal(t, "Users", set.Name)
+		assert.Equal(t, []admin.Query{{
+			ID:     "GetUserByID",
+			SQL:    "SELECT id FROM users WHERE id = $1;",
+			Source: &sqlset.Source{File: "users.sql", Line: 5, EndLine: 7},
+		}}, set.Queries)
+	})
+
+	t.Run("get unknown set", func(t *testing.T) {
+		t.Parallel()
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/sets/unknown", nil))
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("search", func(t *testing.T) {
+		t.Parallel()
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/search?q=from+posts", nil))
+
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var results []sqlset.SearchResult
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &results))
+		assert.Equal(t, []sqlset.SearchResult{{
+			Key:  sqlset.QueryKey{SetID: "posts", QueryID: "GetPostByID"},
+			Line: 1,
+			Text: "SELECT id FROM posts WHERE id = $1;",
+		}}, results)
+	})
+
+	t.Run("search invalid regexp", func(t *testing.T) {
+		t.Parallel()
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/search?regexp=1&q=(", nil))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("stats", func(t *testing.T) {
+		t.Parallel()
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/stats", nil))
+
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var stats sqlset.Stats
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &stats))
+		assert.Equal(t, 2, stats.Sets)
+		assert.Equal(t, 2, stats.Queries)
+		assert.Len(t, stats.Footprint, 2)
+		assert.Equal(t, stats.Footprint["posts"]+stats.Footprint["users"], stats.Bytes)
+	})
+
+	t.Run("inventory", func(t *testing.T) {
+		t.Parallel()
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/inventory", nil))
+
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var entries []sqlset.InventoryEntry
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &entries))
+		require.Len(t, entries, 2)
+		assert.Equal(t, sqlset.QueryKey{SetID: "posts", QueryID: "GetPostByID"}, entries[0].Key)
+		assert.Equal(t, len("SELECT id FROM posts WHERE id = $1;"), entries[0].Size)
+		assert.Len(t, entries[0].Hash, 64)
+	})
+
+	t.Run("inventory openmetrics", func(t *testing.T) {
+		t.Parallel()
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/inventory?format=openmetrics", nil))
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Header().Get("Content-Type"), "application/openmetrics-text")
+
+		body := rec.Body.String()
+		assert.Contains(t, body, `sqlset_query_info{set="users",query="GetUserByID",hash="`)
+		assert.Contains(t, body, `tags="",deprecated="false"} 1`)
+		assert.Contains(t, body, `sqlset_query_size_bytes{set="posts",query="GetPostByID"} 35`)
+		assert.True(t, strings.HasSuffix(body, "# EOF\n"))
+	})
+
+	t.Run("inventory unknown format", func(t *testing.T) {
+		t.Parallel()
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/inventory?format=xml", nil))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("ui", func(t *testing.T) {
+		t.Parallel()
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "SQLSet catalog")
+	})
+}
+
+func TestHandler_TemplateSet(t *testing.T) {
+	t.Parallel()
+
+	sqlSet, err := sqlset.New(fstest.MapFS{
+		"reports.sql.tmpl": &fstest.MapFile{Data: []byte(`--SQL:ListOrders
+SELECT * FROM orders{{if .Archived}} WHERE archived{{end}};
+--end`)},
+	})
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	admin.NewHandler(sqlSet).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/sets/reports", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var set admin.Set
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &set))
+	require.Len(t, set.Queries, 1)
+	assert.Equal(t, "SELECT * FROM orders{{if .Archived}} WHERE archived{{end}};", set.Queries[0].SQL)
+}
+
+func TestHandler_LastModified(t *testing.T) {
+	t.Parallel()
+
+	modTime := time.Date(2024, 5, 1, 12, 30, 15, 0, time.UTC)
+
+	sqlSet, err := sqlset.New(fstest.MapFS{
+		"users.sql": &fstest.MapFile{Data: []byte("--SQL:GetUser\nSELECT 1;\n--end"), ModTime: modTime},
+	})
+	require.NoError(t, err)
+
+	h := admin.NewHandler(sqlSet)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/sets", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "Wed, 01 May 2024 12:30:15 GMT", rec.Header().Get("Last-Modified"))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/sets/users", nil)
+	req.Header.Set("If-Modified-Since", "Wed, 01 May 2024 12:30:15 GMT")
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+}
@@ -0,0 +1,59 @@
+package sqlset
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// checkAliases returns an error if an alias of qs is a query ID or an alias of another query.
+func checkAliases(qs QuerySet) error {
+	ids := make([]string, 0, len(qs.queries))
+	for id := range qs.queries {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	seen := make(map[string]string)
+
+	for _, id := range ids {
+		for _, alias := range qs.queries[id].meta.Aliases {
+			if _, ok := qs.queries[alias]; ok {
+				return fmt.Errorf("alias %s of %s: %w", alias, id, ErrQueryExists)
+			}
+
+			if other, ok := seen[alias]; ok {
+				return fmt.Errorf("alias %s of %s and %s: %w", alias, other, id, ErrQueryExists)
+			}
+
+			seen[alias] = id
+		}
+	}
+
+	return nil
+}
+
+// findAlias returns the query declaring alias and its ID.
+func (qs *QuerySet) findAlias(alias string) (string, query, bool) {
+	for id, q := range qs.queries {
+		if slices.Contains(q.meta.Aliases, alias) {
+			return id, q, true
+		}
+	}
+
+	return "", query{}, false
+}
+
+// warnAlias reports the use of a deprecated alias to the warning handler, see WithWarningHandler.
+func (s *SQLSet) warnAlias(alias, queryID string, q query) {
+	if s.warnings == nil {
+		return
+	}
+
+	s.warnings(Warning{
+		File:    q.source.File,
+		Line:    q.source.Line,
+		Message: fmt.Sprintf("query %s is a deprecated alias of %s", alias, queryID),
+	})
+}
@@ -0,0 +1,186 @@
+// Package analysis provides static analysis of the queries stored in an SQLSet,
+// such as the tables each query depends on, for impact analysis before schema changes.
+//
+// Example:
+//
+//	idx, err := analysis.NewIndex(sqlSet)
+//	if err != nil {
+//		return err
+//	}
+//
+//	for _, key := range idx.QueriesUsingTable("orders") {
+//		fmt.Println(key)
+//	}
+package analysis
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/istovpets/sqlset"
+)
+
+// Catalog is the interface the index uses to read queries.
+// *sqlset.SQLSet implements it.
+type Catalog interface {
+	sqlset.SQLSetsProvider
+	sqlset.SQLQueriesProvider
+}
+
+// Describer is implemented by catalogs describing their queries, which returns the source
+// of the template queries that Get rejects with sqlset.ErrTemplateQuery.
+// *sqlset.SQLSet implements it.
+type Describer interface {
+	Describe(setID, queryID string) (sqlset.Query, error)
+}
+
+// querySQL returns the body of a query, the source of a template query if the catalog describes it.
+func querySQL(catalog Catalog, setID, queryID string) (string, error) {
+	q, err := catalog.Get(setID, queryID)
+
+	describer, ok := catalog.(Describer)
+	if !ok || !errors.Is(err, sqlset.ErrTemplateQuery) {
+		return q, err
+	}
+
+	described, err := describer.Describe(setID, queryID)
+
+	return described.SQL, err
+}
+
+// TableExtractor extracts the names of the tables referenced by an SQL statement.
+// Plug in a real SQL parser with WithExtractor when the best-effort tokenizer is not enough.
+type TableExtractor interface {
+	Tables(sql string) ([]string, error)
+}
+
+// TableExtractorFunc is an adapter to allow the use of ordinary functions as TableExtractor.
+type TableExtractorFunc func(sql string) ([]string, error)
+
+// Tables calls f(sql).
+func (f TableExtractorFunc) Tables(sql string) ([]string, error) {
+	return f(sql)
+}
+
+// Option configures an Index.
+type Option func(*Index)
+
+// WithExtractor replaces the default tokenizer based extractor.
+func WithExtractor(e TableExtractor) Option {
+	return func(idx *Index) {
+		idx.extractor = e
+	}
+}
+
+// Index holds the table dependencies of every query in a catalog and the reverse index.
+type Index struct {
+	extractor TableExtractor
+	deps      map[sqlset.QueryKey][]string
+	byTable   map[string][]sqlset.QueryKey
+}
+
+// NewIndex analyzes every query in the catalog and builds the dependency index.
+func NewIndex(catalog Catalog, opts ...Option) (*Index, error) {
+	idx := &Index{
+		extractor: TableExtractorFunc(func(sql string) ([]string, error) {
+			return ExtractTables(sql), nil
+		}),
+		deps:    make(map[sqlset.QueryKey][]string),
+		byTable: make(map[string][]sqlset.QueryKey),
+	}
+
+	for _, opt := range opts {
+		opt(idx)
+	}
+
+	for _, meta := range catalog.GetSetsMetas() {
+		ids, err := catalog.GetQueryIDs(meta.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, id := range ids {
+			key := sqlset.QueryKey{SetID: meta.ID, QueryID: id}
+
+			q, err := querySQL(catalog, meta.ID, id)
+			if err != nil {
+				return nil, err
+			}
+
+			tables, err := idx.extractor.Tables(q)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+
+			idx.deps[key] = tables
+
+			for _, table := range tables {
+				idx.byTable[table] = append(idx.byTable[table], key)
+			}
+		}
+	}
+
+	return idx, nil
+}
+
+// Dependencies returns the tables referenced by the query.
+// It returns nil if the query is unknown.
+func (idx *Index) Dependencies(setID, queryID string) []string {
+	return idx.deps[sqlset.QueryKey{SetID: setID, QueryID: queryID}]
+}
+
+// QueriesUsingTable returns the sorted keys of the queries referencing table.
+// An unqualified table name also matches schema-qualified references, e.g.
+// "orders" matches "public.orders".
+func (idx *Index) QueriesUsingTable(table string) []sqlset.QueryKey {
+	seen := map[sqlset.QueryKey]bool{}
+
+	var keys []sqlset.QueryKey
+
+	for name, refs := range idx.byTable {
+		if !matchTable(name, table) {
+			continue
+		}
+
+		for _, key := range refs {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+
+	return keys
+}
+
+// Tables returns the sorted names of all tables referenced by the catalog.
+func (idx *Index) Tables() []string {
+	tables := make([]string, 0, len(idx.byTable))
+	for table := range idx.byTable {
+		tables = append(tables, table)
+	}
+
+	sort.Strings(tables)
+
+	return tables
+}
+
+func matchTable(name, table string) bool {
+	if strings.EqualFold(name, table) {
+		return true
+	}
+
+	if strings.Contains(table, ".") {
+		return false
+	}
+
+	i := strings.LastIndex(name, ".")
+
+	return i >= 0 && strings.EqualFold(name[i+1:], table)
+}
@@ -0,0 +1,125 @@
+package analysis_test
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/istovpets/sqlset"
+	"github.com/istovpets/sqlset/analysis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractTables(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		sql      string
+		expected []string
+	}{
+		{
+			name:     "simple select",
+			sql:      "SELECT id, name FROM users WHERE id = $1;",
+			expected: []string{"users"},
+		},
+		{
+			name:     "joins and aliases",
+			sql:      "SELECT * FROM Users u JOIN orders AS o ON o.user_id = u.id LEFT JOIN public.items i ON i.order_id = o.id",
+			expected: []string{"orders", "public.items", "users"},
+		},
+		{
+			name:     "comma separated from list",
+			sql:      "SELECT * FROM users u, orders o WHERE o.user_id = u.id",
+			expected: []string{"orders", "users"},
+		},
+		{
+			name:     "insert select",
+			sql:      "INSERT INTO archive (id) SELECT id FROM orders",
+			expected: []string{"archive", "orders"},
+		},
+		{
+			name:     "update and delete",
+			sql:      "UPDATE users SET name = 'from x' WHERE id = 1; DELETE FROM sessions WHERE user_id = 1",
+			expected: []string{"sessions", "users"},
+		},
+		{
+			name:     "cte and subquery",
+			sql:      "WITH recent AS (SELECT * FROM orders) SELECT * FROM recent r JOIN (SELECT id FROM users) u ON true",
+			expected: []string{"orders", "users"},
+		},
+		{
+			name:     "function calls and comments",
+			sql:      "-- FROM commented\nSELECT EXTRACT(YEAR FROM created_at) FROM generate_series(1, 3), /* JOIN x */ \"Events\"",
+			expected: []string{"Events"},
+		},
+		{
+			name:     "create table",
+			sql:      "CREATE TABLE IF NOT EXISTS audit_log (id int)",
+			expected: []string{"audit_log"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, analysis.ExtractTables(test.sql))
+		})
+	}
+}
+
+func newTestSet(t *testing.T) *sqlset.SQLSet {
+	t.Helper()
+
+	sqlSet, err := sqlset.New(fstest.MapFS{
+		"users.sql": &fstest.MapFile{Data: []byte(`--SQL:GetUserOrders
+SELECT * FROM users u JOIN public.orders o ON o.user_id = u.id;
+--end
+
+--SQL:DeleteUser
+DELETE FROM users WHERE id = $1;
+--end`)},
+		"orders.sql": &fstest.MapFile{Data: []byte(`--SQL:CreateOrder
+INSERT INTO orders (user_id) VALUES ($1);
+--end`)},
+	})
+	require.NoError(t, err)
+
+	return sqlSet
+}
+
+func TestIndex(t *testing.T) {
+	t.Parallel()
+
+	idx, err := analysis.NewIndex(newTestSet(t))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"public.orders", "users"}, idx.Dependencies("users", "GetUserOrders"))
+	assert.Nil(t, idx.Dependencies("users", "unknown"))
+	assert.Equal(t, []string{"orders", "public.orders", "users"}, idx.Tables())
+
+	assert.Equal(t, []sqlset.QueryKey{
+		{SetID: "orders", QueryID: "CreateOrder"},
+		{SetID: "users", QueryID: "GetUserOrders"},
+	}, idx.QueriesUsingTable("orders"))
+	assert.Equal(t, []sqlset.QueryKey{
+		{SetID: "users", QueryID: "GetUserOrders"},
+	}, idx.QueriesUsingTable("public.orders"))
+	assert.Empty(t, idx.QueriesUsingTable("items"))
+}
+
+func TestIndex_WithExtractor(t *testing.T) {
+	t.Parallel()
+
+	errParse := errors.New("parse failed")
+
+	_, err := analysis.NewIndex(newTestSet(t), analysis.WithExtractor(
+		analysis.TableExtractorFunc(func(string) ([]string, error) {
+			return nil, errParse
+		}),
+	))
+
+	require.ErrorIs(t, err, errParse)
+}
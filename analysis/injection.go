@@ -0,0 +1,137 @@
+package analysis
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// fmtVerb matches the fmt verbs left in bodies built with fmt.Sprintf.
+	fmtVerb = regexp.MustCompile(`%[-+# 0]*(\[\d+\])?\d*(\.\d+)?[sdvqxf]\b`)
+	// quotedPlaceholder matches the bind parameters inside string literals, which are not bound.
+	quotedPlaceholder = regexp.MustCompile(`\$\d+|(^|[^:\w]):[A-Za-z_]\w*|(^|[^@\w])@[A-Za-z_]\w*`)
+	// concatPlaceholder matches a bind parameter concatenated with a string literal, marked by literalMark.
+	concatPlaceholder = regexp.MustCompile(`(?i)` + literalMark + `(\d+)\s*\|\|\s*(\$\d+|:[A-Za-z_]\w*|@[A-Za-z_]\w*|\?)` +
+		`|(\$\d+|:[A-Za-z_]\w*|@[A-Za-z_]\w*|\?)\s*\|\|\s*` + literalMark + `(\d+)`)
+	escapeClause = regexp.MustCompile(`(?i)\bESCAPE\b`)
+)
+
+// literalMark replaces the string literals in the code scanned for concatenations.
+const literalMark = "\x00lit"
+
+// Injection returns a rule flagging injection-adjacent mistakes in query bodies:
+// fmt verbs such as %s left from building bodies with fmt.Sprintf, bind parameters
+// inside string literals, values quoted by concatenation, and parameters concatenated
+// into LIKE patterns without an ESCAPE clause, letting % and _ in the input act as wildcards.
+func Injection() Rule {
+	return injectionRule{}
+}
+
+type injectionRule struct{}
+
+func (injectionRule) Name() string {
+	return "injection"
+}
+
+func (injectionRule) Check(sql string) []string {
+	code, literals := splitLiterals(sql)
+
+	var msgs []string
+
+	if verb := fmtVerb.FindString(code); verb != "" {
+		msgs = append(msgs, "fmt verb "+verb+" in the query body")
+	} else {
+		for _, lit := range literals {
+			if verb := fmtVerb.FindString(lit); verb != "" {
+				msgs = append(msgs, "fmt verb "+verb+" in a string literal")
+
+				break
+			}
+		}
+	}
+
+	for _, lit := range literals {
+		if m := quotedPlaceholder.FindString(lit); m != "" {
+			msgs = append(msgs, "placeholder "+m[strings.IndexAny(m, "$:@"):]+" inside a string literal is not bound")
+
+			break
+		}
+	}
+
+	for _, m := range concatPlaceholder.FindAllStringSubmatch(code, -1) {
+		lit, param := m[1], m[2]
+		if lit == "" {
+			lit, param = m[4], m[3]
+		}
+
+		n, _ := strconv.Atoi(lit)
+
+		switch value := literals[n]; {
+		case value == "'":
+			msgs = append(msgs, "placeholder "+param+" is quoted by concatenation")
+		case strings.Contains(value, "%") && !escapeClause.MatchString(code):
+			msgs = append(msgs, "placeholder "+param+" is concatenated into a LIKE pattern without ESCAPE, wildcards in it are not escaped")
+		default:
+			continue
+		}
+
+		break
+	}
+
+	return msgs
+}
+
+// splitLiterals returns the body without comments and with the string literals
+// replaced by numbered marks, and the unquoted literals.
+func splitLiterals(sql string) (string, []string) {
+	var (
+		code     strings.Builder
+		literals []string
+	)
+
+	for i := 0; i < len(sql); i++ {
+		switch c := sql[i]; {
+		case c == '\'':
+			var lit strings.Builder
+
+			for i++; i < len(sql); i++ {
+				if sql[i] == '\'' {
+					if i+1 < len(sql) && sql[i+1] == '\'' {
+						lit.WriteByte('\'')
+						i++
+
+						continue
+					}
+
+					break
+				}
+
+				lit.WriteByte(sql[i])
+			}
+
+			code.WriteString(literalMark + strconv.Itoa(len(literals)) + " ")
+			literals = append(literals, lit.String())
+		case c == '-' && strings.HasPrefix(sql[i:], "--"):
+			j := strings.IndexByte(sql[i:], '\n')
+			if j < 0 {
+				return code.String(), literals
+			}
+
+			i += j
+			code.WriteByte('\n')
+		case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			j := strings.Index(sql[i+2:], "*/")
+			if j < 0 {
+				return code.String(), literals
+			}
+
+			i += j + 3
+			code.WriteByte(' ')
+		default:
+			code.WriteByte(c)
+		}
+	}
+
+	return code.String(), literals
+}
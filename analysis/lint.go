@@ -0,0 +1,130 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/istovpets/sqlset"
+)
+
+// Finding is an issue found by a lint rule in a query.
+type Finding struct {
+	Key     sqlset.QueryKey `json:"key"`
+	Rule    string          `json:"rule"`
+	Message string          `json:"message"`
+}
+
+// String returns the finding in the "setID.queryID: rule: message" form.
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s: %s", f.Key, f.Rule, f.Message)
+}
+
+// Rule is a lint rule checking query bodies.
+type Rule interface {
+	// Name identifies the rule in the findings.
+	Name() string
+	// Check returns the messages of the issues found in the query body.
+	Check(sql string) []string
+}
+
+// Lint checks every query in the catalog with the rules and returns
+// the findings sorted by query key, in the order of the rules.
+func Lint(catalog Catalog, rules ...Rule) ([]Finding, error) {
+	var findings []Finding
+
+	for _, meta := range catalog.GetSetsMetas() {
+		ids, err := catalog.GetQueryIDs(meta.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, id := range ids {
+			key := sqlset.QueryKey{SetID: meta.ID, QueryID: id}
+
+			q, err := querySQL(catalog, meta.ID, id)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, rule := range rules {
+				for _, msg := range rule.Check(q) {
+					findings = append(findings, Finding{Key: key, Rule: rule.Name(), Message: msg})
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Key.String() < findings[j].Key.String()
+	})
+
+	return findings, nil
+}
+
+// SemicolonPolicy is the project policy on the semicolon terminating query bodies.
+type SemicolonPolicy int
+
+const (
+	// RequireSemicolon requires every body to end with a semicolon.
+	RequireSemicolon SemicolonPolicy = iota
+	// ForbidSemicolon forbids the terminating semicolon, which some drivers
+	// reject in prepared statements.
+	ForbidSemicolon
+)
+
+// Semicolon returns a rule enforcing the semicolon policy consistently across the catalog.
+func Semicolon(policy SemicolonPolicy) Rule {
+	return semicolonRule(policy)
+}
+
+type semicolonRule SemicolonPolicy
+
+func (semicolonRule) Name() string {
+	return "semicolon"
+}
+
+func (r semicolonRule) Check(sql string) []string {
+	terminated := strings.HasSuffix(strings.TrimSpace(sql), ";")
+
+	switch {
+	case SemicolonPolicy(r) == RequireSemicolon && !terminated:
+		return []string{"missing terminating semicolon"}
+	case SemicolonPolicy(r) == ForbidSemicolon && terminated:
+		return []string{"terminating semicolon is not allowed"}
+	default:
+		return nil
+	}
+}
+
+// FixSemicolon returns the last line of a query body terminated or not
+// according to policy. Lines ending with a comment are returned unchanged.
+func FixSemicolon(line string, policy SemicolonPolicy) string {
+	if strings.Contains(line, "--") || strings.HasSuffix(strings.TrimSpace(line), "*/") {
+		return line
+	}
+
+	trimmed := strings.TrimRight(line, " \t")
+
+	if policy == ForbidSemicolon {
+		return strings.TrimRight(strings.TrimSuffix(trimmed, ";"), " \t")
+	}
+
+	if strings.HasSuffix(trimmed, ";") || trimmed == "" {
+		return trimmed
+	}
+
+	return trimmed + ";"
+}
+
+// ParseSemicolonPolicy parses "require" or "forbid".
+func ParseSemicolonPolicy(s string) (SemicolonPolicy, error) {
+	switch s {
+	case "require":
+		return RequireSemicolon, nil
+	case "forbid":
+		return ForbidSemicolon, nil
+	default:
+		return 0, fmt.Errorf("unknown semicolon policy %q, expected require or forbid", s)
+	}
+}
@@ -0,0 +1,144 @@
+package analysis_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/istovpets/sqlset"
+	"github.com/istovpets/sqlset/analysis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLintSemicolon(t *testing.T) {
+	t.Parallel()
+
+	sqlSet, err := sqlset.New(fstest.MapFS{
+		"users.sql": {Data: []byte("--SQL:GetUser\nSELECT 1;\n--end\n--SQL:ListUsers\nSELECT 2\n--end\n")},
+	})
+	require.NoError(t, err)
+
+	findings, err := analysis.Lint(sqlSet, analysis.Semicolon(analysis.RequireSemicolon))
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, "users.ListUsers: semicolon: missing terminating semicolon", findings[0].String())
+
+	findings, err = analysis.Lint(sqlSet, analysis.Semicolon(analysis.ForbidSemicolon))
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, sqlset.QueryKey{SetID: "users", QueryID: "GetUser"}, findings[0].Key)
+	assert.Equal(t, "terminating semicolon is not allowed", findings[0].Message)
+}
+
+func TestLint_TemplateSet(t *testing.T) {
+	t.Parallel()
+
+	sqlSet, err := sqlset.New(fstest.MapFS{
+		"reports.sql.tmpl": {Data: []byte("--SQL:ListOrders\nSELECT * FROM orders{{if .Archived}} WHERE archived{{end}}\n--end")},
+	})
+	require.NoError(t, err)
+
+	findings, err := analysis.Lint(sqlSet, analysis.Semicolon(analysis.RequireSemicolon))
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, "reports.ListOrders: semicolon: missing terminating semicolon", findings[0].String())
+
+	idx, err := analysis.NewIndex(sqlSet)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"orders"}, idx.Dependencies("reports", "ListOrders"))
+}
+
+func TestFixSemicolon(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		line     string
+		policy   analysis.SemicolonPolicy
+		expected string
+	}{
+		{name: "add", line: "WHERE id = $1", policy: analysis.RequireSemicolon, expected: "WHERE id = $1;"},
+		{name: "keep", line: "WHERE id = $1;", policy: analysis.RequireSemicolon, expected: "WHERE id = $1;"},
+		{name: "remove", line: "WHERE id = $1 ; ", policy: analysis.ForbidSemicolon, expected: "WHERE id = $1"},
+		{name: "trailing comment", line: "WHERE id = $1 -- by id", policy: analysis.RequireSemicolon, expected: "WHERE id = $1 -- by id"},
+		{name: "block comment", line: "WHERE id = $1; /* by id */", policy: analysis.ForbidSemicolon, expected: "WHERE id = $1; /* by id */"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.expected, analysis.FixSemicolon(tt.line, tt.policy))
+		})
+	}
+}
+
+func TestParseSemicolonPolicy(t *testing.T) {
+	t.Parallel()
+
+	policy, err := analysis.ParseSemicolonPolicy("forbid")
+	require.NoError(t, err)
+	assert.Equal(t, analysis.ForbidSemicolon, policy)
+
+	_, err = analysis.ParseSemicolonPolicy("always")
+	require.Error(t, err)
+}
+
+func TestInjection(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		sql      string
+		expected []string
+	}{
+		{
+			name: "clean",
+			sql:  "SELECT * FROM users WHERE name LIKE '%' || $1 || '%' ESCAPE '\\' AND email <> 'a@b.c' AND created_at::date = $2",
+		},
+		{
+			name:     "fmt verb",
+			sql:      "SELECT * FROM %s WHERE id = $1",
+			expected: []string{"fmt verb %s in the query body"},
+		},
+		{
+			name:     "fmt verb in literal",
+			sql:      "SELECT * FROM users WHERE name = '%v'",
+			expected: []string{"fmt verb %v in a string literal"},
+		},
+		{
+			name:     "quoted placeholder",
+			sql:      "SELECT * FROM users WHERE name LIKE '%$1%'",
+			expected: []string{"placeholder $1 inside a string literal is not bound"},
+		},
+		{
+			name:     "quoted named placeholder",
+			sql:      "SELECT * FROM users WHERE name = ':name'",
+			expected: []string{"placeholder :name inside a string literal is not bound"},
+		},
+		{
+			name:     "quoting by concatenation",
+			sql:      "EXECUTE 'SELECT * FROM users WHERE name = ' || '''' || $1 || ''''",
+			expected: []string{"placeholder $1 is quoted by concatenation"},
+		},
+		{
+			name: "like pattern without escape",
+			sql:  "SELECT * FROM users WHERE name LIKE '%' || :name || '%'",
+			expected: []string{
+				"placeholder :name is concatenated into a LIKE pattern without ESCAPE, wildcards in it are not escaped",
+			},
+		},
+		{
+			name: "comments",
+			sql:  "-- replaces %s\r\nSELECT /* '$1' */ 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.expected, analysis.Injection().Check(tt.sql))
+		})
+	}
+}
@@ -0,0 +1,282 @@
+package analysis
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenQuoted
+	tokenString
+	tokenPunct
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+}
+
+// isWord reports whether the token is an unquoted word equal to kw, compared case-insensitively.
+func (t token) isWord(kw string) bool {
+	return t.kind == tokenWord && strings.EqualFold(t.value, kw)
+}
+
+func (t token) isIdent() bool {
+	return t.kind == tokenQuoted || (t.kind == tokenWord && !reserved[strings.ToUpper(t.value)])
+}
+
+func (t token) name() string {
+	if t.kind == tokenQuoted {
+		return t.value
+	}
+
+	return strings.ToLower(t.value)
+}
+
+// reserved are the keywords that can never be a table name or an alias.
+var reserved = map[string]bool{
+	"SELECT": true, "FROM": true, "WHERE": true, "JOIN": true, "ON": true, "USING": true,
+	"GROUP": true, "ORDER": true, "BY": true, "LIMIT": true, "OFFSET": true, "HAVING": true,
+	"UNION": true, "EXCEPT": true, "INTERSECT": true, "RETURNING": true, "SET": true,
+	"VALUES": true, "WINDOW": true, "FETCH": true, "FOR": true, "LEFT": true, "RIGHT": true,
+	"INNER": true, "OUTER": true, "FULL": true, "CROSS": true, "NATURAL": true, "INTO": true,
+	"UPDATE": true, "DELETE": true, "INSERT": true, "TABLE": true, "WITH": true, "AS": true,
+	"ONLY": true, "LATERAL": true, "IF": true, "NOT": true, "EXISTS": true, "AND": true,
+	"OR": true, "CONFLICT": true, "DO": true, "DEFAULT": true, "MATERIALIZED": true,
+}
+
+// tableModifiers may appear between a table-introducing keyword and the table name.
+var tableModifiers = map[string]bool{
+	"ONLY": true, "LATERAL": true, "IF": true, "NOT": true, "EXISTS": true,
+}
+
+// ExtractTables is the best-effort tokenizer based table extractor.
+// It returns the sorted, deduplicated names of the tables referenced by the statement
+// after FROM, JOIN, INTO, UPDATE and TABLE keywords. Unquoted names are lower-cased,
+// schema-qualified names are kept qualified, common table expressions are excluded.
+func ExtractTables(sql string) []string {
+	tokens := tokenize(sql)
+
+	var (
+		tables = map[string]bool{}
+		ctes   = map[string]bool{}
+		// statement[d] is set when a SELECT, UPDATE or DELETE was seen at paren depth d,
+		// so FROM inside function calls like EXTRACT(YEAR FROM ts) is ignored.
+		statement = []bool{false}
+		depth     int
+		expect    bool
+		fromDepth = -1
+		fromList  bool
+	)
+
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+
+		switch {
+		case tok.kind == tokenPunct && tok.value == "(":
+			depth++
+			statement = append(statement, false)
+			expect = false
+
+			continue
+		case tok.kind == tokenPunct && tok.value == ")":
+			if depth > 0 {
+				depth--
+				statement = statement[:len(statement)-1]
+			}
+
+			if depth < fromDepth {
+				fromList, fromDepth = false, -1
+			}
+
+			expect = false
+
+			continue
+		case tok.kind == tokenPunct && tok.value == ",":
+			if fromList && depth == fromDepth {
+				expect = true
+			}
+
+			continue
+		}
+
+		if isCTEName(tokens, i) {
+			ctes[tok.name()] = true
+
+			continue
+		}
+
+		if tok.kind == tokenWord {
+			kw := strings.ToUpper(tok.value)
+
+			switch kw {
+			case "SELECT", "UPDATE", "DELETE":
+				statement[depth] = true
+			}
+
+			switch kw {
+			case "FROM":
+				if statement[depth] {
+					expect, fromList, fromDepth = true, true, depth
+				}
+
+				continue
+			case "JOIN":
+				expect, fromList, fromDepth = true, true, depth
+
+				continue
+			case "INTO", "UPDATE", "TABLE":
+				expect, fromList = true, false
+
+				continue
+			}
+
+			if expect && tableModifiers[kw] {
+				continue
+			}
+
+			if reserved[kw] && kw != "AS" {
+				expect = false
+
+				if fromList && depth == fromDepth {
+					fromList, fromDepth = false, -1
+				}
+
+				continue
+			}
+		}
+
+		if !expect || !tok.isIdent() {
+			expect = false
+
+			continue
+		}
+
+		expect = false
+
+		// A function call in the FROM list is not a table.
+		if i+1 < len(tokens) && tokens[i+1].kind == tokenPunct && tokens[i+1].value == "(" && fromList {
+			continue
+		}
+
+		tables[tok.name()] = true
+	}
+
+	result := make([]string, 0, len(tables))
+
+	for table := range tables {
+		if !ctes[table] {
+			result = append(result, table)
+		}
+	}
+
+	sort.Strings(result)
+
+	return result
+}
+
+// isCTEName reports whether tokens[i] starts a common table expression: name AS [NOT] [MATERIALIZED] (.
+func isCTEName(tokens []token, i int) bool {
+	if !tokens[i].isIdent() || i+2 >= len(tokens) || !tokens[i+1].isWord("AS") {
+		return false
+	}
+
+	for _, tok := range tokens[i+2:] {
+		switch {
+		case tok.isWord("NOT"), tok.isWord("MATERIALIZED"):
+			continue
+		case tok.kind == tokenPunct && tok.value == "(":
+			return true
+		default:
+			return false
+		}
+	}
+
+	return false
+}
+
+// tokenize splits sql into tokens skipping whitespace and comments.
+// Dot-separated identifiers are joined into a single qualified token.
+//
+//nolint:funlen,gocognit
+func tokenize(sql string) []token {
+	var (
+		tokens []token
+		runes  = []rune(sql)
+	)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			i += 2
+			for i+1 < len(runes) && (runes[i] != '*' || runes[i+1] != '/') {
+				i++
+			}
+			i += 2
+		case r == '\'' || r == '"' || r == '`':
+			start := i + 1
+			i++
+
+			for i < len(runes) {
+				if runes[i] == r {
+					// Doubled quote is an escaped quote.
+					if i+1 < len(runes) && runes[i+1] == r {
+						i += 2
+						continue
+					}
+
+					break
+				}
+				i++
+			}
+
+			kind := tokenQuoted
+			if r == '\'' {
+				kind = tokenString
+			}
+
+			tokens = appendToken(tokens, token{kind: kind, value: string(runes[start:min(i, len(runes))])})
+			i++
+		case r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			start := i
+			for i < len(runes) && (runes[i] == '_' || runes[i] == '$' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+
+			tokens = appendToken(tokens, token{kind: tokenWord, value: string(runes[start:i])})
+		default:
+			tokens = appendToken(tokens, token{kind: tokenPunct, value: string(r)})
+			i++
+		}
+	}
+
+	return tokens
+}
+
+// appendToken appends tok joining it with the preceding "ident." tokens into a qualified name.
+func appendToken(tokens []token, tok token) []token {
+	n := len(tokens)
+	if tok.kind == tokenWord || tok.kind == tokenQuoted {
+		if n >= 2 && tokens[n-1].kind == tokenPunct && tokens[n-1].value == "." &&
+			(tokens[n-2].kind == tokenWord || tokens[n-2].kind == tokenQuoted) {
+			prev := tokens[n-2]
+			qualified := prev.name() + "." + tok.name()
+
+			return append(tokens[:n-2], token{kind: tokenQuoted, value: qualified})
+		}
+	}
+
+	return append(tokens, tok)
+}
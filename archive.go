@@ -0,0 +1,146 @@
+package sqlset
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// tarMagic is the magic of the POSIX tar headers, at offset tarMagicOffset.
+const (
+	tarMagic       = "ustar"
+	tarMagicOffset = 257
+)
+
+// NewFromArchive creates a new SQLSet from a zip, tar or tar.gz archive of .sql files,
+// e.g. a deployment artifact. The format is detected from the content.
+// If the root of the archive holds a single directory, e.g. "queries-v1.2/",
+// the sets are loaded from it, as with NewWithRoot:
+//
+//	f, err := os.Open("queries.zip")
+//	if err != nil {
+//		return err
+//	}
+//	defer f.Close()
+//
+//	info, err := f.Stat()
+//	if err != nil {
+//		return err
+//	}
+//
+//	sqlSet, err := sqlset.NewFromArchive(f, info.Size())
+func NewFromArchive(r io.ReaderAt, size int64, opts ...Option) (*SQLSet, error) {
+	fsys, err := archiveFS(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	fsys, err = archiveRoot(fsys)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidArchive, err)
+	}
+
+	return New(fsys, opts...)
+}
+
+// archiveFS returns the files of the archive by its format.
+func archiveFS(r io.ReaderAt, size int64) (fs.FS, error) {
+	header := make([]byte, tarMagicOffset+len(tarMagic))
+
+	n, err := r.ReadAt(header, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("read archive: %w", err)
+	}
+
+	header = header[:n]
+
+	switch {
+	case bytes.HasPrefix(header, []byte("PK")):
+		zr, err := zip.NewReader(r, size)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidArchive, err.Error())
+		}
+
+		return zr, nil
+	case bytes.HasPrefix(header, []byte{0x1f, 0x8b}):
+		gr, err := gzip.NewReader(io.NewSectionReader(r, 0, size))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidArchive, err.Error())
+		}
+
+		return readTar(gr)
+	case bytes.HasSuffix(header, []byte(tarMagic)):
+		return readTar(io.NewSectionReader(r, 0, size))
+	default:
+		return nil, fmt.Errorf("%w: unknown format", ErrInvalidArchive)
+	}
+}
+
+// readTar reads the files of a tar archive loaded by New into memory, skipping the others.
+func readTar(r io.Reader) (fs.FS, error) {
+	fsys := memFS{}
+	tr := tar.NewReader(r)
+
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return fsys, nil
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidArchive, err.Error())
+		}
+
+		name := path.Clean(strings.TrimPrefix(hdr.Name, "./"))
+
+		switch {
+		case hdr.Typeflag == tar.TypeDir:
+			fsys[name] = &memFile{mode: fs.ModeDir | fs.FileMode(hdr.Mode).Perm(), modTime: hdr.ModTime}
+
+			continue
+		case hdr.Typeflag != tar.TypeReg || !archived(path.Base(name)):
+			continue
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("%w: read %s: %s", ErrInvalidArchive, hdr.Name, err.Error())
+		}
+
+		fsys[name] = &memFile{data: data, mode: fs.FileMode(hdr.Mode).Perm(), modTime: hdr.ModTime}
+	}
+}
+
+// archived reports whether a file of a tar archive is kept in memory: query, directory metadata
+// and pack manifest files.
+func archived(name string) bool {
+	lower := strings.ToLower(name)
+
+	return strings.HasSuffix(lower, filesExt) || strings.HasSuffix(lower, templateExt) ||
+		name == dirMetaFile || name == PackManifestFile
+}
+
+// archiveRoot descends into the single directory at the root of an archive, if any.
+func archiveRoot(fsys fs.FS) (fs.FS, error) {
+	for {
+		entries, err := fs.ReadDir(fsys, ".")
+		if err != nil {
+			return nil, err
+		}
+
+		if len(entries) != 1 || !entries[0].IsDir() {
+			return fsys, nil
+		}
+
+		if fsys, err = fs.Sub(fsys, entries[0].Name()); err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package sqlset_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/istovpets/sqlset"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var archiveFiles = map[string]string{
+	"queries-v1/users.sql":           "--SQL:Get\nSELECT 1;\n--end\n",
+	"queries-v1/billing/_meta.json":  `{"owner": "payments"}`,
+	"queries-v1/billing/bills.sql":   "--SQL:Get\nSELECT 2;\n--end\n",
+	"queries-v1/README.md":           "# Queries",
+	"queries-v1/billing/huge.bin":    "\x00\x01",
+	"queries-v1/reports/daily.sql":   "--SQL:Get\nSELECT 3;\n--end\n",
+	"queries-v1/reports/notes.txt":   "notes",
+	"queries-v1/billing/archive.sql": "--SQL:Old\nSELECT 4;\n--end\n",
+}
+
+func zipArchive(t *testing.T) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	zw := zip.NewWriter(&buf)
+
+	for name, data := range archiveFiles {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+
+		_, err = w.Write([]byte(data))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, zw.Close())
+
+	return buf.Bytes()
+}
+
+func tarArchive(t *testing.T) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	tw := tar.NewWriter(&buf)
+
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "queries-v1/", Typeflag: tar.TypeDir, Mode: 0o755}))
+
+	for name, data := range archiveFiles {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(data))}))
+
+		_, err := tw.Write([]byte(data))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, tw.Close())
+
+	return buf.Bytes()
+}
+
+func gzipped(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	gw := gzip.NewWriter(&buf)
+
+	_, err := gw.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+
+	return buf.Bytes()
+}
+
+func TestNewFromArchive(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		data func(t *testing.T) []byte
+	}{
+		{name: "zip", data: zipArchive},
+		{name: "tar", data: tarArchive},
+		{name: "tar.gz", data: func(t *testing.T) []byte { return gzipped(t, tarArchive(t)) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			data := tt.data(t)
+
+			sqlSet, err := sqlset.NewFromArchive(bytes.NewReader(data), int64(len(data)),
+				sqlset.WithIgnore("archive.sql"))
+			require.NoError(t, err)
+			assert.Len(t, sqlSet.GetSetsMetas(), 3)
+			assert.Equal(t, "SELECT 2;", sqlSet.MustGet("bills", "Get"))
+
+			meta, err := sqlSet.GetMetaLocalized("bills", "")
+			require.NoError(t, err)
+			assert.Equal(t, "payments", meta.Owner)
+
+			src, err := sqlSet.QuerySource("daily", "Get")
+			require.NoError(t, err)
+			assert.Equal(t, "reports/daily.sql", src.File)
+		})
+	}
+}
+
+func TestArchiveFS_Tar(t *testing.T) {
+	t.Parallel()
+
+	data := tarArchive(t)
+
+	fsys, err := sqlset.ArchiveFS(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	require.NoError(t, fstest.TestFS(fsys, "queries-v1/users.sql", "queries-v1/billing/_meta.json",
+		"queries-v1/billing/bills.sql", "queries-v1/reports/daily.sql"))
+
+	_, err = fsys.Open("queries-v1/README.md")
+	require.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestNewFromArchive_Invalid(t *testing.T) {
+	t.Parallel()
+
+	for name, data := range map[string][]byte{
+		"unknown":       []byte("--SQL:Get\nSELECT 1;\n--end\n"),
+		"empty":         nil,
+		"truncated zip": zipArchive(t)[:40],
+		"broken gzip":   {0x1f, 0x8b, 0x00},
+	} {
+		_, err := sqlset.NewFromArchive(bytes.NewReader(data), int64(len(data)))
+		require.ErrorIs(t, err, sqlset.ErrInvalidArchive, name)
+	}
+}
@@ -0,0 +1,109 @@
+package benchmarks_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/istovpets/sqlset"
+	"github.com/istovpets/sqlset/benchmarks"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatalog(t *testing.T) {
+	t.Parallel()
+
+	sqlSet, err := sqlset.New(benchmarks.Catalog(10, 8))
+	require.NoError(t, err)
+	require.Len(t, sqlSet.GetSetsMetas(), 10)
+
+	ids, err := sqlSet.GetQueryIDs(benchmarks.SetID(3))
+	require.NoError(t, err)
+	require.Len(t, ids, 8)
+
+	n, err := sqlSet.Arity(benchmarks.SetID(3), benchmarks.QueryID(2))
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+}
+
+func BenchmarkNew(b *testing.B) {
+	fsys := benchmarks.Catalog(benchmarks.LargeFiles, benchmarks.LargeQueriesPerFile)
+
+	b.ReportAllocs()
+
+	for b.Loop() {
+		if _, err := sqlset.New(fsys); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkParse measures the parser alone on a single file with 10k queries.
+func BenchmarkParse(b *testing.B) {
+	data := []byte(benchmarks.File(0, benchmarks.LargeFiles*benchmarks.LargeQueriesPerFile))
+	fsys := fstest.MapFS{"large.sql": &fstest.MapFile{Data: data}}
+
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+
+	for b.Loop() {
+		if _, err := sqlset.New(fsys); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkNewFromBundle(b *testing.B) {
+	sqlSet, err := sqlset.New(benchmarks.Catalog(benchmarks.LargeFiles, benchmarks.LargeQueriesPerFile))
+	require.NoError(b, err)
+
+	data, err := sqlSet.MarshalBundle()
+	require.NoError(b, err)
+
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+
+	for b.Loop() {
+		if _, err := sqlset.NewFromBundle(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGet(b *testing.B) {
+	sqlSet, err := sqlset.New(benchmarks.Catalog(benchmarks.LargeFiles, benchmarks.LargeQueriesPerFile))
+	require.NoError(b, err)
+
+	setID, queryID := benchmarks.SetID(500), benchmarks.QueryID(5)
+	key := setID + "." + queryID
+
+	b.Run("ids", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for b.Loop() {
+			if _, err := sqlSet.Get(setID, queryID); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("key", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for b.Loop() {
+			if _, err := sqlSet.Get(key); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("parallel", func(b *testing.B) {
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				if _, err := sqlSet.Get(setID, queryID); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	})
+}
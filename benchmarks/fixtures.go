@@ -0,0 +1,97 @@
+// Package benchmarks holds the performance regression suite of sqlset:
+// generated catalogs of realistic size and benchmarks for loading and lookups.
+//
+// Run it with:
+//
+//	go test -run=^$ -bench=. -benchmem ./benchmarks
+//
+// and compare the results of two revisions with benchstat.
+package benchmarks
+
+import (
+	"fmt"
+	"strings"
+	"testing/fstest"
+)
+
+const (
+	// LargeFiles is the number of files of the large catalog.
+	LargeFiles = 1000
+	// LargeQueriesPerFile is the number of queries per file of the large catalog, 10k queries in total.
+	LargeQueriesPerFile = 10
+)
+
+// Catalog returns an in-memory catalog of files with queriesPerFile queries each.
+// The queries mix the shapes found in real catalogs: short lookups, multi-line
+// joins with comments, CTEs and declared parameters. The output is deterministic.
+func Catalog(files, queriesPerFile int) fstest.MapFS {
+	fsys := make(fstest.MapFS, files)
+
+	for f := range files {
+		fsys[fmt.Sprintf("set%04d.sql", f)] = &fstest.MapFile{Data: []byte(File(f, queriesPerFile))}
+	}
+
+	return fsys
+}
+
+// QueryID returns the ID of the q-th query of a generated file.
+func QueryID(q int) string {
+	return fmt.Sprintf("Query%03d", q)
+}
+
+// SetID returns the ID of the f-th generated file.
+func SetID(f int) string {
+	return fmt.Sprintf("set%04d", f)
+}
+
+// File returns the content of the f-th generated file.
+func File(f, queries int) string {
+	var sb strings.Builder
+
+	table := fmt.Sprintf("table_%04d", f)
+
+	fmt.Fprintf(&sb, `--META
+{
+    "name": "Set %d",
+    "description": "Generated queries over %s."
+}
+--end
+
+`, f, table)
+
+	for q := range queries {
+		fmt.Fprintf(&sb, "--SQL:%s\n", QueryID(q))
+
+		switch q % 4 {
+		case 0:
+			fmt.Fprintf(&sb, "--PARAMS: id bigint\nSELECT id, name, created_at FROM %s WHERE id = $1;\n", table)
+		case 1:
+			fmt.Fprintf(&sb, `-- Lists the recent rows with their owners.
+SELECT t.id, t.name, u.email, count(o.id) AS orders
+FROM %s t
+    JOIN users u ON u.id = t.owner_id
+    LEFT JOIN orders o ON o.item_id = t.id
+WHERE t.created_at > now() - interval '30 days'
+    AND t.status = 'active'
+GROUP BY t.id, t.name, u.email
+ORDER BY t.created_at DESC
+LIMIT 100;
+`, table)
+		case 2:
+			fmt.Fprintf(&sb, `--PARAMS: owner_id bigint, since timestamptz
+WITH recent AS (
+    SELECT id, amount FROM %s WHERE owner_id = $1 AND created_at > $2
+), totals AS (
+    SELECT sum(amount) AS total, count(*) AS n FROM recent
+)
+SELECT total, n, total / nullif(n, 0) AS average FROM totals;
+`, table)
+		default:
+			fmt.Fprintf(&sb, "--PARAMS: name text\nINSERT INTO %s (name) VALUES ($1) RETURNING id;\n", table)
+		}
+
+		sb.WriteString("--end\n\n")
+	}
+
+	return sb.String()
+}
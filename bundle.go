@@ -0,0 +1,215 @@
+//go:build !tinygo
+
+package sqlset
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"sort"
+	"time"
+)
+
+const (
+	bundleMagic   = "SQLSETB"
+	bundleVersion = 1
+)
+
+// bundle is the gob-encoded representation of a parsed SQLSet.
+type bundle struct {
+	Version int
+	Sets    []bundleSet
+}
+
+// bundleSet keeps query bodies and declarations in separate maps,
+// so fields can be added without breaking older bundles.
+type bundleSet struct {
+	Meta    QuerySetMeta
+	Queries map[string]string
+	Params  map[string][]QueryParam
+	Returns map[string][]QueryParam
+	Hints   map[string]string
+	Metas   map[string]QueryMeta
+	Sources map[string]Source
+	// Data and DataSources hold the content and location of the data blocks, see GetData.
+	Data        map[string]string
+	DataSources map[string]Source
+	// Expects and ExpectSources hold the content and location of the expect blocks by query ID.
+	Expects       map[string]string
+	ExpectSources map[string]Source
+	ModTime       time.Time
+}
+
+func newBundleSet(qs QuerySet) bundleSet {
+	bs := bundleSet{
+		Meta:    qs.meta,
+		ModTime: qs.modTime,
+		Queries: make(map[string]string, len(qs.queries)),
+		Params:  make(map[string][]QueryParam),
+		Returns: make(map[string][]QueryParam),
+		Hints:   make(map[string]string),
+		Metas:   make(map[string]QueryMeta),
+		Sources: make(map[string]Source),
+	}
+
+	for id, q := range qs.queries {
+		bs.Queries[id] = q.sql
+
+		if q.params != nil {
+			bs.Params[id] = q.params
+		}
+
+		if q.returns != nil {
+			bs.Returns[id] = q.returns
+		}
+
+		if q.hints != "" {
+			bs.Hints[id] = q.hints
+		}
+
+		if !q.meta.isZero() {
+			bs.Metas[id] = q.meta
+		}
+
+		if q.source != (Source{}) {
+			bs.Sources[id] = q.source
+		}
+	}
+
+	for id, d := range qs.data {
+		if bs.Data == nil {
+			bs.Data = make(map[string]string)
+			bs.DataSources = make(map[string]Source)
+		}
+
+		bs.Data[id] = d.content
+		bs.DataSources[id] = d.Source
+	}
+
+	for id, e := range qs.expectations {
+		if bs.Expects == nil {
+			bs.Expects = make(map[string]string)
+			bs.ExpectSources = make(map[string]Source)
+		}
+
+		bs.Expects[id] = e.content
+		bs.ExpectSources[id] = e.Source
+	}
+
+	return bs
+}
+
+func (bs bundleSet) querySet(cfg *config) (QuerySet, error) {
+	qs := QuerySet{meta: bs.Meta, modTime: bs.ModTime}
+
+	for id, sql := range bs.Queries {
+		qs.registerQuery(id, query{
+			sql:     sql,
+			params:  bs.Params[id],
+			returns: bs.Returns[id],
+			hints:   bs.Hints[id],
+			meta:    bs.Metas[id],
+			source:  bs.Sources[id],
+		})
+	}
+
+	for id, content := range bs.Data {
+		d, err := parseData(id, content, bs.DataSources[id])
+		if err != nil {
+			return QuerySet{}, err
+		}
+
+		if err := qs.registerData(d); err != nil {
+			return QuerySet{}, err
+		}
+	}
+
+	for id, content := range bs.Expects {
+		e, err := parseExpectation(id, content, bs.ExpectSources[id])
+		if err != nil {
+			return QuerySet{}, err
+		}
+
+		if err := qs.registerExpectation(id, e); err != nil {
+			return QuerySet{}, err
+		}
+	}
+
+	if err := compileTemplates(cfg, &qs); err != nil {
+		return QuerySet{}, err
+	}
+
+	return qs, nil
+}
+
+// MarshalBundle encodes the parsed SQLSet into a compact binary bundle.
+// The bundle can be loaded with NewFromBundle without re-running the parser.
+func (s *SQLSet) MarshalBundle() ([]byte, error) {
+	b := bundle{
+		Version: bundleVersion,
+		Sets:    make([]bundleSet, 0, len(s.sets)),
+	}
+
+	for _, qs := range s.sets {
+		b.Sets = append(b.Sets, newBundleSet(qs))
+	}
+
+	// Keep the sets order stable for the same input.
+	sort.Slice(b.Sets, func(i, j int) bool {
+		return b.Sets[i].Meta.ID < b.Sets[j].Meta.ID
+	})
+
+	var buf bytes.Buffer
+
+	buf.WriteString(bundleMagic)
+
+	if err := gob.NewEncoder(&buf).Encode(b); err != nil {
+		return nil, fmt.Errorf("encode bundle: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// NewFromBundle creates a new SQLSet from a binary bundle
+// produced by MarshalBundle or `sqlset-gen bundle`.
+//
+// Example with embed.FS:
+//
+//	//go:embed queries.bundle
+//	var queriesBundle []byte
+//
+//	sqlSet, err := sqlset.NewFromBundle(queriesBundle)
+//
+// The parsing options were applied when bundling. The retrieval options, e.g. WithRewriter,
+// WithSoftDelete or WithKeySeparator, apply as with New, and so do WithTemplateFuncs and WithContextKeys.
+func NewFromBundle(data []byte, opts ...Option) (*SQLSet, error) {
+	cfg := newConfig(opts)
+
+	payload, ok := bytes.CutPrefix(data, []byte(bundleMagic))
+	if !ok {
+		return nil, fmt.Errorf("%w: missing header", ErrInvalidBundle)
+	}
+
+	var b bundle
+
+	if err := gob.NewDecoder(bytes.NewReader(payload)).Decode(&b); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidBundle, err.Error())
+	}
+
+	if b.Version != bundleVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidBundle, b.Version)
+	}
+
+	sqlSet := cfg.newSQLSet()
+
+	for _, bs := range b.Sets {
+		qs, err := bs.querySet(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %s", ErrInvalidBundle, bs.Meta.ID, err.Error())
+		}
+
+		sqlSet.registerQuerySet(bs.Meta.ID, qs)
+	}
+
+	return sqlSet, nil
+}
@@ -0,0 +1,129 @@
+//go:build !tinygo
+
+package sqlset_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/istovpets/sqlset"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBundle_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	original, err := sqlset.New(testdataValidMulti)
+	require.NoError(t, err)
+
+	data, err := original.MarshalBundle()
+	require.NoError(t, err)
+
+	loaded, err := sqlset.NewFromBundle(data)
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, original.GetSetsMetas(), loaded.GetSetsMetas())
+
+	for _, meta := range original.GetSetsMetas() {
+		ids, err := loaded.GetQueryIDs(meta.ID)
+		require.NoError(t, err)
+
+		for _, id := range ids {
+			assert.Equal(t, original.MustGet(meta.ID, id), loaded.MustGet(meta.ID, id))
+		}
+	}
+}
+
+func TestNewFromBundle_Options(t *testing.T) {
+	t.Parallel()
+
+	original, err := sqlset.New(testdataValidMulti)
+	require.NoError(t, err)
+
+	data, err := original.MarshalBundle()
+	require.NoError(t, err)
+
+	upper := sqlset.WithRewriter(func(_ context.Context, _ sqlset.QueryKey, sql string) (string, error) {
+		return strings.ToUpper(sql), nil
+	})
+
+	loaded, err := sqlset.NewFromBundle(data, upper, sqlset.WithKeySeparator("/"))
+	require.NoError(t, err)
+
+	fromFiles, err := sqlset.New(testdataValidMulti, upper, sqlset.WithKeySeparator("/"))
+	require.NoError(t, err)
+
+	for _, meta := range original.GetSetsMetas() {
+		ids, err := loaded.GetQueryIDs(meta.ID)
+		require.NoError(t, err)
+
+		for _, id := range ids {
+			expected, err := fromFiles.Get(meta.ID + "/" + id)
+			require.NoError(t, err)
+
+			actual, err := loaded.Get(meta.ID + "/" + id)
+			require.NoError(t, err)
+			assert.Equal(t, expected, actual)
+			assert.Equal(t, strings.ToUpper(original.MustGet(meta.ID, id)), actual)
+		}
+	}
+}
+
+func TestNewFromBundle_WhenInvalid_ExpectError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "empty", data: nil},
+		{name: "missing header", data: []byte("garbage")},
+		{name: "corrupted payload", data: []byte("SQLSETBgarbage")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			set, err := sqlset.NewFromBundle(test.data)
+
+			require.ErrorIs(t, err, sqlset.ErrInvalidBundle)
+			assert.Nil(t, set)
+		})
+	}
+}
+
+func TestBundle_Declarations(t *testing.T) {
+	t.Parallel()
+
+	original, err := sqlset.New(fstest.MapFS{
+		"users.sql": &fstest.MapFile{Data: []byte(`--SQL:FindUsers
+--PARAMS: id uuid, name text
+--HINTS: /*+ SeqScan(users) */
+--META: {"command": "many"}
+SELECT id FROM users WHERE id = $1 AND name = $2;
+--end`)},
+	})
+	require.NoError(t, err)
+
+	data, err := original.MarshalBundle()
+	require.NoError(t, err)
+
+	loaded, err := sqlset.NewFromBundle(data)
+	require.NoError(t, err)
+
+	params, err := loaded.Params("users", "FindUsers")
+	require.NoError(t, err)
+	assert.Equal(t, []sqlset.QueryParam{{Name: "id", Type: "uuid"}, {Name: "name", Type: "text"}}, params)
+
+	hints, err := loaded.Hints("users", "FindUsers")
+	require.NoError(t, err)
+	assert.Equal(t, "/*+ SeqScan(users) */", hints)
+
+	meta, err := loaded.GetQueryMeta("users", "FindUsers")
+	require.NoError(t, err)
+	assert.Equal(t, sqlset.QueryMeta{Command: "many"}, meta)
+}
@@ -0,0 +1,18 @@
+//go:build tinygo
+
+package sqlset
+
+import (
+	"errors"
+	"fmt"
+)
+
+// MarshalBundle is not supported by TinyGo builds, as encoding/gob is not.
+func (s *SQLSet) MarshalBundle() ([]byte, error) {
+	return nil, fmt.Errorf("%w: %w", ErrInvalidBundle, errors.ErrUnsupported)
+}
+
+// NewFromBundle is not supported by TinyGo builds, as encoding/gob is not.
+func NewFromBundle([]byte, ...Option) (*SQLSet, error) {
+	return nil, fmt.Errorf("%w: %w", ErrInvalidBundle, errors.ErrUnsupported)
+}
@@ -0,0 +1,169 @@
+package sqlset
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// CacheOption configures a CachingProvider.
+type CacheOption func(*CachingProvider)
+
+// WithCacheTTL sets the time a query stays fresh in the cache. Default is one minute.
+func WithCacheTTL(ttl time.Duration) CacheOption {
+	return func(p *CachingProvider) {
+		p.ttl = func(string) time.Duration {
+			return ttl
+		}
+	}
+}
+
+// WithCacheKeyTTL sets a per-key TTL. The key is the Get arguments joined with ".",
+// so Get("users", "GetUser") and Get("users.GetUser") share the "users.GetUser" key.
+func WithCacheKeyTTL(ttl func(key string) time.Duration) CacheOption {
+	return func(p *CachingProvider) {
+		p.ttl = ttl
+	}
+}
+
+// WithStaleWhileRevalidate keeps serving an expired query for up to stale
+// while it is refreshed from the source in the background.
+func WithStaleWhileRevalidate(stale time.Duration) CacheOption {
+	return func(p *CachingProvider) {
+		p.stale = stale
+	}
+}
+
+// WithCacheErrorHandler sets a handler for the errors of background refreshes,
+// which can not be returned to the caller.
+func WithCacheErrorHandler(handler func(key string, err error)) CacheOption {
+	return func(p *CachingProvider) {
+		p.onError = handler
+	}
+}
+
+// CachingProvider is an SQLQueriesProvider decorator caching the queries
+// returned by a slow source, e.g. a remote-backed provider, so lookups
+// do not sit on the request path. Errors are not cached.
+// It is safe for concurrent use.
+type CachingProvider struct {
+	src     SQLQueriesProvider
+	ttl     func(key string) time.Duration
+	stale   time.Duration
+	onError func(key string, err error)
+	now     func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+}
+
+type cacheEntry struct {
+	query      string
+	expires    time.Time
+	refreshing bool
+}
+
+// NewCachingProvider returns a caching decorator for src.
+func NewCachingProvider(src SQLQueriesProvider, opts ...CacheOption) *CachingProvider {
+	p := &CachingProvider{
+		src:     src,
+		onError: func(string, error) {},
+		now:     time.Now,
+		entries: make(map[string]*cacheEntry),
+	}
+
+	WithCacheTTL(time.Minute)(p)
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// Get returns the cached query or fetches it from the source, see SQLSet.Get for the arguments.
+func (p *CachingProvider) Get(ids ...string) (string, error) {
+	key := strings.Join(ids, ".")
+	now := p.now()
+
+	p.mu.Lock()
+
+	if e, ok := p.entries[key]; ok {
+		if now.Before(e.expires) {
+			p.mu.Unlock()
+
+			return e.query, nil
+		}
+
+		if now.Before(e.expires.Add(p.stale)) {
+			if !e.refreshing {
+				e.refreshing = true
+
+				go p.refresh(key, ids)
+			}
+
+			p.mu.Unlock()
+
+			return e.query, nil
+		}
+	}
+
+	p.mu.Unlock()
+
+	return p.fetch(key, ids)
+}
+
+// MustGet is like Get but panics if the query cannot be fetched.
+func (p *CachingProvider) MustGet(ids ...string) string {
+	q, err := p.Get(ids...)
+	if err != nil {
+		panic(err)
+	}
+
+	return q
+}
+
+// Invalidate removes the query from the cache, so the next Get fetches it from the source.
+func (p *CachingProvider) Invalidate(ids ...string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.entries, strings.Join(ids, "."))
+}
+
+// InvalidateAll removes all queries from the cache.
+func (p *CachingProvider) InvalidateAll() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.entries = make(map[string]*cacheEntry)
+}
+
+func (p *CachingProvider) fetch(key string, ids []string) (string, error) {
+	q, err := p.src.Get(ids...)
+	if err != nil {
+		return "", err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.entries[key] = &cacheEntry{
+		query:   q,
+		expires: p.now().Add(p.ttl(key)),
+	}
+
+	return q, nil
+}
+
+func (p *CachingProvider) refresh(key string, ids []string) {
+	if _, err := p.fetch(key, ids); err != nil {
+		p.mu.Lock()
+		if e, ok := p.entries[key]; ok {
+			e.refreshing = false
+		}
+		p.mu.Unlock()
+
+		p.onError(key, err)
+	}
+}
@@ -0,0 +1,170 @@
+package sqlset_test
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/istovpets/sqlset"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingProvider returns the number of calls as the query body.
+type countingProvider struct {
+	mu    sync.Mutex
+	calls int
+	err   error
+}
+
+func (p *countingProvider) Get(ids ...string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.err != nil {
+		return "", p.err
+	}
+
+	p.calls++
+
+	return fmt.Sprintf("SELECT %d;", p.calls), nil
+}
+
+func (p *countingProvider) MustGet(ids ...string) string {
+	q, err := p.Get(ids...)
+	if err != nil {
+		panic(err)
+	}
+
+	return q
+}
+
+func (p *countingProvider) setErr(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.err = err
+}
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+func newCachingProvider(src sqlset.SQLQueriesProvider, opts ...sqlset.CacheOption) (*sqlset.CachingProvider, *fakeClock) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	p := sqlset.NewCachingProvider(src, opts...)
+	sqlset.SetCacheClock(p, clock.Now)
+
+	return p, clock
+}
+
+func TestCachingProvider(t *testing.T) {
+	t.Parallel()
+
+	t.Run("ttl", func(t *testing.T) {
+		t.Parallel()
+
+		p, clock := newCachingProvider(&countingProvider{}, sqlset.WithCacheTTL(time.Minute))
+
+		assert.Equal(t, "SELECT 1;", p.MustGet("users", "GetUser"))
+		assert.Equal(t, "SELECT 1;", p.MustGet("users.GetUser"))
+
+		clock.Advance(time.Minute)
+		assert.Equal(t, "SELECT 2;", p.MustGet("users", "GetUser"))
+	})
+
+	t.Run("per key ttl", func(t *testing.T) {
+		t.Parallel()
+
+		p, clock := newCachingProvider(&countingProvider{}, sqlset.WithCacheKeyTTL(func(key string) time.Duration {
+			if key == "users.Static" {
+				return time.Hour
+			}
+
+			return time.Second
+		}))
+
+		assert.Equal(t, "SELECT 1;", p.MustGet("users.Static"))
+		assert.Equal(t, "SELECT 2;", p.MustGet("users.Dynamic"))
+
+		clock.Advance(time.Minute)
+		assert.Equal(t, "SELECT 1;", p.MustGet("users.Static"))
+		assert.Equal(t, "SELECT 3;", p.MustGet("users.Dynamic"))
+	})
+
+	t.Run("stale while revalidate", func(t *testing.T) {
+		t.Parallel()
+
+		src := &countingProvider{}
+		p, clock := newCachingProvider(src,
+			sqlset.WithCacheTTL(time.Minute), sqlset.WithStaleWhileRevalidate(time.Minute))
+
+		assert.Equal(t, "SELECT 1;", p.MustGet("users.GetUser"))
+
+		clock.Advance(90 * time.Second)
+		assert.Equal(t, "SELECT 1;", p.MustGet("users.GetUser"))
+
+		require.Eventually(t, func() bool {
+			return p.MustGet("users.GetUser") == "SELECT 2;"
+		}, time.Second, time.Millisecond)
+	})
+
+	t.Run("refresh error keeps stale value", func(t *testing.T) {
+		t.Parallel()
+
+		errRemote := errors.New("remote unavailable")
+		errs := make(chan error, 1)
+
+		src := &countingProvider{}
+		p, clock := newCachingProvider(src,
+			sqlset.WithCacheTTL(time.Minute),
+			sqlset.WithStaleWhileRevalidate(time.Minute),
+			sqlset.WithCacheErrorHandler(func(_ string, err error) {
+				errs <- err
+			}))
+
+		assert.Equal(t, "SELECT 1;", p.MustGet("users.GetUser"))
+
+		src.setErr(errRemote)
+		clock.Advance(90 * time.Second)
+		assert.Equal(t, "SELECT 1;", p.MustGet("users.GetUser"))
+		require.ErrorIs(t, <-errs, errRemote)
+
+		clock.Advance(time.Minute)
+		_, err := p.Get("users.GetUser")
+		require.ErrorIs(t, err, errRemote)
+	})
+
+	t.Run("invalidate", func(t *testing.T) {
+		t.Parallel()
+
+		p, _ := newCachingProvider(&countingProvider{})
+
+		assert.Equal(t, "SELECT 1;", p.MustGet("users.GetUser"))
+		assert.Equal(t, "SELECT 2;", p.MustGet("users.ListUsers"))
+
+		p.Invalidate("users", "GetUser")
+		assert.Equal(t, "SELECT 3;", p.MustGet("users.GetUser"))
+		assert.Equal(t, "SELECT 2;", p.MustGet("users.ListUsers"))
+
+		p.InvalidateAll()
+		assert.Equal(t, "SELECT 4;", p.MustGet("users.ListUsers"))
+	})
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/istovpets/sqlset"
+	"github.com/istovpets/sqlset/sqlsetdb"
+)
+
+func runBench(args []string) error {
+	flags := flag.NewFlagSet("bench", flag.ExitOnError)
+	dir := flags.String("dir", "queries", "directory with .sql files (relative to current working directory)")
+	driver := flags.String("driver", defaultDriver, "database/sql driver name")
+	dsn := flags.String("dsn", "", "database connection string")
+	runs := flags.Int("n", 100, "number of runs per query")
+	format := flags.String("format", "table", "output format: table or json")
+
+	queryArgs := map[string][]any{}
+
+	flags.Func("arg", "append an argument of a query, e.g. users.GetUserByID=42 (repeatable)", func(s string) error {
+		key, value, ok := strings.Cut(s, "=")
+		if !ok {
+			return fmt.Errorf("invalid argument %q, expected key=value", s)
+		}
+
+		queryArgs[key] = append(queryArgs[key], value)
+
+		return nil
+	})
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: sqlset-gen bench [flags] set.Query [set.Query...]")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		return fmt.Errorf("query key: %w", errFlagRequired)
+	}
+
+	queries := make([]sqlsetdb.BenchQuery, 0, flags.NArg())
+
+	for _, arg := range flags.Args() {
+		setID, queryID, ok := strings.Cut(arg, ".")
+		if !ok {
+			return fmt.Errorf("invalid query key %q, expected set.Query", arg)
+		}
+
+		queries = append(queries, sqlsetdb.BenchQuery{
+			Key:  sqlset.QueryKey{SetID: setID, QueryID: queryID},
+			Args: queryArgs[arg],
+		})
+	}
+
+	sqlSet, err := loadSQLSet(*dir)
+	if err != nil {
+		return err
+	}
+
+	db, err := openDB(*driver, *dsn)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = db.Close()
+	}()
+
+	results, err := sqlsetdb.Bench(context.Background(), db, sqlSet, *runs, queries...)
+	if err != nil {
+		return err
+	}
+
+	return writeBench(os.Stdout, *format, results)
+}
+
+func writeBench(w io.Writer, format string, results []sqlsetdb.BenchResult) error {
+	switch format {
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "query\truns\tmin\tp50\tp90\tp99\tmax")
+
+		for _, r := range results {
+			fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%s\t%s\n", r.Key, r.Runs, r.Min, r.P50, r.P90, r.P99, r.Max)
+		}
+
+		return tw.Flush()
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(results)
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/istovpets/sqlset"
+	"github.com/istovpets/sqlset/sqlsetdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteBench(t *testing.T) {
+	results := []sqlsetdb.BenchResult{{
+		Key:  sqlset.QueryKey{SetID: "users", QueryID: "GetUser"},
+		Runs: 100,
+		Min:  time.Millisecond,
+		P50:  2 * time.Millisecond,
+		P90:  3 * time.Millisecond,
+		P99:  4 * time.Millisecond,
+		Max:  5 * time.Millisecond,
+	}}
+
+	var table bytes.Buffer
+	require.NoError(t, writeBench(&table, "table", results))
+	assert.Equal(t, `query          runs  min  p50  p90  p99  max
+users.GetUser  100   1ms  2ms  3ms  4ms  5ms
+`, table.String())
+
+	var js bytes.Buffer
+	require.NoError(t, writeBench(&js, "json", results))
+	assert.Contains(t, js.String(), `"p99": 4000000`)
+}
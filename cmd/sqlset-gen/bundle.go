@@ -0,0 +1,32 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+func runBundle(args []string) error {
+	flags := flag.NewFlagSet("bundle", flag.ExitOnError)
+	dir := flags.String("dir", "queries", "directory with .sql files (relative to current working directory)")
+	out := flags.String("out", "queries.bundle", "output bundle file path")
+	_ = flags.Parse(args)
+
+	sqlSet, err := loadSQLSet(*dir)
+	if err != nil {
+		return err
+	}
+
+	data, err := sqlSet.MarshalBundle()
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(*out, data, 0644); err != nil {
+		return err
+	}
+
+	fmt.Printf("Bundled: %s (%d sets, %d bytes)\n", *out, len(sqlSet.GetSetsMetas()), len(data))
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// errIdentifierCollision is returned when two generated identifiers are the same.
+var errIdentifierCollision = errors.New("identifier collision")
+
+// The casings of the generated identifiers, see WithCasing.
+const (
+	caseCamel      = "camel"
+	caseSnake      = "snake"
+	caseUpperSnake = "upper-snake"
+)
+
+// WithCasing sets the casing of the generated identifiers: "camel" (UsersGetUserByID, the default),
+// "snake" (Users_get_user_by_id) or "upper-snake" (USERS_GET_USER_BY_ID).
+func WithCasing(casing string) GenerateOption {
+	return func(g *generator) {
+		g.casing = casing
+	}
+}
+
+// WithAcronyms sets the words upper-cased in camel case identifiers, e.g. "ID" and "URL"
+// turn GetUserById into GetUserByID.
+func WithAcronyms(acronyms ...string) GenerateOption {
+	return func(g *generator) {
+		for _, a := range acronyms {
+			if a = strings.TrimSpace(a); a != "" {
+				g.acronyms[strings.ToUpper(a)] = true
+			}
+		}
+	}
+}
+
+// ident returns the identifier of the names, e.g. of a set and a query ID, in the casing of g.
+func (g *generator) ident(names ...string) (string, error) {
+	var sb strings.Builder
+
+	switch g.casing {
+	case "", caseCamel:
+		for _, name := range names {
+			camel := toCamel(name)
+			if len(g.acronyms) == 0 {
+				sb.WriteString(camel)
+
+				continue
+			}
+
+			for _, w := range splitWords(camel) {
+				if g.acronyms[strings.ToUpper(w)] {
+					w = strings.ToUpper(w)
+				}
+
+				sb.WriteString(w)
+			}
+		}
+	case caseSnake, caseUpperSnake:
+		var words []string
+		for _, name := range names {
+			words = append(words, splitWords(name)...)
+		}
+
+		ident := strings.ToLower(strings.Join(words, "_"))
+		if g.casing == caseUpperSnake {
+			ident = strings.ToUpper(ident)
+		} else if ident != "" {
+			ident = strings.ToUpper(ident[:1]) + ident[1:]
+		}
+
+		sb.WriteString(ident)
+	default:
+		return "", fmt.Errorf("unknown casing %q, expected %s, %s or %s", g.casing, caseCamel, caseSnake, caseUpperSnake)
+	}
+
+	return sb.String(), nil
+}
+
+// splitWords splits a name into words at the underscores, hyphens and spaces
+// and at the case changes, keeping acronyms whole: "getHTTPServer_v2" is get, HTTP, Server, v2.
+func splitWords(s string) []string {
+	var words []string
+
+	for _, part := range strings.FieldsFunc(s, func(r rune) bool {
+		return r == '_' || r == '-' || unicode.IsSpace(r)
+	}) {
+		runes := []rune(part)
+		start := 0
+
+		for i := 1; i < len(runes); i++ {
+			prev, cur := runes[i-1], runes[i]
+			next := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+
+			if unicode.IsUpper(cur) && (!unicode.IsUpper(prev) || next) {
+				words = append(words, string(runes[start:i]))
+				start = i
+			}
+		}
+
+		words = append(words, string(runes[start:]))
+	}
+
+	return words
+}
+
+// identifiers detects the collisions of the generated identifiers.
+type identifiers map[string]string
+
+// add records the identifier generated for what, e.g. a query key, and returns
+// errIdentifierCollision if it was already generated for something else.
+func (ids identifiers) add(ident, what string) error {
+	if prev, ok := ids[ident]; ok {
+		return fmt.Errorf("%w: %s and %s both map to %s", errIdentifierCollision, prev, what, ident)
+	}
+
+	ids[ident] = what
+
+	return nil
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/istovpets/sqlset"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitWords(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected []string
+	}{
+		{in: "GetUserByID", expected: []string{"Get", "User", "By", "ID"}},
+		{in: "getHTTPServer_v2", expected: []string{"get", "HTTP", "Server", "v2"}},
+		{in: "user-roles", expected: []string{"user", "roles"}},
+		{in: "ById", expected: []string{"By", "Id"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			assert.Equal(t, tt.expected, splitWords(tt.in))
+		})
+	}
+}
+
+func TestGenerateConstants_Casing(t *testing.T) {
+	sqlSet, err := sqlset.New(fstest.MapFS{
+		"users.sql": &fstest.MapFile{Data: []byte(`--SQL:GetUserById
+--PARAMS: id int
+SELECT 1 WHERE id = $1;
+--end
+
+--SQL:get_avatar_url
+SELECT 2;
+--end`)},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		opts     []GenerateOption
+		expected []string
+	}{
+		{
+			name: "camel",
+			expected: []string{
+				"\tUsersGetUserById = \"users.GetUserById\"\n", "\tUsersGetAvatarUrl = ", "func UsersGetUserByIdArgs(",
+			},
+		},
+		{
+			name:     "acronyms",
+			opts:     []GenerateOption{WithAcronyms("id", " URL")},
+			expected: []string{"\tUsersGetUserByID = ", "\tUsersGetAvatarURL = ", "func UsersGetUserByIDArgs("},
+		},
+		{
+			name:     "snake",
+			opts:     []GenerateOption{WithCasing("snake")},
+			expected: []string{"\tUsers_get_user_by_id = ", "\tUsers_get_avatar_url = ", "func Users_get_user_by_id_args("},
+		},
+		{
+			name:     "upper snake",
+			opts:     []GenerateOption{WithCasing("upper-snake")},
+			expected: []string{"\tUSERS_GET_USER_BY_ID = ", "\tUSERS_GET_AVATAR_URL = ", "func USERS_GET_USER_BY_ID_ARGS("},
+		},
+		{
+			name: "snake structs",
+			opts: []GenerateOption{WithCasing("snake"), WithStructs()},
+			expected: []string{
+				"var Users = users_queries{\n", "\tGet_user_by_id: ", "func (users_queries) Get_user_by_id_args(",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			generated, err := GenerateConstants(sqlSet, "queries", tt.opts...)
+			require.NoError(t, err)
+
+			for _, s := range tt.expected {
+				assert.Contains(t, generated, s)
+			}
+		})
+	}
+
+	_, err = GenerateConstants(sqlSet, "queries", WithCasing("kebab"))
+	require.ErrorContains(t, err, `unknown casing "kebab"`)
+}
+
+func TestGenerateConstants_WhenIdentifiersCollide_ExpectError(t *testing.T) {
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+		opts  []GenerateOption
+		want  string
+	}{
+		{
+			name: "query IDs",
+			files: fstest.MapFS{
+				"users.sql": &fstest.MapFile{Data: []byte("--SQL:get_user\nSELECT 1;\n--end\n--SQL:GetUser\nSELECT 2;\n--end\n")},
+			},
+			want: "users.GetUser and users.get_user both map to UsersGetUser",
+		},
+		{
+			name: "set and query IDs",
+			files: fstest.MapFS{
+				"users.sql":      &fstest.MapFile{Data: []byte("--SQL:ListAll\nSELECT 1;\n--end\n")},
+				"users_list.sql": &fstest.MapFile{Data: []byte("--SQL:All\nSELECT 2;\n--end\n")},
+			},
+			want: "users.ListAll and users_list.All both map to UsersListAll",
+		},
+		{
+			name: "arguments function",
+			files: fstest.MapFS{
+				"users.sql": &fstest.MapFile{Data: []byte(
+					"--SQL:Get\n--PARAMS: id\nSELECT $1;\n--end\n--SQL:GetArgs\nSELECT 2;\n--end\n",
+				)},
+			},
+			opts: []GenerateOption{WithStructs()},
+			want: "the arguments of users.Get and users.GetArgs both map to GetArgs",
+		},
+		{
+			name: "acronyms",
+			files: fstest.MapFS{
+				"users.sql": &fstest.MapFile{Data: []byte("--SQL:GetById\nSELECT 1;\n--end\n--SQL:GetByID\nSELECT 2;\n--end\n")},
+			},
+			opts: []GenerateOption{WithAcronyms("ID")},
+			want: "users.GetByID and users.GetById both map to UsersGetByID",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlSet, err := sqlset.New(tt.files)
+			require.NoError(t, err)
+
+			_, err = GenerateConstants(sqlSet, "queries", tt.opts...)
+			require.ErrorIs(t, err, errIdentifierCollision)
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+}
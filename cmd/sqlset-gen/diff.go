@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/istovpets/sqlset"
+	"github.com/istovpets/sqlset/sqlsetgit"
+)
+
+func runDiff(args []string) error {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	oldDir := flags.String("old", "", "directory with the old .sql files")
+	newDir := flags.String("new", "queries", "directory with the new .sql files")
+	oldRev := flags.String("old-rev", "", "git revision of the old .sql files, read from --old or else --new in the current repository")
+	format := flags.String("format", "text", "output format: text or json")
+	semantic := flags.Bool("semantic", false, "ignore formatting-only changes: whitespace, comments and keyword case")
+	_ = flags.Parse(args)
+
+	var (
+		oldSet *sqlset.SQLSet
+		err    error
+	)
+
+	switch {
+	case *oldRev != "":
+		dir := *oldDir
+		if dir == "" {
+			dir = *newDir
+		}
+
+		oldSet, err = sqlsetgit.New(context.Background(), ".", *oldRev, dir)
+	case *oldDir != "":
+		oldSet, err = loadSQLSet(*oldDir)
+	default:
+		return fmt.Errorf("old: %w", errFlagRequired)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	newSet, err := loadSQLSet(*newDir)
+	if err != nil {
+		return err
+	}
+
+	var opts []sqlset.DiffOption
+	if *semantic {
+		opts = append(opts, sqlset.WithSemanticDiff())
+	}
+
+	return writeDiff(os.Stdout, *format, sqlset.Diff(oldSet, newSet, opts...))
+}
+
+func writeDiff(w io.Writer, format string, changes sqlset.ChangeSet) error {
+	switch format {
+	case "text":
+		for _, key := range changes.Removed {
+			fmt.Fprintf(w, "removed: %s\n", key)
+		}
+
+		for _, key := range changes.Added {
+			fmt.Fprintf(w, "added: %s\n", key)
+		}
+
+		for _, c := range changes.Modified {
+			fmt.Fprint(w, c.Diff)
+		}
+
+		return nil
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(changes)
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
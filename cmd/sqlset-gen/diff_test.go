@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/istovpets/sqlset"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteDiff(t *testing.T) {
+	changes := sqlset.ChangeSet{
+		Added:   []sqlset.QueryKey{{SetID: "users", QueryID: "ListUsers"}},
+		Removed: []sqlset.QueryKey{{SetID: "users", QueryID: "DeleteUser"}},
+		Modified: []sqlset.QueryChange{{
+			Key:  sqlset.QueryKey{SetID: "users", QueryID: "GetUser"},
+			Diff: "--- a/users.GetUser\n+++ b/users.GetUser\n@@ -1,1 +1,1 @@\n-SELECT 1;\n+SELECT 2;\n",
+		}},
+	}
+
+	var text bytes.Buffer
+	require.NoError(t, writeDiff(&text, "text", changes))
+	assert.Equal(t, `removed: users.DeleteUser
+added: users.ListUsers
+--- a/users.GetUser
++++ b/users.GetUser
+@@ -1,1 +1,1 @@
+-SELECT 1;
++SELECT 2;
+`, text.String())
+
+	var js bytes.Buffer
+	require.NoError(t, writeDiff(&js, "json", changes))
+	assert.Contains(t, js.String(), `"removed": [`)
+}
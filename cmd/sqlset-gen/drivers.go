@@ -0,0 +1,31 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+
+	// Registers the "pgx" database/sql driver used by the database commands.
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+const defaultDriver = "pgx"
+
+// openDB opens the database and verifies the connection.
+func openDB(driver, dsn string) (*sql.DB, error) {
+	if dsn == "" {
+		return nil, fmt.Errorf("dsn: %w", errFlagRequired)
+	}
+
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+
+	if err := db.Ping(); err != nil {
+		_ = db.Close()
+
+		return nil, fmt.Errorf("connect to database: %w", err)
+	}
+
+	return db, nil
+}
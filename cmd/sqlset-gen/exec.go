@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+)
+
+func runExec(args []string) error {
+	flags := flag.NewFlagSet("exec", flag.ExitOnError)
+	dir := flags.String("dir", "queries", "directory with .sql files (relative to current working directory)")
+	driver := flags.String("driver", defaultDriver, "database/sql driver name")
+	dsn := flags.String("dsn", "", "database connection string")
+	format := flags.String("format", "table", "output format: table or json")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: sqlset-gen exec [flags] set.Query [--] [args...]")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		return fmt.Errorf("query key: %w", errFlagRequired)
+	}
+
+	key, queryArgs := flags.Arg(0), flags.Args()[1:]
+	if len(queryArgs) > 0 && queryArgs[0] == "--" {
+		queryArgs = queryArgs[1:]
+	}
+
+	sqlSet, err := loadSQLSet(*dir)
+	if err != nil {
+		return err
+	}
+
+	query, err := sqlSet.Get(key)
+	if err != nil {
+		return err
+	}
+
+	db, err := openDB(*driver, *dsn)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = db.Close()
+	}()
+
+	// The arguments are passed as text, the database casts them to the parameter types.
+	bound := make([]any, len(queryArgs))
+	for i, arg := range queryArgs {
+		bound[i] = arg
+	}
+
+	columns, rows, err := queryRows(context.Background(), db, query, bound)
+	if err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+
+	return writeRows(os.Stdout, *format, columns, rows)
+}
+
+// queryRows runs the query and reads all the result rows.
+func queryRows(ctx context.Context, db *sql.DB, query string, args []any) ([]string, [][]any, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var result [][]any
+
+	for rows.Next() {
+		values := make([]any, len(columns))
+		ptrs := make([]any, len(columns))
+
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, nil, err
+		}
+
+		for i, v := range values {
+			if b, ok := v.([]byte); ok {
+				values[i] = string(b)
+			}
+		}
+
+		result = append(result, values)
+	}
+
+	return columns, result, rows.Err()
+}
+
+func writeRows(w io.Writer, format string, columns []string, rows [][]any) error {
+	switch format {
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+		for i, c := range columns {
+			if i > 0 {
+				fmt.Fprint(tw, "\t")
+			}
+
+			fmt.Fprint(tw, c)
+		}
+
+		fmt.Fprintln(tw)
+
+		for _, row := range rows {
+			for i, v := range row {
+				if i > 0 {
+					fmt.Fprint(tw, "\t")
+				}
+
+				if v == nil {
+					v = "NULL"
+				}
+
+				fmt.Fprint(tw, v)
+			}
+
+			fmt.Fprintln(tw)
+		}
+
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+
+		_, err := fmt.Fprintf(w, "(%d rows)\n", len(rows))
+
+		return err
+	case "json":
+		objects := make([]map[string]any, 0, len(rows))
+
+		for _, row := range rows {
+			obj := make(map[string]any, len(columns))
+			for i, c := range columns {
+				obj[c] = row[i]
+			}
+
+			objects = append(objects, obj)
+		}
+
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(objects)
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
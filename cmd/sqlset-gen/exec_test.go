@@ -0,0 +1,31 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteRows(t *testing.T) {
+	columns := []string{"id", "name"}
+	rows := [][]any{
+		{int64(1), "alice"},
+		{int64(42), nil},
+	}
+
+	var table bytes.Buffer
+	require.NoError(t, writeRows(&table, "table", columns, rows))
+	assert.Equal(t, `id  name
+1   alice
+42  NULL
+(2 rows)
+`, table.String())
+
+	var js bytes.Buffer
+	require.NoError(t, writeRows(&js, "json", columns, rows))
+	assert.JSONEq(t, `[{"id": 1, "name": "alice"}, {"id": 42, "name": null}]`, js.String())
+
+	assert.Error(t, writeRows(&js, "xml", columns, rows))
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/istovpets/sqlset/sqlsetdb"
+)
+
+func runExplain(args []string) error {
+	flags := flag.NewFlagSet("explain", flag.ExitOnError)
+	dir := flags.String("dir", "queries", "directory with .sql files (relative to current working directory)")
+	driver := flags.String("driver", defaultDriver, "database/sql driver name")
+	dsn := flags.String("dsn", "", "database connection string")
+	mode := flags.String("mode", "prepare", "validation mode: prepare or explain")
+	_ = flags.Parse(args)
+
+	var explainMode sqlsetdb.ExplainMode
+
+	switch *mode {
+	case "prepare":
+		explainMode = sqlsetdb.ModePrepare
+	case "explain":
+		explainMode = sqlsetdb.ModeExplain
+	default:
+		return fmt.Errorf("unknown mode %q", *mode)
+	}
+
+	sqlSet, err := loadSQLSet(*dir)
+	if err != nil {
+		return err
+	}
+
+	db, err := openDB(*driver, *dsn)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = db.Close()
+	}()
+
+	if err := sqlsetdb.ExplainAll(context.Background(), db, sqlSet, explainMode); err != nil {
+		return err
+	}
+
+	fmt.Printf("Validated: %d sets against the database\n", len(sqlSet.GetSetsMetas()))
+
+	return nil
+}
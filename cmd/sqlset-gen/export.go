@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/istovpets/sqlset"
+)
+
+// exportHeader is the header row of the catalog export.
+var exportHeader = []string{"set", "query", "tags", "owner", "hash", "lines"}
+
+func runExport(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	dir := flags.String("dir", "queries", "directory with .sql files (relative to current working directory)")
+	format := flags.String("format", "csv", "output format: csv or tsv")
+	out := flags.String("out", "", "output file path (default stdout)")
+	_ = flags.Parse(args)
+
+	sqlSet, err := loadSQLSet(*dir)
+	if err != nil {
+		return err
+	}
+
+	rows, err := exportRows(sqlSet)
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(*out, func(w io.Writer) error {
+		return writeExport(w, *format, rows)
+	})
+}
+
+// exportRows returns a row of the catalog inventory for every query, sorted by key.
+// Tags are separated by semicolons.
+func exportRows(sqlSet *sqlset.SQLSet) ([][]string, error) {
+	owners := make(map[string]string)
+	for _, meta := range sqlSet.GetSetsMetas() {
+		owners[meta.ID] = meta.Owner
+	}
+
+	var rows [][]string
+
+	for _, entry := range sqlSet.Inventory() {
+		q, err := sqlSet.Describe(entry.Key.SetID, entry.Key.QueryID)
+		if err != nil {
+			return nil, err
+		}
+
+		rows = append(rows, []string{
+			entry.Key.SetID,
+			entry.Key.QueryID,
+			strings.Join(entry.Tags, ";"),
+			owners[entry.Key.SetID],
+			entry.Hash,
+			strconv.Itoa(strings.Count(q.SQL, "\n") + 1),
+		})
+	}
+
+	return rows, nil
+}
+
+func writeExport(w io.Writer, format string, rows [][]string) error {
+	cw := csv.NewWriter(w)
+
+	switch format {
+	case "csv":
+	case "tsv":
+		cw.Comma = '\t'
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+
+	if err := cw.Write(exportHeader); err != nil {
+		return err
+	}
+
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+
+	return cw.Error()
+}
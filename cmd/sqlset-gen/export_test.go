@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportRows(t *testing.T) {
+	root := t.TempDir()
+
+	writeFiles(t, root, map[string]string{
+		"payments.sql": `--META
+{"owner": "@acme/team-payments"}
+--end
+
+--SQL:Charge
+--META: {"tags": ["billing", "pci"]}
+SELECT 1
+FROM charges;
+--end`,
+		"misc.sql": `--SQL:Ping
+SELECT 3;
+--end`,
+	})
+
+	sqlSet, err := loadSQLSet(root)
+	require.NoError(t, err)
+
+	rows, err := exportRows(sqlSet)
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+
+	hash := rows[1][4]
+	assert.Len(t, hash, 64)
+	assert.Equal(t, []string{"misc", "Ping", "", "", rows[0][4], "1"}, rows[0])
+	assert.Equal(t, []string{"payments", "Charge", "billing;pci", "@acme/team-payments", hash, "2"}, rows[1])
+
+	var buf bytes.Buffer
+	require.NoError(t, writeExport(&buf, "csv", rows))
+	assert.Equal(t, "set,query,tags,owner,hash,lines\nmisc,Ping,,,"+rows[0][4]+",1\n"+
+		"payments,Charge,billing;pci,@acme/team-payments,"+hash+",2\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, writeExport(&buf, "tsv", rows))
+	assert.Contains(t, buf.String(), "set\tquery\ttags\towner\thash\tlines\n")
+
+	require.Error(t, writeExport(&buf, "xlsx", rows))
+}
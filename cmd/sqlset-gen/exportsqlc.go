@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/istovpets/sqlset"
+)
+
+// defaultSQLCCommand is used for queries without a command annotation.
+const defaultSQLCCommand = "exec"
+
+func runExportSQLC(args []string) error {
+	flags := flag.NewFlagSet("export-sqlc", flag.ExitOnError)
+	dir := flags.String("dir", "queries", "directory with .sql files (relative to current working directory)")
+	out := flags.String("out", "sqlc", "output directory for the sqlc-annotated files")
+	_ = flags.Parse(args)
+
+	sqlSet, err := loadSQLSet(*dir)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		return err
+	}
+
+	setIDs := sortedSetIDs(sqlSet)
+
+	for _, setID := range setIDs {
+		path := filepath.Join(*out, setID+".sql")
+
+		err := writeOutput(path, func(w io.Writer) error {
+			return exportSQLC(sqlSet, setID, w)
+		})
+		if err != nil {
+			return fmt.Errorf("export %s: %w", setID, err)
+		}
+	}
+
+	fmt.Printf("Exported: %d sets into %s\n", len(setIDs), *out)
+
+	return nil
+}
+
+// exportSQLC writes the queries of the set in the sqlc annotation format
+// ("-- name: GetAuthor :one"). Queries without a command annotation are exported as :exec,
+// template queries are skipped.
+func exportSQLC(sqlSet *sqlset.SQLSet, setID string, w io.Writer) error {
+	ids, err := sqlSet.GetQueryIDs(setID)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+
+	written := 0
+
+	for _, id := range ids {
+		tmpl, err := sqlSet.IsTemplate(setID, id)
+		if err != nil {
+			return err
+		}
+
+		// sqlc cannot compile the template queries.
+		if tmpl {
+			continue
+		}
+
+		q, err := sqlSet.Describe(setID, id)
+		if err != nil {
+			return err
+		}
+
+		command := q.Meta.Command
+		if command == "" {
+			command = defaultSQLCCommand
+		}
+
+		if written > 0 {
+			fmt.Fprintln(bw)
+		}
+
+		written++
+
+		fmt.Fprintf(bw, "-- name: %s :%s\n", id, command)
+		fmt.Fprintln(bw, strings.ReplaceAll(q.SQL, "\r\n", "\n"))
+	}
+
+	return bw.Flush()
+}
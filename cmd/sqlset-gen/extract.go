@@ -0,0 +1,547 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/istovpets/sqlset"
+)
+
+// sqlVerbs are the first words of the string literals extracted as queries.
+var sqlVerbs = map[string]bool{"SELECT": true, "INSERT": true, "UPDATE": true, "DELETE": true}
+
+// formatVerb matches the fmt verbs of the literals used as format strings, which are not extracted.
+var formatVerb = regexp.MustCompile(`%(\[\d+\])?[-+# 0]*\d*(\.\d+)?[sdvqx]\b`)
+
+// extractedQuery is a SQL string literal moved from Go code into a .sql file.
+type extractedQuery struct {
+	Key sqlset.QueryKey
+	SQL string
+	// Refs are the "file:line" locations of the literals replaced by the query.
+	Refs []string
+}
+
+// extractEdit replaces the bytes [start, end) of a Go file.
+type extractEdit struct {
+	start, end int
+	text       string
+}
+
+// extraction is the result of scanning Go packages for SQL literals.
+type extraction struct {
+	// Sets holds the extracted queries by set ID, in order of appearance.
+	Sets map[string][]*extractedQuery
+	// Skipped lists the "file:line: reason" of the SQL literals left in place.
+	Skipped []string
+	// edits holds the rewrites of the Go files by path.
+	edits map[string][]extractEdit
+	// imports holds the Go files needing the provider import.
+	imports map[string]bool
+}
+
+func runExtract(args []string) error {
+	flags := flag.NewFlagSet("extract", flag.ExitOnError)
+	out := flags.String("out", "queries", "directory of the generated .sql files, one per package")
+	provider := flags.String("provider", "queries.SQL", "Go expression of the provider replacing the literals")
+	importPath := flags.String("import", "", "import path of the provider package, added to the rewritten files")
+	dryRun := flags.Bool("dry-run", false, "list the queries that would be extracted without writing anything")
+	_ = flags.Parse(args)
+
+	patterns := flags.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+
+	dirs, err := packageDirs(patterns)
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[string][]string)
+
+	if _, err := os.Stat(*out); err == nil {
+		sqlSet, err := loadSQLSet(*out)
+		if err != nil {
+			return err
+		}
+
+		for _, setID := range sortedSetIDs(sqlSet) {
+			existing[setID], _ = sqlSet.GetQueryIDs(setID)
+		}
+	}
+
+	ex, err := extractSQL(dirs, *provider, existing)
+	if err != nil {
+		return err
+	}
+
+	for _, skipped := range ex.Skipped {
+		fmt.Println("skipped:", skipped)
+	}
+
+	if *dryRun {
+		for _, setID := range slices.Sorted(maps.Keys(ex.Sets)) {
+			for _, q := range ex.Sets[setID] {
+				fmt.Printf("%s: %s\n", q.Key, strings.Join(q.Refs, ", "))
+			}
+		}
+
+		return nil
+	}
+
+	if err := writeExtracted(*out, ex); err != nil {
+		return err
+	}
+
+	if err := rewriteGoFiles(ex, *importPath); err != nil {
+		return err
+	}
+
+	fmt.Printf("Extracted: %d sets into %s, %d literals skipped\n", len(ex.Sets), *out, len(ex.Skipped))
+
+	return nil
+}
+
+// packageDirs resolves the directory patterns, "dir/..." matches dir and its subdirectories
+// except vendor, testdata and hidden ones.
+func packageDirs(patterns []string) ([]string, error) {
+	var dirs []string
+
+	for _, pattern := range patterns {
+		root, recursive := strings.CutSuffix(filepath.ToSlash(pattern), "/...")
+		if root == "..." {
+			root, recursive = ".", true
+		}
+
+		if !recursive {
+			dirs = append(dirs, filepath.Clean(root))
+
+			continue
+		}
+
+		err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if !entry.IsDir() {
+				return nil
+			}
+
+			name := entry.Name()
+			if path != root && (name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".")) {
+				return filepath.SkipDir
+			}
+
+			dirs = append(dirs, path)
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return dirs, nil
+}
+
+// extractSQL finds the SQL string literals in the Go files of dirs, except tests and generated files,
+// and plans their extraction into a set per package. existing holds the query IDs already in use by set ID.
+//
+//nolint:gocognit
+func extractSQL(dirs []string, provider string, existing map[string][]string) (*extraction, error) {
+	ex := &extraction{
+		Sets:    make(map[string][]*extractedQuery),
+		edits:   make(map[string][]extractEdit),
+		imports: make(map[string]bool),
+	}
+
+	fset := token.NewFileSet()
+	pkgSets := make(map[string]string)
+
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			name := entry.Name()
+			if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+				continue
+			}
+
+			path := filepath.Join(dir, name)
+
+			file, err := parser.ParseFile(fset, path, nil, parser.ParseComments|parser.SkipObjectResolution)
+			if err != nil {
+				return nil, err
+			}
+
+			if isGenerated(file) {
+				continue
+			}
+
+			pkg := dir + "\x00" + file.Name.Name
+			if pkgSets[pkg] == "" {
+				pkgSets[pkg] = uniqueSetID(strings.ToLower(file.Name.Name), pkgSets)
+			}
+
+			fx := fileExtractor{ex: ex, fset: fset, path: path, setID: pkgSets[pkg], provider: provider}
+			fx.used = append([]string(nil), existing[fx.setID]...)
+
+			for _, q := range ex.Sets[fx.setID] {
+				fx.used = append(fx.used, q.Key.QueryID)
+			}
+
+			fx.file(file)
+		}
+	}
+
+	return ex, nil
+}
+
+// uniqueSetID returns base or base with a number suffix, not used by another package.
+// Existing sets of the same name are appended to.
+func uniqueSetID(base string, pkgSets map[string]string) string {
+	taken := func(id string) bool {
+		for _, setID := range pkgSets {
+			if setID == id {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	id := base
+	for i := 2; taken(id); i++ {
+		id = base + strconv.Itoa(i)
+	}
+
+	return id
+}
+
+// fileExtractor plans the extraction of the SQL literals of a single Go file.
+type fileExtractor struct {
+	ex       *extraction
+	fset     *token.FileSet
+	path     string
+	setID    string
+	provider string
+	used     []string
+}
+
+// literal is a SQL string literal found in a declaration.
+type literal struct {
+	lit  *ast.BasicLit
+	sql  string
+	name string
+}
+
+func (fx *fileExtractor) file(file *ast.File) {
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Body != nil {
+				fx.replace(fx.literals(d.Body, d.Name.Name))
+			}
+		case *ast.GenDecl:
+			var lits []literal
+
+			for _, spec := range d.Specs {
+				vs, ok := spec.(*ast.ValueSpec)
+				if !ok {
+					continue
+				}
+
+				for i, value := range vs.Values {
+					name := ""
+					if i < len(vs.Names) {
+						name = vs.Names[i].Name
+					}
+
+					lits = append(lits, fx.literals(value, name)...)
+				}
+			}
+
+			if d.Tok != token.CONST || len(lits) == 0 {
+				fx.replace(lits)
+
+				continue
+			}
+
+			// Constants can not hold provider calls, the declaration becomes var
+			// when all its values are extracted.
+			if !fx.allValues(d, lits) {
+				for _, l := range lits {
+					fx.skip(l.lit, "constant declaration with other values")
+				}
+
+				continue
+			}
+
+			start := fx.fset.Position(d.TokPos).Offset
+			fx.ex.edits[fx.path] = append(fx.ex.edits[fx.path], extractEdit{start: start, end: start + len("const"), text: "var"})
+			fx.replace(lits)
+		}
+	}
+}
+
+// allValues reports whether every value of the declaration is one of lits.
+func (fx *fileExtractor) allValues(d *ast.GenDecl, lits []literal) bool {
+	n := 0
+
+	for _, spec := range d.Specs {
+		vs, ok := spec.(*ast.ValueSpec)
+		if !ok || len(vs.Values) == 0 {
+			return false
+		}
+
+		n += len(vs.Values)
+
+		for _, value := range vs.Values {
+			if !containsLit(lits, value) {
+				return false
+			}
+		}
+	}
+
+	return n == len(lits)
+}
+
+func containsLit(lits []literal, node ast.Node) bool {
+	for _, l := range lits {
+		if ast.Node(l.lit) == node {
+			return true
+		}
+	}
+
+	return false
+}
+
+// literals returns the SQL literals under node, skipping the concatenated and format strings.
+func (fx *fileExtractor) literals(node ast.Node, name string) []literal {
+	var (
+		lits   []literal
+		concat = make(map[*ast.BasicLit]bool)
+	)
+
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.BinaryExpr:
+			if n.Op == token.ADD {
+				for _, operand := range []ast.Expr{n.X, n.Y} {
+					if lit, ok := operand.(*ast.BasicLit); ok {
+						concat[lit] = true
+					}
+				}
+			}
+		case *ast.BasicLit:
+			sql, ok := sqlLiteral(n)
+			if !ok {
+				return true
+			}
+
+			switch {
+			case concat[n]:
+				fx.skip(n, "concatenated string")
+			case formatVerb.MatchString(sql):
+				fx.skip(n, "format string")
+			default:
+				lits = append(lits, literal{lit: n, sql: sql, name: name})
+			}
+		}
+
+		return true
+	})
+
+	return lits
+}
+
+// replace plans the extraction of the literals, reusing the query of an identical body.
+func (fx *fileExtractor) replace(lits []literal) {
+	for _, l := range lits {
+		q := fx.query(l)
+		q.Refs = append(q.Refs, fx.position(l.lit))
+
+		pos := fx.fset.Position(l.lit.Pos()).Offset
+		fx.ex.edits[fx.path] = append(fx.ex.edits[fx.path], extractEdit{
+			start: pos,
+			end:   pos + len(l.lit.Value),
+			text:  fmt.Sprintf("%s.MustGet(%q)", fx.provider, q.Key.String()),
+		})
+		fx.ex.imports[fx.path] = true
+	}
+}
+
+func (fx *fileExtractor) query(l literal) *extractedQuery {
+	for _, q := range fx.ex.Sets[fx.setID] {
+		if q.SQL == l.sql {
+			return q
+		}
+	}
+
+	base := toCamel(l.name)
+	if base == "" {
+		base = "Query"
+	}
+
+	id := base
+	for i := 2; slices.Contains(fx.used, id); i++ {
+		id = base + strconv.Itoa(i)
+	}
+
+	fx.used = append(fx.used, id)
+
+	q := &extractedQuery{Key: sqlset.QueryKey{SetID: fx.setID, QueryID: id}, SQL: l.sql}
+	fx.ex.Sets[fx.setID] = append(fx.ex.Sets[fx.setID], q)
+
+	return q
+}
+
+func (fx *fileExtractor) skip(lit *ast.BasicLit, reason string) {
+	fx.ex.Skipped = append(fx.ex.Skipped, fx.position(lit)+": "+reason)
+}
+
+func (fx *fileExtractor) position(node ast.Node) string {
+	pos := fx.fset.Position(node.Pos())
+
+	return fmt.Sprintf("%s:%d", filepath.ToSlash(pos.Filename), pos.Line)
+}
+
+// sqlLiteral returns the value of a string literal starting with a SQL verb, with its lines trimmed.
+func sqlLiteral(lit *ast.BasicLit) (string, bool) {
+	if lit.Kind != token.STRING {
+		return "", false
+	}
+
+	s, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return "", false
+	}
+
+	fields := strings.Fields(s)
+	if len(fields) < 2 || !sqlVerbs[strings.ToUpper(fields[0])] {
+		return "", false
+	}
+
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+
+	return strings.Join(lines, "\n"), true
+}
+
+// writeExtracted appends the query blocks to the .sql file of every set in dir.
+func writeExtracted(dir string, ex *extraction) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	for _, setID := range slices.Sorted(maps.Keys(ex.Sets)) {
+		var buf bytes.Buffer
+
+		for _, q := range ex.Sets[setID] {
+			fmt.Fprintf(&buf, "\n--SQL:%s\n", q.Key.QueryID)
+
+			buf.WriteString(q.SQL + "\n--end\n")
+		}
+
+		path := filepath.Join(dir, setID+".sql")
+
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644) //nolint:gosec
+		if err != nil {
+			return err
+		}
+
+		if info, err := f.Stat(); err == nil && info.Size() == 0 {
+			buf.Next(1)
+		}
+
+		if _, err := buf.WriteTo(f); err != nil {
+			_ = f.Close()
+
+			return err
+		}
+
+		if err := f.Close(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rewriteGoFiles applies the edits to the Go files, adding the provider import if given.
+func rewriteGoFiles(ex *extraction, importPath string) error {
+	for _, path := range slices.Sorted(maps.Keys(ex.edits)) {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		edits := ex.edits[path]
+		sort.Slice(edits, func(i, j int) bool {
+			return edits[i].start > edits[j].start
+		})
+
+		for _, e := range edits {
+			src = append(src[:e.start:e.start], append([]byte(e.text), src[e.end:]...)...)
+		}
+
+		if importPath != "" && ex.imports[path] {
+			src, err = addImport(src, importPath)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+		}
+
+		formatted, err := format.Source(src)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		if err := os.WriteFile(path, formatted, 0o644); err != nil { //nolint:gosec
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addImport adds an import declaration after the package clause unless the file already imports path.
+func addImport(src []byte, path string) ([]byte, error) {
+	fset := token.NewFileSet()
+
+	file, err := parser.ParseFile(fset, "", src, parser.ImportsOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, spec := range file.Imports {
+		if p, _ := strconv.Unquote(spec.Path.Value); p == path {
+			return src, nil
+		}
+	}
+
+	end := fset.Position(file.Name.End()).Offset
+	decl := fmt.Sprintf("\n\nimport %q\n", path)
+
+	return append(src[:end:end], append([]byte(decl), src[end:]...)...), nil
+}
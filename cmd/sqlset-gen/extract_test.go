@@ -0,0 +1,98 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractSQL(t *testing.T) {
+	root := t.TempDir()
+
+	writeFiles(t, root, map[string]string{
+		"store/users.go": `package store
+
+import "fmt"
+
+const listUsers = ` + "`" + `
+	SELECT id, name
+	FROM users
+` + "`" + `
+
+const (
+	limit     = 10
+	countSQL  = "SELECT count(*) FROM users"
+)
+
+func (r *Repo) GetUser(id int) string {
+	_ = fmt.Sprintf("SELECT * FROM %s WHERE id = $1", "users")
+	_ = "DELETE FROM users WHERE id = " + fmt.Sprint(id)
+	_ = "UPDATE users SET name = $1 WHERE id = $2"
+
+	return "SELECT * FROM users WHERE id = $1"
+}
+
+func other() string {
+	return "SELECT id, name\nFROM users"
+}
+`,
+		"store/gen.go":        "// Code generated by hand. DO NOT EDIT.\n\npackage store\n\nvar q = \"SELECT 1 FROM x\"\n",
+		"store/users_test.go": "package store\n\nvar q = \"SELECT 2 FROM x\"\n",
+		"api/api.go":          "package api\n\nvar Ping = \"select 1 from dual\"\n",
+	})
+
+	dirs, err := packageDirs([]string{filepath.Join(root, "...")})
+	require.NoError(t, err)
+
+	ex, err := extractSQL(dirs, "queries.SQL", map[string][]string{"api": {"Ping"}})
+	require.NoError(t, err)
+
+	require.Len(t, ex.Sets, 2)
+	require.Len(t, ex.Sets["store"], 3)
+	assert.Equal(t, "store.ListUsers", ex.Sets["store"][0].Key.String())
+	assert.Equal(t, "SELECT id, name\nFROM users", ex.Sets["store"][0].SQL)
+	assert.Len(t, ex.Sets["store"][0].Refs, 2)
+	assert.Equal(t, "store.GetUser", ex.Sets["store"][1].Key.String())
+	assert.Equal(t, "store.GetUser2", ex.Sets["store"][2].Key.String())
+	assert.Equal(t, "api.Ping2", ex.Sets["api"][0].Key.String())
+	require.Len(t, ex.Skipped, 3)
+	assert.Contains(t, ex.Skipped[0], "users.go:12: constant declaration with other values")
+	assert.Contains(t, ex.Skipped[1], "users.go:16: format string")
+	assert.Contains(t, ex.Skipped[2], "users.go:17: concatenated string")
+
+	out := filepath.Join(root, "queries")
+	require.NoError(t, writeExtracted(out, ex))
+	require.NoError(t, rewriteGoFiles(ex, "example.com/app/queries"))
+
+	data, err := os.ReadFile(filepath.Join(out, "store.sql"))
+	require.NoError(t, err)
+	assert.Equal(t, `--SQL:ListUsers
+SELECT id, name
+FROM users
+--end
+
+--SQL:GetUser
+UPDATE users SET name = $1 WHERE id = $2
+--end
+
+--SQL:GetUser2
+SELECT * FROM users WHERE id = $1
+--end
+`, string(data))
+
+	sqlSet, err := loadSQLSet(out)
+	require.NoError(t, err)
+	assert.Len(t, sqlSet.GetSetsMetas(), 2)
+
+	src, err := os.ReadFile(filepath.Join(root, "store", "users.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(src), "import \"example.com/app/queries\"")
+	assert.Contains(t, string(src), `var listUsers = queries.SQL.MustGet("store.ListUsers")`)
+	assert.Contains(t, string(src), `countSQL = "SELECT count(*) FROM users"`)
+	assert.Contains(t, string(src), `_ = queries.SQL.MustGet("store.GetUser")`)
+	assert.Contains(t, string(src), `return queries.SQL.MustGet("store.GetUser2")`)
+	assert.Contains(t, string(src), `return queries.SQL.MustGet("store.ListUsers")`)
+}
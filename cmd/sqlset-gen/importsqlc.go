@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const sqlcNameMarker = "name:"
+
+func runImportSQLC(args []string) error {
+	flags := flag.NewFlagSet("import-sqlc", flag.ExitOnError)
+	in := flags.String("in", "", "directory with sqlc-annotated .sql files")
+	out := flags.String("out", "queries", "output directory for the sqlset files")
+	_ = flags.Parse(args)
+
+	if *in == "" {
+		return fmt.Errorf("in: %w", errFlagRequired)
+	}
+
+	var n int
+
+	err := filepath.WalkDir(*in, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() || !strings.HasSuffix(strings.ToLower(entry.Name()), ".sql") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(*in, path)
+		if err != nil {
+			return err
+		}
+
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		var buf bytes.Buffer
+
+		if err := convertSQLC(bytes.NewReader(src), &buf); err != nil {
+			return fmt.Errorf("convert %s: %w", path, err)
+		}
+
+		dst := filepath.Join(*out, rel)
+
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return err
+		}
+
+		n++
+
+		return os.WriteFile(dst, buf.Bytes(), 0o644)
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Imported: %d files into %s\n", n, *out)
+
+	return nil
+}
+
+// convertSQLC converts a file of sqlc-annotated queries ("-- name: GetAuthor :one")
+// into the sqlset syntax, keeping the command annotation as the query metadata.
+func convertSQLC(r io.Reader, w io.Writer) error {
+	var (
+		scanner = bufio.NewScanner(r)
+		bw      = bufio.NewWriter(w)
+		body    []string
+		opened  bool
+	)
+
+	flush := func() {
+		if !opened {
+			return
+		}
+
+		// Blank lines between queries belong to no query.
+		for len(body) > 0 && strings.TrimSpace(body[len(body)-1]) == "" {
+			body = body[:len(body)-1]
+		}
+
+		for _, line := range body {
+			fmt.Fprintln(bw, line)
+		}
+
+		fmt.Fprintln(bw, "--end")
+		fmt.Fprintln(bw)
+
+		body = nil
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		rest, isComment := strings.CutPrefix(trimmed, "--")
+		rest = strings.TrimSpace(rest)
+
+		if isComment && strings.HasPrefix(rest, sqlcNameMarker) {
+			flush()
+
+			fields := strings.Fields(strings.TrimPrefix(rest, sqlcNameMarker))
+			if len(fields) == 0 {
+				return fmt.Errorf("%q: no query name given", trimmed)
+			}
+
+			fmt.Fprintf(bw, "--SQL:%s\n", fields[0])
+
+			if len(fields) > 1 {
+				meta, err := json.Marshal(map[string]string{"command": strings.TrimPrefix(fields[1], ":")})
+				if err != nil {
+					return err
+				}
+
+				fmt.Fprintf(bw, "--META: %s\n", meta)
+			}
+
+			opened = true
+
+			continue
+		}
+
+		if isComment {
+			// Keep comments from being read as sqlset directives, e.g. "--end of list".
+			line = "-- " + rest
+		}
+
+		if opened {
+			body = append(body, line)
+		} else {
+			fmt.Fprintln(bw, line)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	flush()
+
+	return bw.Flush()
+}
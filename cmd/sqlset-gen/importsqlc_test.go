@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/istovpets/sqlset"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sqlcAuthors = `-- Authors queries.
+
+-- name: GetAuthor :one
+SELECT * FROM authors
+WHERE id = $1 LIMIT 1;
+
+-- name: ListAuthors :many
+--end of list is ordered by name
+SELECT * FROM authors
+ORDER BY name;
+
+-- name: DeleteAuthor :exec
+DELETE FROM authors
+WHERE id = $1;
+`
+
+func TestConvertSQLC(t *testing.T) {
+	var buf bytes.Buffer
+
+	require.NoError(t, convertSQLC(strings.NewReader(sqlcAuthors), &buf))
+
+	assert.Equal(t, `-- Authors queries.
+
+--SQL:GetAuthor
+--META: {"command":"one"}
+SELECT * FROM authors
+WHERE id = $1 LIMIT 1;
+--end
+
+--SQL:ListAuthors
+--META: {"command":"many"}
+-- end of list is ordered by name
+SELECT * FROM authors
+ORDER BY name;
+--end
+
+--SQL:DeleteAuthor
+--META: {"command":"exec"}
+DELETE FROM authors
+WHERE id = $1;
+--end
+
+`, buf.String())
+}
+
+func TestRunImportSQLC(t *testing.T) {
+	root := t.TempDir()
+
+	writeFiles(t, root, map[string]string{
+		"sqlc/authors.sql": sqlcAuthors,
+	})
+
+	out := filepath.Join(root, "queries")
+	require.NoError(t, runImportSQLC([]string{"--in=" + filepath.Join(root, "sqlc"), "--out=" + out}))
+
+	sqlSet, err := loadSQLSet(out)
+	require.NoError(t, err)
+
+	assert.Equal(t, "SELECT * FROM authors\r\nORDER BY name;", sqlSet.MustGet("authors", "ListAuthors"))
+
+	meta, err := sqlSet.GetQueryMeta("authors", "GetAuthor")
+	require.NoError(t, err)
+	assert.Equal(t, sqlset.QueryMeta{Command: "one"}, meta)
+}
+
+func TestExportSQLC(t *testing.T) {
+	var converted bytes.Buffer
+
+	require.NoError(t, convertSQLC(strings.NewReader(sqlcAuthors+`
+-- name: CountAuthors
+SELECT count(*) FROM authors;
+`), &converted))
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{"queries/authors.sql": converted.String()})
+
+	sqlSet, err := loadSQLSet(filepath.Join(root, "queries"))
+	require.NoError(t, err)
+
+	var exported bytes.Buffer
+	require.NoError(t, exportSQLC(sqlSet, "authors", &exported))
+
+	assert.Equal(t, `-- name: CountAuthors :exec
+SELECT count(*) FROM authors;
+
+-- name: DeleteAuthor :exec
+DELETE FROM authors
+WHERE id = $1;
+
+-- name: GetAuthor :one
+SELECT * FROM authors
+WHERE id = $1 LIMIT 1;
+
+-- name: ListAuthors :many
+SELECT * FROM authors
+ORDER BY name;
+`, exported.String())
+}
+
+func TestExportSQLC_Template(t *testing.T) {
+	sqlSet, err := sqlset.New(fstest.MapFS{
+		"reports.sql.tmpl": {Data: []byte("--SQL:ListOrders\nSELECT * FROM orders{{if .Archived}};{{end}}\n--end\n")},
+	})
+	require.NoError(t, err)
+
+	var exported bytes.Buffer
+	require.NoError(t, exportSQLC(sqlSet, "reports", &exported))
+	assert.Empty(t, exported.String())
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/istovpets/sqlset"
+	"github.com/istovpets/sqlset/analysis"
+)
+
+var errLintFindings = errors.New("lint findings")
+
+func runLint(args []string) error {
+	flags := flag.NewFlagSet("lint", flag.ExitOnError)
+	dir := flags.String("dir", "queries", "directory with .sql files (relative to current working directory)")
+	semicolon := flags.String("semicolon", "", "terminating semicolon policy: require or forbid, not checked if empty")
+	injection := flags.Bool("injection", true, "check for fmt verbs, quoted placeholders and unescaped LIKE patterns")
+	naming := flags.String("naming", "", "query ID style: PascalCase, camelCase or snake_case, not checked if empty")
+	prefixes := flags.String("prefixes", "", "comma-separated allowed first words of the query IDs, e.g. Get,List,Create")
+	_ = flags.Parse(args)
+
+	rules, err := lintRules(*semicolon, *injection)
+	if err != nil {
+		return err
+	}
+
+	switch style := sqlset.NamingStyle(*naming); style {
+	case "", sqlset.PascalCase, sqlset.CamelCase, sqlset.SnakeCase:
+	default:
+		return fmt.Errorf("unknown naming style %q, expected PascalCase, camelCase or snake_case", style)
+	}
+
+	sqlSet, err := loadSQLSet(*dir)
+	if err != nil {
+		return err
+	}
+
+	findings, err := analysis.Lint(sqlSet, rules...)
+	if err != nil {
+		return err
+	}
+
+	policy := sqlset.NamingPolicy{Style: sqlset.NamingStyle(*naming)}
+	if *prefixes != "" {
+		policy.Prefixes = strings.Split(*prefixes, ",")
+	}
+
+	findings = append(findings, namingFindings(sqlSet, policy)...)
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Key.String() < findings[j].Key.String()
+	})
+
+	return writeFindings(os.Stdout, findings)
+}
+
+// lintRules returns the rules enabled by the flags.
+func lintRules(semicolon string, injection bool) ([]analysis.Rule, error) {
+	var rules []analysis.Rule
+
+	if injection {
+		rules = append(rules, analysis.Injection())
+	}
+
+	if semicolon != "" {
+		policy, err := analysis.ParseSemicolonPolicy(semicolon)
+		if err != nil {
+			return nil, err
+		}
+
+		rules = append(rules, analysis.Semicolon(policy))
+	}
+
+	return rules, nil
+}
+
+// namingFindings checks the query IDs against the naming policy.
+func namingFindings(sqlSet *sqlset.SQLSet, policy sqlset.NamingPolicy) []analysis.Finding {
+	var findings []analysis.Finding
+
+	for _, key := range sortedKeys(sqlSet) {
+		if err := policy.Check(key.QueryID); err != nil {
+			findings = append(findings, analysis.Finding{Key: key, Rule: "naming", Message: err.Error()})
+		}
+	}
+
+	return findings
+}
+
+// writeFindings prints the findings and returns an error if there are any.
+func writeFindings(w io.Writer, findings []analysis.Finding) error {
+	for _, f := range findings {
+		fmt.Fprintln(w, f)
+	}
+
+	if len(findings) > 0 {
+		return fmt.Errorf("%d %w", len(findings), errLintFindings)
+	}
+
+	return nil
+}
+
+func runFmt(args []string) error {
+	flags := flag.NewFlagSet("fmt", flag.ExitOnError)
+	dir := flags.String("dir", "queries", "directory with .sql files (relative to current working directory)")
+	semicolon := flags.String("semicolon", "require", "terminating semicolon policy: require or forbid")
+	check := flags.Bool("check", false, "list the files that would change without writing them, failing if there are any")
+	_ = flags.Parse(args)
+
+	policy, err := analysis.ParseSemicolonPolicy(*semicolon)
+	if err != nil {
+		return err
+	}
+
+	sqlSet, err := loadSQLSet(*dir)
+	if err != nil {
+		return err
+	}
+
+	changed, err := formatFiles(*dir, sqlSet, policy, !*check)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range changed {
+		fmt.Println(file)
+	}
+
+	if *check && len(changed) > 0 {
+		return fmt.Errorf("%d files are not formatted", len(changed))
+	}
+
+	return nil
+}
+
+// formatFiles applies the semicolon policy to the last body line of every query
+// in the files of dir, writing the changed files if write is set.
+// It returns the changed files, sorted.
+func formatFiles(dir string, sqlSet *sqlset.SQLSet, policy analysis.SemicolonPolicy, write bool) ([]string, error) {
+	// Query blocks by file.
+	blocks := make(map[string][]sqlset.Source)
+
+	for _, setID := range sortedSetIDs(sqlSet) {
+		ids, err := sqlSet.GetQueryIDs(setID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, id := range ids {
+			src, err := sqlSet.QuerySource(setID, id)
+			if err != nil {
+				return nil, err
+			}
+
+			if src.File != "" {
+				blocks[src.File] = append(blocks[src.File], src)
+			}
+		}
+	}
+
+	var changed []string
+
+	for file, sources := range blocks {
+		path := filepath.Join(dir, filepath.FromSlash(file))
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		lines := strings.Split(string(data), "\n")
+		modified := false
+
+		for _, src := range sources {
+			i := lastBodyLine(lines, src)
+			if i < 0 {
+				continue
+			}
+
+			line, cr := strings.CutSuffix(lines[i], "\r")
+
+			fixed := analysis.FixSemicolon(line, policy)
+			if fixed != line {
+				if cr {
+					fixed += "\r"
+				}
+
+				lines[i] = fixed
+				modified = true
+			}
+		}
+
+		if !modified {
+			continue
+		}
+
+		changed = append(changed, path)
+
+		if write {
+			if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0o644); err != nil { //nolint:gosec
+				return nil, err
+			}
+		}
+	}
+
+	sort.Strings(changed)
+
+	return changed, nil
+}
+
+// lastBodyLine returns the index in lines of the last line of the query body
+// in the block at src, skipping directives, comments and sub-blocks, or -1.
+func lastBodyLine(lines []string, src sqlset.Source) int {
+	last, sub := -1, false
+
+	for i := src.Line; i < src.EndLine && i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+
+		switch {
+		case line == "--META" || line == "--HINTS":
+			sub = true
+		case sub && strings.HasPrefix(line, "--end"):
+			sub = false
+		case sub, line == "", strings.HasPrefix(line, "--"):
+		default:
+			last = i
+		}
+	}
+
+	return last
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/istovpets/sqlset"
+	"github.com/istovpets/sqlset/analysis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "users.sql")
+
+	require.NoError(t, os.WriteFile(path, []byte(`--SQL:GetUser
+--META
+{"description": "by id"}
+--end
+SELECT *
+FROM users
+WHERE id = $1
+-- the primary key
+
+--end
+
+--SQL:ListUsers
+SELECT * FROM users;
+--end
+`), 0o644))
+
+	sqlSet, err := loadSQLSet(dir)
+	require.NoError(t, err)
+
+	changed, err := formatFiles(dir, sqlSet, analysis.RequireSemicolon, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{path}, changed)
+
+	changed, err = formatFiles(dir, sqlSet, analysis.RequireSemicolon, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{path}, changed)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "WHERE id = $1;\n-- the primary key\n")
+	assert.Contains(t, string(data), `{"description": "by id"}`+"\n")
+
+	sqlSet, err = loadSQLSet(dir)
+	require.NoError(t, err)
+
+	findings, err := analysis.Lint(sqlSet, analysis.Semicolon(analysis.RequireSemicolon))
+	require.NoError(t, err)
+	assert.Empty(t, findings)
+
+	changed, err = formatFiles(dir, sqlSet, analysis.ForbidSemicolon, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{path}, changed)
+
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "WHERE id = $1\n")
+	assert.Contains(t, string(data), "SELECT * FROM users\n")
+}
+
+func TestWriteFindings(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, writeFindings(&buf, nil))
+
+	rules, err := lintRules("require", true)
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+
+	findings := []analysis.Finding{{
+		Key:     sqlset.QueryKey{SetID: "users", QueryID: "GetUser"},
+		Rule:    "semicolon",
+		Message: "missing terminating semicolon",
+	}}
+
+	require.ErrorIs(t, writeFindings(&buf, findings), errLintFindings)
+	assert.Equal(t, "users.GetUser: semicolon: missing terminating semicolon\n", buf.String())
+}
+
+func TestNamingFindings(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"users.sql": "--SQL:GetUser\nSELECT 1;\n--end\n--SQL:fetch_users\nSELECT 2;\n--end\n",
+	})
+
+	sqlSet, err := loadSQLSet(dir)
+	require.NoError(t, err)
+
+	findings := namingFindings(sqlSet, sqlset.NamingPolicy{Style: sqlset.PascalCase, Prefixes: []string{"Get", "List"}})
+	require.Len(t, findings, 1)
+	assert.Equal(t, "users.fetch_users: naming: naming policy violated: fetch_users is not PascalCase", findings[0].String())
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/istovpets/sqlset"
+)
+
+func runLock(args []string) error {
+	flags := flag.NewFlagSet("lock", flag.ExitOnError)
+	dir := flags.String("dir", "queries", "directory with .sql files (relative to current working directory)")
+	out := flags.String("out", sqlset.LockFileName, "lock file path")
+	check := flags.Bool("check", false, "verify the queries against the lock file instead of writing it, e.g. in CI")
+	_ = flags.Parse(args)
+
+	sqlSet, err := loadSQLSet(*dir)
+	if err != nil {
+		return err
+	}
+
+	if *check {
+		return verifyLock(sqlSet, *out)
+	}
+
+	data, err := sqlSet.Lock()
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(*out, data, 0644); err != nil {
+		return err
+	}
+
+	fmt.Printf("Locked: %s (%d queries)\n", *out, len(sqlSet.Inventory()))
+
+	return nil
+}
+
+func verifyLock(sqlSet *sqlset.SQLSet, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if err := sqlSet.Verify(data); err != nil {
+		return fmt.Errorf("%w, review the changes and run sqlset-gen lock", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/istovpets/sqlset"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunLock(t *testing.T) {
+	root := t.TempDir()
+	lockfile := filepath.Join(t.TempDir(), sqlset.LockFileName)
+
+	writeFiles(t, root, map[string]string{
+		"users.sql": "--SQL:GetUser\nSELECT 1;\n--end\n",
+	})
+
+	require.NoError(t, runLock([]string{"--dir", root, "--out", lockfile}))
+	require.NoError(t, runLock([]string{"--dir", root, "--out", lockfile, "--check"}))
+
+	writeFiles(t, root, map[string]string{
+		"users.sql": "--SQL:GetUser\nSELECT 2;\n--end\n",
+	})
+
+	err := runLock([]string{"--dir", root, "--out", lockfile, "--check"})
+	require.ErrorIs(t, err, sqlset.ErrLockMismatch)
+	assert.ErrorContains(t, err, "changed: users.GetUser")
+
+	require.ErrorIs(t, runLock([]string{"--dir", root, "--out", filepath.Join(root, "missing.lock"), "--check"}),
+		os.ErrNotExist)
+}
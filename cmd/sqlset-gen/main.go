@@ -3,42 +3,184 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/istovpets/sqlset"
 )
 
+var errFlagRequired = errors.New("flag is required")
+
+// sqlsetImport is the import path of the library, used by the generated code.
+const sqlsetImport = "github.com/istovpets/sqlset"
+
+// command is a sqlset-gen subcommand, it receives the arguments following its name.
+type command func(args []string) error
+
+var commands = map[string]command{
+	"generate":    runGenerate,
+	"bundle":      runBundle,
+	"xref":        runXref,
+	"explain":     runExplain,
+	"import-sqlc": runImportSQLC,
+	"export-sqlc": runExportSQLC,
+	"serve":       runServe,
+	"exec":        runExec,
+	"bench":       runBench,
+	"diff":        runDiff,
+	"schema":      runSchema,
+	"lint":        runLint,
+	"fmt":         runFmt,
+	"owners":      runOwners,
+	"extract":     runExtract,
+	"export":      runExport,
+	"lock":        runLock,
+	"pack":        runPack,
+	"unpack":      runUnpack,
+	"install":     runInstall,
+}
+
 func main() {
-	dir := flag.String("dir", "queries", "directory with .sql files (relative to current working directory)")
-	out := flag.String("out", "queries/constants.go", "output file path")
-	pkg := flag.String("pkg", "queries", "package name for the generated file")
-	flag.Parse()
+	name, args := "generate", os.Args[1:]
+
+	// Plain `sqlset-gen --dir=...` invocations default to generate.
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+
+	cmd, ok := commands[name]
+	if !ok {
+		log.Fatalf("unknown command %q", name)
+	}
+
+	if err := cmd(args); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func runGenerate(args []string) error {
+	flags := flag.NewFlagSet("generate", flag.ExitOnError)
+	dir := flags.String("dir", "queries", "directory with .sql files (relative to current working directory)")
+	out := flags.String("out", "queries/constants.go", "output file path")
+	pkg := flags.String("pkg", "queries", "package name for the generated file")
+	structs := flags.Bool("structs", false, "group the keys of every set into a struct variable, e.g. Users.GetUserByID")
+	casing := flags.String("case", caseCamel, "casing of the identifiers: camel, snake or upper-snake")
+	acronyms := flags.String("acronyms", "", "comma-separated words upper-cased in camel case identifiers, e.g. ID,URL")
+
+	var opts []GenerateOption
+
+	flags.Func("type", "map a declared SQL type to a Go type, e.g. uuid=github.com/google/uuid.UUID (repeatable)",
+		func(s string) error {
+			sqlType, t, err := parseTypeMapping(s)
+			if err != nil {
+				return err
+			}
+
+			opts = append(opts, WithTypeMapping(sqlType, t.Name, t.Import))
 
-	fsys := os.DirFS(*dir)
+			return nil
+		})
 
-	sqlSet, err := sqlset.New(fsys)
+	_ = flags.Parse(args)
+
+	if *structs {
+		opts = append(opts, WithStructs())
+	}
+
+	opts = append(opts, WithCasing(*casing))
+
+	if *acronyms != "" {
+		opts = append(opts, WithAcronyms(strings.Split(*acronyms, ",")...))
+	}
+
+	sqlSet, err := loadSQLSet(*dir)
 	if err != nil {
-		log.Fatalf("failed to load sqlset from %q: %v", *dir, err)
+		return err
 	}
 
-	generated, err := GenerateConstants(sqlSet, *pkg)
+	generated, err := GenerateConstants(sqlSet, *pkg, opts...)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
 	if err := os.WriteFile(*out, []byte(generated), 0644); err != nil {
-		log.Fatal(err)
+		return err
 	}
 
 	fmt.Printf("Generated: %s (based on %d sets)\n", *out, len(sqlSet.GetSetsMetas()))
+
+	return nil
 }
 
-func GenerateConstants(sqlSet *sqlset.SQLSet, pkgName string) (string, error) {
+// diagnosticLines is the number of lines printed around a parse failure.
+const diagnosticLines = 3
+
+// loadSQLSet loads the sets of dir, the parse errors come with the lines around the failure.
+func loadSQLSet(dir string) (*sqlset.SQLSet, error) {
+	sqlSet, err := sqlset.New(os.DirFS(dir), sqlset.WithDiagnostics(diagnosticLines))
+
+	var parseErr *sqlset.ParseError
+	if errors.As(err, &parseErr) {
+		return nil, fmt.Errorf("failed to load sqlset from %q: %w\n%s", dir, err, parseErr.Diagnostic())
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to load sqlset from %q: %w", dir, err)
+	}
+
+	return sqlSet, nil
+}
+
+// GenerateOption configures GenerateConstants.
+type GenerateOption func(*generator)
+
+// WithTypeMapping maps a declared SQL parameter type to a Go type used by the generated
+// argument functions, e.g. WithTypeMapping("uuid", "uuid.UUID", "github.com/google/uuid").
+// importPath is empty for builtin types.
+func WithTypeMapping(sqlType, goTypeName, importPath string) GenerateOption {
+	return func(g *generator) {
+		g.types[strings.ToLower(sqlType)] = goType{Name: goTypeName, Import: importPath}
+	}
+}
+
+// WithStructs groups the keys of every set into a struct variable instead of flat constants,
+// so that autocompletion lists the queries by set and a key of another set cannot be mistyped:
+//
+//	var Users = usersQueries{
+//		GetUserByID: "users.GetUserByID",
+//	}
+//
+// The argument functions become methods of the structs, e.g. Users.GetUserByIDArgs.
+func WithStructs() GenerateOption {
+	return func(g *generator) {
+		g.structs = true
+	}
+}
+
+type generator struct {
+	types    map[string]goType
+	structs  bool
+	casing   string
+	acronyms map[string]bool
+}
+
+// GenerateConstants generates the Go source with a constant for every query key, or a struct
+// variable for every set with WithStructs, and a VerifyQueries function checking that all
+// of them resolve in a provider.
+// For queries declaring parameters with --PARAMS it also generates a typed function
+// returning the arguments in declaration order.
+func GenerateConstants(sqlSet *sqlset.SQLSet, pkgName string, opts ...GenerateOption) (string, error) {
+	g := generator{types: make(map[string]goType), acronyms: make(map[string]bool)}
+	for _, opt := range opts {
+		opt(&g)
+	}
+
 	var setIDs []string
 	for _, meta := range sqlSet.GetSetsMetas() {
 		if meta.ID != "" {
@@ -47,11 +189,18 @@ func GenerateConstants(sqlSet *sqlset.SQLSet, pkgName string) (string, error) {
 	}
 	sort.Strings(setIDs)
 
-	var sb strings.Builder
+	var (
+		consts  strings.Builder
+		funcs   strings.Builder
+		keys    strings.Builder
+		imports = map[string]bool{sqlsetImport: true}
+		// idents are the package-level identifiers, by what they were generated for.
+		idents = identifiers{"VerifyQueries": "the VerifyQueries function", "queryKeys": "the queryKeys variable"}
+	)
 
-	sb.WriteString(fmt.Sprintf("package %s\n\n", pkgName))
-	sb.WriteString("// Code generated by sqlset-gen. DO NOT EDIT.\n\n")
-	sb.WriteString("const (\n")
+	if !g.structs {
+		consts.WriteString("const (\n")
+	}
 
 	for _, setID := range setIDs {
 		queryIDs, err := sqlSet.GetQueryIDs(setID)
@@ -64,22 +213,244 @@ func GenerateConstants(sqlSet *sqlset.SQLSet, pkgName string) (string, error) {
 
 		sort.Strings(queryIDs)
 
-		sb.WriteString(fmt.Sprintf("\t// %s.sql\n", setID))
+		if g.structs {
+			if err := g.writeStruct(&consts, &funcs, &keys, imports, idents, sqlSet, setID, queryIDs); err != nil {
+				return "", err
+			}
+
+			continue
+		}
+
+		consts.WriteString(fmt.Sprintf("\t// %s.sql\n", setID))
 
 		for _, qID := range queryIDs {
-			constName := toCamel(setID) + toCamel(qID)
 			fullPath := setID + "." + qID
-			sb.WriteString(fmt.Sprintf("\t%s = %q\n", constName, fullPath))
+
+			constName, err := g.ident(setID, qID)
+			if err != nil {
+				return "", err
+			}
+
+			if err := idents.add(constName, fullPath); err != nil {
+				return "", err
+			}
+
+			consts.WriteString(fmt.Sprintf("\t%s = %q\n", constName, fullPath))
+			keys.WriteString(fmt.Sprintf("\t%s,\n", constName))
+
+			params, err := sqlSet.Params(setID, qID)
+			if err != nil {
+				return "", fmt.Errorf("getting params for %q: %w", fullPath, err)
+			}
+
+			if len(params) > 0 {
+				funcName, err := g.ident(setID, qID, "Args")
+				if err != nil {
+					return "", err
+				}
+
+				if err := idents.add(funcName, "the arguments of "+fullPath); err != nil {
+					return "", err
+				}
+
+				g.writeArgsFunc(&funcs, imports, "", funcName, fullPath, params)
+			}
 		}
 
-		sb.WriteString("\n")
+		consts.WriteString("\n")
+	}
+
+	if !g.structs {
+		consts.WriteString(")\n")
 	}
 
-	sb.WriteString(")\n")
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("package %s\n\n", pkgName))
+	sb.WriteString("// Code generated by sqlset-gen. DO NOT EDIT.\n\n")
+
+	if len(imports) > 0 {
+		paths := make([]string, 0, len(imports))
+		for p := range imports {
+			paths = append(paths, p)
+		}
+
+		sort.Strings(paths)
+
+		sb.WriteString("import (\n")
+
+		for _, p := range paths {
+			sb.WriteString(fmt.Sprintf("\t%q\n", p))
+		}
+
+		sb.WriteString(")\n\n")
+	}
+
+	sb.WriteString(consts.String())
+	sb.WriteString("\n// queryKeys lists the keys of all the queries above.\n")
+	sb.WriteString("var queryKeys = []string{\n")
+	sb.WriteString(keys.String())
+	sb.WriteString("}\n\n")
+	sb.WriteString("// VerifyQueries checks that every query above resolves in p, call it at startup and in a test.\n")
+	sb.WriteString("func VerifyQueries(p sqlset.SQLQueriesProvider) error {\n")
+	sb.WriteString("\treturn sqlset.RequireQueries(p, queryKeys...)\n")
+	sb.WriteString("}\n")
+	sb.WriteString(funcs.String())
 
 	return sb.String(), nil
 }
 
+// writeStruct writes the struct type and variable holding the keys of a set, see WithStructs.
+func (g *generator) writeStruct(
+	decls, funcs, keys *strings.Builder, imports map[string]bool, idents identifiers,
+	sqlSet *sqlset.SQLSet, setID string, queryIDs []string,
+) error {
+	varName, err := g.ident(setID)
+	if err != nil {
+		return err
+	}
+
+	typeName := strings.ToLower(varName[:1]) + varName[1:] + "Queries"
+	if g.casing == caseSnake || g.casing == caseUpperSnake {
+		typeName = strings.ToLower(varName) + "_queries"
+	}
+
+	if err := idents.add(varName, "the "+setID+" set"); err != nil {
+		return err
+	}
+
+	if err := idents.add(typeName, "the type of the "+setID+" set"); err != nil {
+		return err
+	}
+
+	var fields, values strings.Builder
+
+	// members are the fields and methods of the struct.
+	members := identifiers{}
+
+	for _, qID := range queryIDs {
+		fullPath := setID + "." + qID
+
+		field, err := g.ident(qID)
+		if err != nil {
+			return err
+		}
+
+		if err := members.add(field, fullPath); err != nil {
+			return err
+		}
+
+		fields.WriteString(fmt.Sprintf("\t%s string\n", field))
+		values.WriteString(fmt.Sprintf("\t%s: %q,\n", field, fullPath))
+		keys.WriteString(fmt.Sprintf("\t%s.%s,\n", varName, field))
+
+		params, err := sqlSet.Params(setID, qID)
+		if err != nil {
+			return fmt.Errorf("getting params for %q: %w", fullPath, err)
+		}
+
+		if len(params) > 0 {
+			funcName, err := g.ident(qID, "Args")
+			if err != nil {
+				return err
+			}
+
+			if err := members.add(funcName, "the arguments of "+fullPath); err != nil {
+				return err
+			}
+
+			g.writeArgsFunc(funcs, imports, typeName, funcName, fullPath, params)
+		}
+	}
+
+	if decls.Len() > 0 {
+		decls.WriteString("\n")
+	}
+
+	decls.WriteString(fmt.Sprintf("// %s holds the keys of the %s.sql queries.\n", typeName, setID))
+	decls.WriteString(fmt.Sprintf("type %s struct {\n%s}\n\n", typeName, fields.String()))
+	decls.WriteString(fmt.Sprintf("// %s holds the keys of the %s.sql queries.\n", varName, setID))
+	decls.WriteString(fmt.Sprintf("var %s = %s{\n%s}\n", varName, typeName, values.String()))
+
+	return nil
+}
+
+// writeArgsFunc writes the function returning the arguments of a query,
+// a method of receiver if not empty.
+func (g *generator) writeArgsFunc(
+	sb *strings.Builder, imports map[string]bool, receiver, funcName, fullPath string, params []sqlset.QueryParam,
+) {
+	args := make([]string, 0, len(params))
+	names := make([]string, 0, len(params))
+	seen := map[string]bool{}
+
+	var defaults strings.Builder
+
+	for i, p := range params {
+		name := paramName(p.Name, i)
+		if seen[name] {
+			name = fmt.Sprintf("%s%d", name, i+1)
+		}
+
+		seen[name] = true
+
+		t := resolveType(g.types, p.Type)
+		if t.Import != "" {
+			imports[t.Import] = true
+		}
+
+		def, ok := p.DefaultValue()
+		if !ok {
+			names = append(names, name)
+			args = append(args, name+" "+t.Name)
+
+			continue
+		}
+
+		// A parameter with a default is a pointer, nil taking the default.
+		arg := name + "Arg"
+		names = append(names, arg)
+		args = append(args, name+" *"+t.Name)
+
+		defaults.WriteString(fmt.Sprintf("\tvar %s any = %s\n", arg, goLiteral(def)))
+		defaults.WriteString(fmt.Sprintf("\tif %s != nil {\n\t\t%s = *%s\n\t}\n\n", name, arg, name))
+	}
+
+	sb.WriteString(fmt.Sprintf("\n// %s returns the arguments of %s in declaration order.\n", funcName, fullPath))
+
+	if defaults.Len() > 0 {
+		sb.WriteString("// The parameters with a default are pointers, nil taking the default.\n")
+	}
+
+	if receiver != "" {
+		funcName = "(" + receiver + ") " + funcName
+	}
+
+	sb.WriteString(fmt.Sprintf("func %s(%s) []any {\n", funcName, strings.Join(args, ", ")))
+	sb.WriteString(defaults.String())
+	sb.WriteString(fmt.Sprintf("\treturn []any{%s}\n", strings.Join(names, ", ")))
+	sb.WriteString("}\n")
+}
+
+// goLiteral returns the Go literal of a parameter default value, see sqlset.QueryParam.DefaultValue.
+func goLiteral(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "nil"
+	case string:
+		return strconv.Quote(v)
+	case float64:
+		lit := strconv.FormatFloat(v, 'g', -1, 64)
+		if !strings.ContainsAny(lit, ".e") {
+			lit += ".0"
+		}
+
+		return lit
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
 // toCamel converts snake_case or kebab-case to CamelCase
 func toCamel(s string) string {
 	s = strings.ReplaceAll(s, "-", " ")
@@ -2,6 +2,10 @@
 package main
 
 import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"testing/fstest"
@@ -39,7 +43,169 @@ SELECT 1;
 	require.Contains(t, generated, `UsersCreateUser = "users.CreateUser"`)
 	require.Contains(t, generated, `PostsGetPostById = "posts.GetPostById"`)
 
+	_, err = parser.ParseFile(token.NewFileSet(), "", generated, 0)
+	require.NoError(t, err)
+
+	require.Contains(t, generated, "var queryKeys = []string{\n"+
+		"\tPostsGetPostById,\n"+
+		"\tUsersCreateUser,\n"+
+		"\tUsersGetUserById,\n"+
+		"}\n")
+	require.Contains(t, generated, "func VerifyQueries(p sqlset.SQLQueriesProvider) error {\n"+
+		"\treturn sqlset.RequireQueries(p, queryKeys...)\n}\n")
+
 	// if err := os.WriteFile("tmp_consts.go", []byte(generated), 0644); err != nil {
 	// 	log.Fatal(err)
 	// }
 }
+
+func TestRunBundle(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "queries.bundle")
+
+	err := runBundle([]string{"--dir=../../testdata/valid_multi", "--out=" + out})
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+
+	sqlSet, err := sqlset.NewFromBundle(data)
+	require.NoError(t, err)
+	require.Len(t, sqlSet.GetSetsMetas(), 2)
+}
+
+func TestGenerateConstants_TypedArgs(t *testing.T) {
+	testFS := fstest.MapFS{
+		"users.sql": &fstest.MapFile{
+			Data: []byte(`--SQL:FindUsers
+--PARAMS: id uuid, created_after timestamptz, amount numeric(10,2), type text, tags text[]
+SELECT 1 WHERE $1::uuid IS NOT NULL AND $2 > now() AND $3 > 0 AND $4 <> '' AND $5 && '{}';
+--end
+
+--SQL:CountUsers
+SELECT count(*) FROM users;
+--end
+
+--SQL:ListUsers
+--PARAMS: name text, limit int = 50, min float8 = 1.5, owner = NULL, sort text = 'name'
+SELECT 1 WHERE $1 <> '' AND $3 > 0 AND $4 IS NULL AND $5 <> '' LIMIT $2;
+--end`),
+		},
+	}
+
+	sqlSet, err := sqlset.New(testFS)
+	require.NoError(t, err)
+
+	generated, err := GenerateConstants(sqlSet, "queries",
+		WithTypeMapping("uuid", "uuid.UUID", "github.com/google/uuid"))
+	require.NoError(t, err)
+
+	_, err = parser.ParseFile(token.NewFileSet(), "", generated, 0)
+	require.NoError(t, err)
+
+	require.Contains(t, generated,
+		"import (\n\t\"github.com/google/uuid\"\n\t\"github.com/istovpets/sqlset\"\n\t\"time\"\n)\n")
+	require.Contains(t, generated, "// UsersFindUsersArgs returns the arguments of users.FindUsers in declaration order.\n"+
+		"func UsersFindUsersArgs(id uuid.UUID, createdAfter time.Time, amount any, type_ string, tags []string) []any {\n"+
+		"\treturn []any{id, createdAfter, amount, type_, tags}\n}\n")
+	require.NotContains(t, generated, "UsersCountUsersArgs")
+	require.Contains(t, generated, "// The parameters with a default are pointers, nil taking the default.\n"+
+		"func UsersListUsersArgs(name string, limit *int32, min *float64, owner *any, sort *string) []any {\n"+
+		"\tvar limitArg any = 50\n"+
+		"\tif limit != nil {\n\t\tlimitArg = *limit\n\t}\n\n"+
+		"\tvar minArg any = 1.5\n"+
+		"\tif min != nil {\n\t\tminArg = *min\n\t}\n\n"+
+		"\tvar ownerArg any = nil\n"+
+		"\tif owner != nil {\n\t\townerArg = *owner\n\t}\n\n"+
+		"\tvar sortArg any = \"name\"\n"+
+		"\tif sort != nil {\n\t\tsortArg = *sort\n\t}\n\n"+
+		"\treturn []any{name, limitArg, minArg, ownerArg, sortArg}\n}\n")
+}
+
+func TestParseTypeMapping(t *testing.T) {
+	tests := []struct {
+		in           string
+		expectedSQL  string
+		expectedType goType
+	}{
+		{
+			in:           "uuid=github.com/google/uuid.UUID",
+			expectedSQL:  "uuid",
+			expectedType: goType{Name: "uuid.UUID", Import: "github.com/google/uuid"},
+		},
+		{
+			in:           "Numeric=github.com/jackc/pgx/v5/pgtype.Numeric",
+			expectedSQL:  "numeric",
+			expectedType: goType{Name: "pgtype.Numeric", Import: "github.com/jackc/pgx/v5/pgtype"},
+		},
+		{
+			in:           "money=github.com/shopspring/decimal/v2.Decimal",
+			expectedSQL:  "money",
+			expectedType: goType{Name: "decimal.Decimal", Import: "github.com/shopspring/decimal/v2"},
+		},
+		{
+			in:           "citext=string",
+			expectedSQL:  "citext",
+			expectedType: goType{Name: "string"},
+		},
+	}
+
+	for _, test := range tests {
+		sqlType, typ, err := parseTypeMapping(test.in)
+		require.NoError(t, err)
+		require.Equal(t, test.expectedSQL, sqlType)
+		require.Equal(t, test.expectedType, typ)
+	}
+
+	_, _, err := parseTypeMapping("uuid")
+	require.Error(t, err)
+}
+
+func TestLoadSQLSet_Diagnostic(t *testing.T) {
+	root := t.TempDir()
+
+	writeFiles(t, root, map[string]string{
+		"users.sql": "--SQL:GetUser\nSELECT 1\n--SQL:ListUsers\nSELECT 2\n--end\n",
+	})
+
+	_, err := loadSQLSet(root)
+	require.ErrorIs(t, err, sqlset.ErrInvalidSyntax)
+	require.ErrorContains(t, err, "users.sql:3: inside SQL block \"GetUser\" opened at line 1\n"+
+		"  1 | --SQL:GetUser\n"+
+		"  2 | SELECT 1\n"+
+		"> 3 | --SQL:ListUsers\n")
+}
+
+func TestGenerateConstants_Structs(t *testing.T) {
+	sqlSet, err := sqlset.New(fstest.MapFS{
+		"users.sql": &fstest.MapFile{Data: []byte(`--SQL:GetUserByID
+--PARAMS: id int
+SELECT 1 WHERE id = $1;
+--end
+
+--SQL:CreateUser
+INSERT INTO users DEFAULT VALUES;
+--end`)},
+		"user_roles.sql": &fstest.MapFile{Data: []byte("--SQL:List\nSELECT 2;\n--end\n")},
+	})
+	require.NoError(t, err)
+
+	generated, err := GenerateConstants(sqlSet, "queries", WithStructs())
+	require.NoError(t, err)
+
+	_, err = parser.ParseFile(token.NewFileSet(), "", generated, 0)
+	require.NoError(t, err)
+
+	require.Contains(t, generated, "// userRolesQueries holds the keys of the user_roles.sql queries.\n"+
+		"type userRolesQueries struct {\n\tList string\n}\n\n"+
+		"// UserRoles holds the keys of the user_roles.sql queries.\n"+
+		"var UserRoles = userRolesQueries{\n\tList: \"user_roles.List\",\n}\n\n"+
+		"// usersQueries holds the keys of the users.sql queries.\n"+
+		"type usersQueries struct {\n\tCreateUser string\n\tGetUserByID string\n}\n\n"+
+		"// Users holds the keys of the users.sql queries.\n"+
+		"var Users = usersQueries{\n\tCreateUser: \"users.CreateUser\",\n\tGetUserByID: \"users.GetUserByID\",\n}\n")
+	require.Contains(t, generated,
+		"var queryKeys = []string{\n\tUserRoles.List,\n\tUsers.CreateUser,\n\tUsers.GetUserByID,\n}\n")
+	require.Contains(t, generated, "// GetUserByIDArgs returns the arguments of users.GetUserByID in declaration order.\n"+
+		"func (usersQueries) GetUserByIDArgs(id int32) []any {\n")
+	require.NotContains(t, generated, "const (")
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/istovpets/sqlset"
+)
+
+// ownersEntry maps a query set and its files to the owner from the set metadata.
+type ownersEntry struct {
+	Set   string   `json:"set"`
+	Files []string `json:"files"`
+	Owner string   `json:"owner,omitempty"`
+}
+
+func runOwners(args []string) error {
+	flags := flag.NewFlagSet("owners", flag.ExitOnError)
+	dir := flags.String("dir", "queries", "directory with .sql files (relative to current working directory)")
+	format := flags.String("format", "codeowners", "output format: codeowners or json")
+	prefix := flags.String("prefix", "", "path prefix of the files in the codeowners format (default /<dir>/)")
+	out := flags.String("out", "", "output file path (default stdout)")
+	_ = flags.Parse(args)
+
+	sqlSet, err := loadSQLSet(*dir)
+	if err != nil {
+		return err
+	}
+
+	entries, err := buildOwners(sqlSet)
+	if err != nil {
+		return err
+	}
+
+	if *prefix == "" {
+		*prefix = path.Clean("/"+filepath.ToSlash(*dir)) + "/"
+	}
+
+	return writeOutput(*out, func(w io.Writer) error {
+		return writeOwners(w, *format, *prefix, entries)
+	})
+}
+
+// buildOwners returns the owner and files of every query set, sorted by set ID.
+func buildOwners(sqlSet *sqlset.SQLSet) ([]ownersEntry, error) {
+	owners := make(map[string]string)
+	for _, meta := range sqlSet.GetSetsMetas() {
+		owners[meta.ID] = meta.Owner
+	}
+
+	var entries []ownersEntry
+
+	for _, setID := range sortedSetIDs(sqlSet) {
+		entry := ownersEntry{Set: setID, Files: []string{}, Owner: owners[setID]}
+
+		ids, err := sqlSet.GetQueryIDs(setID)
+		if err != nil {
+			return nil, err
+		}
+
+		seen := make(map[string]bool)
+
+		for _, id := range ids {
+			src, err := sqlSet.QuerySource(setID, id)
+			if err != nil {
+				return nil, err
+			}
+
+			if src.File != "" && !seen[src.File] {
+				seen[src.File] = true
+				entry.Files = append(entry.Files, src.File)
+			}
+		}
+
+		sort.Strings(entry.Files)
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+func writeOwners(w io.Writer, format, prefix string, entries []ownersEntry) error {
+	switch format {
+	case "codeowners":
+		for _, e := range entries {
+			if e.Owner == "" {
+				continue
+			}
+
+			for _, file := range e.Files {
+				if _, err := fmt.Fprintf(w, "%s%s %s\n", prefix, file, e.Owner); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(entries)
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
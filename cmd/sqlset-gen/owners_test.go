@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildOwners(t *testing.T) {
+	root := t.TempDir()
+
+	writeFiles(t, root, map[string]string{
+		"payments.sql": `--META
+{"name": "Payments", "owner": "@acme/team-payments"}
+--end
+
+--SQL:Charge
+SELECT 1;
+--end`,
+		"reports/daily.sql": `--META
+{"owner": "@acme/analytics"}
+--end
+
+--SQL:Totals
+SELECT 2;
+--end`,
+		"misc.sql": `--SQL:Ping
+SELECT 3;
+--end`,
+	})
+
+	sqlSet, err := loadSQLSet(root)
+	require.NoError(t, err)
+
+	entries, err := buildOwners(sqlSet)
+	require.NoError(t, err)
+	assert.Equal(t, []ownersEntry{
+		{Set: "daily", Files: []string{"reports/daily.sql"}, Owner: "@acme/analytics"},
+		{Set: "misc", Files: []string{"misc.sql"}},
+		{Set: "payments", Files: []string{"payments.sql"}, Owner: "@acme/team-payments"},
+	}, entries)
+
+	var buf bytes.Buffer
+	require.NoError(t, writeOwners(&buf, "codeowners", "/queries/", entries))
+	assert.Equal(t, `/queries/reports/daily.sql @acme/analytics
+/queries/payments.sql @acme/team-payments
+`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, writeOwners(&buf, "json", "", entries))
+	assert.Contains(t, buf.String(), `"owner": "@acme/team-payments"`)
+
+	require.Error(t, writeOwners(&buf, "yaml", "", entries))
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/istovpets/sqlset"
+)
+
+func runPack(args []string) error {
+	flags := flag.NewFlagSet("pack", flag.ExitOnError)
+	dir := flags.String("dir", "queries", "directory with .sql files (relative to current working directory)")
+	name := flags.String("name", "", "name of the pack, e.g. analytics (required)")
+	version := flags.String("version", "", "version of the pack, e.g. 1.4.0 (required)")
+	out := flags.String("out", "", "output pack file path, <name>-<version>.sqlpack.tgz by default")
+	minVersion := flags.String("min-sqlset-version", "",
+		"oldest sqlset version able to load the pack, e.g. 1.2.0 for packs using newer features")
+	_ = flags.Parse(args)
+
+	if *name == "" || *version == "" {
+		return fmt.Errorf("--name and --version: %w", errFlagRequired)
+	}
+
+	if *out == "" {
+		*out = fmt.Sprintf("%s-%s.sqlpack.tgz", *name, *version)
+	}
+
+	// The pack must load in the consuming services.
+	sqlSet, err := loadSQLSet(*dir)
+	if err != nil {
+		return err
+	}
+
+	manifest := sqlset.PackManifest{Name: *name, Version: *version, MinSQLSetVersion: *minVersion}
+
+	for _, meta := range sqlSet.GetSetsMetas() {
+		if meta.Dialect != "" && !slices.Contains(manifest.Dialects, meta.Dialect) {
+			manifest.Dialects = append(manifest.Dialects, meta.Dialect)
+		}
+	}
+
+	slices.Sort(manifest.Dialects)
+
+	var buf bytes.Buffer
+
+	manifest, err = sqlset.WritePack(&buf, os.DirFS(*dir), manifest)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(*out, buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+
+	fmt.Printf("Packed: %s (%s %s, %d files, %d bytes)\n", *out, manifest.Name, manifest.Version,
+		len(manifest.Files), buf.Len())
+
+	return nil
+}
+
+func runUnpack(args []string) error {
+	flags := flag.NewFlagSet("unpack", flag.ExitOnError)
+	pack := flags.String("pack", "", "pack file path (required)")
+	out := flags.String("out", "", "directory to extract the pack to, existing files are not overwritten (required)")
+	_ = flags.Parse(args)
+
+	if *pack == "" || *out == "" {
+		return fmt.Errorf("--pack and --out: %w", errFlagRequired)
+	}
+
+	manifest, err := extractPack(*pack, *out)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Unpacked: %s %s to %s\n", manifest.Name, manifest.Version, *out)
+
+	return nil
+}
+
+func runInstall(args []string) error {
+	flags := flag.NewFlagSet("install", flag.ExitOnError)
+	pack := flags.String("pack", "", "pack file path (required)")
+	dir := flags.String("dir", "sqlpacks", "directory of the vendored packs, the pack is installed to <dir>/<name>")
+	_ = flags.Parse(args)
+
+	if *pack == "" {
+		return fmt.Errorf("--pack: %w", errFlagRequired)
+	}
+
+	// Extracted aside first, so a broken pack leaves the installed version in place.
+	tmp, err := os.MkdirTemp(*dir, ".install-")
+	if err != nil && os.IsNotExist(err) {
+		if err = os.MkdirAll(*dir, 0o755); err == nil {
+			tmp, err = os.MkdirTemp(*dir, ".install-")
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = os.RemoveAll(tmp)
+	}()
+
+	manifest, err := extractPack(*pack, filepath.Join(tmp, "pack"))
+	if err != nil {
+		return err
+	}
+
+	// ReadPack checks the name too, removing the target must never reach out of dir.
+	if !sqlset.ValidPackName(manifest.Name) {
+		return fmt.Errorf("%s: %w: invalid name %q", *pack, sqlset.ErrInvalidPack, manifest.Name)
+	}
+
+	target := filepath.Join(*dir, manifest.Name)
+
+	if err := os.RemoveAll(target); err != nil {
+		return err
+	}
+
+	if err := os.Rename(filepath.Join(tmp, "pack"), target); err != nil {
+		return err
+	}
+
+	fmt.Printf("Installed: %s %s to %s\n", manifest.Name, manifest.Version, target)
+
+	return nil
+}
+
+// extractPack verifies the pack file and writes its files, with the manifest, to the new directory dir.
+func extractPack(pack, dir string) (sqlset.PackManifest, error) {
+	data, err := os.ReadFile(pack)
+	if err != nil {
+		return sqlset.PackManifest{}, err
+	}
+
+	manifest, fsys, err := sqlset.ReadPack(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return sqlset.PackManifest{}, fmt.Errorf("%s: %w", pack, err)
+	}
+
+	if err := os.CopyFS(dir, fsys); err != nil {
+		return sqlset.PackManifest{}, err
+	}
+
+	return manifest, nil
+}
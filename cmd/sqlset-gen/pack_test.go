@@ -0,0 +1,104 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/istovpets/sqlset"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunPack(t *testing.T) {
+	root := t.TempDir()
+	out := t.TempDir()
+
+	writeFiles(t, root, map[string]string{
+		"events.sql":  "--META\n{\"dialect\": \"postgres\"}\n--end\n--SQL:CountEvents\nSELECT count(*) FROM events;\n--end\n",
+		"reports.sql": "--SQL:Daily\nSELECT 1;\n--end\n",
+	})
+
+	pack := filepath.Join(out, "analytics-1.0.0.sqlpack.tgz")
+
+	require.ErrorIs(t, runPack([]string{"--dir", root, "--name", "analytics"}), errFlagRequired)
+	require.NoError(t, runPack([]string{"--dir", root, "--name", "analytics", "--version", "1.0.0", "--out", pack}))
+
+	// Unpacked files load, along with the manifest.
+	unpacked := filepath.Join(out, "unpacked")
+	require.NoError(t, runUnpack([]string{"--pack", pack, "--out", unpacked}))
+
+	sqlSet, err := loadSQLSet(unpacked)
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT 1;", sqlSet.MustGet("reports.Daily"))
+
+	manifest, err := os.ReadFile(filepath.Join(unpacked, sqlset.PackManifestFile))
+	require.NoError(t, err)
+	assert.Contains(t, string(manifest), `"version": "1.0.0"`)
+	assert.Contains(t, string(manifest), `"dialects": [`+"\n    \"postgres\"\n  ]")
+
+	// Installing a new version replaces the vendored one.
+	vendor := filepath.Join(out, "sqlpacks")
+	require.NoError(t, runInstall([]string{"--pack", pack, "--dir", vendor}))
+
+	writeFiles(t, root, map[string]string{"reports.sql": "--SQL:Weekly\nSELECT 7;\n--end\n"})
+	pack = filepath.Join(out, "analytics-1.1.0.sqlpack.tgz")
+	require.NoError(t, runPack([]string{"--dir", root, "--name", "analytics", "--version", "1.1.0", "--out", pack,
+		"--min-sqlset-version", sqlset.Version}))
+	require.NoError(t, runInstall([]string{"--pack", pack, "--dir", vendor}))
+
+	sqlSet, err = loadSQLSet(filepath.Join(vendor, "analytics"))
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT 7;", sqlSet.MustGet("reports.Weekly"))
+	_, ok := sqlSet.TryGet("reports", "Daily")
+	assert.False(t, ok)
+
+	entries, err := os.ReadDir(vendor)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	// A pack for a newer sqlset is not installed.
+	newer := filepath.Join(out, "analytics-2.0.0.sqlpack.tgz")
+	require.NoError(t, runPack([]string{"--dir", root, "--name", "analytics", "--version", "2.0.0", "--out", newer,
+		"--min-sqlset-version", "99.0.0"}))
+	require.ErrorIs(t, runInstall([]string{"--pack", newer, "--dir", vendor}), sqlset.ErrIncompatiblePack)
+
+	// A broken pack keeps the installed version.
+	require.NoError(t, os.WriteFile(pack, []byte("broken"), 0o644))
+	require.ErrorIs(t, runInstall([]string{"--pack", pack, "--dir", vendor}), sqlset.ErrInvalidPack)
+
+	sqlSet, err = loadSQLSet(filepath.Join(vendor, "analytics"))
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT 7;", sqlSet.MustGet("reports.Weekly"))
+}
+
+func TestRunInstall_NameOutOfDir(t *testing.T) {
+	out := t.TempDir()
+	vendor := filepath.Join(out, "sqlpacks")
+	keep := filepath.Join(out, "keep")
+	require.NoError(t, os.MkdirAll(keep, 0o755))
+
+	for _, name := range []string{"..", "../keep", ".", ""} {
+		pack := filepath.Join(out, "evil.sqlpack.tgz")
+		f, err := os.Create(pack)
+		require.NoError(t, err)
+
+		manifest := `{"schema": 1, "name": "` + name + `", "version": "1.0.0", "files": {}}`
+		gw := gzip.NewWriter(f)
+		tw := tar.NewWriter(gw)
+		hdr := &tar.Header{Name: sqlset.PackManifestFile, Mode: 0o644, Size: int64(len(manifest))}
+		require.NoError(t, tw.WriteHeader(hdr))
+		_, err = tw.Write([]byte(manifest))
+		require.NoError(t, err)
+		require.NoError(t, tw.Close())
+		require.NoError(t, gw.Close())
+		require.NoError(t, f.Close())
+
+		err = runInstall([]string{"--pack", pack, "--dir", vendor})
+		require.ErrorIs(t, err, sqlset.ErrInvalidPack, name)
+		assert.DirExists(t, keep)
+		assert.DirExists(t, vendor)
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/istovpets/sqlset"
+)
+
+// queryRefs maps the ways a query can be referenced from Go code to its key:
+// the "setID.queryID" string literal and the generated constant name.
+type queryRefs struct {
+	literals map[string]sqlset.QueryKey
+	idents   map[string]sqlset.QueryKey
+}
+
+func newQueryRefs(sqlSet *sqlset.SQLSet) (queryRefs, error) {
+	refs := queryRefs{
+		literals: make(map[string]sqlset.QueryKey),
+		idents:   make(map[string]sqlset.QueryKey),
+	}
+
+	for _, meta := range sqlSet.GetSetsMetas() {
+		ids, err := sqlSet.GetQueryIDs(meta.ID)
+		if err != nil {
+			return queryRefs{}, err
+		}
+
+		for _, id := range ids {
+			key := sqlset.QueryKey{SetID: meta.ID, QueryID: id}
+			refs.literals[key.String()] = key
+			refs.idents[toCamel(meta.ID)+toCamel(id)] = key
+		}
+	}
+
+	return refs, nil
+}
+
+// scanGoRefs walks the Go files under root and returns, per query key,
+// the sorted slash-separated paths (relative to root) of the files referencing it.
+// Generated files, vendor, testdata and hidden directories are skipped.
+func scanGoRefs(root string, refs queryRefs) (map[sqlset.QueryKey][]string, error) {
+	found := make(map[sqlset.QueryKey]map[string]bool)
+	fset := token.NewFileSet()
+
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			name := entry.Name()
+			if path != root && (name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".")) {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+
+		file, err := parser.ParseFile(fset, path, nil, parser.ParseComments|parser.SkipObjectResolution)
+		if err != nil {
+			return err
+		}
+
+		if isGenerated(file) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		rel = filepath.ToSlash(rel)
+
+		ast.Inspect(file, func(node ast.Node) bool {
+			key, ok := refs.match(node)
+			if ok {
+				if found[key] == nil {
+					found[key] = make(map[string]bool)
+				}
+
+				found[key][rel] = true
+			}
+
+			return true
+		})
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[sqlset.QueryKey][]string, len(found))
+
+	for key, files := range found {
+		for file := range files {
+			result[key] = append(result[key], file)
+		}
+
+		sort.Strings(result[key])
+	}
+
+	return result, nil
+}
+
+// isGenerated is like ast.IsGenerated but also accepts the marker after the package clause,
+// where sqlset-gen puts it.
+func isGenerated(file *ast.File) bool {
+	if ast.IsGenerated(file) {
+		return true
+	}
+
+	for _, group := range file.Comments {
+		for _, c := range group.List {
+			if strings.HasPrefix(c.Text, "// Code generated ") && strings.HasSuffix(c.Text, " DO NOT EDIT.") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (r queryRefs) match(node ast.Node) (sqlset.QueryKey, bool) {
+	switch n := node.(type) {
+	case *ast.BasicLit:
+		if n.Kind != token.STRING {
+			return sqlset.QueryKey{}, false
+		}
+
+		s, err := strconv.Unquote(n.Value)
+		if err != nil {
+			return sqlset.QueryKey{}, false
+		}
+
+		key, ok := r.literals[s]
+
+		return key, ok
+	case *ast.Ident:
+		key, ok := r.idents[n.Name]
+
+		return key, ok
+	}
+
+	return sqlset.QueryKey{}, false
+}
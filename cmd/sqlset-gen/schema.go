@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/istovpets/sqlset"
+)
+
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// jsonSchemaTypes maps common SQL types to JSON Schemas, other types accept any value.
+var jsonSchemaTypes = map[string]map[string]any{
+	"text":                     {"type": "string"},
+	"varchar":                  {"type": "string"},
+	"character varying":        {"type": "string"},
+	"char":                     {"type": "string"},
+	"citext":                   {"type": "string"},
+	"uuid":                     {"type": "string", "format": "uuid"},
+	"smallint":                 {"type": "integer"},
+	"int2":                     {"type": "integer"},
+	"integer":                  {"type": "integer"},
+	"int":                      {"type": "integer"},
+	"int4":                     {"type": "integer"},
+	"serial":                   {"type": "integer"},
+	"bigint":                   {"type": "integer"},
+	"int8":                     {"type": "integer"},
+	"bigserial":                {"type": "integer"},
+	"numeric":                  {"type": "number"},
+	"decimal":                  {"type": "number"},
+	"real":                     {"type": "number"},
+	"float4":                   {"type": "number"},
+	"double precision":         {"type": "number"},
+	"float8":                   {"type": "number"},
+	"boolean":                  {"type": "boolean"},
+	"bool":                     {"type": "boolean"},
+	"bytea":                    {"type": "string", "contentEncoding": "base64"},
+	"date":                     {"type": "string", "format": "date"},
+	"timestamp":                {"type": "string", "format": "date-time"},
+	"timestamptz":              {"type": "string", "format": "date-time"},
+	"timestamp with time zone": {"type": "string", "format": "date-time"},
+	"json":                     {},
+	"jsonb":                    {},
+}
+
+func runSchema(args []string) error {
+	flags := flag.NewFlagSet("schema", flag.ExitOnError)
+	dir := flags.String("dir", "queries", "directory with .sql files (relative to current working directory)")
+	out := flags.String("out", "schemas", "output directory for the JSON Schema files")
+	_ = flags.Parse(args)
+
+	sqlSet, err := loadSQLSet(*dir)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		return err
+	}
+
+	var written int
+
+	for _, setID := range sortedSetIDs(sqlSet) {
+		ids, err := sqlSet.GetQueryIDs(setID)
+		if err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			q, err := sqlSet.Describe(setID, id)
+			if err != nil {
+				return err
+			}
+
+			for kind, schema := range querySchemas(q) {
+				path := filepath.Join(*out, q.Key.String()+"."+kind+".json")
+
+				if err := writeOutput(path, func(w io.Writer) error {
+					return writeSchema(w, schema)
+				}); err != nil {
+					return fmt.Errorf("%s: %w", q.Key, err)
+				}
+
+				written++
+			}
+		}
+	}
+
+	fmt.Printf("Generated: %d schemas into %s\n", written, *out)
+
+	return nil
+}
+
+// querySchemas returns the JSON Schemas of a query by kind: "params" for the object
+// of its --PARAMS arguments and "returns" for the array of its --RETURNS rows.
+// Queries without declarations have no schemas.
+func querySchemas(q sqlset.Query) map[string]map[string]any {
+	schemas := make(map[string]map[string]any)
+
+	if len(q.Params) > 0 {
+		schema := objectSchema(q.Params)
+		schema["$schema"] = jsonSchemaDialect
+		schema["title"] = q.Key.String() + " parameters"
+		schemas["params"] = schema
+	}
+
+	if len(q.Returns) > 0 {
+		schemas["returns"] = map[string]any{
+			"$schema": jsonSchemaDialect,
+			"title":   q.Key.String() + " rows",
+			"type":    "array",
+			"items":   objectSchema(q.Returns),
+		}
+	}
+
+	return schemas
+}
+
+// objectSchema returns the schema of an object with all the fields required but those with a default.
+func objectSchema(fields []sqlset.QueryParam) map[string]any {
+	properties := make(map[string]any, len(fields))
+	required := make([]string, 0, len(fields))
+
+	for _, field := range fields {
+		schema := typeSchema(field.Type)
+		properties[field.Name] = schema
+
+		if def, ok := field.DefaultValue(); ok {
+			schema["default"] = def
+
+			continue
+		}
+
+		required = append(required, field.Name)
+	}
+
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+// typeSchema returns the JSON Schema of a declared SQL type. Type modifiers like
+// varchar(255) are ignored, arrays map to arrays of the element schema.
+func typeSchema(sqlType string) map[string]any {
+	sqlType = strings.ToLower(strings.Join(strings.Fields(sqlType), " "))
+
+	if elem, ok := strings.CutSuffix(sqlType, "[]"); ok {
+		return map[string]any{"type": "array", "items": typeSchema(elem)}
+	}
+
+	for _, candidate := range []string{sqlType, typeModifiers.ReplaceAllString(sqlType, "")} {
+		if schema, ok := jsonSchemaTypes[candidate]; ok {
+			result := make(map[string]any, len(schema))
+			for k, v := range schema {
+				result[k] = v
+			}
+
+			return result
+		}
+	}
+
+	return map[string]any{}
+}
+
+func writeSchema(w io.Writer, schema map[string]any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(schema)
+}
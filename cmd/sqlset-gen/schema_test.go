@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunSchema(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "queries")
+	out := filepath.Join(root, "schemas")
+
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "users.sql"), []byte(`--SQL:FindUsers
+--PARAMS: ids uuid[], name varchar(64), active boolean = true
+--RETURNS: id uuid, name text, created_at timestamptz, settings jsonb
+SELECT id, name, created_at, settings FROM users WHERE id = ANY($1) AND name = $2 AND active = $3;
+--end
+
+--SQL:CountUsers
+SELECT count(*) FROM users;
+--end
+`), 0o600))
+
+	require.NoError(t, runSchema([]string{"--dir", dir, "--out", out}))
+
+	entries, err := os.ReadDir(out)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	params, err := os.ReadFile(filepath.Join(out, "users.FindUsers.params.json"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"title": "users.FindUsers parameters",
+		"type": "object",
+		"properties": {
+			"ids": {"type": "array", "items": {"type": "string", "format": "uuid"}},
+			"name": {"type": "string"},
+			"active": {"type": "boolean", "default": true}
+		},
+		"required": ["ids", "name"],
+		"additionalProperties": false
+	}`, string(params))
+
+	returns, err := os.ReadFile(filepath.Join(out, "users.FindUsers.returns.json"))
+	require.NoError(t, err)
+
+	var schema map[string]any
+	require.NoError(t, json.Unmarshal(returns, &schema))
+	assert.Equal(t, "array", schema["type"])
+	assert.Equal(t, map[string]any{
+		"id":         map[string]any{"type": "string", "format": "uuid"},
+		"name":       map[string]any{"type": "string"},
+		"created_at": map[string]any{"type": "string", "format": "date-time"},
+		"settings":   map[string]any{},
+	}, schema["items"].(map[string]any)["properties"])
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"html"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/istovpets/sqlset"
+	"github.com/istovpets/sqlset/admin"
+)
+
+func runServe(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	dir := flags.String("dir", "queries", "directory with .sql files (relative to current working directory)")
+	addr := flags.String("addr", ":8080", "address to listen on")
+	interval := flags.Duration("interval", 500*time.Millisecond, "interval of checking the directory for changes")
+	_ = flags.Parse(args)
+
+	srv := newDevServer(*dir)
+	srv.reload()
+
+	go func() {
+		for range time.Tick(*interval) {
+			srv.reload()
+		}
+	}()
+
+	log.Printf("Serving %s on %s", *dir, *addr)
+
+	return http.ListenAndServe(*addr, srv) //nolint:gosec
+}
+
+// devServer serves the admin UI for a directory of .sql files, reloading them
+// when they change. While the files do not parse, it responds with the error.
+type devServer struct {
+	dir   string
+	admin http.Handler
+
+	// queries is swapped for every successful load.
+	queries *sqlset.SwappableProvider
+
+	mu      sync.RWMutex
+	err     error
+	version string
+}
+
+func newDevServer(dir string) *devServer {
+	s := &devServer{
+		dir:     dir,
+		queries: sqlset.NewSwappableProvider((&sqlset.SQLSet{}).Freeze()),
+	}
+	s.admin = admin.NewHandler(s)
+
+	return s
+}
+
+// reload loads the directory again if its .sql files changed since the last load.
+func (s *devServer) reload() {
+	version, err := dirVersion(s.dir)
+	if err != nil {
+		s.setError(version, err)
+
+		return
+	}
+
+	s.mu.RLock()
+	unchanged := version == s.version
+	s.mu.RUnlock()
+
+	if unchanged {
+		return
+	}
+
+	sqlSet, err := loadSQLSet(s.dir)
+	if err != nil {
+		s.setError(version, err)
+
+		return
+	}
+
+	s.queries.Swap(sqlSet.Freeze())
+
+	s.mu.Lock()
+	s.err, s.version = nil, version
+	s.mu.Unlock()
+
+	log.Printf("Loaded: %d sets", len(sqlSet.GetSetsMetas()))
+}
+
+func (s *devServer) setError(version string, err error) {
+	s.mu.Lock()
+	s.err, s.version = err, version
+	s.mu.Unlock()
+
+	log.Printf("Error: %v", err)
+}
+
+// dirVersion fingerprints the .sql and .sql.tmpl files of dir by their names, sizes and modification times.
+func dirVersion(dir string) (string, error) {
+	var sb strings.Builder
+
+	err := fs.WalkDir(os.DirFS(dir), ".", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		lower := strings.ToLower(path)
+		if entry.IsDir() || !strings.HasSuffix(lower, ".sql") && !strings.HasSuffix(lower, ".sql.tmpl") {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(&sb, "%s:%d:%d\n", path, info.Size(), info.ModTime().UnixNano())
+
+		return nil
+	})
+
+	return sb.String(), err
+}
+
+func (s *devServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	err := s.err
+	s.mu.RUnlock()
+
+	if err == nil {
+		s.admin.ServeHTTP(w, r)
+
+		return
+	}
+
+	if strings.HasPrefix(r.URL.Path, "/api/") {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, "{\"error\":%q}\n", err.Error())
+
+		return
+	}
+
+	// The page refreshes itself until the error is fixed.
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusInternalServerError)
+	fmt.Fprintf(w, `<!DOCTYPE html>
+<html><head><meta charset="utf-8"><meta http-equiv="refresh" content="1"><title>sqlset: error</title></head>
+<body><h1>Failed to load queries</h1><pre>%s</pre></body></html>
+`, html.EscapeString(err.Error()))
+}
+
+func (s *devServer) current() *sqlset.Snapshot {
+	return s.queries.Load()
+}
+
+func (s *devServer) Get(ids ...string) (string, error) {
+	return s.current().Get(ids...)
+}
+
+func (s *devServer) MustGet(ids ...string) string {
+	return s.current().MustGet(ids...)
+}
+
+func (s *devServer) GetSetsMetas() []sqlset.QuerySetMeta {
+	return s.current().GetSetsMetas()
+}
+
+func (s *devServer) GetQueryIDs(setID string) ([]string, error) {
+	return s.current().GetQueryIDs(setID)
+}
+
+func (s *devServer) Search(pattern string) []sqlset.SearchResult {
+	return s.current().Search(pattern)
+}
+
+func (s *devServer) SearchRegexp(re *regexp.Regexp) []sqlset.SearchResult {
+	return s.current().SearchRegexp(re)
+}
+
+func (s *devServer) Inventory() []sqlset.InventoryEntry {
+	return s.current().Inventory()
+}
+
+func (s *devServer) ModTime() time.Time {
+	return s.current().ModTime()
+}
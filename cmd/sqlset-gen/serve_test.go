@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDevServer_Reload(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "users.sql")
+
+	writeFiles(t, dir, map[string]string{"users.sql": "--SQL:GetUser\nSELECT 1;\n--end\n"})
+
+	srv := newDevServer(dir)
+	srv.reload()
+
+	get := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+
+		return rec
+	}
+
+	rec := get("/api/sets/users")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"GetUser"`)
+
+	// Broken file: the error is reported, both in the API and the UI.
+	require.NoError(t, os.WriteFile(path, []byte("--SQL:GetUser\nSELECT 1;\n"), 0o644))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Second)))
+	srv.reload()
+
+	rec = get("/api/sets")
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Contains(t, rec.Body.String(), "no closing tag found")
+
+	rec = get("/")
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Contains(t, rec.Body.String(), "no closing tag found")
+	assert.Contains(t, rec.Body.String(), `http-equiv="refresh"`)
+
+	// Fixed file: the new queries are served.
+	require.NoError(t, os.WriteFile(path, []byte("--SQL:GetUserByID\nSELECT 1;\n--end\n"), 0o644))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(2*time.Second)))
+	srv.reload()
+
+	rec = get("/api/sets/users")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"GetUserByID"`)
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"go/token"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// goType is a Go type used in generated code for a declared SQL type.
+type goType struct {
+	// Name is the type as written in the generated code, e.g. "uuid.UUID".
+	Name string
+	// Import is the import path of the package declaring the type, empty for builtins.
+	Import string
+}
+
+// anyType is used for parameters without a declared or mapped type.
+var anyType = goType{Name: "any"}
+
+// defaultTypes maps common SQL types to Go types, custom mappings take precedence.
+var defaultTypes = map[string]goType{
+	"text":                     {Name: "string"},
+	"varchar":                  {Name: "string"},
+	"character varying":        {Name: "string"},
+	"char":                     {Name: "string"},
+	"citext":                   {Name: "string"},
+	"smallint":                 {Name: "int16"},
+	"int2":                     {Name: "int16"},
+	"integer":                  {Name: "int32"},
+	"int":                      {Name: "int32"},
+	"int4":                     {Name: "int32"},
+	"serial":                   {Name: "int32"},
+	"bigint":                   {Name: "int64"},
+	"int8":                     {Name: "int64"},
+	"bigserial":                {Name: "int64"},
+	"boolean":                  {Name: "bool"},
+	"bool":                     {Name: "bool"},
+	"real":                     {Name: "float32"},
+	"float4":                   {Name: "float32"},
+	"double precision":         {Name: "float64"},
+	"float8":                   {Name: "float64"},
+	"bytea":                    {Name: "[]byte"},
+	"date":                     {Name: "time.Time", Import: "time"},
+	"timestamp":                {Name: "time.Time", Import: "time"},
+	"timestamptz":              {Name: "time.Time", Import: "time"},
+	"timestamp with time zone": {Name: "time.Time", Import: "time"},
+	"json":                     {Name: "json.RawMessage", Import: "encoding/json"},
+	"jsonb":                    {Name: "json.RawMessage", Import: "encoding/json"},
+}
+
+var typeModifiers = regexp.MustCompile(`\s*\(.*\)`)
+
+// resolveType returns the Go type for the declared SQL type, looking up custom
+// mappings first, then the defaults. Type modifiers like varchar(255) are ignored
+// if the exact type is not mapped, arrays map to slices of the element type.
+func resolveType(custom map[string]goType, sqlType string) goType {
+	sqlType = strings.ToLower(strings.Join(strings.Fields(sqlType), " "))
+	if sqlType == "" {
+		return anyType
+	}
+
+	if elem, ok := strings.CutSuffix(sqlType, "[]"); ok {
+		t := resolveType(custom, elem)
+		if t == anyType {
+			return anyType
+		}
+
+		return goType{Name: "[]" + t.Name, Import: t.Import}
+	}
+
+	for _, candidate := range []string{sqlType, typeModifiers.ReplaceAllString(sqlType, "")} {
+		if t, ok := custom[candidate]; ok {
+			return t
+		}
+
+		if t, ok := defaultTypes[candidate]; ok {
+			return t
+		}
+	}
+
+	return anyType
+}
+
+// parseTypeMapping parses a "sqlType=import/path.Type" mapping as given to the -type flag.
+// Builtin Go types are given without the import path, e.g. "citext=string".
+func parseTypeMapping(s string) (string, goType, error) {
+	sqlType, qualified, ok := strings.Cut(s, "=")
+	if !ok || strings.TrimSpace(sqlType) == "" || strings.TrimSpace(qualified) == "" {
+		return "", goType{}, fmt.Errorf("invalid type mapping %q, expected sqlType=import/path.Type", s)
+	}
+
+	sqlType = strings.ToLower(strings.TrimSpace(sqlType))
+	qualified = strings.TrimSpace(qualified)
+
+	i := strings.LastIndex(qualified, ".")
+	if i < 0 {
+		return sqlType, goType{Name: qualified}, nil
+	}
+
+	importPath, name := qualified[:i], qualified[i+1:]
+
+	return sqlType, goType{Name: packageName(importPath) + "." + name, Import: importPath}, nil
+}
+
+var versionSuffix = regexp.MustCompile(`^v[0-9]+$`)
+
+// packageName guesses the package name from the import path:
+// the last element, skipping major version suffixes like /v5.
+func packageName(importPath string) string {
+	name := path.Base(importPath)
+	if versionSuffix.MatchString(name) {
+		name = path.Base(path.Dir(importPath))
+	}
+
+	name, _, _ = strings.Cut(name, ".")
+
+	return strings.ReplaceAll(name, "-", "")
+}
+
+// paramName converts a declared parameter name into a valid Go identifier.
+func paramName(name string, i int) string {
+	camel := toCamel(name)
+	if camel == "" {
+		return fmt.Sprintf("arg%d", i+1)
+	}
+
+	camel = strings.ToLower(camel[:1]) + camel[1:]
+
+	if token.IsKeyword(camel) {
+		return camel + "_"
+	}
+
+	if !token.IsIdentifier(camel) {
+		return fmt.Sprintf("arg%d", i+1)
+	}
+
+	return camel
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/istovpets/sqlset"
+	"github.com/istovpets/sqlset/analysis"
+)
+
+// xrefEntry is a single row of the cross-reference report.
+type xrefEntry struct {
+	Key    string   `json:"key"`
+	Tables []string `json:"tables"`
+	Files  []string `json:"files"`
+}
+
+func runXref(args []string) error {
+	flags := flag.NewFlagSet("xref", flag.ExitOnError)
+	dir := flags.String("dir", "queries", "directory with .sql files (relative to current working directory)")
+	src := flags.String("src", ".", "root directory of the Go sources to scan for query references")
+	format := flags.String("format", "json", "output format: json or csv")
+	out := flags.String("out", "", "output file path (default stdout)")
+	_ = flags.Parse(args)
+
+	sqlSet, err := loadSQLSet(*dir)
+	if err != nil {
+		return err
+	}
+
+	entries, err := buildXref(sqlSet, *src)
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(*out, func(w io.Writer) error {
+		return writeXref(w, *format, entries)
+	})
+}
+
+func buildXref(sqlSet *sqlset.SQLSet, src string) ([]xrefEntry, error) {
+	idx, err := analysis.NewIndex(sqlSet)
+	if err != nil {
+		return nil, err
+	}
+
+	refs, err := newQueryRefs(sqlSet)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := scanGoRefs(src, refs)
+	if err != nil {
+		return nil, fmt.Errorf("scan %q: %w", src, err)
+	}
+
+	var entries []xrefEntry
+
+	for _, key := range sortedKeys(sqlSet) {
+		entry := xrefEntry{
+			Key:    key.String(),
+			Tables: idx.Dependencies(key.SetID, key.QueryID),
+			Files:  files[key],
+		}
+
+		if entry.Tables == nil {
+			entry.Tables = []string{}
+		}
+
+		if entry.Files == nil {
+			entry.Files = []string{}
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+func writeXref(w io.Writer, format string, entries []xrefEntry) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(entries)
+	case "csv":
+		cw := csv.NewWriter(w)
+		_ = cw.Write([]string{"key", "tables", "files"})
+
+		for _, e := range entries {
+			_ = cw.Write([]string{e.Key, strings.Join(e.Tables, ";"), strings.Join(e.Files, ";")})
+		}
+
+		cw.Flush()
+
+		return cw.Error()
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
+
+// sortedKeys returns the keys of all queries sorted by set ID and query ID.
+func sortedKeys(sqlSet *sqlset.SQLSet) []sqlset.QueryKey {
+	var keys []sqlset.QueryKey
+
+	for _, setID := range sortedSetIDs(sqlSet) {
+		ids, _ := sqlSet.GetQueryIDs(setID)
+		for _, id := range ids {
+			keys = append(keys, sqlset.QueryKey{SetID: setID, QueryID: id})
+		}
+	}
+
+	return keys
+}
+
+func sortedSetIDs(sqlSet *sqlset.SQLSet) []string {
+	var setIDs []string
+	for _, meta := range sqlSet.GetSetsMetas() {
+		setIDs = append(setIDs, meta.ID)
+	}
+
+	sort.Strings(setIDs)
+
+	return setIDs
+}
+
+// writeOutput calls write with the file at path, or with stdout when path is empty.
+func writeOutput(path string, write func(w io.Writer) error) error {
+	if path == "" {
+		return write(os.Stdout)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := write(f); err != nil {
+		_ = f.Close()
+
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+}
+
+func TestBuildXref(t *testing.T) {
+	root := t.TempDir()
+
+	writeFiles(t, root, map[string]string{
+		"queries/users.sql": `--SQL:GetUserByID
+SELECT * FROM users u JOIN profiles p ON p.user_id = u.id WHERE u.id = $1;
+--end
+
+--SQL:CreateUser
+INSERT INTO users (name) VALUES ($1);
+--end
+
+--SQL:Unused
+SELECT 1;
+--end`,
+		"app/repo.go": `package app
+
+const getUser = "users.GetUserByID"
+
+func create() string { return consts.UsersCreateUser }
+`,
+		"app/other.go": `package app
+
+var _ = []string{"users.GetUserByID"}
+`,
+		"consts/queries-gen.go": `package consts
+
+// Code generated by sqlset-gen. DO NOT EDIT.
+
+const UsersUnused = "users.Unused"
+`,
+	})
+
+	sqlSet, err := loadSQLSet(filepath.Join(root, "queries"))
+	require.NoError(t, err)
+
+	entries, err := buildXref(sqlSet, root)
+	require.NoError(t, err)
+
+	assert.Equal(t, []xrefEntry{
+		{Key: "users.CreateUser", Tables: []string{"users"}, Files: []string{"app/repo.go"}},
+		{Key: "users.GetUserByID", Tables: []string{"profiles", "users"}, Files: []string{"app/other.go", "app/repo.go"}},
+		{Key: "users.Unused", Tables: []string{}, Files: []string{}},
+	}, entries)
+
+	var buf bytes.Buffer
+	require.NoError(t, writeXref(&buf, "csv", entries))
+	assert.Equal(t, "key,tables,files\n"+
+		"users.CreateUser,users,app/repo.go\n"+
+		"users.GetUserByID,profiles;users,app/other.go;app/repo.go\n"+
+		"users.Unused,,\n", buf.String())
+}
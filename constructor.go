@@ -1,8 +1,10 @@
 package sqlset
 
 import (
+	"errors"
 	"fmt"
 	"io/fs"
+	"sort"
 	"strings"
 )
 
@@ -18,33 +20,189 @@ import (
 //	var queriesFS embed.FS
 //
 //	sqlSet, err := sqlset.New(queriesFS)
-func New(fsys fs.FS) (*SQLSet, error) {
-	sqlSet := &SQLSet{}
+//
+// The parsing can be configured with options, e.g. WithSyntax.
+// All files are parsed even if some fail. The returned error joins the failures,
+// match them with errors.Is, e.g. ErrInvalidSyntax or ErrUnknownDialect.
+// A query without SQL is only reported as a warning. A duplicate query replaces
+// the existing one unless a WithDuplicateHandler handler rejects it with ErrQueryExists.
+func New(fsys fs.FS, opts ...Option) (*SQLSet, error) {
+	cfg := newConfig(opts)
+	sqlSet := cfg.newSQLSet()
 
-	if err := fs.WalkDir(fsys, ".", func(path string, entry fs.DirEntry, err error) error {
-		if err != nil {
-			return err
+	if err := walkSets(cfg, fsys, sqlSet, ""); err != nil {
+		return nil, fmt.Errorf("failed build SQL set: %w", err)
+	}
+
+	return sqlSet, nil
+}
+
+// newSQLSet returns an empty SQLSet with the retrieval options of cfg.
+func (cfg *config) newSQLSet() *SQLSet {
+	return &SQLSet{
+		softDelete:  cfg.softDelete,
+		rewriters:   cfg.rewriters,
+		keySep:      cfg.keySep,
+		redaction:   cfg.redaction,
+		warnings:    cfg.warnings,
+		contextKeys: cfg.contextKeys,
+	}
+}
+
+// NewFromModules is like New but loads several trees into one SQLSet, mounting every tree
+// under its module name: the set IDs and source files of a tree are prefixed with "<module>/".
+// It lets the services of a monorepo embed their own queries and still share one provider:
+//
+//	sqlSet, err := sqlset.NewFromModules(map[string]fs.FS{
+//		"billing": billing.Queries,
+//		"users":   users.Queries,
+//	})
+//
+//	sqlSet.Get("billing/invoices", "GetInvoice")
+//
+// Module names must be valid fs paths other than ".", ignore patterns are relative to every tree.
+func NewFromModules(modules map[string]fs.FS, opts ...Option) (*SQLSet, error) {
+	cfg := newConfig(opts)
+	sqlSet := cfg.newSQLSet()
+
+	names := make([]string, 0, len(modules))
+	for name := range modules {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var errs []error
+
+	for _, name := range names {
+		if name == "" {
+			return nil, fmt.Errorf("module: %w", ErrArgumentEmpty)
 		}
 
-		return handleDirEntry(fsys, sqlSet, path, entry)
-	}); err != nil {
+		if name == "." || !fs.ValidPath(name) {
+			return nil, fmt.Errorf("module %q: %w", name, fs.ErrInvalid)
+		}
+
+		if err := walkSets(cfg, modules[name], sqlSet, name); err != nil {
+			errs = append(errs, fmt.Errorf("module %s: %w", name, err))
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
 		return nil, fmt.Errorf("failed build SQL set: %w", err)
 	}
 
 	return sqlSet, nil
 }
 
-func handleDirEntry(fsys fs.FS, set *SQLSet, path string, entry fs.DirEntry) error {
+// walkSets adds the sets of the fsys tree to sqlSet, mounted under module if it is not empty.
+// The failures of the entries are joined, the walk going on after them.
+func walkSets(cfg *config, fsys fs.FS, sqlSet *SQLSet, module string) error {
+	// dirs holds the metadata inherited by the sets of every visited directory.
+	dirs := make(dirMetas)
+
+	var errs []error
+
+	err := fs.WalkDir(fsys, ".", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil && cfg.skipUnreadable {
+			sqlSet.skipped = append(sqlSet.skipped, SkippedFile{Path: mount(module, path), Err: err})
+
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		ignored, err := cfg.ignored(path)
+		if err != nil {
+			return err
+		}
+
+		if ignored {
+			if entry.IsDir() {
+				return fs.SkipDir
+			}
+
+			return nil
+		}
+
+		if skip, err := cfg.unselected(path, entry); skip || err != nil {
+			return err
+		}
+
+		if err := handleDirEntry(cfg, fsys, sqlSet, dirs, module, path, entry); err != nil {
+			errs = append(errs, err)
+		}
+
+		return nil
+	})
+
+	return errors.Join(append(errs, err)...)
+}
+
+// mount returns name prefixed with the module it is mounted under, see NewFromModules.
+func mount(module, name string) string {
+	if module == "" {
+		return name
+	}
+
+	return module + "/" + name
+}
+
+// NewWithRoot is like New but walks the root subdirectory of fsys, sparing the fs.Sub call
+// for embedded directories. Source locations and ignore patterns are relative to root:
+//
+//	//go:embed queries
+//	var queriesFS embed.FS
+//
+//	sqlSet, err := sqlset.NewWithRoot(queriesFS, "queries")
+func NewWithRoot(fsys fs.FS, root string, opts ...Option) (*SQLSet, error) {
+	info, err := fs.Stat(fsys, root)
+	if err != nil {
+		return nil, fmt.Errorf("root: %w", err)
+	}
+
+	if !info.IsDir() {
+		return nil, fmt.Errorf("root %s: %w", root, ErrNotDir)
+	}
+
+	sub, err := fs.Sub(fsys, root)
+	if err != nil {
+		return nil, fmt.Errorf("root: %w", err)
+	}
+
+	return New(sub, opts...)
+}
+
+func handleDirEntry(cfg *config, fsys fs.FS, set *SQLSet, dirs dirMetas, module, path string, entry fs.DirEntry) error {
 	if entry.IsDir() {
+		meta, err := loadDirMeta(cfg, fsys, path)
+		if err != nil {
+			return err
+		}
+
+		dirs[path] = meta.inherit(dirs.of(path))
+
+		return nil
+	}
+
+	if entry.Name() == dirSetFile {
 		return nil
 	}
 
-	setID, ok := strings.CutSuffix(strings.ToLower(entry.Name()), filesExt)
+	setID, ok := setIDFromName(cfg, entry.Name())
 	if !ok {
 		return nil
 	}
 
 	f, err := fsys.Open(path)
+	if err != nil && cfg.skipUnreadable {
+		set.skipped = append(set.skipped, SkippedFile{Path: mount(module, path), Err: err})
+
+		return nil
+	}
+
 	if err != nil {
 		return fmt.Errorf("open %s: %w", path, err)
 	}
@@ -53,12 +211,59 @@ func handleDirEntry(fsys fs.FS, set *SQLSet, path string, entry fs.DirEntry) err
 		_ = f.Close()
 	}()
 
-	qs, err := parse(setID, f)
+	qs, err := parse(cfg, setID, mount(module, path), dirs.of(path).Dialect, f)
 	if err != nil {
 		return fmt.Errorf("parse %s: %w", path, err)
 	}
 
-	set.registerQuerySet(qs.GetMeta().ID, qs)
+	qs.meta = qs.meta.inherit(dirs.of(path))
+	qs.meta.ID = mount(module, qs.meta.ID)
+
+	// Compiled with the inherited dialect.
+	if err := compileTemplates(cfg, &qs); err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	if info, err := entry.Info(); err == nil {
+		qs.modTime = info.ModTime()
+	}
+
+	if err := cfg.addQuerySet(set, qs); err != nil {
+		return fmt.Errorf("register %s: %w", path, err)
+	}
 
 	return nil
 }
+
+// setIDFromName returns the set ID of a file name with the .sql or .sql.tmpl extension in any case.
+func setIDFromName(cfg *config, name string) (string, bool) {
+	base := -1
+
+	for _, ext := range []string{filesExt, templateExt} {
+		if i := len(name) - len(ext); i >= 0 && strings.EqualFold(name[i:], ext) {
+			base = i
+		}
+	}
+
+	if base < 0 {
+		return "", false
+	}
+
+	if cfg.preserveFilenameCase {
+		return name[:base], true
+	}
+
+	return strings.ToLower(name[:base]), true
+}
+
+// SkippedFile is a file or directory skipped by New as unreadable, see WithSkipUnreadable.
+type SkippedFile struct {
+	Path string
+	Err  error
+}
+
+// SkippedFiles returns the files and directories skipped as unreadable
+// when the set was loaded with WithSkipUnreadable, in walk order.
+func (s *SQLSet) SkippedFiles() []SkippedFile {
+	return append([]SkippedFile(nil), s.skipped...)
+}
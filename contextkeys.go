@@ -0,0 +1,87 @@
+package sqlset
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// contextRef matches the {{ctx "name"}} references to context values.
+var contextRef = regexp.MustCompile(`\{\{\s*ctx\s+"([^"]*)"\s*\}\}`)
+
+// WithContextKeys makes GetContext replace the {{ctx "name"}} references of the queries
+// with the values of ctx under the context keys of keys by name, e.g. to tag the queries
+// with the request ID as an SQL comment for tracing:
+//
+//	sqlset.New(fsys, sqlset.WithContextKeys(map[string]any{"request_id": requestIDKey{}}))
+//
+//	--SQL:GetUser
+//	/* request_id={{ctx "request_id"}} */ SELECT * FROM users WHERE id = $1
+//	--end
+//
+// A missing value renders empty, Get renders them all empty. The values are formatted
+// with fmt.Sprint and reduced to letters, digits and "_.:/@=+", so they can neither end
+// a block comment nor start a line comment: the references are meant for comments,
+// values go through bind parameters.
+// Template queries use the ctx function, rendered by GetRenderedContext.
+// Referencing a name not in keys fails New with ErrVariableNotFound.
+func WithContextKeys(keys map[string]any) Option {
+	return func(cfg *config) {
+		if cfg.contextKeys == nil {
+			cfg.contextKeys = make(map[string]any, len(keys))
+		}
+
+		for name, key := range keys {
+			cfg.contextKeys[name] = key
+		}
+	}
+}
+
+// checkContextRefs checks that the context references of sql are declared with WithContextKeys.
+func checkContextRefs(sql string, keys map[string]any) error {
+	for _, m := range contextRef.FindAllStringSubmatch(sql, -1) {
+		if _, ok := keys[m[1]]; !ok {
+			return fmt.Errorf("context %q: %w", m[1], ErrVariableNotFound)
+		}
+	}
+
+	return nil
+}
+
+// contextRefFunc returns the ctx template function, declaring the name and keeping the reference
+// for expandContextRefs, as the template functions do not see the context of GetRenderedContext.
+func contextRefFunc(keys map[string]any) func(name string) (string, error) {
+	return func(name string) (string, error) {
+		if _, ok := keys[name]; !ok {
+			return "", fmt.Errorf("context %q: %w", name, ErrVariableNotFound)
+		}
+
+		return fmt.Sprintf("{{ctx %q}}", name), nil
+	}
+}
+
+// expandContextRefs replaces the context references of sql with the values of ctx.
+func expandContextRefs(ctx context.Context, sql string, keys map[string]any) string {
+	if keys == nil || !strings.Contains(sql, "{{") {
+		return sql
+	}
+
+	return contextRef.ReplaceAllStringFunc(sql, func(ref string) string {
+		name := contextRef.FindStringSubmatch(ref)[1]
+
+		v := ctx.Value(keys[name])
+		if v == nil {
+			return ""
+		}
+
+		return strings.Map(func(r rune) rune {
+			if r < 0x80 && (r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' ||
+				strings.ContainsRune("_.:/@=+", r)) {
+				return r
+			}
+
+			return -1
+		}, fmt.Sprint(v))
+	})
+}
@@ -0,0 +1,219 @@
+package sqlset
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// tokenData opens a fixture data block.
+const tokenData = "DATA"
+
+// Data is a fixture of a set, declared in a --DATA block next to the queries using it,
+// e.g. the seed rows of integration tests, see GetData. The rows are CSV with a header line,
+// or a JSON array of objects:
+//
+//	--DATA:Users
+//	id,name
+//	1,alice
+//	2,bob
+//	--end
+type Data struct {
+	// ID is the ID of the block.
+	ID string
+	// Columns are the column names: the CSV header, or the keys of the JSON objects
+	// in order of first appearance.
+	Columns []string
+	// Rows holds the values in column order: strings for CSV, and for JSON the decoded values,
+	// json.Number for numbers and nil for the keys missing from an object.
+	Rows [][]any
+	// Source is the location of the block.
+	Source Source
+
+	// content is the text of the block, kept for the bundles.
+	content string
+}
+
+// clone returns a copy of the data not sharing the slices.
+func (d Data) clone() Data {
+	d.Columns = slices.Clone(d.Columns)
+
+	d.Rows = slices.Clone(d.Rows)
+	for i := range d.Rows {
+		d.Rows[i] = slices.Clone(d.Rows[i])
+	}
+
+	return d
+}
+
+// GetData returns a fixture data block of a set.
+func (s *SQLSet) GetData(setID, dataID string) (Data, error) {
+	qs, ok := s.sets[setID]
+	if !ok {
+		return Data{}, fmt.Errorf("%s: %w", setID, ErrQuerySetNotFound)
+	}
+
+	d, ok := qs.data[dataID]
+	if !ok {
+		return Data{}, fmt.Errorf("%s: %w", dataID, ErrDataNotFound)
+	}
+
+	return d.clone(), nil
+}
+
+// GetDataIDs returns the sorted IDs of the fixture data blocks of a set.
+func (s *SQLSet) GetDataIDs(setID string) ([]string, error) {
+	qs, ok := s.sets[setID]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", setID, ErrQuerySetNotFound)
+	}
+
+	return slices.Sorted(maps.Keys(qs.data)), nil
+}
+
+// registerData adds a data block to the set, failing if its ID is taken.
+func (qs *QuerySet) registerData(d Data) error {
+	if _, ok := qs.data[d.ID]; ok {
+		return fmt.Errorf("data %s: %w", d.ID, ErrAlreadyExists)
+	}
+
+	if qs.data == nil {
+		qs.data = make(map[string]Data)
+	}
+
+	qs.data[d.ID] = d
+
+	return nil
+}
+
+// parseData parses the content of a data block, JSON if it starts with "[", CSV otherwise.
+func parseData(id, content string, src Source) (Data, error) {
+	d := Data{ID: id, Source: src, content: content}
+
+	var err error
+
+	if strings.HasPrefix(strings.TrimSpace(content), "[") {
+		d.Columns, d.Rows, err = parseJSONData(content)
+	} else {
+		d.Columns, d.Rows, err = parseCSVData(content)
+	}
+
+	if err != nil {
+		return Data{}, fmt.Errorf("%w: data %s: %s", ErrInvalidSyntax, id, err.Error())
+	}
+
+	return d, nil
+}
+
+func parseCSVData(content string) ([]string, [][]any, error) {
+	records, err := csv.NewReader(strings.NewReader(content)).ReadAll()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, nil, errors.New("no header line")
+	}
+
+	rows := make([][]any, 0, len(records)-1)
+
+	for _, record := range records[1:] {
+		row := make([]any, len(record))
+		for i, value := range record {
+			row[i] = value
+		}
+
+		rows = append(rows, row)
+	}
+
+	return records[0], rows, nil
+}
+
+func parseJSONData(content string) ([]string, [][]any, error) {
+	dec := json.NewDecoder(strings.NewReader(content))
+	dec.UseNumber()
+
+	var objects []json.RawMessage
+
+	if err := dec.Decode(&objects); err != nil {
+		return nil, nil, err
+	}
+
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, nil, errors.New("unexpected content after the JSON array")
+	}
+
+	var (
+		columns []string
+		values  []map[string]any
+	)
+
+	for _, raw := range objects {
+		keys, err := objectKeys(raw)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, key := range keys {
+			if !slices.Contains(columns, key) {
+				columns = append(columns, key)
+			}
+		}
+
+		var object map[string]any
+
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.UseNumber()
+
+		if err := dec.Decode(&object); err != nil {
+			return nil, nil, err
+		}
+
+		values = append(values, object)
+	}
+
+	rows := make([][]any, len(values))
+
+	for i, object := range values {
+		rows[i] = make([]any, len(columns))
+		for j, column := range columns {
+			rows[i][j] = object[column]
+		}
+	}
+
+	return columns, rows, nil
+}
+
+// objectKeys returns the keys of a JSON object in order.
+func objectKeys(raw json.RawMessage) ([]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, fmt.Errorf("rows must be objects, got %s", raw)
+	}
+
+	var keys []string
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, tok.(string)) //nolint:forcetypeassert // Object keys are strings.
+
+		var value json.RawMessage
+
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+	}
+
+	return keys, nil
+}
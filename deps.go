@@ -0,0 +1,148 @@
+package sqlset
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// TopologicalOrder returns the queries of keys, given as "setID.queryID", with all the queries
+// they require, in an order running every query after its requirements, e.g. for setup
+// and fixture runners. Without keys, all queries are ordered. Queries not depending
+// on each other are sorted by key, so the order is stable.
+//
+// Requirements are declared with --requires lines or the "requires" metadata,
+// as "setID.queryID" keys or query IDs of the same set:
+//
+//	--SQL:CreateOrders
+//	--requires: users.CreateSchema, CreateTypes
+//	CREATE TABLE orders (...)
+//	--end
+//
+// It returns ErrDependencyCycle if the queries require each other and
+// an ErrNotFound error if a required query does not exist.
+func (s *SQLSet) TopologicalOrder(keys ...string) ([]QueryKey, error) {
+	var roots []QueryKey
+
+	if len(keys) == 0 {
+		roots = s.keys()
+	}
+
+	for _, key := range keys {
+		root, _, err := s.lookup(key)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+
+		roots = append(roots, root)
+	}
+
+	sort.Slice(roots, func(i, j int) bool {
+		return roots[i].String() < roots[j].String()
+	})
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+
+	var (
+		order []QueryKey
+		state = make(map[QueryKey]int)
+		visit func(key QueryKey, path []QueryKey) error
+	)
+
+	visit = func(key QueryKey, path []QueryKey) error {
+		switch state[key] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("%w: %s", ErrDependencyCycle, formatCycle(append(path, key)))
+		}
+
+		state[key] = visiting
+
+		deps, err := s.requirements(key)
+		if err != nil {
+			return err
+		}
+
+		for _, dep := range deps {
+			if err := visit(dep, append(path, key)); err != nil {
+				return err
+			}
+		}
+
+		state[key] = visited
+		order = append(order, key)
+
+		return nil
+	}
+
+	for _, root := range roots {
+		if err := visit(root, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return order, nil
+}
+
+// requirements returns the sorted keys of the queries required by the query of key.
+func (s *SQLSet) requirements(key QueryKey) ([]QueryKey, error) {
+	q, err := s.findQuery(key.SetID, key.QueryID)
+	if err != nil {
+		return nil, err
+	}
+
+	deps := make([]QueryKey, 0, len(q.meta.Requires))
+
+	for _, ref := range q.meta.Requires {
+		dep := QueryKey{SetID: key.SetID, QueryID: ref}
+		if setID, queryID, ok := s.splitKey(ref); ok {
+			dep = QueryKey{SetID: setID, QueryID: queryID}
+		}
+
+		if _, err := s.findQuery(dep.SetID, dep.QueryID); err != nil {
+			return nil, fmt.Errorf("%s requires %s: %w", key, ref, err)
+		}
+
+		deps = append(deps, dep)
+	}
+
+	sort.Slice(deps, func(i, j int) bool {
+		return deps[i].String() < deps[j].String()
+	})
+
+	return deps, nil
+}
+
+// keys returns the keys of all queries.
+func (s *SQLSet) keys() []QueryKey {
+	var keys []QueryKey
+
+	for setID, qs := range s.sets {
+		for id := range qs.queries {
+			keys = append(keys, QueryKey{SetID: setID, QueryID: id})
+		}
+	}
+
+	return keys
+}
+
+// formatCycle formats the cycle closed by the last key of path, e.g. "a.A -> a.B -> a.A".
+func formatCycle(path []QueryKey) string {
+	last := path[len(path)-1]
+
+	start := 0
+	for path[start] != last {
+		start++
+	}
+
+	names := make([]string, 0, len(path)-start)
+	for _, key := range path[start:] {
+		names = append(names, key.String())
+	}
+
+	return strings.Join(names, " -> ")
+}
@@ -0,0 +1,98 @@
+package sqlset_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/istovpets/sqlset"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTopologicalOrder(t *testing.T) {
+	t.Parallel()
+
+	sqlSet, err := sqlset.New(fstest.MapFS{
+		"schema.sql": {Data: []byte(`--SQL:CreateTypes
+CREATE TYPE status AS ENUM ('new', 'paid');
+--end
+
+--SQL:CreateUsers
+--requires: CreateTypes
+CREATE TABLE users (id int);
+--end`)},
+		"orders.sql": {Data: []byte(`--SQL:CreateOrders
+--requires: schema.CreateUsers
+--META: {"requires": ["schema.CreateTypes"]}
+CREATE TABLE orders (id int, user_id int REFERENCES users);
+--end
+
+--SQL:Seed
+--requires: CreateOrders
+INSERT INTO orders VALUES (1, 1);
+--end`)},
+	})
+	require.NoError(t, err)
+
+	key := func(setID, queryID string) sqlset.QueryKey {
+		return sqlset.QueryKey{SetID: setID, QueryID: queryID}
+	}
+
+	order, err := sqlSet.TopologicalOrder("orders.Seed")
+	require.NoError(t, err)
+	assert.Equal(t, []sqlset.QueryKey{
+		key("schema", "CreateTypes"),
+		key("schema", "CreateUsers"),
+		key("orders", "CreateOrders"),
+		key("orders", "Seed"),
+	}, order)
+
+	all, err := sqlSet.TopologicalOrder()
+	require.NoError(t, err)
+	assert.Equal(t, order, all)
+
+	meta, err := sqlSet.GetQueryMeta("orders", "CreateOrders")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"schema.CreateTypes", "schema.CreateUsers"}, meta.Requires)
+
+	_, err = sqlSet.TopologicalOrder("orders.Missing")
+	require.ErrorIs(t, err, sqlset.ErrQueryNotFound)
+}
+
+func TestTopologicalOrder_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		data string
+		err  error
+		msg  string
+	}{
+		{
+			name: "cycle",
+			data: "--SQL:A\n--requires: B\nSELECT 1;\n--end\n--SQL:B\n--requires: C\nSELECT 2;\n--end\n" +
+				"--SQL:C\n--requires: B\nSELECT 3;\n--end\n",
+			err: sqlset.ErrDependencyCycle,
+			msg: "dependency cycle: q.B -> q.C -> q.B",
+		},
+		{
+			name: "missing requirement",
+			data: "--SQL:A\n--requires: other.B\nSELECT 1;\n--end\n",
+			err:  sqlset.ErrQuerySetNotFound,
+			msg:  "q.A requires other.B: other: query set not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sqlSet, err := sqlset.New(fstest.MapFS{"q.sql": {Data: []byte(tt.data)}})
+			require.NoError(t, err)
+
+			_, err = sqlSet.TopologicalOrder()
+			require.ErrorIs(t, err, tt.err)
+			assert.EqualError(t, err, tt.msg)
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package sqlset
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WithDiagnostics makes New return the parse errors as *ParseError, holding the failing line
+// with contextLines lines around it and the parser state, so the file need not be opened
+// to find the failure:
+//
+//	_, err := sqlset.New(fsys, sqlset.WithDiagnostics(3))
+//
+//	var parseErr *sqlset.ParseError
+//	if errors.As(err, &parseErr) {
+//		fmt.Fprintln(os.Stderr, parseErr.Diagnostic())
+//	}
+func WithDiagnostics(contextLines int) Option {
+	return func(cfg *config) {
+		cfg.diagnostics = contextLines
+	}
+}
+
+// ParseError is an error parsing a file with its context, see WithDiagnostics.
+type ParseError struct {
+	// File is the path of the file within the loaded file system.
+	File string
+	// Line is the failing line, starting at 1.
+	Line int
+	// Snippet holds the numbered lines around the failing one, which is marked with ">".
+	Snippet string
+	// State describes the parser state at the failure, e.g. `inside SQL block "GetUser" opened at line 12`.
+	State string
+	Err   error
+}
+
+// Error returns the message of the underlying error.
+func (e *ParseError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Diagnostic returns the location, parser state and snippet of the failure, for printing.
+func (e *ParseError) Diagnostic() string {
+	return fmt.Sprintf("%s:%d: %s\n%s", e.File, e.Line, e.State, e.Snippet)
+}
+
+func newParseError(file string, line int, lines []string, contextLines int, state string, err error) *ParseError {
+	var sb strings.Builder
+
+	first, last := max(line-contextLines, 1), min(line+contextLines, len(lines))
+	width := len(fmt.Sprint(last))
+
+	for n := first; n <= last; n++ {
+		marker := " "
+		if n == line {
+			marker = ">"
+		}
+
+		fmt.Fprintf(&sb, "%s %*d | %s\n", marker, width, n, lines[n-1])
+	}
+
+	return &ParseError{File: file, Line: line, Snippet: sb.String(), State: state, Err: err}
+}
+
+// describeState describes the block being parsed and the unterminated SQL construct, if any.
+func describeState(opened *parserToken, lex *lexState) string {
+	var state string
+
+	switch {
+	case opened == nil:
+		state = "outside of a block"
+	case opened.Type == tokenMeta:
+		state = fmt.Sprintf("inside %s block opened at line %d", tokenMeta, opened.Line)
+	default:
+		state = fmt.Sprintf("inside %s block %q opened at line %d", opened.Type, opened.Key, opened.Line)
+
+		if opened.Sub != "" {
+			state += fmt.Sprintf(", in its %s sub-block", opened.Sub)
+		}
+	}
+
+	if lex.inside() {
+		state += ", in an unterminated " + lex.String()
+	}
+
+	return state
+}
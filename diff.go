@@ -0,0 +1,227 @@
+package sqlset
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines around the changes in a unified diff.
+const diffContext = 3
+
+// ChangeSet holds the differences between two SQLSets, see Diff.
+// All slices are sorted by key.
+type ChangeSet struct {
+	// Added lists the queries present only in the new set.
+	Added []QueryKey `json:"added,omitempty"`
+	// Removed lists the queries present only in the old set.
+	Removed []QueryKey `json:"removed,omitempty"`
+	// Modified lists the queries whose bodies differ.
+	Modified []QueryChange `json:"modified,omitempty"`
+}
+
+// QueryChange is a query with a modified body.
+type QueryChange struct {
+	Key QueryKey `json:"key"`
+	// Diff is the unified diff of the old and new bodies.
+	Diff string `json:"diff"`
+}
+
+// Empty reports whether there are no differences.
+func (c ChangeSet) Empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Modified) == 0
+}
+
+// DiffOption configures Diff.
+type DiffOption func(*diffConfig)
+
+type diffConfig struct {
+	semantic bool
+}
+
+// WithSemanticDiff makes Diff compare the normalized query bodies, see Normalize,
+// so that formatting-only changes, e.g. reindented or recommented queries, are not reported.
+// The diffs of the modified queries still show the bodies as written.
+func WithSemanticDiff() DiffOption {
+	return func(cfg *diffConfig) {
+		cfg.semantic = true
+	}
+}
+
+// Diff compares the query bodies of the old set a and the new set b,
+// e.g. to validate the changes of a deployment:
+//
+//	changes := sqlset.Diff(deployed, candidate)
+//	for _, key := range changes.Removed {
+//		log.Printf("removed: %s", key)
+//	}
+func Diff(a, b *SQLSet, opts ...DiffOption) ChangeSet {
+	var (
+		changes ChangeSet
+		cfg     diffConfig
+	)
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	oldQueries, newQueries := a.bodies(), b.bodies()
+
+	for key, oldSQL := range oldQueries {
+		newSQL, ok := newQueries[key]
+		if !ok {
+			changes.Removed = append(changes.Removed, key)
+
+			continue
+		}
+
+		if oldSQL == newSQL || cfg.semantic && Normalize(oldSQL) == Normalize(newSQL) {
+			continue
+		}
+
+		changes.Modified = append(changes.Modified, QueryChange{
+			Key:  key,
+			Diff: unifiedDiff("a/"+key.String(), "b/"+key.String(), oldSQL, newSQL),
+		})
+	}
+
+	for key := range newQueries {
+		if _, ok := oldQueries[key]; !ok {
+			changes.Added = append(changes.Added, key)
+		}
+	}
+
+	sortKeys(changes.Added)
+	sortKeys(changes.Removed)
+	sort.Slice(changes.Modified, func(i, j int) bool {
+		return changes.Modified[i].Key.String() < changes.Modified[j].Key.String()
+	})
+
+	return changes
+}
+
+// bodies returns the bodies of all queries by key.
+func (s *SQLSet) bodies() map[QueryKey]string {
+	result := make(map[QueryKey]string)
+
+	for setID, qs := range s.sets {
+		for id, q := range qs.queries {
+			result[QueryKey{SetID: setID, QueryID: id}] = q.sql
+		}
+	}
+
+	return result
+}
+
+func sortKeys(keys []QueryKey) {
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+}
+
+// diffOp is a line of an edit script: ' ' kept, '-' deleted or '+' inserted.
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// unifiedDiff returns the unified diff of the lines of from and to.
+func unifiedDiff(fromName, toName, from, to string) string {
+	ops := diffLines(strings.Split(from, lineEnding), strings.Split(to, lineEnding))
+
+	var sb strings.Builder
+
+	sb.WriteString("--- " + fromName + "\n")
+	sb.WriteString("+++ " + toName + "\n")
+
+	// Line numbers of ops[i] in from and to.
+	fromLine, toLine := make([]int, len(ops)+1), make([]int, len(ops)+1)
+	fromLine[0], toLine[0] = 1, 1
+
+	for i, op := range ops {
+		fromLine[i+1], toLine[i+1] = fromLine[i], toLine[i]
+
+		if op.kind != '+' {
+			fromLine[i+1]++
+		}
+
+		if op.kind != '-' {
+			toLine[i+1]++
+		}
+	}
+
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+
+			continue
+		}
+
+		// A hunk spans the changes closer than two contexts apart.
+		start := max(i-diffContext, 0)
+		end := i
+
+		for j := i; j < len(ops); j++ {
+			if ops[j].kind != ' ' {
+				end = j + 1
+			} else if j-end >= 2*diffContext {
+				break
+			}
+		}
+
+		end = min(end+diffContext, len(ops))
+
+		fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n",
+			fromLine[start], fromLine[end]-fromLine[start],
+			toLine[start], toLine[end]-toLine[start],
+		)
+
+		for _, op := range ops[start:end] {
+			sb.WriteByte(op.kind)
+			sb.WriteString(op.line + "\n")
+		}
+
+		i = end
+	}
+
+	return sb.String()
+}
+
+// diffLines returns the shortest edit script turning a into b, based on their longest common subsequence.
+func diffLines(a, b []string) []diffOp {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	ops := make([]diffOp, 0, len(a)+len(b))
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, diffOp{kind: ' ', line: a[i]})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{kind: '-', line: a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{kind: '+', line: b[j]})
+			j++
+		}
+	}
+
+	return ops
+}
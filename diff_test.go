@@ -0,0 +1,196 @@
+package sqlset_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/istovpets/sqlset"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	oldSet, err := sqlset.New(fstest.MapFS{
+		"users.sql": &fstest.MapFile{Data: []byte(`--SQL:GetUser
+SELECT id, name
+FROM users
+WHERE id = $1;
+--end
+
+--SQL:CountUsers
+SELECT count(*) FROM users;
+--end
+
+--SQL:DeleteUser
+DELETE FROM users WHERE id = $1;
+--end`)},
+	})
+	require.NoError(t, err)
+
+	newSet, err := sqlset.New(fstest.MapFS{
+		"users.sql": &fstest.MapFile{Data: []byte(`--SQL:GetUser
+SELECT id, name, email
+FROM users
+WHERE id = $1;
+--end
+
+--SQL:CountUsers
+-- A comment is not part of the body.
+SELECT count(*) FROM users;
+--end
+
+--SQL:ListUsers
+SELECT id FROM users;
+--end`)},
+	})
+	require.NoError(t, err)
+
+	changes := sqlset.Diff(oldSet, newSet)
+
+	assert.False(t, changes.Empty())
+	assert.Equal(t, []sqlset.QueryKey{{SetID: "users", QueryID: "ListUsers"}}, changes.Added)
+	assert.Equal(t, []sqlset.QueryKey{{SetID: "users", QueryID: "DeleteUser"}}, changes.Removed)
+	assert.Equal(t, []sqlset.QueryChange{{
+		Key: sqlset.QueryKey{SetID: "users", QueryID: "GetUser"},
+		Diff: `--- a/users.GetUser
++++ b/users.GetUser
+@@ -1,3 +1,3 @@
+-SELECT id, name
++SELECT id, name, email
+ FROM users
+ WHERE id = $1;
+`,
+	}}, changes.Modified)
+
+	assert.True(t, sqlset.Diff(newSet, newSet).Empty())
+}
+
+func TestDiff_Hunks(t *testing.T) {
+	t.Parallel()
+
+	body := func(lines ...string) *sqlset.SQLSet {
+		var data string
+		for _, l := range lines {
+			data += l + "\n"
+		}
+
+		s, err := sqlset.New(fstest.MapFS{
+			"q.sql": &fstest.MapFile{Data: []byte("--SQL:Q\n" + data + "--end")},
+		})
+		require.NoError(t, err)
+
+		return s
+	}
+
+	changes := sqlset.Diff(
+		body("a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l"),
+		body("A", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "L"),
+	)
+
+	require.Len(t, changes.Modified, 1)
+	assert.Equal(t, `--- a/q.Q
++++ b/q.Q
+@@ -1,4 +1,4 @@
+-a
++A
+ b
+ c
+ d
+@@ -9,4 +9,4 @@
+ i
+ j
+ k
+-l
++L
+`, changes.Modified[0].Diff)
+}
+
+func TestDiff_Semantic(t *testing.T) {
+	t.Parallel()
+
+	oldSet, err := sqlset.New(fstest.MapFS{
+		"users.sql": &fstest.MapFile{Data: []byte(`--SQL:GetUser
+select id, name from users where id = $1;
+--end
+
+--SQL:ListUsers
+SELECT id FROM users;
+--end`)},
+	})
+	require.NoError(t, err)
+
+	newSet, err := sqlset.New(fstest.MapFS{
+		"users.sql": &fstest.MapFile{Data: []byte(`--SQL:GetUser
+SELECT id, name
+FROM users
+WHERE id = $1; -- by primary key
+--end
+
+--SQL:ListUsers
+SELECT id FROM users ORDER BY id;
+--end`)},
+	})
+	require.NoError(t, err)
+
+	assert.Len(t, sqlset.Diff(oldSet, newSet).Modified, 2)
+
+	changes := sqlset.Diff(oldSet, newSet, sqlset.WithSemanticDiff())
+	require.Len(t, changes.Modified, 1)
+	assert.Equal(t, sqlset.QueryKey{SetID: "users", QueryID: "ListUsers"}, changes.Modified[0].Key)
+}
+
+func TestNormalize(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		sql  string
+		want string
+	}{
+		{
+			name: "whitespace and keywords",
+			sql:  "select id,\n\tname\r\n  from   users\nwhere id = $1",
+			want: "SELECT id, name FROM users WHERE id = $1",
+		},
+		{
+			name: "comments",
+			sql:  "SELECT id -- the key\nFROM /* all */ users",
+			want: "SELECT id FROM users",
+		},
+		{
+			name: "identifiers kept",
+			sql:  "select Id from Users",
+			want: "SELECT Id FROM Users",
+		},
+		{
+			name: "literals kept",
+			sql:  "select 'a  --  b', \"Select\", E'it''s'  from t",
+			want: "SELECT 'a  --  b', \"Select\", E'it''s' FROM t",
+		},
+		{
+			name: "dollar quotes kept",
+			sql:  "do $fn$ select   1 $fn$",
+			want: "DO $fn$ select   1 $fn$",
+		},
+		{
+			name: "unterminated",
+			sql:  "select 'abc",
+			want: "SELECT 'abc",
+		},
+		{
+			name: "empty",
+			sql:  "  -- nothing\n",
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, sqlset.Normalize(tt.sql))
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package sqlset
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// directivePattern matches the "KEYWORD:value" rest of an unknown directive line.
+var directivePattern = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9_-]*):(.*)$`)
+
+// Directive is a custom "--KEYWORD:value" directive line, see WithDirectiveHandler.
+type Directive struct {
+	// Keyword is the directive keyword as written, e.g. "owner".
+	Keyword string
+	// Value is the trimmed text following the colon.
+	Value string
+	// Key is the query of the block holding the directive, with an empty QueryID
+	// outside of query blocks.
+	Key QueryKey
+	// File is the path of the file within the loaded file system.
+	File string
+	// Line is the line of the directive, starting at 1.
+	Line int
+}
+
+// WithDirectiveHandler passes the directive lines the parser does not know, such as
+// "--owner: @acme/payments" or "--ticket: DB-1234", to handle, so tools can define their
+// own annotations. Without a handler they are plain comments. An error fails New:
+//
+//	tickets := make(map[sqlset.QueryKey]string)
+//
+//	sqlset.New(fsys, sqlset.WithDirectiveHandler(func(d sqlset.Directive) error {
+//		if d.Keyword == "ticket" {
+//			tickets[d.Key] = d.Value
+//		}
+//
+//		return nil
+//	}))
+func WithDirectiveHandler(handle func(Directive) error) Option {
+	return func(cfg *config) {
+		cfg.directives = handle
+	}
+}
+
+// directive passes a comment line to the directive handler if it is a custom directive.
+func (cfg *config) directive(line string, opened *parserToken, setID, file string, lineN int) error {
+	if cfg.directives == nil {
+		return nil
+	}
+
+	m := directivePattern.FindStringSubmatch(line[len(cfg.syntax.Prefix):])
+	if m == nil {
+		return nil
+	}
+
+	d := Directive{Keyword: m[1], Value: strings.TrimSpace(m[2]), Key: QueryKey{SetID: setID}, File: file, Line: lineN}
+	if opened != nil && opened.Type == tokenSQL {
+		d.Key.QueryID = opened.Key
+	}
+
+	if err := cfg.directives(d); err != nil {
+		return fmt.Errorf("directive %s: %w", d.Keyword, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package sqlset
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+)
+
+// Directory metadata files. Their fields are inherited by the sets of the directory
+// and its subdirectories unless the sets override them.
+const (
+	// dirMetaFile holds the metadata as a JSON object.
+	dirMetaFile = "_meta.json"
+	// dirSetFile holds the metadata as a META block, it must not contain queries.
+	dirSetFile = "_set.sql"
+)
+
+// dirMetas holds the metadata inherited by the sets of every directory.
+type dirMetas map[string]QuerySetMeta
+
+// of returns the metadata inherited by the file or directory at name from its parent.
+func (d dirMetas) of(name string) QuerySetMeta {
+	return d[path.Dir(name)]
+}
+
+// loadDirMeta reads the metadata of the _meta.json or _set.sql file in dir, if there is one.
+func loadDirMeta(cfg *config, fsys fs.FS, dir string) (QuerySetMeta, error) {
+	metaPath, setPath := path.Join(dir, dirMetaFile), path.Join(dir, dirSetFile)
+
+	data, err := fs.ReadFile(fsys, metaPath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return QuerySetMeta{}, fmt.Errorf("read %s: %w", metaPath, err)
+	}
+
+	f, setErr := fsys.Open(setPath)
+	if setErr != nil && !errors.Is(setErr, fs.ErrNotExist) {
+		return QuerySetMeta{}, fmt.Errorf("open %s: %w", setPath, setErr)
+	}
+
+	switch {
+	case err == nil && setErr == nil:
+		_ = f.Close()
+
+		return QuerySetMeta{}, fmt.Errorf("%s: %w with %s", setPath, ErrAlreadyExists, dirMetaFile)
+	case err == nil:
+		meta, err := parseMeta("", data)
+		if err != nil {
+			return QuerySetMeta{}, fmt.Errorf("parse %s: %w", metaPath, err)
+		}
+
+		return meta, nil
+	case setErr == nil:
+		defer func() {
+			_ = f.Close()
+		}()
+
+		qs, err := parse(cfg, "", setPath, "", f)
+		if err != nil {
+			return QuerySetMeta{}, fmt.Errorf("parse %s: %w", setPath, err)
+		}
+
+		if len(qs.queries) > 0 {
+			return QuerySetMeta{}, fmt.Errorf("parse %s: %w: only the %s block is allowed", setPath, ErrInvalidSyntax, tokenMeta)
+		}
+
+		return qs.meta, nil
+	default:
+		return QuerySetMeta{}, nil
+	}
+}
+
+// inherit returns m with its empty inheritable fields (Owner, Dialect and Tags) taken from parent.
+func (m QuerySetMeta) inherit(parent QuerySetMeta) QuerySetMeta {
+	if m.Owner == "" {
+		m.Owner = parent.Owner
+	}
+
+	if m.Dialect == "" {
+		m.Dialect = parent.Dialect
+	}
+
+	if m.Tags == nil {
+		m.Tags = slices.Clone(parent.Tags)
+	}
+
+	return m
+}
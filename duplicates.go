@@ -0,0 +1,115 @@
+package sqlset
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+)
+
+// QueryRef describes a query registered twice under the same key, see WithDuplicateHandler.
+type QueryRef struct {
+	Key QueryKey
+	SQL string
+	// Source is the location the query was loaded from.
+	Source Source
+}
+
+// Resolution is the outcome of a duplicate query, returned by a DuplicateHandler.
+type Resolution int
+
+const (
+	// UseIncoming replaces the existing query with the incoming one.
+	UseIncoming Resolution = iota
+	// KeepExisting drops the incoming query.
+	KeepExisting
+	// RejectDuplicate fails New with ErrQueryExists.
+	RejectDuplicate
+)
+
+// DuplicateHandler resolves a query registered twice: within a file, or in
+// two files with the same set ID, e.g. vendor/users.sql and local/users.sql.
+type DuplicateHandler func(existing, incoming QueryRef) Resolution
+
+// WithDuplicateHandler makes New resolve the duplicate queries with handle.
+// Without it, a duplicate query replaces the existing one and a file replaces
+// the whole set loaded before with the same ID. With it, files with the same set ID
+// are merged query by query, keeping the metadata of the first file.
+// Files are loaded in lexical order, so "local wins with a warning" is:
+//
+//	sqlset.New(fsys, sqlset.WithDuplicateHandler(func(existing, incoming sqlset.QueryRef) sqlset.Resolution {
+//		log.Printf("%s: %s overrides %s", incoming.Key, incoming.Source.File, existing.Source.File)
+//		return sqlset.UseIncoming
+//	}))
+func WithDuplicateHandler(handle DuplicateHandler) Option {
+	return func(cfg *config) {
+		cfg.duplicates = handle
+	}
+}
+
+// addQuery registers q in qs, resolving a duplicate with the configured handler.
+func (cfg *config) addQuery(qs *QuerySet, key QueryKey, q query) error {
+	if existing, ok := qs.queries[key.QueryID]; ok && cfg.duplicates != nil {
+		switch cfg.duplicates(existing.ref(key), q.ref(key)) {
+		case UseIncoming:
+		case KeepExisting:
+			return nil
+		case RejectDuplicate:
+			return fmt.Errorf("%s: %w", key, ErrQueryExists)
+		}
+	}
+
+	qs.registerQuery(key.QueryID, q)
+
+	return nil
+}
+
+// addQuerySet registers qs in s, merging it into a set with the same ID
+// when a duplicate handler is configured.
+func (cfg *config) addQuerySet(s *SQLSet, qs QuerySet) error {
+	setID := qs.meta.ID
+
+	existing, ok := s.sets[setID]
+	if !ok || cfg.duplicates == nil {
+		s.registerQuerySet(setID, qs)
+
+		return nil
+	}
+
+	ids := make([]string, 0, len(qs.queries))
+	for id := range qs.queries {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		if err := cfg.addQuery(&existing, QueryKey{SetID: setID, QueryID: id}, qs.queries[id]); err != nil {
+			return err
+		}
+	}
+
+	for _, id := range slices.Sorted(maps.Keys(qs.data)) {
+		if err := existing.registerData(qs.data[id]); err != nil {
+			return fmt.Errorf("%s: %w", setID, err)
+		}
+	}
+
+	for _, id := range slices.Sorted(maps.Keys(qs.expectations)) {
+		if err := existing.registerExpectation(id, qs.expectations[id]); err != nil {
+			return fmt.Errorf("%s: %w", setID, err)
+		}
+	}
+
+	if qs.modTime.After(existing.modTime) {
+		existing.modTime = qs.modTime
+	}
+
+	s.registerQuerySet(setID, existing)
+
+	return nil
+}
+
+func (q query) ref(key QueryKey) QueryRef {
+	return QueryRef{Key: key, SQL: q.sql, Source: q.source}
+}
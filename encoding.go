@@ -0,0 +1,73 @@
+package sqlset
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// decodeInput returns a UTF-8 reader for inp, stripping the UTF-8 byte order mark
+// and transcoding UTF-16 detected by its byte order mark, or by the zero bytes
+// of the ASCII characters when there is none, as files saved by Windows tools often are.
+func decodeInput(inp io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(inp)
+
+	head, err := br.Peek(3)
+	if err != nil && err != io.EOF { //nolint:errorlint
+		return nil, err
+	}
+
+	switch {
+	case bytes.HasPrefix(head, bomUTF8):
+		_, _ = br.Discard(len(bomUTF8))
+
+		return br, nil
+	case bytes.HasPrefix(head, bomUTF16LE):
+		_, _ = br.Discard(len(bomUTF16LE))
+
+		return decodeUTF16(br, binary.LittleEndian)
+	case bytes.HasPrefix(head, bomUTF16BE):
+		_, _ = br.Discard(len(bomUTF16BE))
+
+		return decodeUTF16(br, binary.BigEndian)
+	case len(head) >= 2 && head[0] != 0 && head[1] == 0:
+		return decodeUTF16(br, binary.LittleEndian)
+	case len(head) >= 2 && head[0] == 0 && head[1] != 0:
+		return decodeUTF16(br, binary.BigEndian)
+	default:
+		return br, nil
+	}
+}
+
+func decodeUTF16(r io.Reader, order binary.ByteOrder) (io.Reader, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data)%2 != 0 {
+		return nil, fmt.Errorf("%w: truncated UTF-16 input", ErrInvalidSyntax)
+	}
+
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = order.Uint16(data[2*i:])
+	}
+
+	buf := make([]byte, 0, len(data))
+	for _, r := range utf16.Decode(units) {
+		buf = utf8.AppendRune(buf, r)
+	}
+
+	return bytes.NewReader(buf), nil
+}
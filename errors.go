@@ -20,8 +20,28 @@ var (
 	ErrQuerySetNotFound = fmt.Errorf("query set %w", ErrNotFound)
 	// ErrQueryNotFound indicates that a specific query was not found within a set.
 	ErrQueryNotFound = fmt.Errorf("query %w", ErrNotFound)
+	// ErrDataNotFound indicates that a fixture data block was not found within a set, see SQLSet.GetData.
+	ErrDataNotFound = fmt.Errorf("data %w", ErrNotFound)
+	// ErrExpectationNotFound indicates that a query declares no expected result, see SQLSet.GetExpectation.
+	ErrExpectationNotFound = fmt.Errorf("expectation %w", ErrNotFound)
+	// ErrVariableNotFound indicates that a query references a variable not given with WithVariables.
+	ErrVariableNotFound = fmt.Errorf("variable %w", ErrNotFound)
+	// ErrEmptyQuery indicates that a query has no SQL.
+	ErrEmptyQuery = fmt.Errorf("query %w", ErrEmpty)
+	// ErrAlreadyExists is the base error for when an item is registered twice.
+	ErrAlreadyExists = errors.New("already exists")
+	// ErrDuplicate is an alias of ErrAlreadyExists, the two are interchangeable.
+	// It matches every item registered twice, e.g. ErrQuerySetExists or ErrQueryExists.
+	ErrDuplicate = ErrAlreadyExists
+	// ErrQuerySetExists indicates that a query set with the same ID is already registered.
+	ErrQuerySetExists = fmt.Errorf("query set %w", ErrAlreadyExists)
+	// ErrQueryExists indicates that a query with the same key is already registered.
+	ErrQueryExists = fmt.Errorf("query %w", ErrAlreadyExists)
 	// ErrInvalidSyntax is returned when the parser encounters a syntax error in a .sql file.
 	ErrInvalidSyntax = errors.New("invalid SQLSetList syntax")
+	// ErrUnknownDialect is returned when the metadata of a set declares a dialect
+	// other than the Dialect constants.
+	ErrUnknownDialect = errors.New("unknown dialect")
 	// ErrMaxLineLenExceeded is returned when a line in a .sql file is too long,
 	// which may indicate a corrupted file.
 	ErrMaxLineLenExceeded = errors.New("line too long, possible line corruption")
@@ -30,4 +50,27 @@ var (
 	ErrInvalidArgCount = errors.New("invalid number of arguments")
 	// ErrRequiredArgMissing is returned when a required argument is not specified.
 	ErrRequiredArgMissing = errors.New("required argument not specified")
+	// ErrInvalidBundle is returned when a binary bundle cannot be decoded.
+	ErrInvalidBundle = errors.New("invalid SQL set bundle")
+	// ErrSortNotAllowed is returned when a query is ordered by a column
+	// or in a direction that is not allowed.
+	ErrSortNotAllowed = errors.New("sort not allowed")
+	// ErrProviderPanic is returned by SafeProvider when its source panics.
+	ErrProviderPanic = errors.New("provider panicked")
+	// ErrNotDir is returned when a root given as a directory is a file.
+	ErrNotDir = errors.New("not a directory")
+	// ErrNamingPolicy is returned when a query ID violates the naming policy, see WithNamingPolicy.
+	ErrNamingPolicy = errors.New("naming policy violated")
+	// ErrTemplateQuery is returned when a template query is fetched without rendering, see GetRendered.
+	ErrTemplateQuery = errors.New("query is a template, use GetRendered")
+	// ErrInvalidArchive is returned when an archive of .sql files cannot be read, see NewFromArchive.
+	ErrInvalidArchive = errors.New("invalid SQL set archive")
+	// ErrInvalidPack is returned when a query pack cannot be read or does not match its manifest, see ReadPack.
+	ErrInvalidPack = errors.New("invalid query pack")
+	// ErrIncompatiblePack is returned when a query pack requires a newer version of the library, see ReadPack.
+	ErrIncompatiblePack = errors.New("incompatible query pack")
+	// ErrDependencyCycle is returned when queries require each other, see SQLSet.TopologicalOrder.
+	ErrDependencyCycle = errors.New("dependency cycle")
+	// ErrLockMismatch is returned when queries changed since the lock file was generated, see SQLSet.Verify.
+	ErrLockMismatch = errors.New("queries do not match the lock file")
 )
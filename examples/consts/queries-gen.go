@@ -2,9 +2,24 @@ package consts
 
 // Code generated by sqlset-gen. DO NOT EDIT.
 
+import (
+	"github.com/istovpets/sqlset"
+)
+
 const (
 	// users.sql
 	UsersCreateUser = "users.CreateUser"
 	UsersGetUserByID = "users.GetUserByID"
 
 )
+
+// queryKeys lists the keys of all the queries above.
+var queryKeys = []string{
+	UsersCreateUser,
+	UsersGetUserByID,
+}
+
+// VerifyQueries checks that every query above resolves in p, call it at startup and in a test.
+func VerifyQueries(p sqlset.SQLQueriesProvider) error {
+	return sqlset.RequireQueries(p, queryKeys...)
+}
@@ -0,0 +1,187 @@
+package sqlset
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// tokenExpect opens an expected result block.
+const tokenExpect = "EXPECT"
+
+// Expectation is the expected result shape of a query, declared in an --EXPECT block,
+// so query contract tests can catch schema drift, see sqlsetdb.CheckExpectations.
+// The first line declares the columns as in --RETURNS lines, the types being optional,
+// and the next lines are the expected first rows as CSV; or the block is a JSON array
+// of objects holding the expected first rows:
+//
+//	--EXPECT:GetUser
+//	id int8, name text
+//	1,alice
+//	--end
+type Expectation struct {
+	// Key is the key of the query.
+	Key QueryKey
+	// Columns are the expected result columns, in order.
+	Columns []QueryParam
+	// Rows are the expected first rows, in column order, see Data.Rows.
+	// NULL values are written as NULL in CSV and null in JSON.
+	Rows [][]any
+	// Source is the location of the block.
+	Source Source
+
+	// content is the text of the block, kept for the bundles.
+	content string
+}
+
+// clone returns a copy of the expectation not sharing the slices.
+func (e Expectation) clone() Expectation {
+	e.Columns = slices.Clone(e.Columns)
+
+	e.Rows = slices.Clone(e.Rows)
+	for i := range e.Rows {
+		e.Rows[i] = slices.Clone(e.Rows[i])
+	}
+
+	return e
+}
+
+// GetExpectation returns the expected result of a query, see Expectation.
+func (s *SQLSet) GetExpectation(setID, queryID string) (Expectation, error) {
+	qs, ok := s.sets[setID]
+	if !ok {
+		return Expectation{}, fmt.Errorf("%s: %w", setID, ErrQuerySetNotFound)
+	}
+
+	e, ok := qs.expectations[queryID]
+	if !ok {
+		return Expectation{}, fmt.Errorf("%s.%s: %w", setID, queryID, ErrExpectationNotFound)
+	}
+
+	return e.clone(), nil
+}
+
+// Expectations returns the expected results of all queries declaring one, sorted by query key.
+func (s *SQLSet) Expectations() []Expectation {
+	var result []Expectation
+
+	for setID, qs := range s.sets {
+		for queryID, e := range qs.expectations {
+			e = e.clone()
+			e.Key = QueryKey{SetID: setID, QueryID: queryID}
+			result = append(result, e)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Key.String() < result[j].Key.String()
+	})
+
+	return result
+}
+
+// registerExpectation adds an expectation to the set, failing if the query already has one.
+func (qs *QuerySet) registerExpectation(queryID string, e Expectation) error {
+	if _, ok := qs.expectations[queryID]; ok {
+		return fmt.Errorf("expect %s: %w", queryID, ErrAlreadyExists)
+	}
+
+	if qs.expectations == nil {
+		qs.expectations = make(map[string]Expectation)
+	}
+
+	qs.expectations[queryID] = e
+
+	return nil
+}
+
+// checkExpectations drops the expectations of the queries of disabled features
+// and rejects those of the queries missing from the set.
+func checkExpectations(qs *QuerySet, disabled map[string]bool) error {
+	for _, id := range slices.Sorted(maps.Keys(qs.expectations)) {
+		if _, ok := qs.queries[id]; ok {
+			continue
+		}
+
+		if disabled[id] {
+			delete(qs.expectations, id)
+
+			continue
+		}
+
+		return fmt.Errorf("line %d: %w: expected result of unknown query %s",
+			qs.expectations[id].Source.Line, ErrInvalidSyntax, id)
+	}
+
+	return nil
+}
+
+// parseExpectation parses the content of an expect block: a JSON array of rows,
+// or a column declaration line followed by CSV rows.
+func parseExpectation(queryID, content string, src Source) (Expectation, error) {
+	e := Expectation{Source: src, content: content}
+
+	if strings.HasPrefix(strings.TrimSpace(content), "[") {
+		columns, rows, err := parseJSONData(content)
+		if err != nil {
+			return Expectation{}, fmt.Errorf("%w: expect %s: %s", ErrInvalidSyntax, queryID, err.Error())
+		}
+
+		for _, name := range columns {
+			e.Columns = append(e.Columns, QueryParam{Name: name})
+		}
+
+		e.Rows = rows
+
+		return e, nil
+	}
+
+	decl, rest, _ := strings.Cut(content, lineEnding)
+	if strings.TrimSpace(decl) == "" {
+		return Expectation{}, fmt.Errorf("%w: expect %s: no columns declared", ErrInvalidSyntax, queryID)
+	}
+
+	columns, err := parseParams(decl)
+	if err != nil {
+		return Expectation{}, fmt.Errorf("expect %s: %w", queryID, err)
+	}
+
+	for _, column := range columns {
+		if column.Default != "" {
+			return Expectation{}, fmt.Errorf("%w: expect %s: column %s has a default", ErrInvalidSyntax, queryID, column.Name)
+		}
+	}
+
+	e.Columns = columns
+
+	r := csv.NewReader(strings.NewReader(rest))
+	r.FieldsPerRecord = len(columns)
+
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return Expectation{}, fmt.Errorf("%w: expect %s: %s", ErrInvalidSyntax, queryID, err.Error())
+		}
+
+		row := make([]any, len(record))
+
+		for i, value := range record {
+			if value != "NULL" {
+				row[i] = value
+			}
+		}
+
+		e.Rows = append(e.Rows, row)
+	}
+
+	return e, nil
+}
@@ -0,0 +1,19 @@
+package sqlset
+
+import "time"
+
+// SetCacheClock replaces the clock of the caching provider.
+func SetCacheClock(p *CachingProvider, now func() time.Time) {
+	p.now = now
+}
+
+// SetModTime sets the modification time of a query set.
+func SetModTime(qs *QuerySet, modTime time.Time) {
+	qs.modTime = modTime
+}
+
+// VersionLess reports whether the semantic version a is older than b.
+var VersionLess = versionLess
+
+// ArchiveFS returns the files of an archive.
+var ArchiveFS = archiveFS
@@ -0,0 +1,63 @@
+package sqlset
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WithFeatures enables the features guarding query blocks. A query block with
+// a --when directive exists only if all its features are enabled, or disabled
+// for the features negated with "!", so variants of a query can be switched
+// by feature flags without keeping the dead ones in the catalog:
+//
+//	--SQL:ListUsers
+//	--when: new_search
+//	SELECT * FROM users WHERE search @@ $1;
+//	--end
+//
+//	--SQL:ListUsers
+//	--when: !new_search
+//	SELECT * FROM users WHERE name ILIKE $1;
+//	--end
+//
+// The option can be given several times, the features add up.
+func WithFeatures(features ...string) Option {
+	return func(cfg *config) {
+		if cfg.features == nil {
+			cfg.features = make(map[string]bool, len(features))
+		}
+
+		for _, feature := range features {
+			cfg.features[feature] = true
+		}
+	}
+}
+
+// enabled reports whether all the guards of a query hold.
+func (cfg *config) enabled(guards []string) bool {
+	for _, guard := range guards {
+		feature, negated := strings.CutPrefix(guard, "!")
+		if cfg.features[feature] == negated {
+			return false
+		}
+	}
+
+	return true
+}
+
+// parseGuards parses the comma-separated features of a --when directive.
+func parseGuards(decl string) ([]string, error) {
+	var guards []string
+
+	for _, guard := range strings.Split(decl, ",") {
+		guard = strings.TrimSpace(guard)
+
+		if feature := strings.TrimPrefix(guard, "!"); feature == "" || strings.ContainsAny(feature, " \t!") {
+			return nil, fmt.Errorf("%w: invalid feature guard %q", ErrInvalidSyntax, guard)
+		}
+
+		guards = append(guards, guard)
+	}
+
+	return guards, nil
+}
@@ -0,0 +1,24 @@
+package sqlset
+
+import "context"
+
+// WithFormatter formats the query bodies once when the files are loaded, so that
+// the admin UI, search results and generated docs show consistently formatted SQL
+// regardless of how the authors wrote it. Raw blocks are not formatted.
+// The sqlsetfmt subpackage provides a formatter:
+//
+//	sqlSet, err := sqlset.New(fsys, sqlset.WithFormatter(sqlsetfmt.Format))
+//
+// To format on every retrieval instead, use WithRewriter(FormatRewriter(format)).
+func WithFormatter(format func(sql string) string) Option {
+	return func(cfg *config) {
+		cfg.formatter = format
+	}
+}
+
+// FormatRewriter adapts a formatter to a Rewriter applied on Get, see WithRewriter.
+func FormatRewriter(format func(sql string) string) Rewriter {
+	return func(_ context.Context, _ QueryKey, sql string) (string, error) {
+		return format(sql), nil
+	}
+}
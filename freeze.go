@@ -0,0 +1,288 @@
+package sqlset
+
+import (
+	"context"
+	"maps"
+	"regexp"
+	"slices"
+	"sync/atomic"
+	"time"
+)
+
+// Snapshot is an immutable copy of a SQLSet, safe to share across goroutines.
+// Values returned by its methods are copies, so callers can not modify it either.
+// Use SQLSet.Freeze to create one.
+type Snapshot struct {
+	set *SQLSet
+}
+
+// Freeze returns an immutable snapshot of the set. Later changes to the set,
+// e.g. with AddSet, are not visible in the snapshot.
+func (s *SQLSet) Freeze() *Snapshot {
+	frozen := &SQLSet{
+		sets:        make(map[string]QuerySet, len(s.sets)),
+		softDelete:  s.softDelete,
+		rewriters:   append([]Rewriter(nil), s.rewriters...),
+		skipped:     append([]SkippedFile(nil), s.skipped...),
+		overridden:  append([]QueryKey(nil), s.overridden...),
+		keySep:      s.keySep,
+		redaction:   s.redaction,
+		warnings:    s.warnings,
+		contextKeys: s.contextKeys,
+	}
+
+	for setID, qs := range s.sets {
+		frozen.sets[setID] = QuerySet{
+			meta:         qs.meta.clone(),
+			queries:      maps.Clone(qs.queries),
+			data:         maps.Clone(qs.data),
+			expectations: maps.Clone(qs.expectations),
+			modTime:      qs.modTime,
+		}
+	}
+
+	return &Snapshot{set: frozen}
+}
+
+// Get returns an SQL query by its identifiers, see SQLSet.Get.
+func (s *Snapshot) Get(ids ...string) (string, error) {
+	return s.set.Get(ids...)
+}
+
+// GetContext returns an SQL query by its identifiers, see SQLSet.GetContext.
+func (s *Snapshot) GetContext(ctx context.Context, ids ...string) (string, error) {
+	return s.set.GetContext(ctx, ids...)
+}
+
+// MustGet is like Get but panics if the query is not found.
+func (s *Snapshot) MustGet(ids ...string) string {
+	return s.set.MustGet(ids...)
+}
+
+// TryGet returns a query and whether it exists, see SQLSet.TryGet.
+func (s *Snapshot) TryGet(setID, queryID string) (string, bool) {
+	return s.set.TryGet(setID, queryID)
+}
+
+// GetSetsMetas returns the metadata of all query sets, see SQLSet.GetSetsMetas.
+func (s *Snapshot) GetSetsMetas() []QuerySetMeta {
+	metas := s.set.GetSetsMetas()
+	for i := range metas {
+		metas[i] = metas[i].clone()
+	}
+
+	return metas
+}
+
+// GetQueryIDs returns the sorted query IDs of a set, see SQLSet.GetQueryIDs.
+func (s *Snapshot) GetQueryIDs(setID string) ([]string, error) {
+	return s.set.GetQueryIDs(setID)
+}
+
+// GetQueryMeta returns the metadata of a query, see SQLSet.GetQueryMeta.
+func (s *Snapshot) GetQueryMeta(setID, queryID string) (QueryMeta, error) {
+	meta, err := s.set.GetQueryMeta(setID, queryID)
+
+	return meta.clone(), err
+}
+
+// Describe returns a query with all its declarations, see SQLSet.Describe.
+func (s *Snapshot) Describe(setID, queryID string) (Query, error) {
+	return s.set.Describe(setID, queryID)
+}
+
+// Params returns the declared parameters of a query, see SQLSet.Params.
+func (s *Snapshot) Params(setID, queryID string) ([]QueryParam, error) {
+	return s.set.Params(setID, queryID)
+}
+
+// BindArgs returns the arguments of a query by name, see SQLSet.BindArgs.
+func (s *Snapshot) BindArgs(setID, queryID string, args map[string]any) ([]any, error) {
+	return s.set.BindArgs(setID, queryID, args)
+}
+
+// Returns returns the declared result columns of a query, see SQLSet.Returns.
+func (s *Snapshot) Returns(setID, queryID string) ([]QueryParam, error) {
+	return s.set.Returns(setID, queryID)
+}
+
+// Redacted returns the query with its literals masked for logging, see SQLSet.Redacted.
+func (s *Snapshot) Redacted(setID, queryID string) string {
+	return s.set.Redacted(setID, queryID)
+}
+
+// GetRendered returns a rendered template query, see SQLSet.GetRendered.
+func (s *Snapshot) GetRendered(data any, ids ...string) (string, error) {
+	return s.set.GetRendered(data, ids...)
+}
+
+// TopologicalOrder orders queries after their requirements, see SQLSet.TopologicalOrder.
+func (s *Snapshot) TopologicalOrder(keys ...string) ([]QueryKey, error) {
+	return s.set.TopologicalOrder(keys...)
+}
+
+// QuerySource returns the location of a query, see SQLSet.QuerySource.
+func (s *Snapshot) QuerySource(setID, queryID string) (Source, error) {
+	return s.set.QuerySource(setID, queryID)
+}
+
+// Search returns the query body lines containing pattern, see SQLSet.Search.
+func (s *Snapshot) Search(pattern string) []SearchResult {
+	return s.set.Search(pattern)
+}
+
+// SearchRegexp returns the query body lines matching re, see SQLSet.SearchRegexp.
+func (s *Snapshot) SearchRegexp(re *regexp.Regexp) []SearchResult {
+	return s.set.SearchRegexp(re)
+}
+
+// LastModified returns the modification time of a set, see SQLSet.LastModified.
+func (s *Snapshot) LastModified(setID string) (time.Time, error) {
+	return s.set.LastModified(setID)
+}
+
+// ModTime returns the modification time of the catalog, see SQLSet.ModTime.
+func (s *Snapshot) ModTime() time.Time {
+	return s.set.ModTime()
+}
+
+// Inventory returns the entries of all queries, see SQLSet.Inventory.
+func (s *Snapshot) Inventory() []InventoryEntry {
+	return s.set.Inventory()
+}
+
+// GetData returns a fixture data block of a set, see SQLSet.GetData.
+func (s *Snapshot) GetData(setID, dataID string) (Data, error) {
+	return s.set.GetData(setID, dataID)
+}
+
+// GetDataIDs returns the sorted IDs of the data blocks of a set, see SQLSet.GetDataIDs.
+func (s *Snapshot) GetDataIDs(setID string) ([]string, error) {
+	return s.set.GetDataIDs(setID)
+}
+
+// GetExpectation returns the expected result of a query, see SQLSet.GetExpectation.
+func (s *Snapshot) GetExpectation(setID, queryID string) (Expectation, error) {
+	return s.set.GetExpectation(setID, queryID)
+}
+
+// Expectations returns the expected results of the queries, see SQLSet.Expectations.
+func (s *Snapshot) Expectations() []Expectation {
+	return s.set.Expectations()
+}
+
+// Verify checks the queries against a lock file, see SQLSet.Verify.
+func (s *Snapshot) Verify(lockfile []byte) error {
+	return s.set.Verify(lockfile)
+}
+
+// Stats returns the size of the snapshot, see SQLSet.Stats.
+func (s *Snapshot) Stats() Stats {
+	return s.set.Stats()
+}
+
+// clone returns a copy of the metadata not sharing the translation maps and tags.
+func (m QuerySetMeta) clone() QuerySetMeta {
+	m.NameI18n = maps.Clone(m.NameI18n)
+	m.DescriptionI18n = maps.Clone(m.DescriptionI18n)
+	m.Tags = slices.Clone(m.Tags)
+
+	return m
+}
+
+// SwappableProvider serves the queries of the current snapshot, replaced atomically
+// with Swap, e.g. by a reloader watching the files. Every call reads a single snapshot,
+// callers needing several consistent reads should use Load.
+// Every swap increments the generation of the provider, starting at 0, so consumers
+// can tell a reload from the generation they saw last, see Generation.
+// It is safe for concurrent use.
+type SwappableProvider struct {
+	current atomic.Pointer[generation]
+}
+
+// generation is a snapshot with its generation number.
+type generation struct {
+	snapshot *Snapshot
+	n        uint64
+}
+
+// NewSwappableProvider returns a provider serving initial.
+func NewSwappableProvider(initial *Snapshot) *SwappableProvider {
+	p := &SwappableProvider{}
+	p.current.Store(&generation{snapshot: initial})
+
+	return p
+}
+
+// Load returns the current snapshot.
+func (p *SwappableProvider) Load() *Snapshot {
+	return p.current.Load().snapshot
+}
+
+// Generation returns the number of swaps of the provider. Consumers caching data derived
+// from the queries can invalidate it once per reload by comparing it with the last one seen.
+func (p *SwappableProvider) Generation() uint64 {
+	return p.current.Load().n
+}
+
+// LoadGeneration returns the current snapshot with its generation.
+func (p *SwappableProvider) LoadGeneration() (*Snapshot, uint64) {
+	g := p.current.Load()
+
+	return g.snapshot, g.n
+}
+
+// Swap replaces the current snapshot with next and returns the previous one.
+func (p *SwappableProvider) Swap(next *Snapshot) *Snapshot {
+	prev, _ := p.swap(next)
+
+	return prev
+}
+
+// swap replaces the current snapshot with next, returning the previous one
+// and the generation of next.
+func (p *SwappableProvider) swap(next *Snapshot) (*Snapshot, uint64) {
+	for {
+		prev := p.current.Load()
+		g := &generation{snapshot: next, n: prev.n + 1}
+
+		if p.current.CompareAndSwap(prev, g) {
+			return prev.snapshot, g.n
+		}
+	}
+}
+
+// Get returns a query of the current snapshot, see SQLSet.Get.
+func (p *SwappableProvider) Get(ids ...string) (string, error) {
+	return p.Load().Get(ids...)
+}
+
+// GetContext returns a query of the current snapshot, see SQLSet.GetContext.
+func (p *SwappableProvider) GetContext(ctx context.Context, ids ...string) (string, error) {
+	return p.Load().GetContext(ctx, ids...)
+}
+
+// MustGet is like Get but panics if the query is not found.
+func (p *SwappableProvider) MustGet(ids ...string) string {
+	return p.Load().MustGet(ids...)
+}
+
+// GetSetsMetas returns the metadata of all query sets of the current snapshot.
+func (p *SwappableProvider) GetSetsMetas() []QuerySetMeta {
+	return p.Load().GetSetsMetas()
+}
+
+// GetQueryIDs returns the sorted query IDs of a set of the current snapshot.
+func (p *SwappableProvider) GetQueryIDs(setID string) ([]string, error) {
+	return p.Load().GetQueryIDs(setID)
+}
+
+// Inventory returns the entries of all queries of the current snapshot.
+func (p *SwappableProvider) Inventory() []InventoryEntry {
+	return p.Load().Inventory()
+}
+
+// ModTime returns the modification time of the current snapshot.
+func (p *SwappableProvider) ModTime() time.Time {
+	return p.Load().ModTime()
+}
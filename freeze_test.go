@@ -0,0 +1,192 @@
+package sqlset_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/istovpets/sqlset"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFreeze(t *testing.T) {
+	t.Parallel()
+
+	sqlSet, err := sqlset.New(fstest.MapFS{
+		"users.sql": &fstest.MapFile{Data: []byte(`--META
+{"name": "Users", "name_i18n": {"de": "Benutzer"}}
+--end
+--SQL:GetUser
+--META: {"sortable": ["name"]}
+SELECT * FROM users WHERE id = $1;
+--end`)},
+	})
+	require.NoError(t, err)
+
+	snapshot := sqlSet.Freeze()
+
+	qs := sqlset.NewQuerySet(sqlset.QuerySetMeta{ID: "posts"})
+	require.NoError(t, qs.Register("ListPosts", "SELECT * FROM posts;"))
+	require.NoError(t, sqlSet.AddSet(qs))
+
+	// Sets added after Freeze are not visible in the snapshot.
+	_, ok := snapshot.TryGet("posts", "ListPosts")
+	assert.False(t, ok)
+	assert.Len(t, snapshot.GetSetsMetas(), 1)
+	assert.Equal(t, "SELECT * FROM users WHERE id = $1;", snapshot.MustGet("users.GetUser"))
+
+	// Returned values are copies.
+	snapshot.GetSetsMetas()[0].NameI18n["de"] = "changed"
+	assert.Equal(t, "Benutzer", snapshot.GetSetsMetas()[0].NameI18n["de"])
+
+	meta, err := snapshot.GetQueryMeta("users", "GetUser")
+	require.NoError(t, err)
+	meta.Sortable[0] = "changed"
+
+	meta, err = snapshot.GetQueryMeta("users", "GetUser")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"name"}, meta.Sortable)
+}
+
+func TestSwappableProvider(t *testing.T) {
+	t.Parallel()
+
+	newSnapshot := func(sql string) *sqlset.Snapshot {
+		qs := sqlset.NewQuerySet(sqlset.QuerySetMeta{ID: "users"})
+		require.NoError(t, qs.Register("GetUser", sql))
+
+		sqlSet := &sqlset.SQLSet{}
+		require.NoError(t, sqlSet.AddSet(qs))
+
+		return sqlSet.Freeze()
+	}
+
+	first := newSnapshot("SELECT 1;")
+	p := sqlset.NewSwappableProvider(first)
+	assert.Zero(t, p.Generation())
+
+	var wg sync.WaitGroup
+
+	for range 4 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for range 100 {
+				q, err := p.Get("users", "GetUser")
+				assert.NoError(t, err)
+				assert.Contains(t, []string{"SELECT 1;", "SELECT 2;"}, q)
+			}
+		}()
+	}
+
+	previous := p.Swap(newSnapshot("SELECT 2;"))
+	wg.Wait()
+
+	assert.Same(t, first, previous)
+
+	current, gen := p.LoadGeneration()
+	assert.Same(t, p.Load(), current)
+	assert.Equal(t, uint64(1), gen)
+	assert.Equal(t, "SELECT 2;", p.MustGet("users.GetUser"))
+
+	ids, err := p.GetQueryIDs("users")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"GetUser"}, ids)
+}
+
+func TestLoadWithSnapshot(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join(t.TempDir(), "cache")
+	errUnavailable := errors.New("registry unavailable")
+
+	remote := func(context.Context) (*sqlset.SQLSet, error) {
+		return sqlset.New(fstest.MapFS{
+			"users.sql": &fstest.MapFile{Data: []byte("--SQL:GetUser\nSELECT 1;\n--end\n")},
+		})
+	}
+
+	down := func(context.Context) (*sqlset.SQLSet, error) {
+		return nil, errUnavailable
+	}
+
+	_, _, err := sqlset.LoadWithSnapshot(context.Background(), dir, down)
+	require.ErrorIs(t, err, errUnavailable)
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	sqlSet, info, err := sqlset.LoadWithSnapshot(context.Background(), dir, remote)
+	require.NoError(t, err)
+	require.NoError(t, info.Err)
+	assert.False(t, info.Stale)
+	assert.False(t, info.SavedAt.IsZero())
+	assert.Equal(t, "SELECT 1;", sqlSet.MustGet("users.GetUser"))
+
+	stale, staleInfo, err := sqlset.LoadWithSnapshot(context.Background(), dir, down)
+	require.NoError(t, err)
+	assert.True(t, staleInfo.Stale)
+	require.ErrorIs(t, staleInfo.Err, errUnavailable)
+	assert.Equal(t, info.SavedAt, staleInfo.SavedAt)
+	assert.Equal(t, time.Hour, staleInfo.Age(staleInfo.SavedAt.Add(time.Hour)))
+	assert.Equal(t, "SELECT 1;", stale.MustGet("users.GetUser"))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestLoadWithSnapshot_Options(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	tenant := sqlset.WithRewriter(func(_ context.Context, _ sqlset.QueryKey, sql string) (string, error) {
+		return strings.ReplaceAll(sql, "{{schema}}", "acme"), nil
+	})
+
+	remote := func(context.Context) (*sqlset.SQLSet, error) {
+		return sqlset.New(fstest.MapFS{
+			"users.sql": &fstest.MapFile{Data: []byte("--SQL:GetUser\nSELECT * FROM {{schema}}.users;\n--end\n")},
+		}, tenant)
+	}
+
+	down := func(context.Context) (*sqlset.SQLSet, error) {
+		return nil, errors.New("registry unavailable")
+	}
+
+	_, _, err := sqlset.LoadWithSnapshot(context.Background(), dir, remote, tenant)
+	require.NoError(t, err)
+
+	stale, info, err := sqlset.LoadWithSnapshot(context.Background(), dir, down, tenant)
+	require.NoError(t, err)
+	assert.True(t, info.Stale)
+
+	sql, err := stale.GetContext(context.Background(), "users", "GetUser")
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM acme.users;", sql)
+}
+
+func TestLoadWithSnapshot_WhenSaveFails_ExpectLoaded(t *testing.T) {
+	t.Parallel()
+
+	file := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(file, nil, 0644))
+
+	load := func(context.Context) (*sqlset.SQLSet, error) {
+		return sqlset.New(fstest.MapFS{
+			"users.sql": &fstest.MapFile{Data: []byte("--SQL:GetUser\nSELECT 1;\n--end\n")},
+		})
+	}
+
+	sqlSet, info, err := sqlset.LoadWithSnapshot(context.Background(), file, load)
+	require.NoError(t, err)
+	require.Error(t, info.Err)
+	assert.NotNil(t, sqlSet)
+}
@@ -0,0 +1,311 @@
+// Package gateway provides an optional HTTP handler executing the queries marked
+// "exposed": true in their metadata, turning a query catalog into a minimal internal
+// data API without writing handlers. The arguments are validated against the
+// parameters declared with --PARAMS.
+//
+// Example:
+//
+//	exec := sqlsetdb.NewExecutor(db, sqlSet)
+//	http.Handle("/data/", http.StripPrefix("/data", gateway.NewHandler(sqlSet, exec)))
+package gateway
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/istovpets/sqlset"
+)
+
+// maxBodySize is the size limit of the request bodies.
+const maxBodySize = 1 << 20
+
+// Catalog is the interface the handler uses to read queries and their parameters.
+// *sqlset.SQLSet and *sqlset.Snapshot implement it.
+type Catalog interface {
+	sqlset.SQLSetsProvider
+	GetQueryMeta(setID, queryID string) (sqlset.QueryMeta, error)
+	Params(setID, queryID string) ([]sqlset.QueryParam, error)
+	BindArgs(setID, queryID string, args map[string]any) ([]any, error)
+}
+
+// Executor runs the queries. *sqlsetdb.Executor implements it.
+type Executor interface {
+	QueryContext(ctx context.Context, key sqlset.QueryKey, args ...any) (*sql.Rows, error)
+}
+
+// Endpoint is the JSON representation of an exposed query.
+type Endpoint struct {
+	Key    sqlset.QueryKey     `json:"key"`
+	Path   string              `json:"path"`
+	Params []sqlset.QueryParam `json:"params,omitempty"`
+}
+
+type handler struct {
+	catalog Catalog
+	exec    Executor
+}
+
+// NewHandler returns an http.Handler serving the exposed queries of catalog, run with exec:
+//
+//   - GET /queries - the exposed queries with their parameters,
+//   - POST /queries/{setID}/{queryID} - runs a query with the arguments of a JSON object
+//     in the body, e.g. {"id": 42}, and returns its rows as an array of JSON objects.
+//
+// Omitted arguments take the declared default values. Unknown, missing or mistyped arguments
+// are rejected with 400 Bad Request, and the queries not exposed are not found.
+func NewHandler(catalog Catalog, exec Executor) http.Handler {
+	h := &handler{catalog: catalog, exec: exec}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /queries", h.list)
+	mux.HandleFunc("POST /queries/{setID}/{queryID}", h.run)
+
+	return mux
+}
+
+func (h *handler) list(w http.ResponseWriter, _ *http.Request) {
+	endpoints := []Endpoint{}
+
+	metas := h.catalog.GetSetsMetas()
+	sort.Slice(metas, func(i, j int) bool {
+		return metas[i].ID < metas[j].ID
+	})
+
+	for _, set := range metas {
+		ids, err := h.catalog.GetQueryIDs(set.ID)
+		if err != nil {
+			writeError(w, err)
+
+			return
+		}
+
+		for _, id := range ids {
+			if !h.exposed(set.ID, id) {
+				continue
+			}
+
+			params, err := h.catalog.Params(set.ID, id)
+			if err != nil {
+				writeError(w, err)
+
+				return
+			}
+
+			endpoints = append(endpoints, Endpoint{
+				Key:    sqlset.QueryKey{SetID: set.ID, QueryID: id},
+				Path:   "/queries/" + set.ID + "/" + id,
+				Params: params,
+			})
+		}
+	}
+
+	writeJSON(w, http.StatusOK, endpoints)
+}
+
+func (h *handler) run(w http.ResponseWriter, r *http.Request) {
+	key := sqlset.QueryKey{SetID: r.PathValue("setID"), QueryID: r.PathValue("queryID")}
+
+	if !h.exposed(key.SetID, key.QueryID) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": key.String() + ": " + sqlset.ErrQueryNotFound.Error()})
+
+		return
+	}
+
+	args, err := h.bind(w, r, key)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+
+		return
+	}
+
+	rows, err := h.exec.QueryContext(r.Context(), key, args...)
+	if err != nil {
+		writeError(w, err)
+
+		return
+	}
+
+	result, err := readRows(rows)
+	if err != nil {
+		writeError(w, err)
+
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// exposed reports whether the query exists and is marked "exposed" in its metadata.
+func (h *handler) exposed(setID, queryID string) bool {
+	meta, err := h.catalog.GetQueryMeta(setID, queryID)
+
+	return err == nil && meta.Exposed
+}
+
+// bind decodes the arguments of the request body and returns them in declaration order.
+func (h *handler) bind(w http.ResponseWriter, r *http.Request, key sqlset.QueryKey) ([]any, error) {
+	params, err := h.catalog.Params(key.SetID, key.QueryID)
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]any{}
+
+	if r.ContentLength != 0 {
+		dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+		dec.UseNumber()
+
+		if err := dec.Decode(&values); err != nil {
+			return nil, fmt.Errorf("body: %w", err)
+		}
+	}
+
+	for _, param := range params {
+		v, ok := values[param.Name]
+		if !ok {
+			continue
+		}
+
+		if values[param.Name], err = convert(param, v); err != nil {
+			return nil, err
+		}
+	}
+
+	return h.catalog.BindArgs(key.SetID, key.QueryID, values)
+}
+
+// readRows reads the rows as JSON objects by column name.
+func readRows(rows *sql.Rows) ([]map[string]any, error) {
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	result := []map[string]any{}
+
+	for rows.Next() {
+		values := make([]any, len(columns))
+		dest := make([]any, len(columns))
+
+		for i := range values {
+			dest[i] = &values[i]
+		}
+
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		row := make(map[string]any, len(columns))
+
+		for i, column := range columns {
+			if b, ok := values[i].([]byte); ok {
+				values[i] = string(b)
+			}
+
+			row[column] = values[i]
+		}
+
+		result = append(result, row)
+	}
+
+	return result, rows.Err()
+}
+
+// writeError writes err as 404 Not Found for the missing queries and as 500 Internal Server Error otherwise,
+// without the details of the database errors.
+func writeError(w http.ResponseWriter, err error) {
+	if errors.Is(err, sqlset.ErrNotFound) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+
+		return
+	}
+
+	status := http.StatusInternalServerError
+	writeJSON(w, status, map[string]string{"error": http.StatusText(status)})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// typeKinds maps the declared SQL types, without modifiers, to the JSON kinds of their arguments.
+var typeKinds = map[string]string{
+	"smallint": "integer", "integer": "integer", "int": "integer", "int2": "integer", "int4": "integer",
+	"int8": "integer", "bigint": "integer", "serial": "integer", "bigserial": "integer",
+	"real": "number", "float4": "number", "float8": "number", "double precision": "number",
+	"numeric": "number", "decimal": "number",
+	"boolean": "boolean", "bool": "boolean",
+	"text": "string", "varchar": "string", "character varying": "string", "char": "string",
+	"uuid": "string", "date": "string", "time": "string", "timestamp": "string", "timestamptz": "string",
+	"timestamp with time zone": "string", "timestamp without time zone": "string", "interval": "string",
+}
+
+// convert checks a JSON argument against the declared type of its parameter
+// and converts the numbers to int64 or float64. Arguments of unknown types and nulls are passed as is.
+func convert(param sqlset.QueryParam, v any) (any, error) {
+	sqlType := strings.ToLower(strings.Join(strings.Fields(param.Type), " "))
+	if i := strings.IndexByte(sqlType, '('); i >= 0 {
+		sqlType = strings.TrimSpace(sqlType[:i])
+	}
+
+	kind, ok := typeKinds[sqlType]
+	if !ok || v == nil {
+		return v, nil
+	}
+
+	mismatch := fmt.Errorf("%s: %s expected", param.Name, kind)
+
+	switch kind {
+	case "integer":
+		n, ok := v.(json.Number)
+		if !ok {
+			return nil, mismatch
+		}
+
+		i, err := n.Int64()
+		if err != nil {
+			return nil, fmt.Errorf("%s: integer expected, got %s", param.Name, n)
+		}
+
+		return i, nil
+	case "number":
+		n, ok := v.(json.Number)
+		if !ok {
+			return nil, mismatch
+		}
+
+		if _, err := n.Float64(); err != nil {
+			return nil, fmt.Errorf("%s: number expected, got %s", param.Name, n)
+		}
+
+		// Numeric values are passed as text to keep their precision.
+		if sqlType == "numeric" || sqlType == "decimal" {
+			return n.String(), nil
+		}
+
+		return n.Float64()
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return nil, mismatch
+		}
+	case "string":
+		if _, ok := v.(string); !ok {
+			return nil, mismatch
+		}
+	}
+
+	return v, nil
+}
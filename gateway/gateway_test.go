@@ -0,0 +1,222 @@
+package gateway_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/istovpets/sqlset"
+	"github.com/istovpets/sqlset/gateway"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeExecutor runs the queries on a driver returning one row with the query and the arguments.
+type fakeExecutor struct {
+	db  *sql.DB
+	err error
+}
+
+func (e *fakeExecutor) QueryContext(ctx context.Context, key sqlset.QueryKey, args ...any) (*sql.Rows, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+
+	return e.db.QueryContext(ctx, key.String(), args...)
+}
+
+type echoConnector struct{}
+
+func (echoConnector) Connect(context.Context) (driver.Conn, error) { return echoConn{}, nil }
+func (echoConnector) Driver() driver.Driver                        { return nil }
+
+type echoConn struct{}
+
+func (echoConn) Prepare(query string) (driver.Stmt, error) { return echoStmt{query: query}, nil }
+func (echoConn) Close() error                              { return nil }
+func (echoConn) Begin() (driver.Tx, error)                 { return nil, errors.ErrUnsupported }
+
+type echoStmt struct {
+	query string
+}
+
+func (echoStmt) Close() error  { return nil }
+func (echoStmt) NumInput() int { return -1 }
+
+func (echoStmt) Exec([]driver.Value) (driver.Result, error) { return nil, errors.ErrUnsupported }
+
+func (s echoStmt) Query(args []driver.Value) (driver.Rows, error) {
+	encoded, err := json.Marshal(args)
+	if err != nil {
+		return nil, err
+	}
+
+	return &echoRows{values: []driver.Value{s.query, encoded}}, nil
+}
+
+type echoRows struct {
+	values []driver.Value
+}
+
+func (r *echoRows) Columns() []string { return []string{"query", "args"} }
+func (r *echoRows) Close() error      { return nil }
+
+func (r *echoRows) Next(dest []driver.Value) error {
+	if r.values == nil {
+		return io.EOF
+	}
+
+	copy(dest, r.values)
+	r.values = nil
+
+	return nil
+}
+
+func newTestHandler(t *testing.T, execErr error) http.Handler {
+	t.Helper()
+
+	sqlSet, err := sqlset.New(fstest.MapFS{
+		"orders.sql": &fstest.MapFile{Data: []byte(`--SQL:List
+--META: {"exposed": true}
+--PARAMS: status text, min numeric(10, 2), paid bool, limit int = 50
+SELECT id FROM orders WHERE status = $1 AND total > $2 AND paid = $3 LIMIT $4;
+--end
+
+--SQL:Delete
+DELETE FROM orders;
+--end`)},
+	})
+	require.NoError(t, err)
+
+	db := sql.OpenDB(echoConnector{})
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	return gateway.NewHandler(sqlSet, &fakeExecutor{db: db, err: execErr})
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	h := newTestHandler(t, nil)
+
+	t.Run("list", func(t *testing.T) {
+		t.Parallel()
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/queries", nil))
+
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var endpoints []gateway.Endpoint
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &endpoints))
+		require.Len(t, endpoints, 1)
+		assert.Equal(t, "/queries/orders/List", endpoints[0].Path)
+		assert.Len(t, endpoints[0].Params, 4)
+	})
+
+	tests := []struct {
+		name   string
+		path   string
+		body   string
+		status int
+		want   string
+	}{
+		{
+			name:   "run",
+			path:   "/queries/orders/List",
+			body:   `{"status": "open", "min": 10.5, "paid": true}`,
+			status: http.StatusOK,
+			want:   `[{"query": "orders.List", "args": "[\"open\",\"10.5\",true,50]"}]`,
+		},
+		{
+			name:   "explicit default",
+			path:   "/queries/orders/List",
+			body:   `{"status": "open", "min": 1, "paid": false, "limit": 5}`,
+			status: http.StatusOK,
+			want:   `[{"query": "orders.List", "args": "[\"open\",\"1\",false,5]"}]`,
+		},
+		{
+			name:   "missing argument",
+			path:   "/queries/orders/List",
+			body:   `{"status": "open", "min": 1}`,
+			status: http.StatusBadRequest,
+			want:   `{"error": "paid: required argument not specified"}`,
+		},
+		{
+			name:   "unknown argument",
+			path:   "/queries/orders/List",
+			body:   `{"status": "open", "min": 1, "paid": true, "offset": 5}`,
+			status: http.StatusBadRequest,
+			want:   `{"error": "invalid number of arguments: parameter \"offset\" is not declared"}`,
+		},
+		{
+			name:   "mistyped argument",
+			path:   "/queries/orders/List",
+			body:   `{"status": "open", "min": 1, "paid": true, "limit": 1.5}`,
+			status: http.StatusBadRequest,
+			want:   `{"error": "limit: integer expected, got 1.5"}`,
+		},
+		{
+			name:   "string expected",
+			path:   "/queries/orders/List",
+			body:   `{"status": 1, "min": 1, "paid": true}`,
+			status: http.StatusBadRequest,
+			want:   `{"error": "status: string expected"}`,
+		},
+		{
+			name:   "invalid body",
+			path:   "/queries/orders/List",
+			body:   `[1]`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "not exposed",
+			path:   "/queries/orders/Delete",
+			status: http.StatusNotFound,
+			want:   `{"error": "orders.Delete: query not found"}`,
+		},
+		{
+			name:   "missing",
+			path:   "/queries/orders/Missing",
+			status: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body)))
+
+			require.Equal(t, tt.status, rec.Code, rec.Body.String())
+
+			if tt.want != "" {
+				assert.JSONEq(t, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_WhenExecutionFails_ExpectInternalError(t *testing.T) {
+	t.Parallel()
+
+	h := newTestHandler(t, errors.New(`password authentication failed for user "app"`))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/queries/orders/List",
+		strings.NewReader(`{"status": "open", "min": 1, "paid": true}`)))
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.JSONEq(t, `{"error": "Internal Server Error"}`, rec.Body.String())
+}
@@ -0,0 +1,160 @@
+package sqlset
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// ignored reports whether the path matches any of the WithIgnore patterns.
+func (cfg *config) ignored(name string) (bool, error) {
+	if name == "." {
+		return false, nil
+	}
+
+	for _, pattern := range cfg.ignore {
+		ok, err := matchPattern(pattern, name)
+		if err != nil {
+			return false, fmt.Errorf("ignore pattern %q: %w", pattern, err)
+		}
+
+		if ok {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// matchGlob matches the path segments against the pattern segments,
+// where a "**" segment matches any number of path segments.
+func matchGlob(pattern, segments []string) (bool, error) {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(segments); i++ {
+				ok, err := matchGlob(pattern[1:], segments[i:])
+				if ok || err != nil {
+					return ok, err
+				}
+			}
+
+			return false, nil
+		}
+
+		if len(segments) == 0 {
+			return false, nil
+		}
+
+		ok, err := path.Match(pattern[0], segments[0])
+		if !ok || err != nil {
+			return false, err
+		}
+
+		pattern, segments = pattern[1:], segments[1:]
+	}
+
+	return len(segments) == 0, nil
+}
+
+// selected reports whether the file is selected by the WithOnly patterns: a pattern matches
+// the file or one of its directories. Without patterns all files are selected.
+func (cfg *config) selected(name string) (bool, error) {
+	if cfg.only == nil {
+		return true, nil
+	}
+
+	for dir := name; dir != "."; dir = path.Dir(dir) {
+		for _, pattern := range cfg.only {
+			ok, err := matchPattern(pattern, dir)
+			if err != nil {
+				return false, fmt.Errorf("only pattern %q: %w", pattern, err)
+			}
+
+			if ok {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// pruned reports whether no file in the directory can be selected by the WithOnly patterns,
+// so the walk skips it.
+func (cfg *config) pruned(dir string) (bool, error) {
+	if cfg.only == nil || dir == "." {
+		return false, nil
+	}
+
+	if ok, err := cfg.selected(dir); ok || err != nil {
+		return false, err
+	}
+
+	for _, pattern := range cfg.only {
+		// Base name patterns match at any depth.
+		if !strings.Contains(pattern, "/") {
+			return false, nil
+		}
+
+		ok, err := matchGlobPrefix(strings.Split(pattern, "/"), strings.Split(dir, "/"))
+		if err != nil {
+			return false, fmt.Errorf("only pattern %q: %w", pattern, err)
+		}
+
+		if ok {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// matchPattern matches the path against a WithIgnore or WithOnly pattern.
+func matchPattern(pattern, name string) (bool, error) {
+	target := name
+	if !strings.Contains(pattern, "/") {
+		target = path.Base(name)
+	}
+
+	return matchGlob(strings.Split(pattern, "/"), strings.Split(target, "/"))
+}
+
+// matchGlobPrefix reports whether the directory segments can start a path matching the pattern segments.
+func matchGlobPrefix(pattern, segments []string) (bool, error) {
+	for len(segments) > 0 {
+		if len(pattern) == 0 {
+			return false, nil
+		}
+
+		if pattern[0] == "**" {
+			return true, nil
+		}
+
+		ok, err := path.Match(pattern[0], segments[0])
+		if !ok || err != nil {
+			return false, err
+		}
+
+		pattern, segments = pattern[1:], segments[1:]
+	}
+
+	return true, nil
+}
+
+// unselected reports whether the walk skips the entry as not selected by the WithOnly patterns,
+// returning fs.SkipDir as the error for pruned directories.
+func (cfg *config) unselected(name string, entry fs.DirEntry) (bool, error) {
+	if entry.IsDir() {
+		pruned, err := cfg.pruned(name)
+		if pruned {
+			return true, fs.SkipDir
+		}
+
+		return false, err
+	}
+
+	ok, err := cfg.selected(name)
+
+	return !ok, err
+}
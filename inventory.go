@@ -0,0 +1,43 @@
+package sqlset
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+)
+
+// InventoryEntry describes a query for the inventories of operations tooling, e.g. a CMDB.
+type InventoryEntry struct {
+	Key QueryKey `json:"key"`
+	// Hash is the hex-encoded SHA-256 of the query body, changing with every edit.
+	Hash string `json:"hash"`
+	// Size is the length of the query body in bytes.
+	Size       int      `json:"size"`
+	Tags       []string `json:"tags,omitempty"`
+	Deprecated bool     `json:"deprecated,omitempty"`
+}
+
+// Inventory returns the entries of all queries, sorted by key.
+func (s *SQLSet) Inventory() []InventoryEntry {
+	var entries []InventoryEntry
+
+	for setID, qs := range s.sets {
+		for id, q := range qs.queries {
+			sum := sha256.Sum256([]byte(q.sql))
+
+			entries = append(entries, InventoryEntry{
+				Key:        QueryKey{SetID: setID, QueryID: id},
+				Hash:       hex.EncodeToString(sum[:]),
+				Size:       len(q.sql),
+				Tags:       append([]string(nil), q.meta.Tags...),
+				Deprecated: q.meta.Deprecated,
+			})
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Key.String() < entries[j].Key.String()
+	})
+
+	return entries
+}
@@ -0,0 +1,106 @@
+package sqlset
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// LockFileName is the conventional name of the lock file, see SQLSet.Lock.
+const LockFileName = "sqlset.lock"
+
+// lockVersion is the version of the lock file format.
+const lockVersion = 1
+
+// LockFile pins the hashes of the queries, so that unreviewed changes are detected, see SQLSet.Verify.
+type LockFile struct {
+	Version int `json:"version"`
+	// Queries maps the query keys, "setID.queryID", to their hashes, see InventoryEntry.Hash.
+	Queries map[string]string `json:"queries"`
+}
+
+// Lock returns the lock file of the queries, marshaled as indented JSON with sorted keys
+// so that it can be committed and reviewed along with them:
+//
+//	data, err := sqlSet.Lock()
+//	if err != nil {
+//		return err
+//	}
+//
+//	err = os.WriteFile(sqlset.LockFileName, data, 0644)
+func (s *SQLSet) Lock() ([]byte, error) {
+	lock := LockFile{Version: lockVersion, Queries: make(map[string]string)}
+
+	for _, entry := range s.Inventory() {
+		lock.Queries[entry.Key.String()] = entry.Hash
+	}
+
+	data, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshal lock file: %w", err)
+	}
+
+	return append(data, '\n'), nil
+}
+
+// Verify checks the queries against a lock file generated by Lock, e.g. at startup or in CI.
+// It returns ErrLockMismatch listing the changed, added and removed queries if any query
+// changed without the lock file being regenerated.
+//
+//	if err := sqlSet.Verify(lockfile); err != nil {
+//		log.Fatal(err)
+//	}
+func (s *SQLSet) Verify(lockfile []byte) error {
+	var lock LockFile
+
+	if err := json.Unmarshal(lockfile, &lock); err != nil {
+		return fmt.Errorf("unmarshal lock file: %w", err)
+	}
+
+	if lock.Version != lockVersion {
+		return fmt.Errorf("unsupported lock file version %d", lock.Version)
+	}
+
+	var changed, added, removed []string
+
+	current := make(map[string]bool)
+
+	for _, entry := range s.Inventory() {
+		key := entry.Key.String()
+		current[key] = true
+
+		hash, ok := lock.Queries[key]
+
+		switch {
+		case !ok:
+			added = append(added, key)
+		case hash != entry.Hash:
+			changed = append(changed, key)
+		}
+	}
+
+	for key := range lock.Queries {
+		if !current[key] {
+			removed = append(removed, key)
+		}
+	}
+
+	if len(changed)+len(added)+len(removed) == 0 {
+		return nil
+	}
+
+	var details []string
+
+	for _, group := range []struct {
+		name string
+		keys []string
+	}{{"changed", changed}, {"added", added}, {"removed", removed}} {
+		if len(group.keys) > 0 {
+			slices.Sort(group.keys)
+			details = append(details, group.name+": "+strings.Join(group.keys, ", "))
+		}
+	}
+
+	return fmt.Errorf("%w: %s", ErrLockMismatch, strings.Join(details, "; "))
+}
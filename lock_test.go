@@ -0,0 +1,58 @@
+package sqlset_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/istovpets/sqlset"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLSet_Verify(t *testing.T) {
+	t.Parallel()
+
+	locked, err := sqlset.New(fstest.MapFS{
+		"users.sql": &fstest.MapFile{Data: []byte("--SQL:GetUser\nSELECT 1;\n--end\n--SQL:ListUsers\nSELECT 2;\n--end\n")},
+	})
+	require.NoError(t, err)
+
+	lockfile, err := locked.Lock()
+	require.NoError(t, err)
+	assert.Contains(t, string(lockfile), `"users.GetUser": "`)
+	require.NoError(t, locked.Verify(lockfile))
+	require.NoError(t, locked.Freeze().Verify(lockfile))
+
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{
+			name:    "changed",
+			data:    "--SQL:GetUser\nSELECT 3;\n--end\n--SQL:ListUsers\nSELECT 2;\n--end\n",
+			wantErr: "changed: users.GetUser",
+		},
+		{
+			name:    "added and removed",
+			data:    "--SQL:GetUser\nSELECT 1;\n--end\n--SQL:CountUsers\nSELECT 4;\n--end\n",
+			wantErr: "added: users.CountUsers; removed: users.ListUsers",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sqlSet, err := sqlset.New(fstest.MapFS{"users.sql": &fstest.MapFile{Data: []byte(tt.data)}})
+			require.NoError(t, err)
+
+			err = sqlSet.Verify(lockfile)
+			require.ErrorIs(t, err, sqlset.ErrLockMismatch)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+
+	require.Error(t, locked.Verify([]byte("{")))
+	require.Error(t, locked.Verify([]byte(`{"version": 9}`)))
+}
@@ -0,0 +1,32 @@
+package sqlset
+
+import (
+	"fmt"
+	"time"
+)
+
+// LastModified returns the modification time of the file of a set, the latest one
+// if the set was merged from several files. It is zero if the file system does not
+// record modification times, as embed.FS, and for sets built in code.
+// HTTP handlers and caches can use it for Last-Modified headers.
+func (s *SQLSet) LastModified(setID string) (time.Time, error) {
+	qs, ok := s.sets[setID]
+	if !ok {
+		return time.Time{}, fmt.Errorf("%s: %w", setID, ErrQuerySetNotFound)
+	}
+
+	return qs.modTime, nil
+}
+
+// ModTime returns the latest modification time of all sets, see LastModified.
+func (s *SQLSet) ModTime() time.Time {
+	var latest time.Time
+
+	for _, qs := range s.sets {
+		if qs.modTime.After(latest) {
+			latest = qs.modTime
+		}
+	}
+
+	return latest
+}
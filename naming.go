@@ -0,0 +1,121 @@
+package sqlset
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// NamingStyle is a case style of query IDs.
+type NamingStyle string
+
+const (
+	// PascalCase IDs start with an upper case letter and contain letters and digits only, e.g. GetUserByID.
+	PascalCase NamingStyle = "PascalCase"
+	// CamelCase IDs start with a lower case letter and contain letters and digits only, e.g. getUserByID.
+	CamelCase NamingStyle = "camelCase"
+	// SnakeCase IDs contain lower case letters, digits and single underscores, e.g. get_user_by_id.
+	SnakeCase NamingStyle = "snake_case"
+)
+
+// NamingPolicy is the convention of query IDs enforced by WithNamingPolicy.
+type NamingPolicy struct {
+	// Style is the case style of the IDs, not checked if empty.
+	Style NamingStyle
+	// Prefixes lists the allowed first words of the IDs, e.g. Get, List and Create,
+	// compared case-insensitively. Not checked if empty.
+	Prefixes []string
+	// Strict makes New fail on the first violation, otherwise violations are reported
+	// as warnings, see WithWarningHandler.
+	Strict bool
+}
+
+// WithNamingPolicy makes New check the query IDs against policy:
+//
+//	sqlSet, err := sqlset.New(fsys, sqlset.WithNamingPolicy(sqlset.NamingPolicy{
+//		Style:    sqlset.PascalCase,
+//		Prefixes: []string{"Get", "List", "Create", "Update", "Delete"},
+//		Strict:   true,
+//	}))
+func WithNamingPolicy(policy NamingPolicy) Option {
+	return func(cfg *config) {
+		cfg.naming = &policy
+	}
+}
+
+// Check returns an error wrapping ErrNamingPolicy if the query ID violates the policy.
+func (p NamingPolicy) Check(queryID string) error {
+	if p.Style != "" && !p.Style.matches(queryID) {
+		return fmt.Errorf("%w: %s is not %s", ErrNamingPolicy, queryID, p.Style)
+	}
+
+	if len(p.Prefixes) == 0 {
+		return nil
+	}
+
+	for _, prefix := range p.Prefixes {
+		if hasWordPrefix(queryID, prefix) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %s does not start with %s", ErrNamingPolicy, queryID, strings.Join(p.Prefixes, ", "))
+}
+
+func (s NamingStyle) matches(id string) bool {
+	first, _ := utf8.DecodeRuneInString(id)
+
+	switch s {
+	case PascalCase, CamelCase:
+		if s == PascalCase && !unicode.IsUpper(first) || s == CamelCase && !unicode.IsLower(first) {
+			return false
+		}
+
+		return strings.IndexFunc(id, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) < 0
+	case SnakeCase:
+		if !unicode.IsLower(first) || strings.HasSuffix(id, "_") || strings.Contains(id, "__") {
+			return false
+		}
+
+		return strings.IndexFunc(id, func(r rune) bool {
+			return r != '_' && !unicode.IsLower(r) && !unicode.IsDigit(r)
+		}) < 0
+	default:
+		return true
+	}
+}
+
+// hasWordPrefix reports whether prefix is the first word of id: it is followed
+// by the end of id, an underscore, a digit or an upper case letter.
+func hasWordPrefix(id, prefix string) bool {
+	if len(id) < len(prefix) || !strings.EqualFold(id[:len(prefix)], prefix) {
+		return false
+	}
+
+	if len(id) == len(prefix) {
+		return true
+	}
+
+	next, _ := utf8.DecodeRuneInString(id[len(prefix):])
+
+	return next == '_' || unicode.IsDigit(next) || unicode.IsUpper(next)
+}
+
+// checkName reports the violations of the naming policy, as an error in strict mode.
+func (cfg *config) checkName(src Source, queryID string) error {
+	if cfg.naming == nil {
+		return nil
+	}
+
+	err := cfg.naming.Check(queryID)
+	if err == nil || cfg.naming.Strict {
+		return err
+	}
+
+	cfg.warn(src.File, src.Line, "%s", err.Error())
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package sqlset
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// normalizedKeywords are the words upper-cased by Normalize.
+var normalizedKeywords = func() map[string]bool {
+	set := make(map[string]bool)
+
+	for _, w := range strings.Fields("select from where and or not in is null insert into values update set delete " +
+		"join left right inner outer full cross natural on using as group by order having limit offset fetch " +
+		"returning with recursive union intersect except all distinct case when then else end between like " +
+		"ilike exists asc desc nulls first last conflict do nothing over partition lateral true false " +
+		"create alter drop table index view if primary key references default constraint unique") {
+		set[strings.ToUpper(w)] = true
+	}
+
+	return set
+}()
+
+// Normalize returns sql with its comments stripped, its whitespace collapsed to single spaces
+// and its keywords upper-cased, so that queries differing only by formatting compare equal.
+// Literals, quoted identifiers and other words are kept as written.
+//
+//nolint:gocognit,gocyclo
+func Normalize(sql string) string {
+	var (
+		sb    strings.Builder
+		space bool // whitespace or a comment pending before the next token
+	)
+
+	write := func(token string) {
+		if space && sb.Len() > 0 {
+			sb.WriteByte(' ')
+		}
+
+		space = false
+
+		sb.WriteString(token)
+	}
+
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f':
+			space = true
+		case c == '-' && strings.HasPrefix(sql[i:], "--"):
+			j := strings.IndexByte(sql[i:], '\n')
+			if j < 0 {
+				j = len(sql) - i
+			}
+
+			i += j - 1
+			space = true
+		case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			j := strings.Index(sql[i+2:], "*/")
+			if j < 0 {
+				j = len(sql) - i - 4
+			}
+
+			i += j + 3
+			space = true
+		case c == '\'' || c == '"':
+			j := i + 1
+
+			for j < len(sql) {
+				if sql[j] == c {
+					if j+1 < len(sql) && sql[j+1] == c {
+						j += 2
+
+						continue
+					}
+
+					break
+				}
+
+				j++
+			}
+
+			j = min(j+1, len(sql))
+
+			write(sql[i:j])
+			i = j - 1
+		case c == '$' && isDollarQuote(sql[i:]):
+			tag, _ := dollarTag(sql[i:])
+
+			j := strings.Index(sql[i+len(tag):], tag)
+			if j < 0 {
+				write(sql[i:])
+
+				return sb.String()
+			}
+
+			end := i + 2*len(tag) + j
+			write(sql[i:end])
+			i = end - 1
+		case isIdentStart(c) || c >= utf8.RuneSelf:
+			j := i + 1
+			for j < len(sql) && (isIdentByte(sql[j]) || sql[j] >= utf8.RuneSelf) {
+				j++
+			}
+
+			word := sql[i:j]
+			if upper := strings.ToUpper(word); normalizedKeywords[upper] {
+				word = upper
+			}
+
+			write(word)
+			i = j - 1
+		default:
+			write(sql[i : i+1])
+		}
+	}
+
+	return sb.String()
+}
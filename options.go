@@ -0,0 +1,242 @@
+package sqlset
+
+import (
+	"context"
+	"text/template"
+)
+
+// Option configures New.
+type Option func(*config)
+
+type config struct {
+	syntax Syntax
+	// implicitEnd makes blocks end at the next block or the end of file.
+	implicitEnd bool
+	softDelete  SoftDeleteRewriter
+	rewriters   []Rewriter
+	variables   map[string]string
+	// preserveBlankLines keeps the blank lines inside query bodies.
+	preserveBlankLines bool
+	// preserveFilenameCase keeps the case of the file names in the set IDs.
+	preserveFilenameCase bool
+	ignore               []string
+	only                 []string
+	skipUnreadable       bool
+	duplicates           DuplicateHandler
+	warnings             func(Warning)
+	keySep               string
+	features             map[string]bool
+	redaction            *RedactionProfile
+	naming               *NamingPolicy
+	formatter            func(sql string) string
+	// diagnostics is the number of context lines of the parse errors, see WithDiagnostics.
+	diagnostics int
+	// directives handles the unknown directives, see WithDirectiveHandler.
+	directives func(Directive) error
+	// templateFuncs extends the standard template functions, see WithTemplateFuncs.
+	templateFuncs template.FuncMap
+	// contextKeys maps the names of the context references to the context keys, see WithContextKeys.
+	contextKeys map[string]any
+}
+
+func newConfig(opts []Option) *config {
+	cfg := &config{
+		syntax: DefaultSyntax,
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// Syntax defines the directive lines of the .sql files.
+// Empty fields keep their DefaultSyntax values.
+type Syntax struct {
+	// Prefix starts every directive line, lines starting with Prefix
+	// that are not directives are comments.
+	Prefix string
+	// SQLKeyword opens a query block, it is followed by ":" and the query ID.
+	SQLKeyword string
+	// RawKeyword opens a raw query block, kept verbatim up to the line
+	// consisting of Prefix and EndKeyword only. It is followed by ":" and the query ID.
+	RawKeyword string
+	// MetaKeyword opens the metadata block.
+	MetaKeyword string
+	// EndKeyword closes a block.
+	EndKeyword string
+}
+
+// DefaultSyntax is the syntax used unless WithSyntax is given:
+//
+//	--SQL:QueryID
+//	--SQLRAW:QueryID
+//	--META
+//	--end
+var DefaultSyntax = Syntax{
+	Prefix:      tokenPrefix,
+	SQLKeyword:  tokenSQL,
+	RawKeyword:  tokenSQLRaw,
+	MetaKeyword: tokenMeta,
+	EndKeyword:  tokenEnd,
+}
+
+// WithSyntax overrides the directive syntax, e.g. for files also consumed by other tools:
+//
+//	sqlset.New(fsys, sqlset.WithSyntax(sqlset.Syntax{Prefix: "#", SQLKeyword: "sql", EndKeyword: "end"}))
+func WithSyntax(syntax Syntax) Option {
+	return func(cfg *config) {
+		if syntax.Prefix != "" {
+			cfg.syntax.Prefix = syntax.Prefix
+		}
+
+		if syntax.SQLKeyword != "" {
+			cfg.syntax.SQLKeyword = syntax.SQLKeyword
+		}
+
+		if syntax.RawKeyword != "" {
+			cfg.syntax.RawKeyword = syntax.RawKeyword
+		}
+
+		if syntax.MetaKeyword != "" {
+			cfg.syntax.MetaKeyword = syntax.MetaKeyword
+		}
+
+		if syntax.EndKeyword != "" {
+			cfg.syntax.EndKeyword = syntax.EndKeyword
+		}
+	}
+}
+
+// WithDotSQLCompat enables the single-marker format of dotsql and goyesql,
+// so their files can be loaded without rewriting:
+//
+//	-- name: GetUserByID
+//	SELECT id, name FROM users WHERE id = $1;
+//
+//	-- name: CreateUser
+//	INSERT INTO users (name) VALUES ($1);
+//
+// A block ends at the next "-- name:" or "-- META" marker or at the end of file,
+// "--end" lines are plain comments. As a consequence, only the inline --HINTS: form is supported.
+func WithDotSQLCompat() Option {
+	return func(cfg *config) {
+		cfg.syntax.SQLKeyword = "name"
+		cfg.implicitEnd = true
+	}
+}
+
+// WithSoftDelete enables the soft-delete policy: queries declaring
+// "soft_delete_table" in their metadata are rewritten by rewrite when retrieved,
+// e.g. with SoftDeleteCondition:
+//
+//	sqlset.New(fsys, sqlset.WithSoftDelete(sqlset.SoftDeleteCondition("deleted_at")))
+func WithSoftDelete(rewrite SoftDeleteRewriter) Option {
+	return func(cfg *config) {
+		cfg.softDelete = rewrite
+	}
+}
+
+// WithVariables sets the values of the ${name} variables in the query bodies.
+// They are substituted once when the files are loaded, so environment constants
+// such as a schema name cost nothing on Get:
+//
+//	sqlset.New(fsys, sqlset.WithVariables(map[string]string{"schema": "billing"}))
+//
+// Variables are substituted in string literals and comments too. Loading fails with
+// ErrVariableNotFound if a query references a variable not in vars, except in string
+// literals and comments, where unknown references such as JSON templates are kept.
+// Without this option the query bodies are kept as is.
+func WithVariables(vars map[string]string) Option {
+	return func(cfg *config) {
+		cfg.variables = vars
+	}
+}
+
+// WithPreserveBlankLines keeps the blank lines inside query bodies, which are dropped by default,
+// so long queries stay readable when logged or shown in the admin UI.
+// Leading and trailing blank lines of a body are still removed.
+func WithPreserveBlankLines() Option {
+	return func(cfg *config) {
+		cfg.preserveBlankLines = true
+	}
+}
+
+// WithPreserveFilenameCase keeps the case of the file names in the set IDs,
+// so UserAccounts.sql is the "UserAccounts" set instead of "useraccounts".
+// The .sql extension is matched case-insensitively either way.
+//
+// Set IDs are not Unicode-normalized: a name stored decomposed (NFD), as some
+// macOS tools do, differs from the same name typed in the composed form (NFC).
+func WithPreserveFilenameCase() Option {
+	return func(cfg *config) {
+		cfg.preserveFilenameCase = true
+	}
+}
+
+// WithIgnore skips the files and directories matching any of the glob patterns
+// when walking the file system, e.g. drafts or archived queries:
+//
+//	sqlset.New(fsys, sqlset.WithIgnore("*_draft.sql", "archive/**"))
+//
+// Patterns use the path.Match syntax, extended with "**" matching any number of directories.
+// A pattern without "/" is matched against the base name at any depth,
+// otherwise against the slash-separated path from the root of the file system.
+func WithIgnore(globs ...string) Option {
+	return func(cfg *config) {
+		cfg.ignore = append(cfg.ignore, globs...)
+	}
+}
+
+// WithOnly loads only the files matching any of the glob patterns, or inside a directory
+// matching one, e.g. for a service embedding the query tree shared by a monorepo:
+//
+//	sqlset.New(fsys, sqlset.WithOnly("users*.sql", "billing/**"))
+//
+// The patterns have the WithIgnore syntax, WithIgnore still skips the matching files.
+// Directories no pattern can match inside are not walked.
+func WithOnly(globs ...string) Option {
+	return func(cfg *config) {
+		cfg.only = append(cfg.only, globs...)
+	}
+}
+
+// WithSkipUnreadable makes New skip the files and directories that can not be opened
+// or listed, such as broken symlinks or permission-denied entries on shared mounts,
+// instead of failing. The skipped entries are reported by SQLSet.SkippedFiles.
+// Files that are read but fail to parse still fail New.
+func WithSkipUnreadable() Option {
+	return func(cfg *config) {
+		cfg.skipUnreadable = true
+	}
+}
+
+// Rewriter rewrites a query when it is retrieved with Get or GetContext.
+type Rewriter func(ctx context.Context, key QueryKey, sql string) (string, error)
+
+// WithRewriter adds a rewriter applied to every query on retrieval, after the soft-delete policy.
+// Rewriters run in the order they are given. It lets integrators apply query-wide policies
+// consistently, e.g. substituting the tenant schema taken from the context:
+//
+//	sqlset.New(fsys, sqlset.WithRewriter(func(ctx context.Context, _ sqlset.QueryKey, sql string) (string, error) {
+//		return strings.ReplaceAll(sql, "{{schema}}", tenantSchema(ctx)), nil
+//	}))
+func WithRewriter(rewrite Rewriter) Option {
+	return func(cfg *config) {
+		cfg.rewriters = append(cfg.rewriters, rewrite)
+	}
+}
+
+// WithKeySeparator sets the separator of the set and query IDs in the single-argument
+// form of Get, a dot by default, e.g. for the query IDs containing dots:
+//
+//	sqlSet, err := sqlset.New(fsys, sqlset.WithKeySeparator(":"))
+//	q, err := sqlSet.Get("users:legacy.get_user")
+//
+// The two-argument form of Get is not affected.
+func WithKeySeparator(sep string) Option {
+	return func(cfg *config) {
+		cfg.keySep = sep
+	}
+}
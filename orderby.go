@@ -0,0 +1,58 @@
+package sqlset
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// Direction is the sort direction of an ORDER BY clause.
+type Direction string
+
+const (
+	// Asc sorts in ascending order, the default.
+	Asc Direction = "ASC"
+	// Desc sorts in descending order.
+	Desc Direction = "DESC"
+)
+
+// OrderBy appends an ORDER BY clause to the query, after checking that column
+// is one of allowed and dir is Asc or Desc, so user input such as a sort request
+// parameter never reaches the SQL text unchecked. An empty dir means Asc.
+// A trailing semicolon of the query is removed.
+func OrderBy(query, column string, allowed []string, dir Direction) (string, error) {
+	if !slices.Contains(allowed, column) {
+		return "", fmt.Errorf("column %q: %w", column, ErrSortNotAllowed)
+	}
+
+	switch dir {
+	case "":
+		dir = Asc
+	case Asc, Desc:
+	default:
+		return "", fmt.Errorf("direction %q: %w", dir, ErrSortNotAllowed)
+	}
+
+	return trimStatement(query) + " ORDER BY " + column + " " + string(dir), nil
+}
+
+// GetOrdered is like Get but orders the query by column, which must be declared
+// in the "sortable" list of the query metadata:
+//
+//	--SQL:ListUsers
+//	--META: {"sortable": ["name", "created_at"]}
+//	SELECT * FROM users
+//	--end
+func (s *SQLSet) GetOrdered(column string, dir Direction, ids ...string) (string, error) {
+	key, q, err := s.lookup(ids...)
+	if err != nil {
+		return "", err
+	}
+
+	sql, err := s.rewrite(context.Background(), key, q)
+	if err != nil {
+		return "", err
+	}
+
+	return OrderBy(sql, column, q.meta.Sortable, dir)
+}
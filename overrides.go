@@ -0,0 +1,61 @@
+package sqlset
+
+import (
+	"fmt"
+	"io/fs"
+)
+
+// NewWithOverrides creates a new SQLSet from the base files, with the queries
+// of the overrides files replacing the base queries with the same keys, e.g.
+// to customize a shared catalog per deployment:
+//
+//	sqlSet, err := sqlset.NewWithOverrides(queriesFS, os.DirFS("/etc/app/queries"))
+//
+// Queries and sets present only in overrides are added. The set metadata
+// of base is kept for the sets present in both. Both file systems are loaded
+// with the same options. The replaced queries are reported by Overridden.
+func NewWithOverrides(base, overrides fs.FS, opts ...Option) (*SQLSet, error) {
+	sqlSet, err := New(base, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("base: %w", err)
+	}
+
+	custom, err := New(overrides, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("overrides: %w", err)
+	}
+
+	for setID, qs := range custom.sets {
+		existing, ok := sqlSet.sets[setID]
+		if !ok {
+			sqlSet.registerQuerySet(setID, qs)
+
+			continue
+		}
+
+		for id, q := range qs.queries {
+			if _, ok := existing.queries[id]; ok {
+				sqlSet.overridden = append(sqlSet.overridden, QueryKey{SetID: setID, QueryID: id})
+			}
+
+			existing.registerQuery(id, q)
+		}
+
+		if qs.modTime.After(existing.modTime) {
+			existing.modTime = qs.modTime
+		}
+
+		sqlSet.registerQuerySet(setID, existing)
+	}
+
+	sortKeys(sqlSet.overridden)
+
+	sqlSet.skipped = append(sqlSet.skipped, custom.skipped...)
+
+	return sqlSet, nil
+}
+
+// Overridden returns the keys of the base queries replaced by NewWithOverrides, sorted.
+func (s *SQLSet) Overridden() []QueryKey {
+	return append([]QueryKey(nil), s.overridden...)
+}
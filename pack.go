@@ -0,0 +1,258 @@
+package sqlset
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// PackManifestFile is the name of the manifest at the root of a query pack, see WritePack.
+const PackManifestFile = "sqlset-pack.json"
+
+// packSchema is the version of the pack format.
+const packSchema = 1
+
+// PackManifest describes a query pack: a versioned tar.gz artifact of a directory of .sql files,
+// shared by several services.
+type PackManifest struct {
+	// Schema is the version of the pack format, set by WritePack.
+	Schema int `json:"schema"`
+	// Name identifies the pack, e.g. "analytics". It is a single path element,
+	// the directory the pack is installed to.
+	Name string `json:"name"`
+	// Version is the version of the queries, e.g. "1.4.0".
+	Version string `json:"version"`
+	// MinSQLSetVersion is the oldest version of the library able to load the pack, e.g. "1.2.0",
+	// for packs using newer features. Any version if empty.
+	MinSQLSetVersion string `json:"min_sqlset_version,omitempty"`
+	// Dialects lists the dialects of the sets of the pack.
+	Dialects []Dialect `json:"dialects,omitempty"`
+	// Files maps the paths of the packed files to the hex-encoded SHA-256 of their content,
+	// set by WritePack.
+	Files map[string]string `json:"files"`
+}
+
+// WritePack writes the .sql, .sql.tmpl and directory metadata files of fsys to w as a tar.gz
+// query pack, with manifest at its root completed with the file hashes.
+// A pack is an archive, it can be loaded with NewFromArchive, or with NewFromPack
+// to also verify its manifest.
+func WritePack(w io.Writer, fsys fs.FS, manifest PackManifest) (PackManifest, error) {
+	if !ValidPackName(manifest.Name) {
+		return PackManifest{}, fmt.Errorf("%w: invalid name %q", ErrInvalidPack, manifest.Name)
+	}
+
+	manifest.Schema = packSchema
+	manifest.Files = make(map[string]string)
+
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+
+	err := fs.WalkDir(fsys, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || name == PackManifestFile || !archived(path.Base(name)) {
+			return err
+		}
+
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		sum := sha256.Sum256(data)
+		manifest.Files[name] = hex.EncodeToString(sum[:])
+
+		return writeTarFile(tw, name, data, info.ModTime().Unix())
+	})
+	if err != nil {
+		return PackManifest{}, fmt.Errorf("pack: %w", err)
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return PackManifest{}, fmt.Errorf("marshal pack manifest: %w", err)
+	}
+
+	if err := writeTarFile(tw, PackManifestFile, append(data, '\n'), 0); err != nil {
+		return PackManifest{}, fmt.Errorf("pack: %w", err)
+	}
+
+	if err := tw.Close(); err != nil {
+		return PackManifest{}, fmt.Errorf("pack: %w", err)
+	}
+
+	if err := gw.Close(); err != nil {
+		return PackManifest{}, fmt.Errorf("pack: %w", err)
+	}
+
+	return manifest, nil
+}
+
+func writeTarFile(tw *tar.Writer, name string, data []byte, modTime int64) error {
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    0o644,
+		Size:    int64(len(data)),
+		ModTime: time.Unix(modTime, 0),
+		Format:  tar.FormatPAX,
+	}
+
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+
+	_, err := tw.Write(data)
+
+	return err
+}
+
+// ReadPack reads a query pack written by WritePack and verifies its files against the manifest:
+// a missing, modified or unlisted file fails with ErrInvalidPack, and so does a name that is not
+// a valid pack name, see ValidPackName. A pack of a newer format,
+// or requiring a newer library with its min_sqlset_version, fails with ErrIncompatiblePack.
+// The files are returned with the manifest, e.g. to be vendored into another repository.
+func ReadPack(r io.ReaderAt, size int64) (PackManifest, fs.FS, error) {
+	fsys, err := archiveFS(r, size)
+	if err != nil {
+		return PackManifest{}, nil, fmt.Errorf("%w: %w", ErrInvalidPack, err)
+	}
+
+	data, err := fs.ReadFile(fsys, PackManifestFile)
+	if err != nil {
+		return PackManifest{}, nil, fmt.Errorf("%w: manifest: %w", ErrInvalidPack, err)
+	}
+
+	if err := checkPackCompat(data); err != nil {
+		return PackManifest{}, nil, err
+	}
+
+	var manifest PackManifest
+
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return PackManifest{}, nil, fmt.Errorf("%w: manifest: %s", ErrInvalidPack, err.Error())
+	}
+
+	if !ValidPackName(manifest.Name) {
+		return PackManifest{}, nil, fmt.Errorf("%w: invalid name %q", ErrInvalidPack, manifest.Name)
+	}
+
+	if err := manifest.verify(fsys); err != nil {
+		return PackManifest{}, nil, err
+	}
+
+	return manifest, fsys, nil
+}
+
+// ValidPackName reports whether name can name a pack: a single path element, not "." or "..",
+// so that it cannot install a pack out of its directory.
+func ValidPackName(name string) bool {
+	return fs.ValidPath(name) && name != "." && !strings.ContainsAny(name, `/\`)
+}
+
+// checkPackCompat checks that the library can read a pack from the versions of its manifest,
+// decoded alone so that the newer formats fail with ErrIncompatiblePack rather than
+// with their decoding errors.
+func checkPackCompat(manifest []byte) error {
+	var versions struct {
+		Schema           int    `json:"schema"`
+		MinSQLSetVersion string `json:"min_sqlset_version"`
+	}
+
+	// The other fields may have changed in the newer schemas.
+	if err := json.Unmarshal(manifest, &versions); err != nil {
+		return fmt.Errorf("%w: manifest: %s", ErrInvalidPack, err.Error())
+	}
+
+	switch {
+	case versions.Schema > packSchema:
+		return fmt.Errorf("%w: pack schema %d is newer than the supported schema %d, upgrade sqlset",
+			ErrIncompatiblePack, versions.Schema, packSchema)
+	case versions.Schema < 1:
+		return fmt.Errorf("%w: unsupported schema %d", ErrInvalidPack, versions.Schema)
+	case versions.MinSQLSetVersion == "":
+		return nil
+	}
+
+	newer, err := versionLess(Version, versions.MinSQLSetVersion)
+	if err != nil {
+		return fmt.Errorf("%w: min_sqlset_version: %w", ErrInvalidPack, err)
+	}
+
+	if newer {
+		return fmt.Errorf("%w: pack requires sqlset %s or newer, this is %s",
+			ErrIncompatiblePack, versions.MinSQLSetVersion, Version)
+	}
+
+	return nil
+}
+
+// verify checks the files of fsys against the manifest hashes.
+func (m PackManifest) verify(fsys fs.FS) error {
+	var packed []string
+
+	err := fs.WalkDir(fsys, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || name == PackManifestFile {
+			return err
+		}
+
+		packed = append(packed, name)
+
+		want, ok := m.Files[name]
+		if !ok {
+			return fmt.Errorf("%w: %s not in the manifest", ErrInvalidPack, name)
+		}
+
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+
+		if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != want {
+			return fmt.Errorf("%w: %s does not match its hash", ErrInvalidPack, name)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(m.Files)) {
+		if !slices.Contains(packed, name) {
+			return fmt.Errorf("%w: %s missing", ErrInvalidPack, name)
+		}
+	}
+
+	return nil
+}
+
+// NewFromPack is like NewFromArchive but loads a query pack written by WritePack,
+// verifying it first, see ReadPack:
+//
+//	sqlSet, manifest, err := sqlset.NewFromPack(f, info.Size())
+func NewFromPack(r io.ReaderAt, size int64, opts ...Option) (*SQLSet, PackManifest, error) {
+	manifest, fsys, err := ReadPack(r, size)
+	if err != nil {
+		return nil, PackManifest{}, err
+	}
+
+	sqlSet, err := New(fsys, opts...)
+	if err != nil {
+		return nil, PackManifest{}, err
+	}
+
+	return sqlSet, manifest, nil
+}
@@ -0,0 +1,201 @@
+package sqlset_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"testing/fstest"
+
+	"github.com/istovpets/sqlset"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tarGz returns a tar.gz archive of files.
+func tarGz(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	tw := tar.NewWriter(&buf)
+
+	for name, data := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(data))}))
+
+		_, err := tw.Write([]byte(data))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, tw.Close())
+
+	return gzipped(t, buf.Bytes())
+}
+
+func sha256Hex(data string) string {
+	sum := sha256.Sum256([]byte(data))
+
+	return hex.EncodeToString(sum[:])
+}
+
+func TestPack(t *testing.T) {
+	t.Parallel()
+
+	users := "--META\n{\"dialect\": \"postgres\"}\n--end\n--SQL:Get\nSELECT 1;\n--end\n"
+	fsys := fstest.MapFS{
+		"users.sql":             {Data: []byte(users)},
+		"reports/_meta.json":    {Data: []byte(`{"owner": "analytics"}`)},
+		"reports/daily.sql":     {Data: []byte("--SQL:Get\nSELECT 2;\n--end\n")},
+		"README.md":             {Data: []byte("# Analytics")},
+		sqlset.PackManifestFile: {Data: []byte(`{"name": "stale"}`)},
+	}
+
+	var buf bytes.Buffer
+
+	manifest, err := sqlset.WritePack(&buf, fsys, sqlset.PackManifest{
+		Name: "analytics", Version: "1.4.0", MinSQLSetVersion: "v" + sqlset.Version,
+		Dialects: []sqlset.Dialect{sqlset.DialectPostgres},
+	})
+	require.NoError(t, err)
+
+	expected := sqlset.PackManifest{
+		Schema:           1,
+		Name:             "analytics",
+		Version:          "1.4.0",
+		MinSQLSetVersion: "v" + sqlset.Version,
+		Dialects:         []sqlset.Dialect{sqlset.DialectPostgres},
+		Files: map[string]string{
+			"users.sql":          sha256Hex(users),
+			"reports/_meta.json": sha256Hex(`{"owner": "analytics"}`),
+			"reports/daily.sql":  sha256Hex("--SQL:Get\nSELECT 2;\n--end\n"),
+		},
+	}
+	assert.Equal(t, expected, manifest)
+
+	sqlSet, manifest, err := sqlset.NewFromPack(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	assert.Equal(t, expected, manifest)
+	assert.Equal(t, "SELECT 2;", sqlSet.MustGet("daily.Get"))
+
+	// A pack is an archive.
+	sqlSet, err = sqlset.NewFromArchive(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT 1;", sqlSet.MustGet("users.Get"))
+
+	_, err = sqlset.WritePack(&buf, fsys, sqlset.PackManifest{Name: "analytics/../..", Version: "1.4.0"})
+	require.ErrorIs(t, err, sqlset.ErrInvalidPack)
+}
+
+func TestReadPack_Invalid(t *testing.T) {
+	t.Parallel()
+
+	query := "--SQL:Get\nSELECT 1;\n--end\n"
+	manifest := `{"schema": 1, "name": "analytics", "version": "1.0.0", "files": {"users.sql": "` +
+		sha256Hex(query) + `"}}`
+
+	tests := []struct {
+		name  string
+		files map[string]string
+		err   error
+		msg   string
+	}{
+		{
+			name:  "no manifest",
+			files: map[string]string{"users.sql": query},
+			msg:   "manifest",
+		},
+		{
+			name:  "modified file",
+			files: map[string]string{sqlset.PackManifestFile: manifest, "users.sql": "--SQL:Get\nDROP TABLE users;\n--end\n"},
+			msg:   "users.sql does not match its hash",
+		},
+		{
+			name:  "missing file",
+			files: map[string]string{sqlset.PackManifestFile: manifest},
+			msg:   "users.sql missing",
+		},
+		{
+			name:  "unlisted file",
+			files: map[string]string{sqlset.PackManifestFile: manifest, "users.sql": query, "extra.sql": query},
+			msg:   "extra.sql not in the manifest",
+		},
+		{
+			name:  "unknown schema",
+			files: map[string]string{sqlset.PackManifestFile: `{"schema": 0}`, "users.sql": query},
+			msg:   "unsupported schema 0",
+		},
+		{
+			name:  "newer schema",
+			files: map[string]string{sqlset.PackManifestFile: `{"schema": 2, "files": [{"path": "users.sql"}]}`},
+			err:   sqlset.ErrIncompatiblePack,
+			msg:   "pack schema 2 is newer than the supported schema 1, upgrade sqlset",
+		},
+		{
+			name:  "newer library",
+			files: map[string]string{sqlset.PackManifestFile: `{"schema": 1, "min_sqlset_version": "99.1.0"}`},
+			err:   sqlset.ErrIncompatiblePack,
+			msg:   "pack requires sqlset 99.1.0 or newer, this is " + sqlset.Version,
+		},
+		{
+			name:  "name out of the directory",
+			files: map[string]string{sqlset.PackManifestFile: `{"schema": 1, "name": "../..", "files": {}}`},
+			msg:   `invalid name "../.."`,
+		},
+		{
+			name:  "no name",
+			files: map[string]string{sqlset.PackManifestFile: `{"schema": 1, "name": ".", "files": {}}`},
+			msg:   `invalid name "."`,
+		},
+		{
+			name:  "invalid version",
+			files: map[string]string{sqlset.PackManifestFile: `{"schema": 1, "min_sqlset_version": "latest"}`},
+			msg:   `invalid version "latest"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			data := tarGz(t, test.files)
+
+			_, _, err := sqlset.ReadPack(bytes.NewReader(data), int64(len(data)))
+			if test.err == nil {
+				test.err = sqlset.ErrInvalidPack
+			}
+
+			require.ErrorIs(t, err, test.err)
+			require.ErrorContains(t, err, test.msg)
+		})
+	}
+}
+
+func TestVersionLess(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		a, b     string
+		expected bool
+	}{
+		{a: "1.0.0", b: "1.0.1", expected: true},
+		{a: "1.2.0", b: "1.10.0", expected: true},
+		{a: "v2.0.0", b: "1.9.9", expected: false},
+		{a: "1.2", b: "1.2.0", expected: false},
+		{a: "1.2.0-rc1", b: "1.2.0", expected: true},
+		{a: "1.2.0", b: "1.2.0-rc1", expected: false},
+		{a: "1.2.0-rc1", b: "1.2.0-rc2", expected: true},
+		{a: "1.2.0+build5", b: "1.2.0", expected: false},
+	}
+
+	for _, test := range tests {
+		less, err := sqlset.VersionLess(test.a, test.b)
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, less, "%s < %s", test.a, test.b)
+	}
+
+	for _, invalid := range []string{"", "latest", "1.2.3.4", "1.-2"} {
+		_, err := sqlset.VersionLess("1.0.0", invalid)
+		require.Error(t, err, invalid)
+	}
+}
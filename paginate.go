@@ -0,0 +1,134 @@
+package sqlset
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Dialect is the SQL dialect used to render pagination clauses.
+type Dialect string
+
+const (
+	// DialectPostgres renders LIMIT/OFFSET clauses, also understood by MySQL and SQLite.
+	// It is the default when the dialect is not set.
+	DialectPostgres Dialect = "postgres"
+	// DialectMySQL renders LIMIT/OFFSET clauses.
+	DialectMySQL Dialect = "mysql"
+	// DialectSQLite renders LIMIT/OFFSET clauses.
+	DialectSQLite Dialect = "sqlite"
+	// DialectSQLServer renders the standard OFFSET/FETCH clauses, the query must be ordered.
+	DialectSQLServer Dialect = "sqlserver"
+)
+
+// Page describes an offset-based page of results.
+type Page struct {
+	// Limit is the maximum number of rows, zero or negative for no limit.
+	Limit int
+	// Offset is the number of rows to skip.
+	Offset int
+	// Dialect selects the pagination syntax, DialectPostgres if empty.
+	Dialect Dialect
+}
+
+// KeysetPage describes a keyset-based ("seek") page of results:
+// rows following the last seen value of an ordering column.
+type KeysetPage struct {
+	// Column is the unique ordering column, as it is named in the query result.
+	Column string
+	// Desc orders the rows in descending order.
+	Desc bool
+	// Limit is the maximum number of rows, zero or negative for no limit.
+	Limit int
+	// After is the placeholder for the last seen value, e.g. "$3" or "?".
+	// Empty for the first page.
+	After string
+	// Dialect selects the pagination syntax, DialectPostgres if empty.
+	Dialect Dialect
+}
+
+// Paginate appends the pagination clauses for page to the query.
+// A trailing semicolon of the query is removed.
+//
+//	q := sqlset.Paginate(sqlSet.MustGet("users.List"), sqlset.Page{Limit: 20, Offset: 40})
+func Paginate(query string, page Page) string {
+	var sb strings.Builder
+
+	sb.WriteString(trimStatement(query))
+	writePage(&sb, page.Dialect, page.Limit, page.Offset)
+
+	return sb.String()
+}
+
+// PaginateKeyset wraps the query in a subquery filtered and ordered by the page column:
+//
+//	SELECT * FROM (<query>) AS page WHERE id > $3 ORDER BY id LIMIT 20
+//
+// The ordering of the wrapped query is replaced by the ordering of the column.
+func PaginateKeyset(query string, page KeysetPage) string {
+	var sb strings.Builder
+
+	sb.WriteString("SELECT * FROM (" + lineEnding)
+	sb.WriteString(trimStatement(query))
+	sb.WriteString(lineEnding + ") AS page")
+
+	if page.After != "" {
+		op := " > "
+		if page.Desc {
+			op = " < "
+		}
+
+		sb.WriteString(" WHERE " + page.Column + op + page.After)
+	}
+
+	sb.WriteString(" ORDER BY " + page.Column)
+
+	if page.Desc {
+		sb.WriteString(" DESC")
+	}
+
+	writePage(&sb, page.Dialect, page.Limit, 0)
+
+	return sb.String()
+}
+
+// known reports whether d is one of the Dialect constants or empty.
+func (d Dialect) known() bool {
+	switch d {
+	case "", DialectPostgres, DialectMySQL, DialectSQLite, DialectSQLServer:
+		return true
+	default:
+		return false
+	}
+}
+
+func writePage(sb *strings.Builder, dialect Dialect, limit, offset int) {
+	if dialect == DialectSQLServer {
+		if limit <= 0 && offset <= 0 {
+			return
+		}
+
+		sb.WriteString(" OFFSET " + strconv.Itoa(max(offset, 0)) + " ROWS")
+
+		if limit > 0 {
+			sb.WriteString(" FETCH NEXT " + strconv.Itoa(limit) + " ROWS ONLY")
+		}
+
+		return
+	}
+
+	if limit > 0 {
+		sb.WriteString(" LIMIT " + strconv.Itoa(limit))
+	}
+
+	if offset > 0 {
+		sb.WriteString(" OFFSET " + strconv.Itoa(offset))
+	}
+}
+
+// trimStatement removes the trailing whitespace and semicolon of an SQL statement.
+func trimStatement(query string) string {
+	query = strings.TrimRight(query, " \t\r\n")
+	query = strings.TrimSuffix(query, ";")
+
+	return strings.TrimRight(query, " \t\r\n")
+}
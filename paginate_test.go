@@ -0,0 +1,92 @@
+package sqlset_test
+
+import (
+	"testing"
+
+	"github.com/istovpets/sqlset"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaginate(t *testing.T) {
+	t.Parallel()
+
+	const query = "SELECT id FROM users\r\nORDER BY id;"
+
+	tests := []struct {
+		name     string
+		page     sqlset.Page
+		expected string
+	}{
+		{
+			name:     "limit and offset",
+			page:     sqlset.Page{Limit: 20, Offset: 40},
+			expected: "SELECT id FROM users\r\nORDER BY id LIMIT 20 OFFSET 40",
+		},
+		{
+			name:     "limit only",
+			page:     sqlset.Page{Limit: 20},
+			expected: "SELECT id FROM users\r\nORDER BY id LIMIT 20",
+		},
+		{
+			name:     "no limit",
+			page:     sqlset.Page{Offset: 5, Dialect: sqlset.DialectMySQL},
+			expected: "SELECT id FROM users\r\nORDER BY id OFFSET 5",
+		},
+		{
+			name:     "sqlserver",
+			page:     sqlset.Page{Limit: 20, Offset: 40, Dialect: sqlset.DialectSQLServer},
+			expected: "SELECT id FROM users\r\nORDER BY id OFFSET 40 ROWS FETCH NEXT 20 ROWS ONLY",
+		},
+		{
+			name:     "sqlserver without paging",
+			page:     sqlset.Page{Dialect: sqlset.DialectSQLServer},
+			expected: "SELECT id FROM users\r\nORDER BY id",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, sqlset.Paginate(query, test.page))
+		})
+	}
+}
+
+func TestPaginateKeyset(t *testing.T) {
+	t.Parallel()
+
+	const query = "SELECT id, name FROM users WHERE active = $1;"
+
+	tests := []struct {
+		name     string
+		page     sqlset.KeysetPage
+		expected string
+	}{
+		{
+			name:     "first page",
+			page:     sqlset.KeysetPage{Column: "id", Limit: 10},
+			expected: "SELECT * FROM (\r\nSELECT id, name FROM users WHERE active = $1\r\n) AS page ORDER BY id LIMIT 10",
+		},
+		{
+			name: "next page",
+			page: sqlset.KeysetPage{Column: "id", Limit: 10, After: "$2"},
+			expected: "SELECT * FROM (\r\nSELECT id, name FROM users WHERE active = $1\r\n) AS page" +
+				" WHERE id > $2 ORDER BY id LIMIT 10",
+		},
+		{
+			name: "descending",
+			page: sqlset.KeysetPage{Column: "id", Desc: true, Limit: 10, After: "$2"},
+			expected: "SELECT * FROM (\r\nSELECT id, name FROM users WHERE active = $1\r\n) AS page" +
+				" WHERE id < $2 ORDER BY id DESC LIMIT 10",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, sqlset.PaginateKeyset(query, test.page))
+		})
+	}
+}
@@ -2,21 +2,33 @@ package sqlset
 
 import (
 	"bufio"
+	"cmp"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"strings"
+	"time"
 )
 
 const (
 	maxCapacity = 1024
+	// maxMetaCapacity is the line length limit of the metadata, which holds
+	// rich descriptions and translations on a single line.
+	maxMetaCapacity = 64 * 1024
 
 	tokenPrefix  = "--"
 	tokenKeySep  = ":"
 	tokenComment = tokenPrefix
 	tokenSQL     = "SQL"
+	tokenSQLRaw  = "SQLRAW"
 	tokenMeta    = "META"
+	tokenParams  = "PARAMS"
+	tokenReturns = "RETURNS"
+	tokenWhen    = "when"
+	tokenHints   = "HINTS"
+	tokenAlias   = "ALIAS"
+	tokenRequire = "requires"
 	tokenEnd     = "end"
 
 	filesExt   = ".sql"
@@ -27,59 +39,297 @@ type parserToken struct {
 	Type    string
 	Key     string
 	Content strings.Builder
+	Params  []QueryParam
+	Returns []QueryParam
+	// When lists the feature guards of the query, see WithFeatures.
+	When  []string
+	Hints strings.Builder
+	Meta  strings.Builder
+	// Sub is the type of the sub-block of a query being parsed, tokenHints or tokenMeta.
+	Sub string
+	// Raw marks a raw query block, kept verbatim up to the exact end line.
+	Raw bool
+	// Line is the line of the opening directive.
+	Line int
+	// Aliases lists the former IDs of the query declared with --ALIAS.
+	Aliases []string
+	// Requires lists the queries required by the query declared with --requires.
+	Requires []string
+	// Dialect is the dialect of the set when the query was opened, for its quoting rules.
+	Dialect Dialect
 }
 
-//nolint:funlen
-func parse(setID string, inp io.Reader) (QuerySet, error) {
+// parse parses the set file. The quoting rules of its queries follow dialect, the inherited one,
+// or the one declared by the META block of the set once read.
+//
+//nolint:funlen,gocognit,gocyclo
+func parse(cfg *config, setID, file string, dialect Dialect, inp io.Reader) (qs QuerySet, err error) {
+	inp, err = decodeInput(inp)
+	if err != nil {
+		return QuerySet{}, fmt.Errorf("decode: %w", err)
+	}
+
 	scanner := bufio.NewScanner(inp)
 	buf := make([]byte, maxCapacity)
-	scanner.Buffer(buf, maxCapacity)
+	scanner.Buffer(buf, maxMetaCapacity)
 
 	var (
 		openedToken *parserToken
 		lineN       int
 		metaBuf     []byte
+		// metaLine is the line of the set metadata directive.
+		metaLine int
+		lex      = lexState{dialect: dialect}
+		// errLine is the line of a failure other than the current one.
+		errLine int
+		// lines holds the lines read for the diagnostics of a failure, see WithDiagnostics.
+		lines []string
 	)
 
-	qs := QuerySet{}
+	defer func() {
+		if err == nil || cfg.diagnostics <= 0 {
+			return
+		}
+
+		// Read the lines following the failure for the snippet.
+		for i := 0; i < cfg.diagnostics && scanner.Scan(); i++ {
+			lines = append(lines, scanner.Text())
+		}
+
+		if errLine == 0 {
+			errLine = lineN
+		}
+
+		err = newParseError(file, errLine, lines, cfg.diagnostics, describeState(openedToken, &lex), err)
+	}()
+
+	// closeToken closes the opened block ending at the line endLine.
+	// disabled holds the IDs of the queries of disabled features, see WithFeatures.
+	disabled := make(map[string]bool)
+
+	closeToken := func(endLine int) error {
+		src := Source{File: file, Line: openedToken.Line, EndLine: endLine}
+
+		if openedToken.Type == tokenSQL && !cfg.enabled(openedToken.When) {
+			disabled[openedToken.Key] = true
+		}
+
+		meta, err := openedToken.close(cfg, &qs, setID, src)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", lineN, err)
+		}
+
+		if meta != nil {
+			metaBuf, metaLine = meta, openedToken.Line
+			lex.dialect = cmp.Or(metaDialect(meta), lex.dialect)
+		}
+
+		openedToken = nil
+
+		return nil
+	}
 
 	for scanner.Scan() {
 		lineN++
 
 		line := scanner.Text()
+
+		if cfg.diagnostics > 0 {
+			lines = append(lines, line)
+		}
+
+		// Only the metadata can exceed maxCapacity.
+		if len(line) >= maxCapacity && !cfg.syntax.isMetaLine(openedToken, line) {
+			return QuerySet{}, fmt.Errorf("line %d: %w", lineN, ErrMaxLineLenExceeded)
+		}
+
+		if openedToken != nil && openedToken.Raw {
+			if strings.TrimRight(line, " \t") == cfg.syntax.Prefix+cfg.syntax.EndKeyword {
+				if err := closeToken(lineN); err != nil {
+					return QuerySet{}, err
+				}
+			} else {
+				openedToken.Content.WriteString(line + lineEnding)
+			}
+
+			continue
+		}
+
 		line = strings.TrimSpace(line)
 
+		if todoMarker.MatchString(line) {
+			cfg.warn(file, lineN, "%s marker", todoMarker.FindString(line))
+		}
+
 		if len(line) == 0 {
+			// Blank lines inside string literals are part of the value.
+			keep := cfg.preserveBlankLines || lex.quote != 0 || lex.dollarTag != ""
+
+			if keep && openedToken != nil && openedToken.Type == tokenSQL && openedToken.Sub == "" &&
+				openedToken.Content.Len() > 0 {
+				openedToken.Content.WriteString(lineEnding)
+			}
+
 			continue
 		}
 
-		token, key, err := detectToken(line)
-		if err != nil {
-			return QuerySet{}, fmt.Errorf("line %d: %w", lineN, err)
+		if cfg.implicitEnd {
+			// Allow "-- name: ID" as well as "--name: ID".
+			if rest, ok := strings.CutPrefix(line, cfg.syntax.Prefix); ok {
+				line = cfg.syntax.Prefix + strings.TrimLeft(rest, " \t")
+			}
+		}
+
+		var token, key string
+
+		if lex.inside() || !strings.HasPrefix(line, cfg.syntax.Prefix) {
+			// The content of the other blocks is not SQL, e.g. a META description mentioning "/*".
+			if openedToken == nil || openedToken.Type == tokenSQL && openedToken.Sub == "" {
+				lex.scan(line, openedToken != nil)
+			}
+		} else {
+			token, key, err = cfg.syntax.detectToken(line)
+			if err != nil {
+				return QuerySet{}, fmt.Errorf("line %d: %w", lineN, err)
+			}
+		}
+
+		if cfg.implicitEnd {
+			// Blocks end at the next block, end keywords are plain comments.
+			if token == tokenEnd {
+				token = tokenComment
+			}
+
+			if openedToken != nil && (token == tokenSQL || token == tokenSQLRaw || token == tokenData || token == tokenExpect ||
+				(token == tokenMeta && key == "")) {
+				if err := closeToken(lineN - 1); err != nil {
+					return QuerySet{}, err
+				}
+			}
 		}
 
-		if openedToken != nil && (token == tokenSQL || token == tokenMeta) {
+		// Inside a query, META is the query metadata.
+		queryMeta := token == tokenMeta && openedToken != nil && openedToken.Type == tokenSQL
+
+		if openedToken != nil && (token == tokenSQL || token == tokenSQLRaw || token == tokenData || token == tokenExpect ||
+			(token == tokenMeta && !queryMeta)) {
 			return QuerySet{}, fmt.Errorf(
 				"line %d: %w: unexpected %s inside %s",
 				lineN, ErrInvalidSyntax, token, openedToken.Type,
 			)
 		}
 
+		if openedToken != nil && openedToken.Sub != "" && token != tokenEnd && token != tokenComment && token != "" {
+			return QuerySet{}, fmt.Errorf(
+				"line %d: %w: unexpected %s inside %s",
+				lineN, ErrInvalidSyntax, token, openedToken.Sub,
+			)
+		}
+
 		switch token {
 		case tokenComment:
+			if err := cfg.directive(line, openedToken, setID, file, lineN); err != nil {
+				return QuerySet{}, fmt.Errorf("line %d: %w", lineN, err)
+			}
+
 			continue
-		case tokenSQL:
+		case tokenSQL, tokenSQLRaw:
 			openedToken = &parserToken{
-				Type: tokenSQL,
-				Key:  key,
+				Type:    tokenSQL,
+				Key:     key,
+				Raw:     token == tokenSQLRaw,
+				Line:    lineN,
+				Dialect: lex.dialect,
+			}
+
+			continue
+		case tokenData, tokenExpect:
+			openedToken = &parserToken{Type: token, Key: key, Line: lineN}
+
+			continue
+		case tokenParams, tokenReturns, tokenHints, tokenWhen, tokenAlias, tokenRequire:
+			if openedToken == nil || openedToken.Type != tokenSQL {
+				return QuerySet{}, fmt.Errorf(
+					"line %d: %w: unexpected %s outside %s",
+					lineN, ErrInvalidSyntax, token, tokenSQL,
+				)
+			}
+
+			if token == tokenWhen {
+				guards, err := parseGuards(key)
+				if err != nil {
+					return QuerySet{}, fmt.Errorf("line %d: %w", lineN, err)
+				}
+
+				openedToken.When = append(openedToken.When, guards...)
+
+				continue
+			}
+
+			if token == tokenAlias || token == tokenRequire {
+				ids, err := parseIDList(key)
+				if err != nil {
+					return QuerySet{}, fmt.Errorf("line %d: %s: %w", lineN, token, err)
+				}
+
+				if token == tokenAlias {
+					openedToken.Aliases = append(openedToken.Aliases, ids...)
+				} else {
+					openedToken.Requires = append(openedToken.Requires, ids...)
+				}
+
+				continue
+			}
+
+			if token == tokenHints {
+				if key == "" {
+					openedToken.Sub = tokenHints
+				} else {
+					openedToken.Hints.WriteString(key + lineEnding)
+				}
+
+				continue
+			}
+
+			params, err := parseParams(key)
+			if err != nil {
+				return QuerySet{}, fmt.Errorf("line %d: %w", lineN, err)
+			}
+
+			if token == tokenReturns {
+				openedToken.Returns = append(openedToken.Returns, params...)
+			} else {
+				openedToken.Params = append(openedToken.Params, params...)
 			}
 
 			continue
 		case tokenMeta:
+			if queryMeta {
+				if openedToken.Meta.Len() > 0 {
+					return QuerySet{}, fmt.Errorf("line %d: %w: unexpected multiple metadata", lineN, ErrInvalidSyntax)
+				}
+
+				if key == "" {
+					openedToken.Sub = tokenMeta
+				} else {
+					openedToken.Meta.WriteString(key)
+				}
+
+				continue
+			}
+
 			if metaBuf != nil {
 				return QuerySet{}, fmt.Errorf("line %d: %w: unexpected multiple metadata", lineN, ErrInvalidSyntax)
 			}
-			openedToken = &parserToken{Type: tokenMeta}
+
+			if key != "" {
+				metaBuf, metaLine = []byte(key), lineN
+				lex.dialect = cmp.Or(metaDialect(metaBuf), lex.dialect)
+
+				continue
+			}
+
+			openedToken = &parserToken{Type: tokenMeta, Line: lineN}
 
 			continue
 		}
@@ -92,37 +342,58 @@ func parse(setID string, inp io.Reader) (QuerySet, error) {
 				)
 			}
 
-			switch {
-			case openedToken.Type == tokenSQL:
-				qs.registerQuery(
-					openedToken.Key,
-					strings.TrimSuffix(openedToken.Content.String(), lineEnding),
-				)
-			case openedToken.Type == tokenMeta:
-				metaBuf = []byte(openedToken.Content.String())
+			if openedToken.Sub != "" {
+				openedToken.Sub = ""
+
+				continue
 			}
 
-			openedToken.Content.Reset()
-			openedToken = nil
+			if err := closeToken(lineN); err != nil {
+				return QuerySet{}, err
+			}
 
 			continue
 		}
 
 		if openedToken == nil {
+			cfg.warn(file, lineN, "content outside of a block is ignored")
+
 			continue
 		}
 
-		openedToken.Content.WriteString(line + lineEnding)
+		switch openedToken.Sub {
+		case tokenHints:
+			openedToken.Hints.WriteString(line + lineEnding)
+		case tokenMeta:
+			openedToken.Meta.WriteString(line + lineEnding)
+		default:
+			openedToken.Content.WriteString(line + lineEnding)
+		}
 	}
 
 	if err := scanner.Err(); err != nil {
 		if errors.Is(err, bufio.ErrTooLong) {
+			errLine = lineN + 1
+
 			return QuerySet{}, fmt.Errorf("line %d: %w", lineN+1, ErrMaxLineLenExceeded)
 		}
 
 		return QuerySet{}, fmt.Errorf("scanning error: %w", err)
 	}
 
+	if openedToken != nil && cfg.implicitEnd {
+		if err := closeToken(lineN); err != nil {
+			return QuerySet{}, err
+		}
+	}
+
+	if openedToken != nil && lex.inside() {
+		return QuerySet{}, fmt.Errorf(
+			"%w: unterminated %s in '%s:%s'",
+			ErrInvalidSyntax, &lex, openedToken.Type, openedToken.Key,
+		)
+	}
+
 	if openedToken != nil {
 		return QuerySet{}, fmt.Errorf(
 			"%w: no closing tag found for '%s:%s'",
@@ -132,25 +403,301 @@ func parse(setID string, inp io.Reader) (QuerySet, error) {
 
 	meta, err := parseMeta(setID, metaBuf)
 	if err != nil {
-		return qs, fmt.Errorf("parse meta: %w", err)
+		errLine = metaLine
+
+		return qs, fmt.Errorf("line %d: parse meta: %w", metaLine, err)
+	}
+
+	if err := checkAliases(qs); err != nil {
+		return QuerySet{}, err
+	}
+
+	if err := checkExpectations(&qs, disabled); err != nil {
+		return QuerySet{}, err
 	}
 
 	qs.meta = meta
+	qs.meta.Template = qs.meta.Template || strings.HasSuffix(strings.ToLower(file), templateExt)
 
 	return qs, nil
 }
 
-func detectToken(line string) (token string, key string, err error) {
+// close registers the query of a closed SQL block in qs, with the variables expanded
+// and its source location, or the rows of a DATA or EXPECT block; for a META block it returns
+// the metadata content instead.
+func (t *parserToken) close(cfg *config, qs *QuerySet, setID string, src Source) ([]byte, error) {
+	if t.Type == tokenMeta {
+		return []byte(t.Content.String()), nil
+	}
+
+	if t.Type == tokenData {
+		d, err := parseData(t.Key, t.Content.String(), src)
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, qs.registerData(d)
+	}
+
+	if t.Type == tokenExpect {
+		e, err := parseExpectation(t.Key, t.Content.String(), src)
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, qs.registerExpectation(t.Key, e)
+	}
+
+	// Queries of disabled features do not exist.
+	if !cfg.enabled(t.When) {
+		return nil, nil
+	}
+
+	if err := cfg.checkName(src, t.Key); err != nil {
+		return nil, err
+	}
+
+	meta, err := parseQueryMeta(t.Meta.String())
+	if err != nil {
+		return nil, fmt.Errorf("parse %s meta: %w", t.Key, err)
+	}
+
+	meta.Aliases = append(meta.Aliases, t.Aliases...)
+	meta.Requires = append(meta.Requires, t.Requires...)
+
+	sql := strings.TrimSuffix(t.Content.String(), lineEnding)
+	if !t.Raw {
+		// Trailing blank lines, kept with WithPreserveBlankLines.
+		sql = strings.TrimRight(sql, lineEnding)
+	}
+
+	// Raw blocks are not interpreted.
+	if strings.TrimSpace(sql) == "" {
+		cfg.warn(src.File, src.Line, "query %s has an empty body", t.Key)
+	}
+
+	if cfg.contextKeys != nil {
+		if err := checkContextRefs(sql, cfg.contextKeys); err != nil {
+			return nil, fmt.Errorf("%s: %w", t.Key, err)
+		}
+	}
+
+	if cfg.variables != nil && !t.Raw {
+		sql, err = expandVariables(sql, cfg.variables, t.Dialect)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", t.Key, err)
+		}
+	}
+
+	if t.Params != nil {
+		if err := findPlaceholders(sql).check(t.Params); err != nil {
+			return nil, fmt.Errorf("%s: %w", t.Key, err)
+		}
+	}
+
+	if cfg.formatter != nil && !t.Raw {
+		sql = cfg.formatter(sql)
+	}
+
+	return nil, cfg.addQuery(qs, QueryKey{SetID: setID, QueryID: t.Key}, query{
+		sql:     sql,
+		params:  t.Params,
+		returns: t.Returns,
+		hints:   strings.TrimSuffix(t.Hints.String(), lineEnding),
+		meta:    meta,
+		source:  src,
+	})
+}
+
+// lexState tracks the SQL constructs spanning lines: block comments,
+// string literals, quoted identifiers and dollar-quoted strings.
+// Lines starting inside them are never directives.
+type lexState struct {
+	// dialect selects the quoting rules: MySQL escapes with backslashes in every string,
+	// quotes identifiers with backticks and has no dollar-quoted strings.
+	dialect      Dialect
+	commentDepth int
+	// quote is the quote character of an unterminated literal or identifier.
+	quote byte
+	// escapes is set inside a Postgres escape string, E'...', or a MySQL string,
+	// where a backslash escapes the next character.
+	escapes bool
+	// dollarTag is the tag of an unterminated dollar-quoted string, e.g. "$$" or "$body$".
+	dollarTag string
+	// lineComment is set when the last scanned line ends in a -- comment.
+	lineComment bool
+}
+
+func (l *lexState) inside() bool {
+	return l.commentDepth > 0 || l.quote != 0 || l.dollarTag != ""
+}
+
+// String describes the unterminated construct.
+func (l *lexState) String() string {
+	switch {
+	case l.commentDepth > 0:
+		return "block comment"
+	case l.quote == '`' || l.quote == '"' && l.dialect != DialectMySQL:
+		return "quoted identifier"
+	case l.quote != 0:
+		return "string literal"
+	case l.dollarTag != "":
+		return "dollar-quoted string " + l.dollarTag
+	default:
+		return ""
+	}
+}
+
+// scan updates the state with the constructs opened and closed in line.
+// Unless code is set, only block comments are tracked, as the line is between blocks.
+//
+//nolint:gocognit
+func (l *lexState) scan(line string, code bool) {
+	l.lineComment = false
+
+	for i := 0; i < len(line); i++ {
+		rest := line[i:]
+
+		switch {
+		case l.commentDepth > 0:
+			if strings.HasPrefix(rest, "/*") {
+				l.commentDepth++
+				i++
+			} else if strings.HasPrefix(rest, "*/") {
+				l.commentDepth--
+				i++
+			}
+		case l.quote != 0:
+			j := l.closingQuote(rest)
+			if j < 0 {
+				return
+			}
+
+			// A doubled quote reopens the literal on the next iteration.
+			l.quote = 0
+			l.escapes = false
+			i += j
+		case l.dollarTag != "":
+			j := strings.Index(rest, l.dollarTag)
+			if j < 0 {
+				return
+			}
+
+			i += j + len(l.dollarTag) - 1
+			l.dollarTag = ""
+		case strings.HasPrefix(rest, "/*"):
+			l.commentDepth++
+			i++
+		case strings.HasPrefix(rest, "--"):
+			l.lineComment = true
+
+			return
+		case !code:
+		case l.dialect == DialectMySQL && (rest[0] == '\'' || rest[0] == '"' || rest[0] == '`'):
+			l.quote = rest[0]
+			l.escapes = rest[0] != '`'
+		case rest[0] == '\'' || rest[0] == '"':
+			l.quote = rest[0]
+			l.escapes = rest[0] == '\'' && i > 0 && (line[i-1] == 'E' || line[i-1] == 'e') &&
+				(i == 1 || !isIdentByte(line[i-2]))
+		case rest[0] == '$' && l.dialect != DialectMySQL && (i == 0 || !isIdentByte(line[i-1])):
+			if tag, ok := dollarTag(rest); ok {
+				l.dollarTag = tag
+				i += len(tag) - 1
+			}
+		}
+	}
+}
+
+// closingQuote returns the index of the quote ending the literal in rest, skipping
+// the characters escaped by a backslash and the doubled quotes in escape strings,
+// or -1 if it does not end.
+func (l *lexState) closingQuote(rest string) int {
+	if !l.escapes {
+		return strings.IndexByte(rest, l.quote)
+	}
+
+	for i := 0; i < len(rest); i++ {
+		switch {
+		case rest[i] == '\\':
+			i++
+		case rest[i] != l.quote:
+		case i+1 < len(rest) && rest[i+1] == l.quote:
+			i++
+		default:
+			return i
+		}
+	}
+
+	return -1
+}
+
+// metaDialect returns the dialect declared by the set metadata data, empty if none or invalid.
+func metaDialect(data []byte) Dialect {
+	var meta struct {
+		Dialect Dialect `json:"dialect"`
+	}
+
+	if json.Unmarshal(data, &meta) != nil {
+		return ""
+	}
+
+	return meta.Dialect
+}
+
+// dollarTag returns the dollar-quote opening s, "$$" or "$tag$".
+func dollarTag(s string) (string, bool) {
+	for j := 1; j < len(s); j++ {
+		switch {
+		case s[j] == '$':
+			return s[:j+1], true
+		case isIdentStart(s[j]) || (j > 1 && isIdentByte(s[j])):
+		default:
+			return "", false
+		}
+	}
+
+	return "", false
+}
+
+// isMetaLine reports whether line holds metadata: it is the content of a metadata block
+// or an inline metadata directive.
+func (s Syntax) isMetaLine(opened *parserToken, line string) bool {
+	if opened != nil && (opened.Type == tokenMeta || opened.Sub == tokenMeta) {
+		return true
+	}
+
+	if opened != nil && opened.Raw {
+		return false
+	}
+
+	rest, ok := strings.CutPrefix(strings.TrimSpace(line), s.Prefix)
+
+	return ok && strings.HasPrefix(strings.TrimLeft(rest, " \t"), s.MetaKeyword+tokenKeySep)
+}
+
+func (s Syntax) detectToken(line string) (token string, key string, err error) {
 	var ok bool
 
-	line, ok = strings.CutPrefix(line, tokenPrefix)
+	line, ok = strings.CutPrefix(line, s.Prefix)
 	if !ok {
 		// Not a token nor comment, skipping.
 		return "", "", nil
 	}
 
+	// SQLRAW:key
+	key, ok = strings.CutPrefix(line, s.RawKeyword+tokenKeySep)
+	if ok {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			return "", "", fmt.Errorf("%w: no SQL set query key given", ErrInvalidSyntax)
+		}
+
+		return tokenSQLRaw, key, nil
+	}
+
 	// SQL:key
-	key, ok = strings.CutPrefix(line, tokenSQL+tokenKeySep)
+	key, ok = strings.CutPrefix(line, s.SQLKeyword+tokenKeySep)
 	if ok {
 		key = strings.TrimSpace(key)
 		if key == "" {
@@ -160,13 +707,92 @@ func detectToken(line string) (token string, key string, err error) {
 		return tokenSQL, key, nil
 	}
 
+	// PARAMS:name type, ...
+	params, ok := strings.CutPrefix(line, tokenParams+tokenKeySep)
+	if ok {
+		return tokenParams, strings.TrimSpace(params), nil
+	}
+
+	// RETURNS:name type, ...
+	columns, ok := strings.CutPrefix(line, tokenReturns+tokenKeySep)
+	if ok {
+		return tokenReturns, strings.TrimSpace(columns), nil
+	}
+
+	// when:feature, !feature
+	guards, ok := strings.CutPrefix(line, tokenWhen+tokenKeySep)
+	if ok {
+		return tokenWhen, strings.TrimSpace(guards), nil
+	}
+
+	// requires:setID.queryID, ...
+	requires, ok := strings.CutPrefix(line, tokenRequire+tokenKeySep)
+	if ok {
+		return tokenRequire, strings.TrimSpace(requires), nil
+	}
+
+	// ALIAS:OldName, ...
+	aliases, ok := strings.CutPrefix(line, tokenAlias+tokenKeySep)
+	if ok {
+		return tokenAlias, strings.TrimSpace(aliases), nil
+	}
+
+	// DATA:id
+	key, ok = strings.CutPrefix(line, tokenData+tokenKeySep)
+	if ok {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			return "", "", fmt.Errorf("%w: no data ID given", ErrInvalidSyntax)
+		}
+
+		return tokenData, key, nil
+	}
+
+	// EXPECT:queryID
+	key, ok = strings.CutPrefix(line, tokenExpect+tokenKeySep)
+	if ok {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			return "", "", fmt.Errorf("%w: no query ID given for the expected result", ErrInvalidSyntax)
+		}
+
+		return tokenExpect, key, nil
+	}
+
+	// HINTS:inline hints
+	hints, ok := strings.CutPrefix(line, tokenHints+tokenKeySep)
+	if ok {
+		hints = strings.TrimSpace(hints)
+		if hints == "" {
+			return "", "", fmt.Errorf("%w: no hints given", ErrInvalidSyntax)
+		}
+
+		return tokenHints, hints, nil
+	}
+
+	// HINTS sub-block
+	if strings.TrimSpace(line) == tokenHints {
+		return tokenHints, "", nil
+	}
+
+	// META:inline JSON
+	meta, ok := strings.CutPrefix(line, s.MetaKeyword+tokenKeySep)
+	if ok {
+		meta = strings.TrimSpace(meta)
+		if meta == "" {
+			return "", "", fmt.Errorf("%w: no metadata given", ErrInvalidSyntax)
+		}
+
+		return tokenMeta, meta, nil
+	}
+
 	// META
-	if strings.HasPrefix(line, tokenMeta) {
+	if strings.HasPrefix(line, s.MetaKeyword) {
 		return tokenMeta, "", nil
 	}
 
 	// --end
-	if strings.HasPrefix(line, tokenEnd) {
+	if strings.HasPrefix(line, s.EndKeyword) {
 		return tokenEnd, "", nil
 	}
 
@@ -187,7 +813,7 @@ func parseMeta(setID string, jsonData []byte) (QuerySetMeta, error) {
 	var parsed QuerySetMeta
 
 	if err := json.Unmarshal(jsonData, &parsed); err != nil {
-		return QuerySetMeta{}, fmt.Errorf("%w: %s", ErrInvalidSyntax, err.Error())
+		return QuerySetMeta{}, fmt.Errorf("%w: %s%s", ErrInvalidSyntax, err.Error(), jsonErrorContext(jsonData, err))
 	}
 
 	if parsed.ID != "" {
@@ -199,6 +825,207 @@ func parseMeta(setID string, jsonData []byte) (QuerySetMeta, error) {
 	}
 
 	meta.Description = parsed.Description
+	meta.NameI18n = parsed.NameI18n
+	meta.DescriptionI18n = parsed.DescriptionI18n
+	meta.Owner = parsed.Owner
+	meta.Dialect = parsed.Dialect
+	if !meta.Dialect.known() {
+		return QuerySetMeta{}, fmt.Errorf("%w: %w %q", ErrInvalidSyntax, ErrUnknownDialect, meta.Dialect)
+	}
+
+	meta.Tags = parsed.Tags
+	meta.Template = parsed.Template
 
 	return meta, nil
 }
+
+// jsonErrorContext returns the JSON around the offset of a syntax or type error,
+// to locate it in long or wrapped metadata.
+func jsonErrorContext(data []byte, err error) string {
+	var (
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+		offset    int64
+	)
+
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		offset = typeErr.Offset
+	default:
+		return ""
+	}
+
+	start, end := max(offset-jsonContextLen, 0), min(offset+jsonContextLen, int64(len(data)))
+
+	return fmt.Sprintf(" near %q", strings.ReplaceAll(string(data[start:end]), lineEnding, " "))
+}
+
+// jsonContextLen is the number of bytes shown before and after a JSON error.
+const jsonContextLen = 20
+
+// parseIDList parses an "ID, OtherID" list of the --ALIAS and --requires directives.
+func parseIDList(decl string) ([]string, error) {
+	var ids []string
+
+	for id := range strings.SplitSeq(decl, ",") {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			return nil, fmt.Errorf("%w: empty ID in %q", ErrInvalidSyntax, decl)
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// parseParams parses a "name type, name type = default" parameters declaration.
+// Commas inside parentheses, as in numeric(10,2), or quotes do not separate parameters.
+func parseParams(decl string) ([]QueryParam, error) {
+	var (
+		params []QueryParam
+		depth  int
+		quoted bool
+		start  int
+	)
+
+	for i := 0; i <= len(decl); i++ {
+		if i < len(decl) {
+			switch {
+			case decl[i] == '\'':
+				quoted = !quoted
+				continue
+			case quoted:
+				continue
+			case decl[i] == '(':
+				depth++
+				continue
+			case decl[i] == ')':
+				depth--
+				continue
+			case decl[i] != ',' || depth > 0:
+				continue
+			}
+		}
+
+		param, err := parseParam(decl[start:i])
+		if err != nil {
+			return nil, err
+		}
+
+		params = append(params, param)
+		start = i + 1
+	}
+
+	return params, nil
+}
+
+// parseParam parses a "name type = default" parameter declaration, the type and default being optional.
+func parseParam(decl string) (QueryParam, error) {
+	decl, def, hasDefault := strings.Cut(decl, "=")
+
+	fields := strings.Fields(decl)
+	if len(fields) == 0 {
+		return QueryParam{}, fmt.Errorf("%w: empty parameter declaration", ErrInvalidSyntax)
+	}
+
+	param := QueryParam{
+		Name: fields[0],
+		Type: strings.Join(fields[1:], " "),
+	}
+
+	if hasDefault {
+		param.Default = strings.TrimSpace(def)
+		if _, ok := literalValue(param.Default); !ok {
+			return QueryParam{}, fmt.Errorf("%w: default of parameter %q is not a literal: %q",
+				ErrInvalidSyntax, param.Name, param.Default)
+		}
+	}
+
+	return param, nil
+}
+
+func parseQueryMeta(data string) (QueryMeta, error) {
+	var meta QueryMeta
+
+	if data == "" {
+		return meta, nil
+	}
+
+	if err := json.Unmarshal([]byte(data), &meta); err != nil {
+		return QueryMeta{}, fmt.Errorf("%w: %s", ErrInvalidSyntax, err.Error())
+	}
+
+	var errs []error
+
+	if meta.Retry != nil && meta.Retry.Attempts < 1 {
+		errs = append(errs, fmt.Errorf("%w: retry attempts %d, at least 1 expected", ErrInvalidSyntax, meta.Retry.Attempts))
+	}
+
+	if meta.CacheTTL < 0 {
+		errs = append(errs, fmt.Errorf("%w: negative cache TTL %s", ErrInvalidSyntax, time.Duration(meta.CacheTTL)))
+	}
+
+	switch meta.Route {
+	case "", RoutePrimary, RouteReplica:
+	default:
+		errs = append(errs, fmt.Errorf("%w: unknown route %q", ErrInvalidSyntax, meta.Route))
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return QueryMeta{}, err
+	}
+
+	return meta, nil
+}
+
+// expandVariables replaces the ${name} references in sql with their values.
+// References to unknown variables in string literals and comments are kept, e.g. in JSON templates.
+func expandVariables(sql string, vars map[string]string, dialect Dialect) (string, error) {
+	var (
+		sb  strings.Builder
+		lex = lexState{dialect: dialect}
+	)
+
+	for _, line := range strings.SplitAfter(sql, "\n") {
+		pos := 0
+
+		for {
+			start := strings.Index(line[pos:], "${")
+			if start < 0 {
+				break
+			}
+
+			start += pos
+
+			end := strings.IndexByte(line[start:], '}')
+			if end < 0 {
+				break
+			}
+
+			end += start + 1
+
+			value, ok := vars[line[start+2:end-1]]
+			if !ok {
+				at := lex
+				at.scan(line[:start], true)
+
+				if !at.inside() && !at.lineComment {
+					return "", fmt.Errorf("%q: %w", line[start+2:end-1], ErrVariableNotFound)
+				}
+
+				value = line[start:end]
+			}
+
+			sb.WriteString(line[pos:start] + value)
+			pos = end
+		}
+
+		sb.WriteString(line[pos:])
+		lex.scan(line, true)
+	}
+
+	return sb.String(), nil
+}
@@ -0,0 +1,167 @@
+package sqlset
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// placeholders holds the bind parameters referenced by a query body.
+type placeholders struct {
+	// positional is the highest $N placeholder number.
+	positional int
+	// anonymous is the number of ? placeholders.
+	anonymous int
+	// named lists the distinct :name and @name placeholders in order of appearance.
+	named []string
+}
+
+// arity returns the number of arguments the placeholders take.
+func (p placeholders) arity() int {
+	switch {
+	case p.positional > 0:
+		return p.positional
+	case len(p.named) > 0:
+		return len(p.named)
+	default:
+		return p.anonymous
+	}
+}
+
+// check reports the mismatches between the placeholders and the declared params.
+func (p placeholders) check(params []QueryParam) error {
+	if p.positional == 0 && len(p.named) > 0 {
+		declared := make([]string, len(params))
+		for i, param := range params {
+			declared[i] = param.Name
+		}
+
+		for _, name := range p.named {
+			if !slices.Contains(declared, name) {
+				return fmt.Errorf("%w: placeholder %q is not declared in %s", ErrInvalidSyntax, name, tokenParams)
+			}
+		}
+
+		for _, name := range declared {
+			if !slices.Contains(p.named, name) {
+				return fmt.Errorf("%w: parameter %q is declared in %s but not used", ErrInvalidSyntax, name, tokenParams)
+			}
+		}
+
+		return nil
+	}
+
+	if n := p.arity(); n != len(params) {
+		return fmt.Errorf(
+			"%w: %d parameters declared in %s, but the query takes %d",
+			ErrInvalidSyntax, len(params), tokenParams, n,
+		)
+	}
+
+	return nil
+}
+
+// findPlaceholders scans the query body for bind parameters: $N, ? and :name or @name,
+// skipping string literals, quoted identifiers, dollar-quoted strings, comments and :: casts.
+// A ? counts only when there are no $N placeholders, as in Postgres it is a jsonb operator.
+//
+//nolint:gocognit,gocyclo
+func findPlaceholders(sql string) placeholders {
+	var p placeholders
+
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+
+		switch {
+		case c == '\'' || c == '"':
+			j := strings.IndexByte(sql[i+1:], c)
+			if j < 0 {
+				i = len(sql)
+
+				continue
+			}
+
+			i += j + 1
+		case c == '-' && strings.HasPrefix(sql[i:], "--"):
+			j := strings.IndexByte(sql[i:], '\n')
+			if j < 0 {
+				i = len(sql)
+
+				continue
+			}
+
+			i += j
+		case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			j := strings.Index(sql[i+2:], "*/")
+			if j < 0 {
+				i = len(sql)
+
+				continue
+			}
+
+			i += j + 3
+		case c == '$':
+			j := i + 1
+			for j < len(sql) && '0' <= sql[j] && sql[j] <= '9' {
+				j++
+			}
+
+			if j > i+1 {
+				n, _ := strconv.Atoi(sql[i+1 : j])
+				p.positional = max(p.positional, n)
+				i = j - 1
+
+				continue
+			}
+
+			// Dollar-quoted string: $$...$$ or $tag$...$tag$.
+			for j < len(sql) && isIdentByte(sql[j]) {
+				j++
+			}
+
+			if j < len(sql) && sql[j] == '$' {
+				tag := sql[i : j+1]
+
+				k := strings.Index(sql[j+1:], tag)
+				if k < 0 {
+					i = len(sql)
+
+					continue
+				}
+
+				i = j + k + len(tag)
+			}
+		case c == '?':
+			p.anonymous++
+		case c == ':' && strings.HasPrefix(sql[i:], "::"):
+			// Type cast.
+			i++
+		case (c == ':' || c == '@') && i+1 < len(sql) && isIdentStart(sql[i+1]) && (i == 0 || !isIdentByte(sql[i-1])):
+			j := i + 1
+			for j < len(sql) && isIdentByte(sql[j]) {
+				j++
+			}
+
+			if name := sql[i+1 : j]; !slices.Contains(p.named, name) {
+				p.named = append(p.named, name)
+			}
+
+			i = j - 1
+		}
+	}
+
+	if p.positional > 0 {
+		p.anonymous = 0
+	}
+
+	return p
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+func isIdentByte(c byte) bool {
+	return isIdentStart(c) || ('0' <= c && c <= '9')
+}
@@ -0,0 +1,76 @@
+package sqlset
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ProviderFunc adapts a function to SQLQueriesProvider, e.g. to stub queries in tests
+// or to back them by a custom source:
+//
+//	var queries sqlset.SQLQueriesProvider = sqlset.ProviderFunc(func(setID, queryID string) (string, error) {
+//		return "SELECT 1", nil
+//	})
+//
+// Get accepts the same arguments as SQLSet.Get, a single query ID is passed with an empty set ID.
+type ProviderFunc func(setID, queryID string) (string, error)
+
+// Get calls f with the set and query IDs.
+func (f ProviderFunc) Get(ids ...string) (string, error) {
+	for i, id := range ids {
+		if id == "" {
+			return "", fmt.Errorf("%d: %w", i, ErrArgumentEmpty)
+		}
+	}
+
+	switch len(ids) {
+	case 1:
+		if setID, queryID, ok := strings.Cut(ids[0], "."); ok {
+			return f(setID, queryID)
+		}
+
+		return f("", ids[0])
+	case 2:
+		return f(ids[0], ids[1])
+	default:
+		return "", fmt.Errorf("%d: %w", len(ids), ErrInvalidArgCount)
+	}
+}
+
+// MustGet is like Get but panics if the query cannot be returned.
+func (f ProviderFunc) MustGet(ids ...string) string {
+	return Must(f.Get(ids...))
+}
+
+// Must returns the query or panics if err is not nil. It wraps the calls returning
+// a query and an error, such as Get of any provider:
+//
+//	var getUser = sqlset.Must(provider.Get("users", "GetUser"))
+func Must(query string, err error) string {
+	if err != nil {
+		panic(err)
+	}
+
+	return query
+}
+
+// RequireQueries verifies that all the "setID.queryID" keys resolve in p and returns
+// the failures joined into a single error. It uses the Require method of p if there is one,
+// as *SQLSet has, otherwise it gets every query.
+// sqlset-gen generates a VerifyQueries function calling it with all the generated keys.
+func RequireQueries(p SQLQueriesProvider, keys ...string) error {
+	if r, ok := p.(interface{ Require(keys ...string) error }); ok {
+		return r.Require(keys...)
+	}
+
+	var errs []error
+
+	for _, key := range keys {
+		if _, err := p.Get(key); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
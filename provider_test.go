@@ -0,0 +1,85 @@
+package sqlset_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/istovpets/sqlset"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProviderFunc(t *testing.T) {
+	t.Parallel()
+
+	var provider sqlset.SQLQueriesProvider = sqlset.ProviderFunc(func(setID, queryID string) (string, error) {
+		if queryID == "Missing" {
+			return "", sqlset.ErrQueryNotFound
+		}
+
+		return "SELECT '" + setID + "." + queryID + "'", nil
+	})
+
+	tests := []struct {
+		name     string
+		ids      []string
+		expected string
+		err      error
+	}{
+		{name: "set and query", ids: []string{"users", "Get"}, expected: "SELECT 'users.Get'"},
+		{name: "dotted key", ids: []string{"users.Get"}, expected: "SELECT 'users.Get'"},
+		{name: "query only", ids: []string{"Get"}, expected: "SELECT '.Get'"},
+		{name: "not found", ids: []string{"users", "Missing"}, err: sqlset.ErrQueryNotFound},
+		{name: "empty id", ids: []string{"users", ""}, err: sqlset.ErrArgumentEmpty},
+		{name: "no ids", err: sqlset.ErrInvalidArgCount},
+		{name: "too many ids", ids: []string{"a", "b", "c"}, err: sqlset.ErrInvalidArgCount},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			q, err := provider.Get(test.ids...)
+			if test.err != nil {
+				require.ErrorIs(t, err, test.err)
+				assert.Panics(t, func() { provider.MustGet(test.ids...) })
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, q)
+			assert.Equal(t, test.expected, provider.MustGet(test.ids...))
+		})
+	}
+}
+
+func TestMust(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "SELECT 1", sqlset.Must("SELECT 1", nil))
+	assert.PanicsWithError(t, "failed", func() { sqlset.Must("", errors.New("failed")) })
+}
+
+func TestRequireQueries(t *testing.T) {
+	t.Parallel()
+
+	stub := sqlset.ProviderFunc(func(setID, queryID string) (string, error) {
+		if setID != "users" {
+			return "", sqlset.ErrQuerySetNotFound
+		}
+
+		return "SELECT 1", nil
+	})
+
+	require.NoError(t, sqlset.RequireQueries(stub, "users.Get", "users.List"))
+
+	err := sqlset.RequireQueries(stub, "users.Get", "orders.Get")
+	require.ErrorIs(t, err, sqlset.ErrQuerySetNotFound)
+	assert.EqualError(t, err, "orders.Get: query set not found")
+
+	sqlSet, err := sqlset.New(testdataValidMulti)
+	require.NoError(t, err)
+
+	require.ErrorIs(t, sqlset.RequireQueries(sqlSet, "missing.Get"), sqlset.ErrQuerySetNotFound)
+}
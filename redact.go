@@ -0,0 +1,211 @@
+package sqlset
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// RedactionProfile configures how Redacted masks query text for logging.
+type RedactionProfile struct {
+	// Mask replaces the masked literals, "?" if empty.
+	Mask string
+	// KeepNumbers leaves the numeric literals unmasked.
+	KeepNumbers bool
+	// MaxInList is the number of items of IN lists kept, longer lists are collapsed
+	// to a single mask and a comment with the number of items. Zero keeps all items.
+	MaxInList int
+}
+
+// DefaultRedactionProfile masks all literals and collapses IN lists longer than 5 items.
+var DefaultRedactionProfile = RedactionProfile{Mask: "?", MaxInList: 5}
+
+// WithRedactionProfile sets the profile used by Redacted, DefaultRedactionProfile by default.
+func WithRedactionProfile(profile RedactionProfile) Option {
+	return func(cfg *config) {
+		cfg.redaction = &profile
+	}
+}
+
+// Redacted returns the query with its literal values masked and long IN lists collapsed
+// according to the redaction profile, so that the query text can be logged safely.
+// Bind parameters, identifiers and comments are kept. It returns an empty string
+// if the query does not exist.
+func (s *SQLSet) Redacted(setID, queryID string) string {
+	q, err := s.findQuery(setID, queryID)
+	if err != nil {
+		return ""
+	}
+
+	profile := DefaultRedactionProfile
+	if s.redaction != nil {
+		profile = *s.redaction
+	}
+
+	return Redact(q.sql, profile)
+}
+
+// Redact masks the literal values and collapses the long IN lists of sql according to profile.
+//
+//nolint:gocognit,gocyclo
+func Redact(sql string, profile RedactionProfile) string {
+	mask := profile.Mask
+	if mask == "" {
+		mask = "?"
+	}
+
+	var sb strings.Builder
+
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+
+		switch {
+		case c == '\'':
+			// String literals, dropping the E'', N'', X'' and B'' prefixes.
+			if i > 0 && strings.IndexByte("EeNnXxBb", sql[i-1]) >= 0 && (i == 1 || !isIdentByte(sql[i-2])) {
+				s := sb.String()
+				sb.Reset()
+				sb.WriteString(s[:len(s)-1])
+			}
+
+			for i++; i < len(sql); i++ {
+				if sql[i] == '\'' {
+					if i+1 < len(sql) && sql[i+1] == '\'' {
+						i++
+
+						continue
+					}
+
+					break
+				}
+			}
+
+			sb.WriteString(mask)
+		case c == '"':
+			j := strings.IndexByte(sql[i+1:], '"')
+			if j < 0 {
+				j = len(sql) - i - 1
+			}
+
+			sb.WriteString(sql[i : i+j+2])
+			i += j + 1
+		case c == '-' && strings.HasPrefix(sql[i:], "--"):
+			j := strings.IndexByte(sql[i:], '\n')
+			if j < 0 {
+				j = len(sql) - i
+			}
+
+			sb.WriteString(sql[i : i+j])
+			i += j - 1
+		case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			j := strings.Index(sql[i+2:], "*/")
+			if j < 0 {
+				j = len(sql) - i - 4
+			}
+
+			sb.WriteString(sql[i : i+j+4])
+			i += j + 3
+		case c == '$' && isDollarQuote(sql[i:]):
+			tag, _ := dollarTag(sql[i:])
+
+			sb.WriteString(mask)
+
+			j := strings.Index(sql[i+len(tag):], tag)
+			if j < 0 {
+				return sb.String()
+			}
+
+			i += 2*len(tag) + j - 1
+		case c == '$' || c == ':' || c == '@' || isIdentStart(c) || c >= utf8.RuneSelf:
+			// Identifiers and bind parameters, with the digits they contain.
+			j := i + 1
+			for j < len(sql) && (isIdentByte(sql[j]) || sql[j] >= utf8.RuneSelf) {
+				j++
+			}
+
+			sb.WriteString(sql[i:j])
+			i = j - 1
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(sql) && sql[i+1] >= '0' && sql[i+1] <= '9':
+			j := i + 1
+			for j < len(sql) && (isIdentByte(sql[j]) || sql[j] == '.') {
+				j++
+			}
+
+			if profile.KeepNumbers {
+				sb.WriteString(sql[i:j])
+			} else {
+				sb.WriteString(mask)
+			}
+
+			i = j - 1
+		default:
+			sb.WriteByte(c)
+		}
+	}
+
+	if profile.MaxInList <= 0 {
+		return sb.String()
+	}
+
+	return collapseInLists(sb.String(), mask, profile.MaxInList)
+}
+
+var (
+	inList   = regexp.MustCompile(`(?i)\bIN\s*\(`)
+	subquery = regexp.MustCompile(`(?i)^\s*(SELECT|WITH|VALUES)\b`)
+)
+
+// collapseInLists replaces the items of the IN lists longer than maxItems
+// by mask and a comment with the number of items.
+func collapseInLists(sql, mask string, maxItems int) string {
+	var sb strings.Builder
+
+	for {
+		loc := inList.FindStringIndex(sql)
+		if loc == nil {
+			sb.WriteString(sql)
+
+			return sb.String()
+		}
+
+		sb.WriteString(sql[:loc[1]])
+		sql = sql[loc[1]:]
+
+		depth, items, end := 0, 1, -1
+
+	scan:
+		for i := 0; i < len(sql); i++ {
+			switch sql[i] {
+			case '(':
+				depth++
+			case ')':
+				if depth == 0 {
+					end = i
+
+					break scan
+				}
+
+				depth--
+			case ',':
+				if depth == 0 {
+					items++
+				}
+			}
+		}
+
+		if end < 0 || items <= maxItems || subquery.MatchString(sql[:end]) {
+			continue
+		}
+
+		sb.WriteString(mask + " /* " + strconv.Itoa(items) + " values */")
+		sql = sql[end:]
+	}
+}
+
+// isDollarQuote reports whether s starts with a dollar-quoted string.
+func isDollarQuote(s string) bool {
+	_, ok := dollarTag(s)
+
+	return ok
+}
@@ -0,0 +1,82 @@
+package sqlset_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/istovpets/sqlset"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedact(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		sql      string
+		profile  sqlset.RedactionProfile
+		expected string
+	}{
+		{
+			name:     "literals",
+			sql:      "SELECT * FROM users WHERE email = 'john@example.com' AND age > 42 AND note = E'it''s' AND id = $1",
+			profile:  sqlset.DefaultRedactionProfile,
+			expected: "SELECT * FROM users WHERE email = ? AND age > ? AND note = ? AND id = $1",
+		},
+		{
+			name:     "identifiers, comments and casts",
+			sql:      "SELECT \"col'1\", t2.x FROM t2 -- it's 42\nWHERE y = :name::int4 /* 'z' */",
+			profile:  sqlset.DefaultRedactionProfile,
+			expected: "SELECT \"col'1\", t2.x FROM t2 -- it's 42\nWHERE y = :name::int4 /* 'z' */",
+		},
+		{
+			name:     "dollar quoted",
+			sql:      "SELECT $tag$secret$tag$, $$x$$, $2",
+			profile:  sqlset.RedactionProfile{Mask: "***"},
+			expected: "SELECT ***, ***, $2",
+		},
+		{
+			name:     "keep numbers",
+			sql:      "SELECT * FROM t WHERE a = 1.5 AND b = 'x' LIMIT 10",
+			profile:  sqlset.RedactionProfile{KeepNumbers: true},
+			expected: "SELECT * FROM t WHERE a = 1.5 AND b = ? LIMIT 10",
+		},
+		{
+			name:     "long in lists",
+			sql:      "SELECT * FROM t WHERE a IN (1, 2, 3) AND b in ('a', 'b', 'c', 'd') AND c IN (SELECT f(1, 2, 3) FROM u)",
+			profile:  sqlset.RedactionProfile{MaxInList: 3},
+			expected: "SELECT * FROM t WHERE a IN (?, ?, ?) AND b in (? /* 4 values */) AND c IN (SELECT f(?, ?, ?) FROM u)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.expected, sqlset.Redact(tt.sql, tt.profile))
+		})
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"users.sql": {Data: []byte("--SQL:GetActive\nSELECT * FROM users WHERE status = 'active' AND id IN (1, 2, 3, 4, 5, 6);\n--end\n")},
+	}
+
+	sets, err := sqlset.New(fsys)
+	require.NoError(t, err)
+
+	assert.Equal(t, "SELECT * FROM users WHERE status = ? AND id IN (? /* 6 values */);", sets.Redacted("users", "GetActive"))
+	assert.Empty(t, sets.Redacted("users", "Missing"))
+
+	sets, err = sqlset.New(fsys, sqlset.WithRedactionProfile(sqlset.RedactionProfile{Mask: "<redacted>", KeepNumbers: true}))
+	require.NoError(t, err)
+
+	assert.Equal(t,
+		"SELECT * FROM users WHERE status = <redacted> AND id IN (1, 2, 3, 4, 5, 6);",
+		sets.Freeze().Redacted("users", "GetActive"),
+	)
+}
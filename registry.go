@@ -0,0 +1,77 @@
+package sqlset
+
+import (
+	"fmt"
+	"io/fs"
+	"sync"
+)
+
+// registry holds the trees registered with Register, loaded into one set on the first Default call.
+var registry struct {
+	mu      sync.Mutex
+	modules map[string]fs.FS
+	set     *SQLSet
+	err     error
+	loaded  bool
+}
+
+// Register adds the queries of fsys to the default set returned by Default,
+// mounted under name as with NewFromModules. It is meant to be called from init
+// functions of small apps that do not pass a provider to every constructor:
+//
+//	//go:embed queries
+//	var queriesFS embed.FS
+//
+//	func init() {
+//		sqlset.Register("users", queriesFS)
+//	}
+//
+// Register panics if name is registered twice, fsys is nil or the default set is already loaded.
+// It is safe for concurrent use.
+func Register(name string, fsys fs.FS) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	if fsys == nil {
+		panic("sqlset: Register fsys is nil")
+	}
+
+	if registry.loaded {
+		panic(fmt.Sprintf("sqlset: Register %s after the default set is loaded", name))
+	}
+
+	if _, ok := registry.modules[name]; ok {
+		panic(fmt.Sprintf("sqlset: Register called twice for %s", name))
+	}
+
+	if registry.modules == nil {
+		registry.modules = make(map[string]fs.FS)
+	}
+
+	registry.modules[name] = fsys
+}
+
+// LoadDefault returns the default set of the trees added with Register,
+// loading it on the first call. Later calls return the same set or error.
+// It is safe for concurrent use.
+func LoadDefault() (*SQLSet, error) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	if !registry.loaded {
+		registry.set, registry.err = NewFromModules(registry.modules)
+		registry.loaded = true
+	}
+
+	return registry.set, registry.err
+}
+
+// Default is like LoadDefault but panics if the registered trees fail to load.
+func Default() *SQLSet {
+	set, err := LoadDefault()
+	if err != nil {
+		panic(err)
+	}
+
+	return set
+}
@@ -0,0 +1,57 @@
+package sqlset_test
+
+import (
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/istovpets/sqlset"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefault(t *testing.T) {
+	t.Parallel()
+
+	sqlset.Register("users", fstest.MapFS{
+		"accounts.sql": {Data: []byte("--SQL:Get\nSELECT 1;\n--end\n")},
+	})
+	sqlset.Register("billing", fstest.MapFS{
+		"invoices.sql": {Data: []byte("--SQL:Get\nSELECT 2;\n--end\n")},
+	})
+
+	assert.PanicsWithValue(t, "sqlset: Register called twice for users", func() {
+		sqlset.Register("users", fstest.MapFS{})
+	})
+	assert.Panics(t, func() {
+		sqlset.Register("nil", nil)
+	})
+
+	var (
+		wg   sync.WaitGroup
+		sets [8]*sqlset.SQLSet
+	)
+
+	for i := range sets {
+		wg.Go(func() {
+			sets[i] = sqlset.Default()
+		})
+	}
+
+	wg.Wait()
+
+	for _, set := range sets {
+		assert.Same(t, sets[0], set)
+	}
+
+	assert.Equal(t, "SELECT 1;", sets[0].MustGet("users/accounts", "Get"))
+	assert.Equal(t, "SELECT 2;", sets[0].MustGet("billing/invoices", "Get"))
+
+	set, err := sqlset.LoadDefault()
+	require.NoError(t, err)
+	assert.Same(t, sets[0], set)
+
+	assert.PanicsWithValue(t, "sqlset: Register late after the default set is loaded", func() {
+		sqlset.Register("late", fstest.MapFS{})
+	})
+}
@@ -0,0 +1,161 @@
+package sqlset
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ReloadOption configures a Reloader.
+type ReloadOption func(*Reloader)
+
+// WithDebounce sets the quiet period after a change before reloading, so a burst
+// of changes, e.g. an editor saving several files, is loaded once. Default is 100ms.
+func WithDebounce(d time.Duration) ReloadOption {
+	return func(r *Reloader) {
+		r.debounce = d
+	}
+}
+
+// WithMaxDelay bounds the time between the first change of a burst and the reload,
+// so continuous changes do not postpone it forever. Default is one second, 0 disables it.
+func WithMaxDelay(d time.Duration) ReloadOption {
+	return func(r *Reloader) {
+		r.maxDelay = d
+	}
+}
+
+// WithReloadHook sets a function called after every reload with the generation of the provider,
+// and the error if the reload failed. It is called once per swap, in generation order, so it can
+// invalidate the caches derived from the queries.
+func WithReloadHook(hook func(generation uint64, err error)) ReloadOption {
+	return func(r *Reloader) {
+		r.hook = hook
+	}
+}
+
+// Reloader reloads the queries served by a SwappableProvider when they change.
+// The changes are reported with Notify, e.g. by a file system watcher, and Run reloads
+// once per burst of changes. A reload loads the whole catalog and swaps it in one step,
+// so lookups never see a partial reload; a failed reload keeps the previous queries:
+//
+//	provider := sqlset.NewSwappableProvider(sqlSet.Freeze())
+//	reloader := sqlset.NewReloader(provider, func(context.Context) (*sqlset.SQLSet, error) {
+//		return sqlset.New(os.DirFS("queries"))
+//	}, sqlset.WithReloadHook(func(gen uint64, err error) {
+//		if err == nil {
+//			statements.Invalidate()
+//		}
+//	}))
+//
+//	go reloader.Run(ctx)
+//
+//	for range watcher.Events {
+//		reloader.Notify()
+//	}
+type Reloader struct {
+	provider *SwappableProvider
+	load     func(ctx context.Context) (*SQLSet, error)
+	debounce time.Duration
+	maxDelay time.Duration
+	hook     func(generation uint64, err error)
+	changes  chan struct{}
+
+	// mu serializes the reloads.
+	mu sync.Mutex
+}
+
+// NewReloader returns a reloader swapping the sets returned by load into provider.
+func NewReloader(
+	provider *SwappableProvider, load func(ctx context.Context) (*SQLSet, error), opts ...ReloadOption,
+) *Reloader {
+	r := &Reloader{
+		provider: provider,
+		load:     load,
+		debounce: 100 * time.Millisecond,
+		maxDelay: time.Second,
+		hook:     func(uint64, error) {},
+		changes:  make(chan struct{}, 1),
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// Notify reports a change to be reloaded by Run. It does not block.
+func (r *Reloader) Notify() {
+	select {
+	case r.changes <- struct{}{}:
+	default:
+	}
+}
+
+// Run reloads the queries after every burst of changes until ctx is done,
+// then returns ctx.Err(). The reload errors are reported to the reload hook.
+func (r *Reloader) Run(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-r.changes:
+		}
+
+		if err := r.settle(ctx); err != nil {
+			return err
+		}
+
+		_, _ = r.Reload(ctx)
+	}
+}
+
+// settle waits for the end of a burst of changes: the debounce period without changes,
+// or the max delay since the first one.
+func (r *Reloader) settle(ctx context.Context) error {
+	deadline := time.Now().Add(r.maxDelay)
+
+	timer := time.NewTimer(r.wait(deadline))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-r.changes:
+			timer.Reset(r.wait(deadline))
+		case <-timer.C:
+			return nil
+		}
+	}
+}
+
+// wait returns the time to wait for further changes before reloading.
+func (r *Reloader) wait(deadline time.Time) time.Duration {
+	if r.maxDelay <= 0 {
+		return r.debounce
+	}
+
+	return max(min(r.debounce, time.Until(deadline)), 0)
+}
+
+// Reload loads the queries now and swaps them into the provider, returning its new generation.
+// On error, the provider keeps serving the previous queries and its generation is returned.
+func (r *Reloader) Reload(ctx context.Context) (uint64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sqlSet, err := r.load(ctx)
+	if err != nil {
+		gen := r.provider.Generation()
+		r.hook(gen, err)
+
+		return gen, err
+	}
+
+	_, gen := r.provider.swap(sqlSet.Freeze())
+	r.hook(gen, nil)
+
+	return gen, nil
+}
@@ -0,0 +1,152 @@
+package sqlset_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/istovpets/sqlset"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// reloadRecorder loads sets returning the number of loads and records the reload hook calls.
+type reloadRecorder struct {
+	loads atomic.Int32
+	fail  atomic.Bool
+
+	mu    sync.Mutex
+	gens  []uint64
+	errs  []error
+	calls chan struct{}
+}
+
+func newReloadRecorder() *reloadRecorder {
+	return &reloadRecorder{calls: make(chan struct{}, 100)}
+}
+
+func (r *reloadRecorder) load(context.Context) (*sqlset.SQLSet, error) {
+	n := r.loads.Add(1)
+	if r.fail.Load() {
+		return nil, errors.New("broken file")
+	}
+
+	qs := sqlset.NewQuerySet(sqlset.QuerySetMeta{ID: "users"})
+	if err := qs.Register("GetUser", "SELECT "+string(rune('0'+n))+";"); err != nil {
+		return nil, err
+	}
+
+	sqlSet := &sqlset.SQLSet{}
+
+	return sqlSet, sqlSet.AddSet(qs)
+}
+
+func (r *reloadRecorder) hook(gen uint64, err error) {
+	r.mu.Lock()
+	r.gens = append(r.gens, gen)
+	r.errs = append(r.errs, err)
+	r.mu.Unlock()
+
+	r.calls <- struct{}{}
+}
+
+func TestReloader_Debounce(t *testing.T) {
+	t.Parallel()
+
+	rec := newReloadRecorder()
+	provider := sqlset.NewSwappableProvider((&sqlset.SQLSet{}).Freeze())
+	reloader := sqlset.NewReloader(provider, rec.load,
+		sqlset.WithDebounce(50*time.Millisecond), sqlset.WithMaxDelay(0), sqlset.WithReloadHook(rec.hook))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+
+	go func() {
+		done <- reloader.Run(ctx)
+	}()
+
+	// An editor save burst is reloaded once.
+	for range 5 {
+		reloader.Notify()
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	<-rec.calls
+	time.Sleep(100 * time.Millisecond)
+
+	assert.Equal(t, int32(1), rec.loads.Load())
+	assert.Equal(t, uint64(1), provider.Generation())
+	assert.Equal(t, "SELECT 1;", provider.MustGet("users.GetUser"))
+
+	// A failed reload keeps the queries and the generation.
+	rec.fail.Store(true)
+	reloader.Notify()
+	<-rec.calls
+
+	assert.Equal(t, uint64(1), provider.Generation())
+	assert.Equal(t, "SELECT 1;", provider.MustGet("users.GetUser"))
+
+	cancel()
+	require.ErrorIs(t, <-done, context.Canceled)
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	assert.Equal(t, []uint64{1, 1}, rec.gens)
+	require.Len(t, rec.errs, 2)
+	require.NoError(t, rec.errs[0])
+	require.EqualError(t, rec.errs[1], "broken file")
+}
+
+func TestReloader_MaxDelay(t *testing.T) {
+	t.Parallel()
+
+	rec := newReloadRecorder()
+	provider := sqlset.NewSwappableProvider((&sqlset.SQLSet{}).Freeze())
+	reloader := sqlset.NewReloader(provider, rec.load,
+		sqlset.WithDebounce(time.Second), sqlset.WithMaxDelay(50*time.Millisecond), sqlset.WithReloadHook(rec.hook))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		_ = reloader.Run(ctx)
+	}()
+
+	// Continuous changes are reloaded by the deadline, long before the debounce period ends.
+	stop := time.After(500 * time.Millisecond)
+
+	for reloaded := false; !reloaded; {
+		reloader.Notify()
+
+		select {
+		case <-rec.calls:
+			reloaded = true
+		case <-stop:
+			t.Fatal("no reload before the max delay")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+
+	assert.Equal(t, uint64(1), provider.Generation())
+}
+
+func TestReloader_Reload(t *testing.T) {
+	t.Parallel()
+
+	rec := newReloadRecorder()
+	provider := sqlset.NewSwappableProvider((&sqlset.SQLSet{}).Freeze())
+	reloader := sqlset.NewReloader(provider, rec.load)
+
+	gen, err := reloader.Reload(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), gen)
+
+	gen, err = reloader.Reload(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), gen)
+	assert.Equal(t, "SELECT 2;", provider.MustGet("users.GetUser"))
+}
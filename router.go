@@ -0,0 +1,131 @@
+package sqlset
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// RouterProvider is an SQLQueriesProvider serving each query set from its own provider,
+// e.g. most sets from the embedded files and a fast-changing one from a remote registry:
+//
+//	queries := sqlset.NewRouterProvider(map[string]sqlset.SQLQueriesProvider{
+//		"pricing": sqlset.NewCachingProvider(registryProvider),
+//	}, embeddedSQLSet)
+//
+// The sets not in the routing table are served by the fallback provider.
+// It is safe for concurrent use if the providers are.
+type RouterProvider struct {
+	routes   map[string]SQLQueriesProvider
+	fallback SQLQueriesProvider
+}
+
+// NewRouterProvider returns a provider routing the queries by set ID with routes.
+// fallback serves the other sets, it may be nil to serve only the routed ones.
+// A single query ID without a set ID is always looked up in fallback.
+func NewRouterProvider(routes map[string]SQLQueriesProvider, fallback SQLQueriesProvider) *RouterProvider {
+	return &RouterProvider{routes: maps.Clone(routes), fallback: fallback}
+}
+
+// Get returns a query from the provider of its set.
+// It accepts the same arguments as SQLSet.Get, the routed providers are called with the set and query IDs.
+// A single "setID.queryID" key is routed by the longest routed set ID it starts with, so prefixed sets
+// such as "vendor.users" are routed as a whole; other single keys are passed unsplit to fallback,
+// which resolves them as it does for its own callers, e.g. with WithKeySeparator.
+func (p *RouterProvider) Get(ids ...string) (string, error) {
+	if len(ids) != 1 || ids[0] == "" {
+		return ProviderFunc(p.get).Get(ids...)
+	}
+
+	key := ids[0]
+
+	for i := strings.LastIndex(key, "."); i > 0; i = strings.LastIndex(key[:i], ".") {
+		if src, ok := p.routes[key[:i]]; ok {
+			return src.Get(key[:i], key[i+1:])
+		}
+	}
+
+	if p.fallback == nil {
+		return "", fmt.Errorf("%s: %w", key, ErrQuerySetNotFound)
+	}
+
+	return p.fallback.Get(key)
+}
+
+// MustGet is like Get but panics if the query cannot be returned.
+func (p *RouterProvider) MustGet(ids ...string) string {
+	return Must(p.Get(ids...))
+}
+
+func (p *RouterProvider) get(setID, queryID string) (string, error) {
+	src, err := p.provider(setID)
+	if err != nil {
+		return "", err
+	}
+
+	if setID == "" {
+		return src.Get(queryID)
+	}
+
+	return src.Get(setID, queryID)
+}
+
+// provider returns the provider of the set.
+func (p *RouterProvider) provider(setID string) (SQLQueriesProvider, error) {
+	if src, ok := p.routes[setID]; ok {
+		return src, nil
+	}
+
+	if p.fallback == nil {
+		return nil, fmt.Errorf("%s: %w", setID, ErrQuerySetNotFound)
+	}
+
+	return p.fallback, nil
+}
+
+// GetSetsMetas returns the metadata of the routed sets and of the fallback sets, sorted by ID,
+// from the providers implementing SQLSetsProvider. A routed set is taken from its provider only.
+func (p *RouterProvider) GetSetsMetas() []QuerySetMeta {
+	var metas []QuerySetMeta
+
+	for _, setID := range slices.Sorted(maps.Keys(p.routes)) {
+		if sets, ok := p.routes[setID].(SQLSetsProvider); ok {
+			for _, meta := range sets.GetSetsMetas() {
+				if meta.ID == setID {
+					metas = append(metas, meta)
+				}
+			}
+		}
+	}
+
+	if sets, ok := p.fallback.(SQLSetsProvider); ok {
+		for _, meta := range sets.GetSetsMetas() {
+			if _, routed := p.routes[meta.ID]; !routed {
+				metas = append(metas, meta)
+			}
+		}
+	}
+
+	slices.SortFunc(metas, func(a, b QuerySetMeta) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return metas
+}
+
+// GetQueryIDs returns the query IDs of a set from its provider, which must implement SQLSetsProvider.
+func (p *RouterProvider) GetQueryIDs(setID string) ([]string, error) {
+	src, err := p.provider(setID)
+	if err != nil {
+		return nil, err
+	}
+
+	sets, ok := src.(SQLSetsProvider)
+	if !ok {
+		return nil, fmt.Errorf("%s: provider %T does not list queries: %w", setID, src, ErrQuerySetNotFound)
+	}
+
+	return sets.GetQueryIDs(setID)
+}
@@ -0,0 +1,110 @@
+package sqlset_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/istovpets/sqlset"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouterProvider(t *testing.T) {
+	t.Parallel()
+
+	embedded, err := sqlset.New(fstest.MapFS{
+		"users.sql":   &fstest.MapFile{Data: []byte("--SQL:GetUser\nSELECT 1;\n--end\n")},
+		"pricing.sql": &fstest.MapFile{Data: []byte("--SQL:GetPrice\nSELECT 'stale';\n--end\n")},
+	})
+	require.NoError(t, err)
+
+	remote, err := sqlset.New(fstest.MapFS{
+		"pricing.sql": &fstest.MapFile{Data: []byte("--SQL:GetPrice\nSELECT 'fresh';\n--end\n")},
+		"other.sql":   &fstest.MapFile{Data: []byte("--SQL:Ping\nSELECT 2;\n--end\n")},
+	})
+	require.NoError(t, err)
+
+	vendor, err := sqlset.New(fstest.MapFS{
+		"users.sql":   &fstest.MapFile{Data: []byte("--SQL:GetUser\nSELECT 'vendor';\n--end\n")},
+		"pricing.sql": &fstest.MapFile{Data: []byte("--SQL:GetPrice\nSELECT 'vendor fresh';\n--end\n")},
+	})
+	require.NoError(t, err)
+	require.NoError(t, embedded.MergeWithPrefix(vendor, "vendor"))
+	require.NoError(t, remote.MergeWithPrefix(vendor, "vendor"))
+
+	var calls []string
+
+	audit := sqlset.ProviderFunc(func(setID, queryID string) (string, error) {
+		calls = append(calls, setID+"/"+queryID)
+
+		return "SELECT 'audit'", nil
+	})
+
+	router := sqlset.NewRouterProvider(map[string]sqlset.SQLQueriesProvider{
+		"pricing":        remote,
+		"vendor.pricing": remote,
+		"audit":          audit,
+	}, embedded)
+
+	tests := []struct {
+		name    string
+		ids     []string
+		want    string
+		wantErr error
+	}{
+		{name: "routed", ids: []string{"pricing", "GetPrice"}, want: "SELECT 'fresh';"},
+		{name: "routed dot notation", ids: []string{"pricing.GetPrice"}, want: "SELECT 'fresh';"},
+		{name: "fallback", ids: []string{"users", "GetUser"}, want: "SELECT 1;"},
+		{name: "fallback prefixed set", ids: []string{"vendor.users.GetUser"}, want: "SELECT 'vendor';"},
+		{name: "routed prefixed set", ids: []string{"vendor.pricing.GetPrice"}, want: "SELECT 'vendor fresh';"},
+		{name: "fallback query ID", ids: []string{"GetUser"}, wantErr: sqlset.ErrRequiredArgMissing},
+		{name: "routed func", ids: []string{"audit.Log"}, want: "SELECT 'audit'"},
+		{name: "not in fallback", ids: []string{"other", "Ping"}, wantErr: sqlset.ErrQuerySetNotFound},
+		{name: "empty", ids: []string{"pricing", ""}, wantErr: sqlset.ErrArgumentEmpty},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := router.Get(tt.ids...)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, q)
+		})
+	}
+
+	assert.Equal(t, []string{"audit/Log"}, calls)
+
+	metas := router.GetSetsMetas()
+	require.Len(t, metas, 4)
+	assert.Equal(t, "pricing", metas[0].ID)
+	assert.Equal(t, "users", metas[1].ID)
+	assert.Equal(t, "vendor.pricing", metas[2].ID)
+	assert.Equal(t, "vendor.users", metas[3].ID)
+
+	ids, err := router.GetQueryIDs("pricing")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"GetPrice"}, ids)
+
+	_, err = router.GetQueryIDs("audit")
+	require.ErrorIs(t, err, sqlset.ErrQuerySetNotFound)
+
+	slashed, err := sqlset.New(fstest.MapFS{
+		"users.sql": &fstest.MapFile{Data: []byte("--SQL:GetUser\nSELECT 1;\n--end\n")},
+	}, sqlset.WithKeySeparator("/"))
+	require.NoError(t, err)
+
+	q, err := sqlset.NewRouterProvider(nil, slashed).Get("users/GetUser")
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT 1;", q)
+
+	only := sqlset.NewRouterProvider(map[string]sqlset.SQLQueriesProvider{"pricing": remote}, nil)
+
+	_, err = only.Get("users", "GetUser")
+	require.ErrorIs(t, err, sqlset.ErrQuerySetNotFound)
+	assert.Panics(t, func() { only.MustGet("users.GetUser") })
+}
@@ -0,0 +1,52 @@
+package sqlset
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SafeProvider is an SQLQueriesProvider decorator for background workers that can not
+// afford panics: it recovers the panics of the source and reports every failure to
+// an error handler, and its MustGet returns an empty query instead of panicking.
+// Each consumer can wrap the same source with its own handler:
+//
+//	queries := sqlset.NewSafeProvider(sqlSet, func(key string, err error) {
+//		log.Printf("worker: query %s: %v", key, err)
+//		failures.Inc()
+//	})
+//
+// It is safe for concurrent use if the source and the handler are.
+type SafeProvider struct {
+	src     SQLQueriesProvider
+	onError func(key string, err error)
+}
+
+// NewSafeProvider returns a decorator of src reporting the failures to onError.
+// The key is the Get arguments joined with ".".
+func NewSafeProvider(src SQLQueriesProvider, onError func(key string, err error)) *SafeProvider {
+	return &SafeProvider{src: src, onError: onError}
+}
+
+// Get returns the query from the source. A panic of the source is returned
+// as an error wrapping ErrProviderPanic. Errors are reported before being returned.
+func (p *SafeProvider) Get(ids ...string) (query string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			query, err = "", fmt.Errorf("%w: %v", ErrProviderPanic, r)
+		}
+
+		if err != nil {
+			p.onError(strings.Join(ids, "."), err)
+		}
+	}()
+
+	return p.src.Get(ids...)
+}
+
+// MustGet returns the query from the source, or an empty query after reporting the failure.
+// Unlike the MustGet of other providers, it never panics.
+func (p *SafeProvider) MustGet(ids ...string) string {
+	q, _ := p.Get(ids...)
+
+	return q
+}
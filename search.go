@@ -0,0 +1,77 @@
+package sqlset
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// QueryKey identifies a query within an SQLSet.
+type QueryKey struct {
+	SetID   string `json:"set_id"`
+	QueryID string `json:"query_id"`
+}
+
+// String returns the key in the "setID.queryID" form accepted by Get.
+func (k QueryKey) String() string {
+	return k.SetID + "." + k.QueryID
+}
+
+// SearchResult is a single line of a query body matching a search.
+type SearchResult struct {
+	// Key identifies the matched query.
+	Key QueryKey `json:"key"`
+	// Line is the 1-based line number within the query body.
+	Line int `json:"line"`
+	// Text is the matched line.
+	Text string `json:"text"`
+}
+
+// Search returns all query body lines containing pattern, compared case-insensitively.
+// Results are sorted by set ID, query ID and line.
+func (s *SQLSet) Search(pattern string) []SearchResult {
+	pattern = strings.ToLower(pattern)
+
+	return s.search(func(line string) bool {
+		return strings.Contains(strings.ToLower(line), pattern)
+	})
+}
+
+// SearchRegexp returns all query body lines matching re.
+// Results are sorted by set ID, query ID and line.
+func (s *SQLSet) SearchRegexp(re *regexp.Regexp) []SearchResult {
+	return s.search(re.MatchString)
+}
+
+func (s *SQLSet) search(match func(line string) bool) []SearchResult {
+	var results []SearchResult
+
+	for setID, qs := range s.sets {
+		for queryID, q := range qs.queries {
+			for i, line := range strings.Split(q.sql, lineEnding) {
+				if match(line) {
+					results = append(results, SearchResult{
+						Key:  QueryKey{SetID: setID, QueryID: queryID},
+						Line: i + 1,
+						Text: line,
+					})
+				}
+			}
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Key != b.Key {
+			if a.Key.SetID != b.Key.SetID {
+				return a.Key.SetID < b.Key.SetID
+			}
+
+			return a.Key.QueryID < b.Key.QueryID
+		}
+
+		return a.Line < b.Line
+	})
+
+	return results
+}
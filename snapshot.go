@@ -0,0 +1,129 @@
+package sqlset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// snapshotFile is the name of the last good catalog in the snapshot directory, see LoadWithSnapshot.
+const snapshotFile = "last-good.bundle"
+
+// SnapshotInfo describes the catalog returned by LoadWithSnapshot.
+type SnapshotInfo struct {
+	// Stale is set when the catalog was loaded from the snapshot, as the remote was unavailable.
+	Stale bool
+	// SavedAt is the time the snapshot was saved, the zero time if none was.
+	SavedAt time.Time
+	// Err is the error of the remote load if Stale, otherwise the error saving the snapshot, if any.
+	Err error
+}
+
+// Age returns how old the snapshot is at now.
+func (i SnapshotInfo) Age(now time.Time) time.Duration {
+	return now.Sub(i.SavedAt)
+}
+
+// LoadWithSnapshot loads the catalog with load, e.g. from a remote registry, and saves it as
+// a bundle in the directory dir, so that when the remote is unavailable at startup, the last
+// good catalog is loaded from the directory instead of blocking the boot. The returned info
+// tells whether the catalog is stale and how old it is, e.g. to expose it as a metric:
+//
+//	sqlSet, info, err := sqlset.LoadWithSnapshot(ctx, "/var/cache/app/queries", loadFromRegistry,
+//		sqlset.WithRewriter(tenantRewriter))
+//	if err != nil {
+//		return err
+//	}
+//
+//	if info.Stale {
+//		log.Printf("registry unavailable, using queries saved %s ago: %v", info.Age(time.Now()), info.Err)
+//	}
+//
+// A snapshot keeps the queries and metadata only, see MarshalBundle. The stale catalog is loaded
+// with opts, see NewFromBundle: pass the retrieval options of load, e.g. WithRewriter or WithSoftDelete,
+// so that they apply during an outage too. Failing to save the snapshot does not fail the load,
+// it is reported by info.Err. An error is returned only if both load and the snapshot fail.
+func LoadWithSnapshot(
+	ctx context.Context, dir string, load func(ctx context.Context) (*SQLSet, error), opts ...Option,
+) (*SQLSet, SnapshotInfo, error) {
+	path := filepath.Join(dir, snapshotFile)
+
+	sqlSet, loadErr := load(ctx)
+	if loadErr == nil {
+		savedAt, err := saveSnapshot(sqlSet, dir, path)
+
+		return sqlSet, SnapshotInfo{SavedAt: savedAt, Err: err}, nil
+	}
+
+	stale, info, err := readSnapshot(path, opts)
+	if err != nil {
+		return nil, SnapshotInfo{}, errors.Join(fmt.Errorf("load: %w", loadErr), err)
+	}
+
+	info.Stale, info.Err = true, loadErr
+
+	return stale, info, nil
+}
+
+// saveSnapshot writes the bundle of the catalog to path atomically and returns its modification time.
+func saveSnapshot(sqlSet *SQLSet, dir, path string) (time.Time, error) {
+	data, err := sqlSet.MarshalBundle()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("save snapshot: %w", err)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return time.Time{}, fmt.Errorf("save snapshot: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, snapshotFile+".*")
+	if err != nil {
+		return time.Time{}, fmt.Errorf("save snapshot: %w", err)
+	}
+
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+
+	if err != nil {
+		return time.Time{}, fmt.Errorf("save snapshot: %w", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("save snapshot: %w", err)
+	}
+
+	return info.ModTime(), nil
+}
+
+func readSnapshot(path string, opts []Option) (*SQLSet, SnapshotInfo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, SnapshotInfo{}, fmt.Errorf("read snapshot: %w", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, SnapshotInfo{}, fmt.Errorf("read snapshot: %w", err)
+	}
+
+	sqlSet, err := NewFromBundle(data, opts...)
+	if err != nil {
+		return nil, SnapshotInfo{}, fmt.Errorf("read snapshot %s: %w", path, err)
+	}
+
+	return sqlSet, SnapshotInfo{SavedAt: info.ModTime()}, nil
+}
@@ -0,0 +1,144 @@
+package sqlset
+
+import (
+	"strings"
+)
+
+// SoftDeleteRewriter rewrites a query reading the soft-deleted table, see WithSoftDelete.
+type SoftDeleteRewriter func(sql, table string) (string, error)
+
+// clauseKeywords end the WHERE clause of a statement.
+var clauseKeywords = map[string]bool{
+	"GROUP":     true,
+	"HAVING":    true,
+	"WINDOW":    true,
+	"ORDER":     true,
+	"LIMIT":     true,
+	"OFFSET":    true,
+	"FETCH":     true,
+	"FOR":       true,
+	"RETURNING": true,
+	"UNION":     true,
+	"INTERSECT": true,
+	"EXCEPT":    true,
+}
+
+// SoftDeleteCondition returns a SoftDeleteRewriter adding the "table.column IS NULL"
+// condition to the top-level WHERE clause of the query, or a new WHERE clause if there is none:
+//
+//	SELECT * FROM users WHERE name = $1 ORDER BY id
+//	SELECT * FROM users WHERE (name = $1) AND users.deleted_at IS NULL ORDER BY id
+//
+// The table name qualifies the column, so a query aliasing the table should declare the alias instead.
+// Only the first SELECT of a compound (UNION, INTERSECT, EXCEPT) query is filtered.
+func SoftDeleteCondition(column string) SoftDeleteRewriter {
+	return func(sql, table string) (string, error) {
+		return addCondition(sql, table+"."+column+" IS NULL"), nil
+	}
+}
+
+// addCondition adds cond to the top-level WHERE clause of sql.
+func addCondition(sql, cond string) string {
+	const spaces = " \t\r\n"
+
+	where := -1
+	end := len(sql)
+
+	for _, w := range topLevelWords(sql) {
+		if w.text == "WHERE" && where < 0 {
+			where = w.end
+
+			continue
+		}
+
+		if w.text == ";" || clauseKeywords[w.text] {
+			end = w.start
+
+			break
+		}
+	}
+
+	if where < 0 {
+		head := strings.TrimRight(sql[:end], spaces)
+
+		sep := sql[len(head):end]
+		if sep == "" && end < len(sql) && sql[end] != ';' {
+			sep = " "
+		}
+
+		return head + " WHERE " + cond + sep + sql[end:]
+	}
+
+	expr := strings.TrimRight(sql[where:end], spaces)
+	sep := sql[where+len(expr) : end]
+
+	return sql[:where] + " (" + strings.TrimLeft(expr, spaces) + ") AND " + cond + sep + sql[end:]
+}
+
+type sqlWord struct {
+	text       string
+	start, end int
+}
+
+// topLevelWords returns the upper-cased words and semicolons of sql outside of
+// parentheses, quoted strings, identifiers and comments.
+//
+//nolint:gocognit
+func topLevelWords(sql string) []sqlWord {
+	var (
+		words []sqlWord
+		depth int
+	)
+
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+
+		switch {
+		case c == '\'' || c == '"':
+			j := strings.IndexByte(sql[i+1:], c)
+			if j < 0 {
+				return words
+			}
+
+			i += j + 1
+		case c == '-' && strings.HasPrefix(sql[i:], "--"):
+			j := strings.IndexByte(sql[i:], '\n')
+			if j < 0 {
+				return words
+			}
+
+			i += j
+		case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			j := strings.Index(sql[i+2:], "*/")
+			if j < 0 {
+				return words
+			}
+
+			i += j + 3
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ';' && depth == 0:
+			words = append(words, sqlWord{text: ";", start: i, end: i + 1})
+		case isWordByte(c):
+			j := i
+			for j < len(sql) && isWordByte(sql[j]) {
+				j++
+			}
+
+			if depth == 0 {
+				words = append(words, sqlWord{text: strings.ToUpper(sql[i:j]), start: i, end: j})
+			}
+
+			i = j - 1
+		}
+	}
+
+	return words
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c == '$' || c == '.' ||
+		('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
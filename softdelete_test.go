@@ -0,0 +1,113 @@
+package sqlset_test
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/istovpets/sqlset"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSoftDeleteCondition(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		sql      string
+		expected string
+	}{
+		{
+			name:     "no where",
+			sql:      "SELECT * FROM users",
+			expected: "SELECT * FROM users WHERE users.deleted_at IS NULL",
+		},
+		{
+			name:     "no where with semicolon",
+			sql:      "SELECT * FROM users;",
+			expected: "SELECT * FROM users WHERE users.deleted_at IS NULL;",
+		},
+		{
+			name:     "no where before order",
+			sql:      "SELECT *\r\nFROM users\r\nORDER BY id",
+			expected: "SELECT *\r\nFROM users WHERE users.deleted_at IS NULL\r\nORDER BY id",
+		},
+		{
+			name:     "where",
+			sql:      "SELECT * FROM users WHERE name = $1 OR email = $2 ORDER BY id;",
+			expected: "SELECT * FROM users WHERE (name = $1 OR email = $2) AND users.deleted_at IS NULL ORDER BY id;",
+		},
+		{
+			name:     "nested where and keywords are ignored",
+			sql:      "SELECT * FROM users WHERE id IN (SELECT user_id FROM orders WHERE total > 0 ORDER BY 1) AND note <> 'limit'",
+			expected: "SELECT * FROM users WHERE (id IN (SELECT user_id FROM orders WHERE total > 0 ORDER BY 1) AND note <> 'limit') AND users.deleted_at IS NULL",
+		},
+		{
+			name:     "comments are ignored",
+			sql:      "SELECT * FROM users -- where\r\n/* order */ LIMIT 10",
+			expected: "SELECT * FROM users -- where\r\n/* order */ WHERE users.deleted_at IS NULL LIMIT 10",
+		},
+		{
+			name:     "update",
+			sql:      "UPDATE users SET name = $2 WHERE id = $1 RETURNING id",
+			expected: "UPDATE users SET name = $2 WHERE (id = $1) AND users.deleted_at IS NULL RETURNING id",
+		},
+	}
+
+	rewrite := sqlset.SoftDeleteCondition("deleted_at")
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			sql, err := rewrite(test.sql, "users")
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, sql)
+		})
+	}
+}
+
+func TestWithSoftDelete(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"users.sql": &fstest.MapFile{Data: []byte(`--SQL:ListUsers
+--META: {"soft_delete_table": "users"}
+SELECT * FROM users;
+--end
+
+--SQL:ListAllUsers
+SELECT * FROM users;
+--end`)},
+	}
+
+	sqlSet, err := sqlset.New(fsys, sqlset.WithSoftDelete(sqlset.SoftDeleteCondition("deleted_at")))
+	require.NoError(t, err)
+
+	assert.Equal(t, "SELECT * FROM users WHERE users.deleted_at IS NULL;", sqlSet.MustGet("users", "ListUsers"))
+	assert.Equal(t, "SELECT * FROM users;", sqlSet.MustGet("users", "ListAllUsers"))
+
+	t.Run("disabled", func(t *testing.T) {
+		t.Parallel()
+
+		sqlSet, err := sqlset.New(fsys)
+		require.NoError(t, err)
+
+		assert.Equal(t, "SELECT * FROM users;", sqlSet.MustGet("users", "ListUsers"))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		t.Parallel()
+
+		errRewrite := errors.New("rewrite failed")
+
+		sqlSet, err := sqlset.New(fsys, sqlset.WithSoftDelete(func(string, string) (string, error) {
+			return "", errRewrite
+		}))
+		require.NoError(t, err)
+
+		_, err = sqlSet.Get("users", "ListUsers")
+		require.ErrorIs(t, err, errRewrite)
+	})
+}
@@ -3,12 +3,24 @@
 // Every file contains queries, marked with query IDs using special syntax,
 // see `testdata/valid/*.sql` files for examples.
 // Also file may contain JSON-encoded query set metadata with name and description.
+//
+// The package depends on the standard library only and builds for WASM targets and TinyGo.
+// Under TinyGo (the tinygo build tag) binary bundles are not supported, as encoding/gob is not.
 package sqlset
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"math"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
+	"text/template"
+	"time"
 )
 
 // SQLQueriesProvider is the interface for getting SQL queries.
@@ -33,7 +45,19 @@ type SQLSetsProvider interface {
 // It provides methods to access SQL queries and metadata.
 // Use New to create a new instance.
 type SQLSet struct {
-	sets map[string]QuerySet
+	sets       map[string]QuerySet
+	softDelete SoftDeleteRewriter
+	rewriters  []Rewriter
+	skipped    []SkippedFile
+	overridden []QueryKey
+	// keySep separates the set and query IDs in single-argument keys, see WithKeySeparator.
+	keySep string
+	// redaction is the profile of Redacted, see WithRedactionProfile.
+	redaction *RedactionProfile
+	// warnings reports the use of deprecated aliases, see WithWarningHandler.
+	warnings func(Warning)
+	// contextKeys resolves the context references of the queries, see WithContextKeys.
+	contextKeys map[string]any
 }
 
 // Get returns an SQL query by its identifiers.
@@ -55,25 +79,109 @@ type SQLSet struct {
 //   - any identifier is empty,
 //   - the query set or query cannot be found.
 func (s *SQLSet) Get(ids ...string) (string, error) {
+	return s.GetContext(context.Background(), ids...)
+}
+
+// GetContext is like Get but passes ctx to the rewriters, see WithRewriter.
+func (s *SQLSet) GetContext(ctx context.Context, ids ...string) (string, error) {
+	key, q, err := s.lookup(ids...)
+	if err != nil {
+		return "", err
+	}
+
+	return s.rewrite(ctx, key, q)
+}
+
+// rewrite applies the retrieval-time policies and rewriters to the query.
+// Template queries must be rendered first, see GetRendered.
+func (s *SQLSet) rewrite(ctx context.Context, key QueryKey, q query) (string, error) {
+	if q.tmpl != nil {
+		return "", fmt.Errorf("%s: %w", key, ErrTemplateQuery)
+	}
+
+	return s.apply(ctx, key, q, q.sql)
+}
+
+// apply applies the retrieval-time policies and rewriters to sql, the body of q.
+func (s *SQLSet) apply(ctx context.Context, key QueryKey, q query, sql string) (string, error) {
+	sql = expandContextRefs(ctx, sql, s.contextKeys)
+
+	if s.softDelete != nil && q.meta.SoftDeleteTable != "" {
+		var err error
+
+		sql, err = s.softDelete(sql, q.meta.SoftDeleteTable)
+		if err != nil {
+			return "", fmt.Errorf("%s: soft delete: %w", key, err)
+		}
+	}
+
+	for _, rewrite := range s.rewriters {
+		var err error
+
+		sql, err = rewrite(ctx, key, sql)
+		if err != nil {
+			return "", fmt.Errorf("%s: rewrite: %w", key, err)
+		}
+	}
+
+	return sql, nil
+}
+
+// lookup validates the Get arguments and finds the query.
+func (s *SQLSet) lookup(ids ...string) (QueryKey, query, error) {
 	for i, id := range ids {
 		if id == "" {
-			return "", fmt.Errorf("%d: %w", i, ErrArgumentEmpty)
+			return QueryKey{}, query{}, fmt.Errorf("%d: %w", i, ErrArgumentEmpty)
 		}
 	}
 
 	l := len(ids)
 	if l == 0 || l > 2 {
-		return "", fmt.Errorf("%d: %w", l, ErrInvalidArgCount)
+		return QueryKey{}, query{}, fmt.Errorf("%d: %w", l, ErrInvalidArgCount)
 	}
 
 	if l == 1 {
-		left, right, ok := strings.Cut(ids[0], ".")
-		if ok {
-			ids = []string{left, right}
+		if setID, queryID, ok := s.splitKey(ids[0]); ok {
+			ids = []string{setID, queryID}
+		}
+	}
+
+	q, err := s.findQuery(ids...)
+	if err != nil {
+		return QueryKey{}, query{}, err
+	}
+
+	key := QueryKey{QueryID: ids[len(ids)-1]}
+
+	if len(ids) == 2 {
+		key.SetID = ids[0]
+	} else {
+		// The only set.
+		for setID := range s.sets {
+			key.SetID = setID
 		}
 	}
 
-	return s.findQuery(ids...)
+	return key, q, nil
+}
+
+// splitKey splits a "setID.queryID" key at the key separator, a dot unless
+// given with WithKeySeparator. Set IDs may contain the separator, e.g. the prefixed
+// sets of MergeWithPrefix, so the key is split at the last separator preceded by
+// an existing set ID, or at the first separator when there is none.
+func (s *SQLSet) splitKey(key string) (setID, queryID string, ok bool) {
+	sep := s.keySep
+	if sep == "" {
+		sep = "."
+	}
+
+	for i := strings.LastIndex(key, sep); i > 0; i = strings.LastIndex(key[:i], sep) {
+		if _, exists := s.sets[key[:i]]; exists {
+			return key[:i], key[i+len(sep):], true
+		}
+	}
+
+	return strings.Cut(key, sep)
 }
 
 // MustGet is like Get but panics if the query set or query is not found.
@@ -87,6 +195,42 @@ func (s *SQLSet) MustGet(ids ...string) string {
 	return q
 }
 
+// TryGet is like Get but reports whether the query was returned instead of an error,
+// for optional queries such as dialect-specific optimizations.
+// Rewriter failures report false as well.
+func (s *SQLSet) TryGet(setID, queryID string) (string, bool) {
+	q, err := s.Get(setID, queryID)
+	if err != nil {
+		return "", false
+	}
+
+	return q, true
+}
+
+// GetOr returns the query or fallback if it cannot be returned, see TryGet.
+func (s *SQLSet) GetOr(setID, queryID, fallback string) string {
+	if q, ok := s.TryGet(setID, queryID); ok {
+		return q
+	}
+
+	return fallback
+}
+
+// Require verifies that all the queries exist, given as "setID.queryID" keys
+// such as the constants generated by sqlset-gen, and returns all the missing ones
+// joined into a single error. Call it at startup to fail fast instead of at first use.
+func (s *SQLSet) Require(keys ...string) error {
+	var errs []error
+
+	for _, key := range keys {
+		if _, _, err := s.lookup(key); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
 // GetSetsMetas returns a slice of metadata for all the query sets loaded.
 // The order of the returned slice is not guaranteed.
 func (s *SQLSet) GetSetsMetas() []QuerySetMeta {
@@ -99,6 +243,17 @@ func (s *SQLSet) GetSetsMetas() []QuerySetMeta {
 	return metas
 }
 
+// GetMetaLocalized returns the metadata of the query set with its name and description
+// translated to lang, see QuerySetMeta.Localized.
+func (s *SQLSet) GetMetaLocalized(setID, lang string) (QuerySetMeta, error) {
+	qs, ok := s.sets[setID]
+	if !ok {
+		return QuerySetMeta{}, fmt.Errorf("%s: %w", setID, ErrQuerySetNotFound)
+	}
+
+	return qs.meta.Localized(lang), nil
+}
+
 // GetQueryIDs returns a sorted slice of all query IDs within a specific query set.
 func (s *SQLSet) GetQueryIDs(setID string) ([]string, error) {
 	if s.sets == nil {
@@ -123,9 +278,202 @@ func (s *SQLSet) GetQueryIDs(setID string) ([]string, error) {
 	return ids, nil
 }
 
-func (s *SQLSet) findQuery(ids ...string) (string, error) {
+// GetWithHints is like Get but prepends the execution plan hints declared
+// for the query with the --HINTS directive, e.g. pg_hint_plan comments.
+// Queries without hints are returned as is.
+func (s *SQLSet) GetWithHints(ids ...string) (string, error) {
+	key, q, err := s.lookup(ids...)
+	if err != nil {
+		return "", err
+	}
+
+	sql, err := s.rewrite(context.Background(), key, q)
+	if err != nil {
+		return "", err
+	}
+
+	if q.hints == "" {
+		return sql, nil
+	}
+
+	return q.hints + lineEnding + sql, nil
+}
+
+// Hints returns the execution plan hints declared for the query, empty if there are none.
+func (s *SQLSet) Hints(setID, queryID string) (string, error) {
+	q, err := s.findQuery(setID, queryID)
+	if err != nil {
+		return "", err
+	}
+
+	return q.hints, nil
+}
+
+// GetQueryMeta returns the metadata of the query, zero if the query declares none.
+func (s *SQLSet) GetQueryMeta(setID, queryID string) (QueryMeta, error) {
+	q, err := s.findQuery(setID, queryID)
+	if err != nil {
+		return QueryMeta{}, err
+	}
+
+	return q.meta, nil
+}
+
+// Params returns the parameters declared for the query with the --PARAMS directive,
+// in declaration order. It returns an empty slice if the query declares no parameters.
+func (s *SQLSet) Params(setID, queryID string) ([]QueryParam, error) {
+	q, err := s.findQuery(setID, queryID)
+	if err != nil {
+		return nil, err
+	}
+
+	if q.params == nil {
+		return []QueryParam{}, nil
+	}
+
+	return append([]QueryParam(nil), q.params...), nil
+}
+
+// BindArgs returns the arguments of the query in the declaration order of its --PARAMS,
+// taken by name from args. Omitted parameters take their declared default, see QueryParam.DefaultValue:
+//
+//	// --PARAMS: status text, limit int = 50
+//	args, err := sqlSet.BindArgs("orders", "List", map[string]any{"status": "open"})
+//	// args: ["open", int64(50)]
+//
+// It returns ErrRequiredArgMissing for an omitted parameter without default
+// and ErrInvalidArgCount for an argument not declared.
+func (s *SQLSet) BindArgs(setID, queryID string, args map[string]any) ([]any, error) {
+	q, err := s.findQuery(setID, queryID)
+	if err != nil {
+		return nil, err
+	}
+
+	bound := make([]any, len(q.params))
+
+	for i, param := range q.params {
+		if v, ok := args[param.Name]; ok {
+			bound[i] = v
+
+			continue
+		}
+
+		v, ok := param.DefaultValue()
+		if !ok {
+			return nil, fmt.Errorf("%s: %w", param.Name, ErrRequiredArgMissing)
+		}
+
+		bound[i] = v
+	}
+
+	for name := range args {
+		if !slices.ContainsFunc(q.params, func(p QueryParam) bool { return p.Name == name }) {
+			return nil, fmt.Errorf("%w: parameter %q is not declared", ErrInvalidArgCount, name)
+		}
+	}
+
+	return bound, nil
+}
+
+// Returns returns the result columns declared for the query with the --RETURNS directive,
+// in declaration order. It returns an empty slice if the query declares no columns.
+func (s *SQLSet) Returns(setID, queryID string) ([]QueryParam, error) {
+	q, err := s.findQuery(setID, queryID)
+	if err != nil {
+		return nil, err
+	}
+
+	if q.returns == nil {
+		return []QueryParam{}, nil
+	}
+
+	return append([]QueryParam(nil), q.returns...), nil
+}
+
+// Arity returns the number of arguments the query takes: the number of parameters
+// declared with --PARAMS, checked against the placeholders when the files are loaded,
+// otherwise the number of the placeholders found in the body ($N, :name, @name or ?).
+// Executors can use it to reject wrong argument counts before hitting the database.
+func (s *SQLSet) Arity(setID, queryID string) (int, error) {
+	q, err := s.findQuery(setID, queryID)
+	if err != nil {
+		return 0, err
+	}
+
+	if q.params != nil {
+		return len(q.params), nil
+	}
+
+	return findPlaceholders(q.sql).arity(), nil
+}
+
+// Query holds a query with all its declarations, see SQLSet.Describe.
+type Query struct {
+	Key QueryKey `json:"key"`
+	// SQL is the query body as loaded, before the rewriters given to New.
+	SQL    string       `json:"sql"`
+	Meta   QueryMeta    `json:"meta"`
+	Params []QueryParam `json:"params,omitempty"`
+	// Returns lists the result columns declared with --RETURNS.
+	Returns []QueryParam `json:"returns,omitempty"`
+	Source  Source       `json:"source"`
+}
+
+// Describe returns a query with its metadata, parameters and source location in one lookup,
+// for the tools assembling query information, e.g. documentation generators.
+// The returned values are copies.
+func (s *SQLSet) Describe(setID, queryID string) (Query, error) {
+	q, err := s.findQuery(setID, queryID)
+	if err != nil {
+		return Query{}, err
+	}
+
+	return Query{
+		Key:     QueryKey{SetID: setID, QueryID: queryID},
+		SQL:     q.sql,
+		Meta:    q.meta.clone(),
+		Params:  slices.Clone(q.params),
+		Returns: slices.Clone(q.returns),
+		Source:  q.source,
+	}, nil
+}
+
+// Source is the location of a query in the files it was loaded from.
+type Source struct {
+	// File is the path of the file within the loaded file system.
+	File string `json:"file"`
+	// Line is the line of the opening directive, starting at 1.
+	Line int `json:"line"`
+	// EndLine is the line closing the query block.
+	EndLine int `json:"end_line"`
+}
+
+// Source returns the file and the line a query starts at, e.g. to link errors
+// to the repository. It returns an empty file and line 0 for unknown queries
+// and queries without a location, such as those registered in code.
+func (s *SQLSet) Source(setID, queryID string) (file string, line int) {
+	src, err := s.QuerySource(setID, queryID)
+	if err != nil {
+		return "", 0
+	}
+
+	return src.File, src.Line
+}
+
+// QuerySource returns the location of a query, including the line its block ends at.
+// The location is zero for queries registered in code.
+func (s *SQLSet) QuerySource(setID, queryID string) (Source, error) {
+	q, err := s.findQuery(setID, queryID)
+	if err != nil {
+		return Source{}, err
+	}
+
+	return q.source, nil
+}
+
+func (s *SQLSet) findQuery(ids ...string) (query, error) {
 	if s.sets == nil {
-		return "", ErrQuerySetsEmpty
+		return query{}, ErrQuerySetsEmpty
 	}
 
 	var (
@@ -136,7 +484,7 @@ func (s *SQLSet) findQuery(ids ...string) (string, error) {
 
 	if len(ids) == 1 {
 		if len(s.sets) > 1 {
-			return "", fmt.Errorf("query set: %w", ErrRequiredArgMissing)
+			return query{}, fmt.Errorf("query set: %w", ErrRequiredArgMissing)
 		}
 
 		queryID = ids[0]
@@ -150,15 +498,23 @@ func (s *SQLSet) findQuery(ids ...string) (string, error) {
 
 		qs, ok = s.sets[ids[0]]
 		if !ok {
-			return "", fmt.Errorf("%s: %w", ids[0], ErrQuerySetNotFound)
+			return query{}, fmt.Errorf("%s: %w", ids[0], ErrQuerySetNotFound)
 		}
 	} else {
-		return "", fmt.Errorf("%d: %w", len(ids), ErrInvalidArgCount)
+		return query{}, fmt.Errorf("%d: %w", len(ids), ErrInvalidArgCount)
 	}
 
 	q, err := qs.findQuery(queryID)
+	if errors.Is(err, ErrQueryNotFound) {
+		if id, aliased, ok := qs.findAlias(queryID); ok {
+			s.warnAlias(queryID, id, aliased)
+
+			return aliased, nil
+		}
+	}
+
 	if err != nil {
-		return "", err
+		return query{}, err
 	}
 
 	return q, nil
@@ -175,7 +531,289 @@ func (s *SQLSet) registerQuerySet(setID string, qs QuerySet) {
 // QuerySet represents a single set of queries, usually from a single .sql file.
 type QuerySet struct {
 	meta    QuerySetMeta
-	queries map[string]string
+	queries map[string]query
+	// data holds the fixture data blocks by ID, see GetData.
+	data map[string]Data
+	// expectations holds the expected results by query ID, see GetExpectation.
+	expectations map[string]Expectation
+	// modTime is the latest modification time of the files of the set.
+	modTime time.Time
+}
+
+// query is a single parsed query with its declarations.
+type query struct {
+	sql    string
+	params []QueryParam
+	// returns lists the result columns declared with --RETURNS.
+	returns []QueryParam
+	hints   string
+	meta    QueryMeta
+	source  Source
+	// tmpl is the compiled body of a template query, see GetRendered.
+	tmpl *template.Template
+}
+
+// QueryMeta holds the metadata of a single query, declared with a --META
+// directive inside the query block, either inline or as a sub-block:
+//
+//	--SQL:GetAuthor
+//	--META: {"command": "one"}
+//	SELECT * FROM authors WHERE id = $1;
+//	--end
+type QueryMeta struct {
+	// Command is the sqlc-style command annotation of the query, e.g. "one", "many" or "exec".
+	Command string `json:"command,omitempty"`
+	// Sortable lists the columns the query may be ordered by, see SQLSet.GetOrdered.
+	Sortable []string `json:"sortable,omitempty"`
+	// SoftDeleteTable is the soft-deleted table the query reads, see WithSoftDelete.
+	SoftDeleteTable string `json:"soft_delete_table,omitempty"`
+	// Tags are free-form labels of the query, e.g. the owning service or "reporting".
+	Tags []string `json:"tags,omitempty"`
+	// Deprecated marks a query scheduled for removal.
+	Deprecated bool `json:"deprecated,omitempty"`
+	// Aliases are the former IDs the query is still found by, e.g. after a rename,
+	// declared in the metadata or with --ALIAS lines. Every use is reported as
+	// a deprecation warning, see WithWarningHandler.
+	Aliases []string `json:"aliases,omitempty"`
+	// Retry is the retry policy of the query on transient errors, nil if it must not be retried.
+	Retry *RetryPolicy `json:"retry,omitempty"`
+	// Route is the connection pool the query is run on, the primary if empty.
+	Route Route `json:"route,omitempty"`
+	// CacheTTL is the time the results of the query may be cached, e.g. "30s",
+	// zero if they must not be. It is honored by sqlsetdb.ResultCache.
+	CacheTTL Duration `json:"cache_ttl,omitempty"`
+	// SpanName is the name of the tracing spans of the query, e.g. "users.lookup",
+	// the query key if empty. It is honored by the tracing of sqlsetdb.Executor.
+	SpanName string `json:"span_name,omitempty"`
+	// Requires lists the queries to run before this one, as "setID.queryID" keys or
+	// query IDs of the same set, declared in the metadata or with --requires lines,
+	// see SQLSet.TopologicalOrder.
+	Requires []string `json:"requires,omitempty"`
+	// Sanitized makes tracing attach the query text with its literals masked,
+	// see Redact, instead of the full text, e.g. for queries with PII in literals.
+	Sanitized bool `json:"sanitized,omitempty"`
+	// Exposed whitelists the query for execution over HTTP by the gateway subpackage.
+	Exposed bool `json:"exposed,omitempty"`
+	// Capabilities lists the database extensions the query needs, e.g. "pgcrypto" or "postgis",
+	// see sqlsetdb.CheckCapabilities.
+	Capabilities []string `json:"capabilities,omitempty"`
+}
+
+// Duration is a time.Duration encoded in JSON as a string, e.g. "30s" or "1m30s".
+type Duration time.Duration
+
+// MarshalJSON encodes the duration as a string.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON decodes a duration string, see time.ParseDuration.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration: %w", err)
+	}
+
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(parsed)
+
+	return nil
+}
+
+// Route is the connection pool a query is run on, honored by executors such as sqlsetdb.Executor.
+type Route string
+
+const (
+	// RoutePrimary runs the query on the primary database, e.g. writes and reads that
+	// must see them.
+	RoutePrimary Route = "primary"
+	// RouteReplica runs the query on a read replica, e.g. reporting queries
+	// tolerating replication lag.
+	RouteReplica Route = "replica"
+)
+
+// RetryPolicy declares how an idempotent query is retried on transient errors,
+// e.g. serialization failures. It is honored by executors such as sqlsetdb.Executor:
+//
+//	--SQL:GetBalance
+//	--META: {"retry": {"attempts": 3, "on": ["serialization_failure"]}}
+//	SELECT balance FROM accounts WHERE id = $1
+//	--end
+type RetryPolicy struct {
+	// Attempts is the maximum number of executions, including the first one.
+	Attempts int `json:"attempts"`
+	// On lists the retried error conditions, by PostgreSQL condition name,
+	// e.g. "deadlock_detected", or by SQLSTATE code, e.g. "40001".
+	// Empty means the executor's default transient conditions.
+	On []string `json:"on,omitempty"`
+}
+
+func (m QueryMeta) isZero() bool {
+	return m.Command == "" && m.Sortable == nil && m.SoftDeleteTable == "" && m.Tags == nil && !m.Deprecated &&
+		m.Aliases == nil && m.Retry == nil && m.Route == "" && m.CacheTTL == 0 && m.SpanName == "" && !m.Sanitized &&
+		m.Requires == nil && !m.Exposed && m.Capabilities == nil
+}
+
+// clone returns a copy of the metadata not sharing the slices.
+func (m QueryMeta) clone() QueryMeta {
+	m.Sortable = slices.Clone(m.Sortable)
+	m.Tags = slices.Clone(m.Tags)
+	m.Aliases = slices.Clone(m.Aliases)
+	m.Requires = slices.Clone(m.Requires)
+	m.Capabilities = slices.Clone(m.Capabilities)
+
+	if m.Retry != nil {
+		retry := *m.Retry
+		retry.On = slices.Clone(retry.On)
+		m.Retry = &retry
+	}
+
+	return m
+}
+
+// QueryParam is a query parameter declared with the --PARAMS directive.
+type QueryParam struct {
+	// Name is the parameter name.
+	Name string `json:"name"`
+	// Type is the declared SQL type of the parameter, empty if not declared.
+	Type string `json:"type,omitempty"`
+	// Default is the SQL literal declared as the default value, e.g. "50" for "limit int = 50",
+	// empty if the parameter is required. See DefaultValue.
+	Default string `json:"default,omitempty"`
+}
+
+// DefaultValue returns the value of the declared default and whether there is one.
+// Quoted literals are strings, with the doubled quotes unescaped, numbers are int64 or float64,
+// TRUE and FALSE are bools and NULL is nil. Defaults that are not literals, as allowed
+// for the queries built in code, are returned as written.
+func (p QueryParam) DefaultValue() (any, bool) {
+	if p.Default == "" {
+		return nil, false
+	}
+
+	if v, ok := literalValue(p.Default); ok {
+		return v, true
+	}
+
+	return p.Default, true
+}
+
+// literalValue returns the value of an SQL literal, see QueryParam.DefaultValue.
+func literalValue(lit string) (any, bool) {
+	switch {
+	case len(lit) >= 2 && lit[0] == '\'' && lit[len(lit)-1] == '\'':
+		return strings.ReplaceAll(lit[1:len(lit)-1], "''", "'"), true
+	case strings.EqualFold(lit, "null"):
+		return nil, true
+	case strings.EqualFold(lit, "true"), strings.EqualFold(lit, "false"):
+		return strings.EqualFold(lit, "true"), true
+	}
+
+	if n, err := strconv.ParseInt(lit, 10, 64); err == nil {
+		return n, true
+	}
+
+	if f, err := strconv.ParseFloat(lit, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+		return f, true
+	}
+
+	return nil, false
+}
+
+// NewQuerySet returns an empty query set for building in code, e.g. from an ORM model.
+// Add queries with Register and the set to an SQLSet with AddSet.
+// The name defaults to the ID, as for the sets loaded from files.
+func NewQuerySet(meta QuerySetMeta) *QuerySet {
+	if meta.Name == "" {
+		meta.Name = meta.ID
+	}
+
+	return &QuerySet{meta: meta}
+}
+
+// Register adds the query to the set, replacing a query with the same ID.
+// A blank query is rejected with ErrEmptyQuery.
+func (qs *QuerySet) Register(queryID, sql string) error {
+	if queryID == "" {
+		return fmt.Errorf("query ID: %w", ErrArgumentEmpty)
+	}
+
+	if strings.TrimSpace(sql) == "" {
+		return fmt.Errorf("%s: %w", queryID, ErrEmptyQuery)
+	}
+
+	qs.registerQuery(queryID, query{sql: sql})
+
+	return nil
+}
+
+// AddSet adds a copy of the query set to the SQLSet, so sets built in code
+// can be mixed with the sets loaded from files. The zero SQLSet is ready to use
+// for sets built in code only. Queries registered in qs later are not added.
+func (s *SQLSet) AddSet(qs *QuerySet) error {
+	id := qs.meta.ID
+	if id == "" {
+		return fmt.Errorf("query set ID: %w", ErrArgumentEmpty)
+	}
+
+	if _, ok := s.sets[id]; ok {
+		return fmt.Errorf("%s: %w", id, ErrQuerySetExists)
+	}
+
+	s.registerQuerySet(id, QuerySet{
+		meta:         qs.meta,
+		queries:      maps.Clone(qs.queries),
+		data:         maps.Clone(qs.data),
+		expectations: maps.Clone(qs.expectations),
+		modTime:      qs.modTime,
+	})
+
+	return nil
+}
+
+// MergeWithPrefix adds copies of the sets of other with their IDs prefixed
+// with prefix and a dot, so the sets of an external pack become e.g. "vendor.users"
+// without colliding with the local ones:
+//
+//	if err := sqlSet.MergeWithPrefix(vendorSet, "vendor"); err != nil {
+//		return err
+//	}
+//
+//	q, err := sqlSet.Get("vendor.users", "GetUser") // or Get("vendor.users.GetUser")
+//
+// Nothing is added if a prefixed ID is already registered. other is not modified.
+func (s *SQLSet) MergeWithPrefix(other *SQLSet, prefix string) error {
+	if prefix == "" {
+		return fmt.Errorf("prefix: %w", ErrArgumentEmpty)
+	}
+
+	for setID := range other.sets {
+		if _, ok := s.sets[prefix+"."+setID]; ok {
+			return fmt.Errorf("%s.%s: %w", prefix, setID, ErrQuerySetExists)
+		}
+	}
+
+	for setID, qs := range other.sets {
+		id := prefix + "." + setID
+
+		meta := qs.meta.clone()
+		meta.ID = id
+
+		s.registerQuerySet(id, QuerySet{
+			meta:         meta,
+			queries:      maps.Clone(qs.queries),
+			data:         maps.Clone(qs.data),
+			expectations: maps.Clone(qs.expectations),
+			modTime:      qs.modTime,
+		})
+	}
+
+	return nil
 }
 
 // GetMeta returns the metadata associated with the query set.
@@ -183,22 +821,22 @@ func (qs *QuerySet) GetMeta() QuerySetMeta {
 	return qs.meta
 }
 
-func (qs *QuerySet) registerQuery(id string, query string) {
+func (qs *QuerySet) registerQuery(id string, q query) {
 	if qs.queries == nil {
-		qs.queries = make(map[string]string)
+		qs.queries = make(map[string]query)
 	}
 
-	qs.queries[id] = query
+	qs.queries[id] = q
 }
 
-func (qs *QuerySet) findQuery(id string) (string, error) {
+func (qs *QuerySet) findQuery(id string) (query, error) {
 	if qs.queries == nil {
-		return "", fmt.Errorf("%s: %w", qs.meta.ID, ErrQuerySetEmpty)
+		return query{}, fmt.Errorf("%s: %w", qs.meta.ID, ErrQuerySetEmpty)
 	}
 
 	q, ok := qs.queries[id]
 	if !ok {
-		return "", fmt.Errorf("%s: %w", id, ErrQueryNotFound)
+		return query{}, fmt.Errorf("%s: %w", id, ErrQueryNotFound)
 	}
 
 	return q, nil
@@ -212,4 +850,49 @@ type QuerySetMeta struct {
 	Name string `json:"name"`
 	// Description provides more details about the query set, from the metadata block.
 	Description string `json:"description,omitempty"`
+	// NameI18n holds the localized names keyed by language tag, from the metadata block.
+	NameI18n map[string]string `json:"name_i18n,omitempty"`
+	// DescriptionI18n holds the localized descriptions keyed by language tag, from the metadata block.
+	DescriptionI18n map[string]string `json:"description_i18n,omitempty"`
+	// Owner is the team or person owning the query set, from the metadata block.
+	Owner string `json:"owner,omitempty"`
+	// Dialect is the SQL dialect of the queries, from the metadata block.
+	Dialect Dialect `json:"dialect,omitempty"`
+	// Tags classify the query set, from the metadata block.
+	Tags []string `json:"tags,omitempty"`
+	// Template marks the queries of the set as templates, see GetRendered.
+	// It is set for .sql.tmpl files.
+	Template bool `json:"template,omitempty"`
+}
+
+// Localized returns a copy of the metadata with Name and Description replaced
+// by their translations for lang. A regional tag falls back to its base language
+// ("de-AT" to "de"), missing translations fall back to the default values.
+func (m QuerySetMeta) Localized(lang string) QuerySetMeta {
+	if name, ok := lookupLocalized(m.NameI18n, lang); ok {
+		m.Name = name
+	}
+
+	if description, ok := lookupLocalized(m.DescriptionI18n, lang); ok {
+		m.Description = description
+	}
+
+	return m
+}
+
+func lookupLocalized(values map[string]string, lang string) (string, bool) {
+	for lang != "" {
+		if v, ok := values[lang]; ok && v != "" {
+			return v, true
+		}
+
+		i := strings.LastIndexAny(lang, "-_")
+		if i < 0 {
+			break
+		}
+
+		lang = lang[:i]
+	}
+
+	return "", false
 }
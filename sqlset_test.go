@@ -1,9 +1,22 @@
 package sqlset_test
 
 import (
+	"context"
+	"crypto/sha256"
 	"embed"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
 	"testing"
+	"testing/fstest"
+	"time"
+	"unicode/utf16"
 
 	"github.com/istovpets/sqlset"
 	"github.com/stretchr/testify/assert"
@@ -16,6 +29,9 @@ var testdataValidMulti embed.FS
 //go:embed testdata/valid_single/*.sql
 var testdataValidSingle embed.FS
 
+//go:embed testdata/valid_i18n/*.sql
+var testdataValidI18n embed.FS
+
 //go:embed testdata/invalid/meta1.sql
 var testdataInvalidMeta1 embed.FS
 
@@ -31,6 +47,33 @@ var testdataInvalidSyntax2 embed.FS
 //go:embed testdata/invalid/long-lines.sql
 var testdataInvalidLongLines embed.FS
 
+//go:embed testdata/valid_dotsql/users.sql
+var testdataValidDotSQL embed.FS
+
+//go:embed testdata/valid_block_comments/users.sql
+var testdataValidBlockComments embed.FS
+
+//go:embed testdata/valid_quoted/functions.sql
+var testdataValidQuoted embed.FS
+
+//go:embed testdata/valid_raw/triggers.sql
+var testdataValidRaw embed.FS
+
+//go:embed testdata/valid_blank_lines/reports.sql
+var testdataValidBlankLines embed.FS
+
+//go:embed testdata/valid_query_meta/authors.sql
+var testdataValidQueryMeta embed.FS
+
+//go:embed testdata/valid_hints/orders.sql
+var testdataValidHints embed.FS
+
+//go:embed testdata/valid_arity/users.sql
+var testdataValidArity embed.FS
+
+//go:embed testdata/valid_features/users.sql
+var testdataValidFeatures embed.FS
+
 //nolint:funlen,lll
 func TestSQLSet(t *testing.T) {
 	sqlSet, err := sqlset.New(testdataValidMulti)
@@ -288,6 +331,252 @@ func TestNew_WhenInvalid_ExpectError(t *testing.T) {
 			fs:          testdataInvalidLongLines,
 			expectedErr: sqlset.ErrMaxLineLenExceeded,
 		},
+		{
+			name:        "raw block not closed",
+			fs:          fstest.MapFS{"triggers.sql": {Data: []byte("--SQLRAW:Broken\nSELECT 1;\n  --end")}},
+			expectedErr: sqlset.ErrInvalidSyntax,
+		},
+		{
+			name:        "query meta: broken json",
+			fs:          fstest.MapFS{"test.sql": {Data: []byte("--SQL:Get\n--META: {\"command\": \n--end")}},
+			expectedErr: sqlset.ErrInvalidSyntax,
+		},
+		{
+			name: "query meta: multiple metadata",
+			fs: fstest.MapFS{
+				"test.sql": {Data: []byte("--SQL:Get\n--META: {}\n--META: {}\nSELECT 1;\n--end")},
+			},
+			expectedErr: sqlset.ErrInvalidSyntax,
+		},
+		{
+			name:        "query meta: empty inline metadata",
+			fs:          fstest.MapFS{"test.sql": {Data: []byte("--SQL:Get\n--META:\nSELECT 1;\n--end")}},
+			expectedErr: sqlset.ErrInvalidSyntax,
+		},
+		{
+			name: "query meta: hints inside metadata",
+			fs: fstest.MapFS{
+				"test.sql": {Data: []byte("--SQL:Get\n--META\n--HINTS: /*+ SeqScan(t) */\n--end\nSELECT 1;\n--end")},
+			},
+			expectedErr: sqlset.ErrInvalidSyntax,
+		},
+		{
+			name: "query meta: no retry attempts",
+			fs: fstest.MapFS{
+				"test.sql": {Data: []byte("--SQL:Get\n--META: {\"retry\": {\"attempts\": 0}}\nSELECT 1;\n--end")},
+			},
+			expectedErr: sqlset.ErrInvalidSyntax,
+		},
+		{
+			name: "query meta: invalid cache ttl",
+			fs: fstest.MapFS{
+				"test.sql": {Data: []byte("--SQL:Get\n--META: {\"cache_ttl\": \"soon\"}\nSELECT 1;\n--end")},
+			},
+			expectedErr: sqlset.ErrInvalidSyntax,
+		},
+		{
+			name: "hints outside query",
+			fs: fstest.MapFS{
+				"test.sql": {Data: []byte("--HINTS: /*+ SeqScan(t) */\n--SQL:Get\nSELECT 1;\n--end")},
+			},
+			expectedErr: sqlset.ErrInvalidSyntax,
+		},
+		{
+			name:        "empty inline hints",
+			fs:          fstest.MapFS{"test.sql": {Data: []byte("--SQL:Get\n--HINTS:\nSELECT 1;\n--end")}},
+			expectedErr: sqlset.ErrInvalidSyntax,
+		},
+		{
+			name: "params inside hints",
+			fs: fstest.MapFS{
+				"test.sql": {Data: []byte("--SQL:Get\n--HINTS\n--PARAMS: id int\n--end\nSELECT 1;\n--end")},
+			},
+			expectedErr: sqlset.ErrInvalidSyntax,
+		},
+		{
+			name: "unclosed query after hints",
+			fs: fstest.MapFS{
+				"test.sql": {Data: []byte("--SQL:Get\n--HINTS\n/*+ SeqScan(t) */\n--end\nSELECT 1;")},
+			},
+			expectedErr: sqlset.ErrInvalidSyntax,
+		},
+		{
+			name:        "params: params outside query",
+			fs:          fstest.MapFS{"test.sql": {Data: []byte("--PARAMS: id int\n--SQL:Get\nSELECT 1;\n--end")}},
+			expectedErr: sqlset.ErrInvalidSyntax,
+		},
+		{
+			name:        "params: empty params",
+			fs:          fstest.MapFS{"test.sql": {Data: []byte("--SQL:Get\n--PARAMS:\nSELECT 1;\n--end")}},
+			expectedErr: sqlset.ErrInvalidSyntax,
+		},
+		{
+			name: "params: empty parameter",
+			fs: fstest.MapFS{
+				"test.sql": {Data: []byte("--SQL:Get\n--PARAMS: id int,,name text\nSELECT 1;\n--end")},
+			},
+			expectedErr: sqlset.ErrInvalidSyntax,
+		},
+		{
+			name: "params: fewer positional placeholders",
+			fs: fstest.MapFS{
+				"test.sql": {Data: []byte("--SQL:Get\n--PARAMS: id int, name text\nSELECT 1 WHERE id = $1;\n--end")},
+			},
+			expectedErr: sqlset.ErrInvalidSyntax,
+		},
+		{
+			name: "params: more positional placeholders",
+			fs: fstest.MapFS{
+				"test.sql": {Data: []byte("--SQL:Get\n--PARAMS: id int\nSELECT 1 WHERE id = $1 AND name = $2;\n--end")},
+			},
+			expectedErr: sqlset.ErrInvalidSyntax,
+		},
+		{
+			name: "params: undeclared named placeholder",
+			fs: fstest.MapFS{
+				"test.sql": {Data: []byte("--SQL:Get\n--PARAMS: id int\nSELECT 1 WHERE id = :id AND name = :name;\n--end")},
+			},
+			expectedErr: sqlset.ErrInvalidSyntax,
+		},
+		{
+			name: "params: unused named parameter",
+			fs: fstest.MapFS{
+				"test.sql": {Data: []byte("--SQL:Get\n--PARAMS: id int, name text\nSELECT 1 WHERE id = @id;\n--end")},
+			},
+			expectedErr: sqlset.ErrInvalidSyntax,
+		},
+		{
+			name:        "params: no placeholders",
+			fs:          fstest.MapFS{"test.sql": {Data: []byte("--SQL:Get\n--PARAMS: id int\nSELECT 1;\n--end")}},
+			expectedErr: sqlset.ErrInvalidSyntax,
+		},
+		{
+			name: "params: empty default",
+			fs: fstest.MapFS{
+				"test.sql": {Data: []byte("--SQL:Get\n--PARAMS: id int =\nSELECT 1 WHERE id = $1;\n--end")},
+			},
+			expectedErr: sqlset.ErrInvalidSyntax,
+		},
+		{
+			name: "params: default not a literal",
+			fs: fstest.MapFS{
+				"test.sql": {Data: []byte("--SQL:Get\n--PARAMS: at timestamptz = now()\nSELECT 1 WHERE at < $1;\n--end")},
+			},
+			expectedErr: sqlset.ErrInvalidSyntax,
+		},
+		{
+			name: "alias of a query ID",
+			fs: fstest.MapFS{
+				"test.sql": {Data: []byte("--SQL:A\n--ALIAS: B\nSELECT 1;\n--end\n--SQL:B\nSELECT 2;\n--end\n")},
+			},
+			expectedErr: sqlset.ErrQueryExists,
+		},
+		{
+			name: "alias of two queries",
+			fs: fstest.MapFS{
+				"test.sql": {Data: []byte("--SQL:A\n--ALIAS: C\nSELECT 1;\n--end\n--SQL:B\n--ALIAS: C\nSELECT 2;\n--end\n")},
+			},
+			expectedErr: sqlset.ErrQueryExists,
+		},
+		{
+			name:        "empty alias",
+			fs:          fstest.MapFS{"test.sql": {Data: []byte("--SQL:A\n--ALIAS: B,\nSELECT 1;\n--end\n")}},
+			expectedErr: sqlset.ErrInvalidSyntax,
+		},
+		{
+			name:        "alias outside query",
+			fs:          fstest.MapFS{"test.sql": {Data: []byte("--ALIAS: B\n")}},
+			expectedErr: sqlset.ErrInvalidSyntax,
+		},
+		{
+			name:        "feature guard outside query",
+			fs:          fstest.MapFS{"test.sql": {Data: []byte("--when: beta\n--SQL:Get\nSELECT 1;\n--end")}},
+			expectedErr: sqlset.ErrInvalidSyntax,
+		},
+		{
+			name:        "feature guard empty feature",
+			fs:          fstest.MapFS{"test.sql": {Data: []byte("--SQL:Get\n--when: beta,\nSELECT 1;\n--end")}},
+			expectedErr: sqlset.ErrInvalidSyntax,
+		},
+		{
+			name:        "feature guard double negation",
+			fs:          fstest.MapFS{"test.sql": {Data: []byte("--SQL:Get\n--when: !!beta\nSELECT 1;\n--end")}},
+			expectedErr: sqlset.ErrInvalidSyntax,
+		},
+		{
+			name:        "data: no id",
+			fs:          fstest.MapFS{"users.sql": {Data: []byte("--DATA:\nid\n--end")}},
+			expectedErr: sqlset.ErrInvalidSyntax,
+		},
+		{
+			name:        "data: bad csv",
+			fs:          fstest.MapFS{"users.sql": {Data: []byte("--DATA:Users\nid,name\n1\n--end")}},
+			expectedErr: sqlset.ErrInvalidSyntax,
+		},
+		{
+			name:        "data: bad json",
+			fs:          fstest.MapFS{"users.sql": {Data: []byte("--DATA:Users\n[1, 2]\n--end")}},
+			expectedErr: sqlset.ErrInvalidSyntax,
+		},
+		{
+			name:        "data: duplicate",
+			fs:          fstest.MapFS{"users.sql": {Data: []byte("--DATA:Users\nid\n--end\n--DATA:Users\nid\n--end")}},
+			expectedErr: sqlset.ErrAlreadyExists,
+		},
+		{
+			name:        "data: inside a query",
+			fs:          fstest.MapFS{"users.sql": {Data: []byte("--SQL:GetUser\nSELECT 1\n--DATA:Users\nid\n--end")}},
+			expectedErr: sqlset.ErrInvalidSyntax,
+		},
+		{
+			name:        "expect: no query id",
+			fs:          fstest.MapFS{"users.sql": {Data: []byte("--EXPECT:\nid\n--end")}},
+			expectedErr: sqlset.ErrInvalidSyntax,
+		},
+		{
+			name:        "expect: no columns",
+			fs:          fstest.MapFS{"users.sql": {Data: []byte("--EXPECT:GetUser\n--end")}},
+			expectedErr: sqlset.ErrInvalidSyntax,
+		},
+		{
+			name:        "expect: column count",
+			fs:          fstest.MapFS{"users.sql": {Data: []byte("--EXPECT:GetUser\nid int, name text\n1\n--end")}},
+			expectedErr: sqlset.ErrInvalidSyntax,
+		},
+		{
+			name: "expect: unknown query",
+			fs: fstest.MapFS{
+				"users.sql": {Data: []byte("--SQL:GetUser\nSELECT 1\n--end\n--EXPECT:GetUsers\nid\n--end")},
+			},
+			expectedErr: sqlset.ErrInvalidSyntax,
+		},
+		{
+			name: "expect: duplicate",
+			fs: fstest.MapFS{
+				"users.sql": {Data: []byte("--EXPECT:GetUser\nid\n--end\n--EXPECT:GetUser\nid\n--end")},
+			},
+			expectedErr: sqlset.ErrAlreadyExists,
+		},
+		{
+			name: "queries in _set.sql",
+			fs: fstest.MapFS{
+				"_set.sql": {Data: []byte("--META\n{\"owner\": \"a\"}\n--end\n--SQL:Get\nSELECT 1;\n--end\n")},
+			},
+			expectedErr: sqlset.ErrInvalidSyntax,
+		},
+		{
+			name:        "invalid _meta.json",
+			fs:          fstest.MapFS{"sub/_meta.json": {Data: []byte(`{"owner":`)}},
+			expectedErr: sqlset.ErrInvalidSyntax,
+		},
+		{
+			name: "both _meta.json and _set.sql",
+			fs: fstest.MapFS{
+				"_meta.json": {Data: []byte(`{}`)},
+				"_set.sql":   {Data: []byte("--META\n{}\n--end\n")},
+			},
+			expectedErr: sqlset.ErrAlreadyExists,
+		},
 	}
 
 	for _, test := range tests {
@@ -302,3 +591,2408 @@ func TestNew_WhenInvalid_ExpectError(t *testing.T) {
 		})
 	}
 }
+
+func TestNew_WithSyntax(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		syntax sqlset.Syntax
+		data   string
+	}{
+		{
+			name:   "hash prefix",
+			syntax: sqlset.Syntax{Prefix: "#", SQLKeyword: "sql", MetaKeyword: "meta"},
+			data: `#meta
+{"name": "Users"}
+#end
+
+# comment to be ignored
+#sql:GetUser
+SELECT id FROM users WHERE id = $1;
+#end`,
+		},
+		{
+			name:   "name marker",
+			syntax: sqlset.Syntax{Prefix: "-- ", SQLKeyword: "name", MetaKeyword: "meta", EndKeyword: "end"},
+			data: `-- meta
+{"name": "Users"}
+-- end
+
+-- name: GetUser
+SELECT id FROM users WHERE id = $1;
+-- end`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			sqlSet, err := sqlset.New(
+				fstest.MapFS{"users.sql": &fstest.MapFile{Data: []byte(test.data)}},
+				sqlset.WithSyntax(test.syntax),
+			)
+			require.NoError(t, err)
+
+			assert.Equal(t, "SELECT id FROM users WHERE id = $1;", sqlSet.MustGet("users", "GetUser"))
+			assert.Equal(t, []sqlset.QuerySetMeta{{ID: "users", Name: "Users"}}, sqlSet.GetSetsMetas())
+		})
+	}
+
+	t.Run("default syntax is not recognized", func(t *testing.T) {
+		t.Parallel()
+
+		sqlSet, err := sqlset.New(
+			fstest.MapFS{"users.sql": &fstest.MapFile{Data: []byte("--SQL:GetUser\nSELECT 1;\n--end")}},
+			sqlset.WithSyntax(sqlset.Syntax{Prefix: "#"}),
+		)
+		require.NoError(t, err)
+
+		ids, err := sqlSet.GetQueryIDs("users")
+		require.NoError(t, err)
+		assert.Empty(t, ids)
+	})
+}
+
+func TestNew_WithDotSQLCompat(t *testing.T) {
+	t.Parallel()
+
+	sqlSet, err := sqlset.New(testdataValidDotSQL, sqlset.WithDotSQLCompat())
+	require.NoError(t, err)
+
+	assert.Equal(t, []sqlset.QuerySetMeta{{ID: "users", Name: "Users"}}, sqlSet.GetSetsMetas())
+
+	ids, err := sqlSet.GetQueryIDs("users")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"GetUser", "ListUsers", "create-users-table"}, ids)
+
+	assert.Equal(t, "CREATE TABLE users (id int, name text);", sqlSet.MustGet("users", "create-users-table"))
+	assert.Equal(t, "SELECT id, name\r\nFROM users\r\nWHERE id = $1;", sqlSet.MustGet("users", "GetUser"))
+	assert.Equal(t, "SELECT id, name FROM users;", sqlSet.MustGet("users", "ListUsers"))
+
+	hints, err := sqlSet.Hints("users", "GetUser")
+	require.NoError(t, err)
+	assert.Equal(t, "/*+ IndexScan(users) */", hints)
+}
+
+func TestNew_BlockComments(t *testing.T) {
+	t.Parallel()
+
+	sqlSet, err := sqlset.New(testdataValidBlockComments)
+	require.NoError(t, err)
+
+	ids, err := sqlSet.GetQueryIDs("users")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"GetUser", "ListUsers"}, ids)
+
+	assert.Equal(t, "SELECT id /* , name\r\n--end */ FROM users;", sqlSet.MustGet("users", "GetUser"))
+	assert.Equal(t,
+		"/* outer /* nested */\r\n--SQL:Hidden\r\n*/ SELECT '/*' AS open FROM users; -- /*",
+		sqlSet.MustGet("users", "ListUsers"),
+	)
+}
+
+func TestNew_BlockCommentsInMeta(t *testing.T) {
+	t.Parallel()
+
+	sqlSet, err := sqlset.New(fstest.MapFS{
+		"users.sql": &fstest.MapFile{Data: []byte(`--META
+{"name": "Users", "description": "Loaded from users/*.csv"}
+--end
+
+--SQL:GetUser
+--META
+{"description": "Reads /* the cache"}
+--end
+--HINTS
+/*+ SeqScan(users) /* nested
+--end
+SELECT * FROM users WHERE id = $1;
+--end`)},
+	})
+	require.NoError(t, err)
+
+	metas := sqlSet.GetSetsMetas()
+	require.Len(t, metas, 1)
+	assert.Equal(t, "Loaded from users/*.csv", metas[0].Description)
+	assert.Equal(t, "SELECT * FROM users WHERE id = $1;", sqlSet.MustGet("users", "GetUser"))
+}
+
+func TestNew_QuotedStrings(t *testing.T) {
+	t.Parallel()
+
+	sqlSet, err := sqlset.New(testdataValidQuoted)
+	require.NoError(t, err)
+
+	ids, err := sqlSet.GetQueryIDs("functions")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"CreateTagged", "CreateTouch", "Literal"}, ids)
+
+	assert.Equal(t,
+		"CREATE FUNCTION touch() RETURNS trigger AS $$\r\nBEGIN\r\n-- keep the update time\r\n--end of the comment\r\n"+
+			"NEW.updated_at := now();\r\nRETURN NEW;\r\nEND;\r\n$$ LANGUAGE plpgsql;",
+		sqlSet.MustGet("functions", "CreateTouch"),
+	)
+	assert.Equal(t,
+		"CREATE FUNCTION one() RETURNS int AS $body$\r\nSELECT 1; -- $$\r\n--end\r\n$body$ LANGUAGE sql;",
+		sqlSet.MustGet("functions", "CreateTagged"),
+	)
+	assert.Equal(t,
+		"SELECT 'it''s\r\n--end\r\nmulti-line', \"odd\"\"\r\n--SQL:name\";",
+		sqlSet.MustGet("functions", "Literal"),
+	)
+}
+
+func TestNew_EscapeStrings(t *testing.T) {
+	t.Parallel()
+
+	sqlSet, err := sqlset.New(fstest.MapFS{
+		"users.sql": &fstest.MapFile{Data: []byte(`--SQL:Escaped
+SELECT E'it\'s', e'a\\', E'b''c' FROM users;
+--end
+
+--SQL:Name
+SELECT name'it\' FROM users;
+--end`)},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, `SELECT E'it\'s', e'a\\', E'b''c' FROM users;`, sqlSet.MustGet("users", "Escaped"))
+	assert.Equal(t, `SELECT name'it\' FROM users;`, sqlSet.MustGet("users", "Name"))
+}
+
+func TestNew_MySQLStrings(t *testing.T) {
+	t.Parallel()
+
+	sqlSet, err := sqlset.New(fstest.MapFS{
+		"users.sql": &fstest.MapFile{Data: []byte("--META: {\"dialect\": \"mysql\"}\n" +
+			"--SQL:Escaped\nSELECT * FROM users WHERE a = 'it\\'s' AND b = \"\\\"\";\n--end\n" +
+			"--SQL:Backticks\nSELECT `it's` FROM users;\n--end\n")},
+		"legacy/_meta.json": &fstest.MapFile{Data: []byte(`{"dialect": "mysql"}`)},
+		"legacy/orders.sql": &fstest.MapFile{Data: []byte("--SQL:Get\nSELECT `it's`, 'a\\'b', $$ FROM orders;\n--end\n")},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, `SELECT * FROM users WHERE a = 'it\'s' AND b = "\"";`, sqlSet.MustGet("users", "Escaped"))
+	assert.Equal(t, "SELECT `it's` FROM users;", sqlSet.MustGet("users", "Backticks"))
+	assert.Equal(t, "SELECT `it's`, 'a\\'b', $$ FROM orders;", sqlSet.MustGet("orders", "Get"))
+}
+
+func TestNew_WhenUnterminatedString_ExpectError(t *testing.T) {
+	t.Parallel()
+
+	_, err := sqlset.New(fstest.MapFS{
+		"functions.sql": &fstest.MapFile{Data: []byte("--SQL:Broken\nSELECT $$ unterminated;\n--end")},
+	})
+	require.ErrorIs(t, err, sqlset.ErrInvalidSyntax)
+	assert.ErrorContains(t, err, "unterminated dollar-quoted string $$")
+}
+
+func TestNew_RawBlocks(t *testing.T) {
+	t.Parallel()
+
+	sqlSet, err := sqlset.New(testdataValidRaw, sqlset.WithVariables(map[string]string{}))
+	require.NoError(t, err)
+
+	ids, err := sqlSet.GetQueryIDs("triggers")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"CountAudit", "CreateAudit"}, ids)
+
+	assert.Equal(t, "CREATE FUNCTION audit() RETURNS trigger AS $$\r\n"+
+		"    BEGIN\r\n"+
+		"        -- comments are kept\r\n"+
+		"        --SQL:NotADirective\r\n"+
+		"\r\n"+
+		"        INSERT INTO audit_log VALUES (NEW.*); -- '\r\n"+
+		"        RETURN NEW;\r\n"+
+		"    --end is not the end line\r\n"+
+		"    END;\r\n"+
+		"$$ LANGUAGE plpgsql;",
+		sqlSet.MustGet("triggers", "CreateAudit"),
+	)
+	assert.Equal(t, "SELECT count(*) FROM audit_log;", sqlSet.MustGet("triggers", "CountAudit"))
+}
+
+func TestWithPreserveBlankLines(t *testing.T) {
+	t.Parallel()
+
+	sqlSet, err := sqlset.New(testdataValidBlankLines, sqlset.WithPreserveBlankLines())
+	require.NoError(t, err)
+
+	assert.Equal(t, "WITH paid AS (\r\n"+
+		"SELECT user_id, sum(amount) AS total FROM payments GROUP BY user_id\r\n"+
+		"),\r\n"+
+		"\r\n"+
+		"refunded AS (\r\n"+
+		"SELECT user_id, sum(amount) AS total FROM refunds GROUP BY user_id\r\n"+
+		")\r\n"+
+		"\r\n"+
+		"SELECT p.user_id, p.total - coalesce(r.total, 0)\r\n"+
+		"FROM paid p LEFT JOIN refunded r USING (user_id);",
+		sqlSet.MustGet("reports", "Totals"),
+	)
+
+	t.Run("default", func(t *testing.T) {
+		t.Parallel()
+
+		sqlSet, err := sqlset.New(testdataValidBlankLines)
+		require.NoError(t, err)
+
+		assert.NotContains(t, sqlSet.MustGet("reports", "Totals"), "\r\n\r\n")
+		// Blank lines inside string literals are always kept.
+		assert.Equal(t, "SELECT 'first\r\n\r\nthird';", sqlSet.MustGet("reports", "Literal"))
+	})
+}
+
+const encodedQueries = "--META: {\"name\": \"Benutzer\"}\r\n--SQL:GetUser\r\nSELECT * FROM users WHERE name = 'Jürgen';\r\n--end\r\n"
+
+func encodeUTF16(s string, order binary.AppendByteOrder, bom bool) []byte {
+	var data []byte
+
+	if bom {
+		data = order.AppendUint16(data, 0xFEFF)
+	}
+
+	for _, u := range utf16.Encode([]rune(s)) {
+		data = order.AppendUint16(data, u)
+	}
+
+	return data
+}
+
+func TestNew_Encodings(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "utf-8", data: []byte(encodedQueries)},
+		{name: "utf-8 with bom", data: append([]byte{0xEF, 0xBB, 0xBF}, encodedQueries...)},
+		{name: "utf-16le with bom", data: encodeUTF16(encodedQueries, binary.LittleEndian, true)},
+		{name: "utf-16be with bom", data: encodeUTF16(encodedQueries, binary.BigEndian, true)},
+		{name: "utf-16le", data: encodeUTF16(encodedQueries, binary.LittleEndian, false)},
+		{name: "utf-16be", data: encodeUTF16(encodedQueries, binary.BigEndian, false)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			sqlSet, err := sqlset.New(fstest.MapFS{"users.sql": &fstest.MapFile{Data: test.data}})
+			require.NoError(t, err)
+
+			assert.Equal(t, []sqlset.QuerySetMeta{{ID: "users", Name: "Benutzer"}}, sqlSet.GetSetsMetas())
+			assert.Equal(t, "SELECT * FROM users WHERE name = 'Jürgen';", sqlSet.MustGet("users", "GetUser"))
+		})
+	}
+}
+
+func TestNew_WhenTruncatedUTF16_ExpectError(t *testing.T) {
+	t.Parallel()
+
+	data := encodeUTF16(encodedQueries, binary.LittleEndian, true)
+
+	_, err := sqlset.New(fstest.MapFS{"users.sql": &fstest.MapFile{Data: data[:len(data)-1]}})
+	require.ErrorIs(t, err, sqlset.ErrInvalidSyntax)
+}
+
+func TestLongMetaLines(t *testing.T) {
+	t.Parallel()
+
+	description := strings.Repeat("Long description. ", 200)
+
+	tests := []struct {
+		name string
+		data string
+	}{
+		{
+			name: "block",
+			data: "--META\n{\"description\": \"" + description + "\"}\n--end\n--SQL:Get\nSELECT 1;\n--end\n",
+		},
+		{
+			name: "inline",
+			data: "--META: {\"description\": \"" + description + "\"}\n--SQL:Get\nSELECT 1;\n--end\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sets, err := sqlset.New(fstest.MapFS{"users.sql": {Data: []byte(tt.data)}})
+			require.NoError(t, err)
+
+			metas := sets.GetSetsMetas()
+			require.Len(t, metas, 1)
+			assert.Equal(t, description, metas[0].Description)
+		})
+	}
+
+	t.Run("query meta", func(t *testing.T) {
+		t.Parallel()
+
+		data := "--SQL:Get\n--META: {\"tags\": [\"" + description + "\"]}\nSELECT 1;\n--end\n"
+
+		sets, err := sqlset.New(fstest.MapFS{"users.sql": {Data: []byte(data)}})
+		require.NoError(t, err)
+
+		meta, err := sets.GetQueryMeta("users", "Get")
+		require.NoError(t, err)
+		assert.Equal(t, []string{description}, meta.Tags)
+	})
+}
+
+func TestLongLineErrors(t *testing.T) {
+	t.Parallel()
+
+	long := strings.Repeat("x", 2000)
+
+	_, err := sqlset.New(fstest.MapFS{
+		"users.sql": {Data: []byte("--SQL:Get\nSELECT '" + long + "';\n--end\n")},
+	})
+	require.ErrorIs(t, err, sqlset.ErrMaxLineLenExceeded)
+	assert.Contains(t, err.Error(), "line 2")
+
+	_, err = sqlset.New(fstest.MapFS{
+		"users.sql": {Data: []byte("--META: {\"description\": \"" + strings.Repeat(long, 40) + "\"}\n")},
+	})
+	require.ErrorIs(t, err, sqlset.ErrMaxLineLenExceeded)
+}
+
+func TestMetaJSONErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := sqlset.New(fstest.MapFS{
+		"users.sql": {Data: []byte("--SQL:Get\nSELECT 1;\n--end\n--META\n{\n\"name\": \"Users\",\n\"description\": 42\n}\n--end\n")},
+	})
+	require.ErrorIs(t, err, sqlset.ErrInvalidSyntax)
+	assert.Contains(t, err.Error(), "line 4: parse meta")
+	assert.Contains(t, err.Error(), `\"description\": 42`)
+
+	_, err = sqlset.New(fstest.MapFS{
+		"users.sql": {Data: []byte("--META: {\"name\": \"Users\",}\n")},
+	})
+	require.ErrorIs(t, err, sqlset.ErrInvalidSyntax)
+	assert.Contains(t, err.Error(), "line 1: parse meta")
+	assert.Contains(t, err.Error(), `near "{\"name\": \"Users\",}"`)
+}
+
+func TestSQLSet_GetQueryMeta(t *testing.T) {
+	t.Parallel()
+
+	sqlSet, err := sqlset.New(testdataValidQueryMeta)
+	require.NoError(t, err)
+
+	assert.Equal(t, []sqlset.QuerySetMeta{{ID: "authors", Name: "Authors"}}, sqlSet.GetSetsMetas())
+
+	tests := []struct {
+		queryID       string
+		expectedMeta  sqlset.QueryMeta
+		expectedQuery string
+	}{
+		{
+			queryID:       "GetAuthor",
+			expectedMeta:  sqlset.QueryMeta{Command: "one"},
+			expectedQuery: "SELECT * FROM authors WHERE id = $1;",
+		},
+		{
+			queryID:       "ListAuthors",
+			expectedMeta:  sqlset.QueryMeta{Command: "many"},
+			expectedQuery: "SELECT * FROM authors;",
+		},
+		{
+			queryID:       "DeleteAuthor",
+			expectedQuery: "DELETE FROM authors WHERE id = $1;",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.queryID, func(t *testing.T) {
+			t.Parallel()
+
+			meta, err := sqlSet.GetQueryMeta("authors", test.queryID)
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedMeta, meta)
+			assert.Equal(t, test.expectedQuery, sqlSet.MustGet("authors", test.queryID))
+		})
+	}
+
+	_, err = sqlSet.GetQueryMeta("authors", "unknown")
+	require.ErrorIs(t, err, sqlset.ErrQueryNotFound)
+}
+
+func TestSQLSet_GetMetaLocalized(t *testing.T) {
+	t.Parallel()
+
+	sqlSet, err := sqlset.New(testdataValidI18n)
+	require.NoError(t, err)
+
+	tests := []struct {
+		lang                string
+		expectedName        string
+		expectedDescription string
+	}{
+		{lang: "de", expectedName: "Benutzer", expectedDescription: "Benutzerverwaltung"},
+		{lang: "de-AT", expectedName: "Benutzer", expectedDescription: "Benutzerverwaltung"},
+		{lang: "fr", expectedName: "Utilisateurs", expectedDescription: "User management"},
+		{lang: "en", expectedName: "Users", expectedDescription: "User management"},
+		{lang: "", expectedName: "Users", expectedDescription: "User management"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.lang, func(t *testing.T) {
+			t.Parallel()
+
+			meta, err := sqlSet.GetMetaLocalized("users", test.lang)
+			require.NoError(t, err)
+
+			assert.Equal(t, "users", meta.ID)
+			assert.Equal(t, test.expectedName, meta.Name)
+			assert.Equal(t, test.expectedDescription, meta.Description)
+		})
+	}
+
+	t.Run("unknown set", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := sqlSet.GetMetaLocalized("unknown", "de")
+		require.ErrorIs(t, err, sqlset.ErrQuerySetNotFound)
+	})
+}
+
+func TestSQLSet_GetWithHints(t *testing.T) {
+	t.Parallel()
+
+	sqlSet, err := sqlset.New(testdataValidHints)
+	require.NoError(t, err)
+
+	tests := []struct {
+		queryID       string
+		expectedQuery string
+		expectedHints string
+	}{
+		{
+			queryID:       "ListOrders",
+			expectedQuery: "SELECT * FROM orders;",
+			expectedHints: "/*+\r\nSeqScan(orders)\r\n*/",
+		},
+		{
+			queryID:       "GetOrder",
+			expectedQuery: "SELECT * FROM orders WHERE id = $1;",
+			expectedHints: "/*+ IndexScan(orders) */",
+		},
+		{
+			queryID:       "CountOrders",
+			expectedQuery: "SELECT count(*) FROM orders;",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.queryID, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expectedQuery, sqlSet.MustGet("orders", test.queryID))
+
+			hints, err := sqlSet.Hints("orders", test.queryID)
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedHints, hints)
+
+			withHints, err := sqlSet.GetWithHints("orders." + test.queryID)
+			require.NoError(t, err)
+
+			if test.expectedHints == "" {
+				assert.Equal(t, test.expectedQuery, withHints)
+			} else {
+				assert.Equal(t, test.expectedHints+"\r\n"+test.expectedQuery, withHints)
+			}
+		})
+	}
+
+	t.Run("unknown query", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := sqlSet.GetWithHints("orders", "unknown")
+		require.ErrorIs(t, err, sqlset.ErrQueryNotFound)
+	})
+}
+
+func TestSQLSet_Params(t *testing.T) {
+	t.Parallel()
+
+	sqlSet, err := sqlset.New(fstest.MapFS{
+		"users.sql": &fstest.MapFile{Data: []byte(`--SQL:FindUsers
+--PARAMS: id uuid, amount numeric(10, 2)
+--PARAMS: created_after timestamp with time zone, name
+SELECT id FROM users WHERE id = $1 AND amount > $2 AND created_at > $3 AND name = $4;
+--end
+
+--SQL:CountUsers
+SELECT count(*) FROM users;
+--end`)},
+	})
+	require.NoError(t, err)
+
+	expected := []sqlset.QueryParam{
+		{Name: "id", Type: "uuid"},
+		{Name: "amount", Type: "numeric(10, 2)"},
+		{Name: "created_after", Type: "timestamp with time zone"},
+		{Name: "name"},
+	}
+
+	params, err := sqlSet.Params("users", "FindUsers")
+	require.NoError(t, err)
+	assert.Equal(t, expected, params)
+	assert.Equal(t,
+		"SELECT id FROM users WHERE id = $1 AND amount > $2 AND created_at > $3 AND name = $4;",
+		sqlSet.MustGet("users", "FindUsers"),
+	)
+
+	params, err = sqlSet.Params("users", "CountUsers")
+	require.NoError(t, err)
+	assert.Empty(t, params)
+
+	_, err = sqlSet.Params("users", "unknown")
+	require.ErrorIs(t, err, sqlset.ErrQueryNotFound)
+}
+
+func TestSQLSet_BindArgs(t *testing.T) {
+	t.Parallel()
+
+	sqlSet, err := sqlset.New(fstest.MapFS{
+		"orders.sql": &fstest.MapFile{Data: []byte(`--SQL:List
+--PARAMS: status text, note text = 'a, b''s', limit int = 50
+--PARAMS: min numeric(10, 2) = 0.5, archived bool = false, owner = NULL
+SELECT id FROM orders WHERE status = $1 AND note <> $2 AND total > $4 AND archived = $5
+	AND owner IS NOT DISTINCT FROM $6 LIMIT $3;
+--end`)},
+	})
+	require.NoError(t, err)
+
+	params, err := sqlSet.Params("orders", "List")
+	require.NoError(t, err)
+	assert.Equal(t, []sqlset.QueryParam{
+		{Name: "status", Type: "text"},
+		{Name: "note", Type: "text", Default: "'a, b''s'"},
+		{Name: "limit", Type: "int", Default: "50"},
+		{Name: "min", Type: "numeric(10, 2)", Default: "0.5"},
+		{Name: "archived", Type: "bool", Default: "false"},
+		{Name: "owner", Default: "NULL"},
+	}, params)
+
+	args, err := sqlSet.BindArgs("orders", "List", map[string]any{"status": "open", "limit": 10})
+	require.NoError(t, err)
+	assert.Equal(t, []any{"open", "a, b's", 10, 0.5, false, nil}, args)
+
+	args, err = sqlSet.Freeze().BindArgs("orders", "List", map[string]any{"status": "open"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(50), args[2])
+
+	_, err = sqlSet.BindArgs("orders", "List", map[string]any{"limit": 10})
+	require.ErrorIs(t, err, sqlset.ErrRequiredArgMissing)
+	assert.ErrorContains(t, err, "status")
+
+	_, err = sqlSet.BindArgs("orders", "List", map[string]any{"status": "open", "offset": 5})
+	require.ErrorIs(t, err, sqlset.ErrInvalidArgCount)
+
+	_, err = sqlSet.BindArgs("orders", "Missing", nil)
+	require.ErrorIs(t, err, sqlset.ErrQueryNotFound)
+}
+
+func TestSQLSet_Arity(t *testing.T) {
+	t.Parallel()
+
+	sqlSet, err := sqlset.New(testdataValidArity)
+	require.NoError(t, err)
+
+	tests := []struct {
+		queryID  string
+		expected int
+	}{
+		{queryID: "Declared", expected: 2},
+		{queryID: "Positional", expected: 2},
+		{queryID: "Named", expected: 2},
+		{queryID: "Anonymous", expected: 2},
+		{queryID: "JSONBOperator", expected: 1},
+		{queryID: "NoArgs", expected: 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.queryID, func(t *testing.T) {
+			t.Parallel()
+
+			n, err := sqlSet.Arity("users", test.queryID)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, n)
+		})
+	}
+
+	_, err = sqlSet.Arity("users", "unknown")
+	require.ErrorIs(t, err, sqlset.ErrQueryNotFound)
+}
+
+func TestSQLSet_Returns(t *testing.T) {
+	t.Parallel()
+
+	sqlSet, err := sqlset.New(fstest.MapFS{
+		"users.sql": &fstest.MapFile{Data: []byte(`--SQL:GetUser
+--PARAMS: id bigint
+--RETURNS: id bigint, name text
+--RETURNS: tags text[]
+SELECT id, name, tags FROM users WHERE id = $1;
+--end
+
+--SQL:DeleteUser
+--PARAMS: id bigint
+DELETE FROM users WHERE id = $1;
+--end`)},
+	})
+	require.NoError(t, err)
+
+	columns, err := sqlSet.Returns("users", "GetUser")
+	require.NoError(t, err)
+	assert.Equal(t, []sqlset.QueryParam{
+		{Name: "id", Type: "bigint"},
+		{Name: "name", Type: "text"},
+		{Name: "tags", Type: "text[]"},
+	}, columns)
+
+	// RETURNS does not count as parameters.
+	n, err := sqlSet.Arity("users", "GetUser")
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	columns, err = sqlSet.Returns("users", "DeleteUser")
+	require.NoError(t, err)
+	assert.Empty(t, columns)
+
+	_, err = sqlset.New(fstest.MapFS{
+		"users.sql": &fstest.MapFile{Data: []byte("--RETURNS: id int\n--SQL:Get\nSELECT 1;\n--end")},
+	})
+	require.ErrorIs(t, err, sqlset.ErrInvalidSyntax)
+}
+
+func TestSQLSet_Require(t *testing.T) {
+	t.Parallel()
+
+	sqlSet, err := sqlset.New(fstest.MapFS{
+		"users.sql":  &fstest.MapFile{Data: []byte("--SQL:Get\nSELECT 1;\n--end\n--SQL:List\nSELECT 2;\n--end")},
+		"orders.sql": &fstest.MapFile{Data: []byte("--SQL:Get\nSELECT 3;\n--end")},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, sqlSet.Require("users.Get", "users.List", "orders.Get"))
+	require.NoError(t, sqlSet.Require())
+
+	err = sqlSet.Require("users.Get", "users.Delete", "payments.Get", "orders.Get")
+	require.ErrorIs(t, err, sqlset.ErrQueryNotFound)
+	require.ErrorIs(t, err, sqlset.ErrQuerySetNotFound)
+	assert.EqualError(t, err, "users.Delete: Delete: query not found\npayments.Get: payments: query set not found")
+}
+
+func TestAliases(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"users.sql": {Data: []byte(`--SQL:GetUser
+--META: {"aliases": ["GetUserById"]}
+--ALIAS: FindUser, LoadUser
+SELECT * FROM users WHERE id = $1;
+--end
+`)},
+	}
+
+	var (
+		mu       sync.Mutex
+		warnings []sqlset.Warning
+	)
+
+	sets, err := sqlset.New(fsys, sqlset.WithWarningHandler(func(w sqlset.Warning) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		warnings = append(warnings, w)
+	}))
+	require.NoError(t, err)
+	assert.Empty(t, warnings)
+
+	for _, alias := range []string{"GetUserById", "FindUser", "LoadUser"} {
+		sql, err := sets.Get("users", alias)
+		require.NoError(t, err, alias)
+		assert.Equal(t, "SELECT * FROM users WHERE id = $1;", sql)
+	}
+
+	_, err = sets.Freeze().Get("users.GetUserById")
+	require.NoError(t, err)
+
+	require.Len(t, warnings, 4)
+	assert.Equal(t, "users.sql:1: query GetUserById is a deprecated alias of GetUser", warnings[0].String())
+
+	_, err = sets.Get("users", "GetUser")
+	require.NoError(t, err)
+	assert.Len(t, warnings, 4)
+
+	meta, err := sets.GetQueryMeta("users", "GetUser")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"GetUserById", "FindUser", "LoadUser"}, meta.Aliases)
+
+	ids, err := sets.GetQueryIDs("users")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"GetUser"}, ids)
+}
+
+func TestWithFeatures(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		features     []string
+		listUsers    string
+		experimental bool
+	}{
+		{
+			name:      "no features",
+			listUsers: "SELECT * FROM users WHERE name ILIKE $1;",
+		},
+		{
+			name:      "new search",
+			features:  []string{"new_search"},
+			listUsers: "SELECT * FROM users WHERE search @@ $1;",
+		},
+		{
+			name:         "all features",
+			features:     []string{"new_search", "beta"},
+			listUsers:    "SELECT * FROM users WHERE search @@ $1;",
+			experimental: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sqlSet, err := sqlset.New(testdataValidFeatures, sqlset.WithFeatures(tt.features...))
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.listUsers, sqlSet.MustGet("users", "ListUsers"))
+			assert.Equal(t, "SELECT * FROM users WHERE id = $1;", sqlSet.MustGet("users", "GetUser"))
+
+			_, ok := sqlSet.TryGet("users", "Experimental")
+			assert.Equal(t, tt.experimental, ok)
+		})
+	}
+}
+
+func TestWithDirectiveHandler(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{"users.sql": {Data: []byte(`--owner: @acme/accounts
+-- A plain comment: not a directive
+--SQL:GetUser
+--ticket: DB-1234
+--x-review-by:alice
+SELECT 1;
+--end
+`)}}
+
+	var directives []sqlset.Directive
+
+	sqlSet, err := sqlset.New(fsys, sqlset.WithDirectiveHandler(func(d sqlset.Directive) error {
+		directives = append(directives, d)
+
+		return nil
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT 1;", sqlSet.MustGet("users", "GetUser"))
+
+	assert.Equal(t, []sqlset.Directive{
+		{Keyword: "owner", Value: "@acme/accounts", Key: sqlset.QueryKey{SetID: "users"}, File: "users.sql", Line: 1},
+		{
+			Keyword: "ticket", Value: "DB-1234", Key: sqlset.QueryKey{SetID: "users", QueryID: "GetUser"},
+			File: "users.sql", Line: 4,
+		},
+		{
+			Keyword: "x-review-by", Value: "alice", Key: sqlset.QueryKey{SetID: "users", QueryID: "GetUser"},
+			File: "users.sql", Line: 5,
+		},
+	}, directives)
+
+	errUnknown := errors.New("unknown annotation")
+
+	_, err = sqlset.New(fsys, sqlset.WithDirectiveHandler(func(d sqlset.Directive) error {
+		if d.Keyword == "ticket" {
+			return errUnknown
+		}
+
+		return nil
+	}))
+	require.ErrorIs(t, err, errUnknown)
+	assert.ErrorContains(t, err, "line 4: directive ticket")
+}
+
+func TestWithVariables(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"users.sql": &fstest.MapFile{Data: []byte(`--SQL:ListUsers
+SELECT * FROM ${schema}.users
+JOIN ${schema}.${roles} USING (role_id)
+WHERE id = $1;
+--end
+
+--SQL:Literals
+SELECT '{"path": "${path}"}'::jsonb, '${schema}.users'::regclass -- ${todo}
+/* ${note} */ FROM t
+--end`)},
+	}
+
+	sqlSet, err := sqlset.New(fsys, sqlset.WithVariables(map[string]string{
+		"schema": "billing",
+		"roles":  "user_roles",
+	}))
+	require.NoError(t, err)
+
+	assert.Equal(t,
+		"SELECT * FROM billing.users\r\nJOIN billing.user_roles USING (role_id)\r\nWHERE id = $1;",
+		sqlSet.MustGet("users", "ListUsers"),
+	)
+
+	assert.Equal(t,
+		"SELECT '{\"path\": \"${path}\"}'::jsonb, 'billing.users'::regclass -- ${todo}\r\n/* ${note} */ FROM t",
+		sqlSet.MustGet("users", "Literals"),
+	)
+
+	t.Run("without variables", func(t *testing.T) {
+		t.Parallel()
+
+		sqlSet, err := sqlset.New(fsys)
+		require.NoError(t, err)
+
+		assert.Contains(t, sqlSet.MustGet("users", "ListUsers"), "${schema}.users")
+	})
+
+	t.Run("undefined variable", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := sqlset.New(fsys, sqlset.WithVariables(map[string]string{"schema": "billing"}))
+		require.ErrorIs(t, err, sqlset.ErrVariableNotFound)
+		assert.ErrorContains(t, err, `"roles"`)
+	})
+}
+
+type requestIDKey struct{}
+
+func TestWithContextKeys(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"users.sql": {Data: []byte(`--SQL:GetUser
+/* request_id={{ctx "request_id"}} */ SELECT * FROM users WHERE id = $1
+--end`)},
+		"reports.sql.tmpl": {Data: []byte(`--SQL:List
+/* request_id={{ctx "request_id"}} */ SELECT * FROM {{.Table}}
+--end`)},
+	}
+
+	sqlSet, err := sqlset.New(fsys, sqlset.WithContextKeys(map[string]any{"request_id": requestIDKey{}}))
+	require.NoError(t, err)
+
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "3f2a-77*/ DROP TABLE users; --")
+
+	tests := []struct {
+		name     string
+		get      func() (string, error)
+		expected string
+	}{
+		{
+			name: "context",
+			get: func() (string, error) {
+				return sqlSet.GetContext(ctx, "users.GetUser")
+			},
+			expected: "/* request_id=3f2a77/DROPTABLEusers */ SELECT * FROM users WHERE id = $1",
+		},
+		{
+			name: "no value",
+			get: func() (string, error) {
+				return sqlSet.Get("users.GetUser")
+			},
+			expected: "/* request_id= */ SELECT * FROM users WHERE id = $1",
+		},
+		{
+			name: "template",
+			get: func() (string, error) {
+				return sqlSet.GetRenderedContext(ctx, map[string]any{"Table": "orders"}, "reports.List")
+			},
+			expected: "/* request_id=3f2a77/DROPTABLEusers */ SELECT * FROM orders",
+		},
+		{
+			name: "snapshot",
+			get: func() (string, error) {
+				return sqlSet.Freeze().GetContext(ctx, "users.GetUser")
+			},
+			expected: "/* request_id=3f2a77/DROPTABLEusers */ SELECT * FROM users WHERE id = $1",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			sql, err := test.get()
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, sql)
+		})
+	}
+
+	// Undeclared names fail loading.
+	_, err = sqlset.New(fsys, sqlset.WithContextKeys(map[string]any{"trace_id": requestIDKey{}}))
+	require.ErrorIs(t, err, sqlset.ErrVariableNotFound)
+	assert.Contains(t, err.Error(), `context "request_id"`)
+
+	// Without context keys, the references are kept as is.
+	plain, err := sqlset.New(fstest.MapFS{"users.sql": fsys["users.sql"]})
+	require.NoError(t, err)
+	assert.Equal(t, `/* request_id={{ctx "request_id"}} */ SELECT * FROM users WHERE id = $1`,
+		plain.MustGet("users.GetUser"))
+}
+
+func TestGetData(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"users.sql": {Data: []byte(`--SQL:GetUser
+SELECT * FROM users WHERE id = $1
+--end
+--DATA:Users
+id,name
+1,"O'Brien, Pat"
+2,bob
+--end
+--DATA:Orders
+[
+	{"id": 1, "user_id": 1},
+	{"id": 2, "user_id": 2, "note": "gift"}
+]
+--end`)},
+	}
+
+	sqlSet, err := sqlset.New(fsys)
+	require.NoError(t, err)
+
+	bundle, err := sqlSet.MarshalBundle()
+	require.NoError(t, err)
+
+	fromBundle, err := sqlset.NewFromBundle(bundle)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		dataID  string
+		columns []string
+		rows    [][]any
+		line    int
+		endLine int
+	}{
+		{
+			name:    "csv",
+			dataID:  "Users",
+			columns: []string{"id", "name"},
+			rows:    [][]any{{"1", "O'Brien, Pat"}, {"2", "bob"}},
+			line:    4,
+			endLine: 8,
+		},
+		{
+			name:    "json",
+			dataID:  "Orders",
+			columns: []string{"id", "user_id", "note"},
+			rows: [][]any{
+				{json.Number("1"), json.Number("1"), nil},
+				{json.Number("2"), json.Number("2"), "gift"},
+			},
+			line:    9,
+			endLine: 14,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			for _, s := range []*sqlset.SQLSet{sqlSet, fromBundle} {
+				d, err := s.GetData("users", tt.dataID)
+				require.NoError(t, err)
+
+				assert.Equal(t, tt.dataID, d.ID)
+				assert.Equal(t, tt.columns, d.Columns)
+				assert.Equal(t, tt.rows, d.Rows)
+				assert.Equal(t, sqlset.Source{File: "users.sql", Line: tt.line, EndLine: tt.endLine}, d.Source)
+			}
+		})
+	}
+
+	ids, err := sqlSet.GetDataIDs("users")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Orders", "Users"}, ids)
+
+	_, err = sqlSet.GetData("users", "Missing")
+	require.ErrorIs(t, err, sqlset.ErrDataNotFound)
+	require.ErrorIs(t, err, sqlset.ErrNotFound)
+
+	_, err = sqlSet.GetData("missing", "Users")
+	require.ErrorIs(t, err, sqlset.ErrQuerySetNotFound)
+
+	// The returned rows are copies.
+	d, err := sqlSet.GetData("users", "Users")
+	require.NoError(t, err)
+
+	d.Rows[0][0] = "changed"
+
+	d, err = sqlSet.GetData("users", "Users")
+	require.NoError(t, err)
+	assert.Equal(t, "1", d.Rows[0][0])
+}
+
+func TestGetExpectation(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"users.sql": {Data: []byte(`--SQL:GetUser
+SELECT id, name, price FROM users WHERE id = $1
+--end
+--EXPECT:GetUser
+id bigint, name, price numeric(10,2)
+1,alice,NULL
+--end
+--SQL:ListUsers
+SELECT id, name FROM users
+--end
+--EXPECT:ListUsers
+[{"id": 1, "name": "alice"}]
+--end`)},
+	}
+
+	sqlSet, err := sqlset.New(fsys)
+	require.NoError(t, err)
+
+	bundle, err := sqlSet.MarshalBundle()
+	require.NoError(t, err)
+
+	fromBundle, err := sqlset.NewFromBundle(bundle)
+	require.NoError(t, err)
+
+	for _, s := range []*sqlset.SQLSet{sqlSet, fromBundle} {
+		e, err := s.GetExpectation("users", "GetUser")
+		require.NoError(t, err)
+
+		assert.Equal(t, []sqlset.QueryParam{
+			{Name: "id", Type: "bigint"},
+			{Name: "name"},
+			{Name: "price", Type: "numeric(10,2)"},
+		}, e.Columns)
+		assert.Equal(t, [][]any{{"1", "alice", nil}}, e.Rows)
+		assert.Equal(t, sqlset.Source{File: "users.sql", Line: 4, EndLine: 7}, e.Source)
+
+		all := s.Expectations()
+		require.Len(t, all, 2)
+		assert.Equal(t, sqlset.QueryKey{SetID: "users", QueryID: "GetUser"}, all[0].Key)
+		assert.Equal(t, sqlset.QueryKey{SetID: "users", QueryID: "ListUsers"}, all[1].Key)
+		assert.Equal(t, []sqlset.QueryParam{{Name: "id"}, {Name: "name"}}, all[1].Columns)
+		assert.Equal(t, [][]any{{json.Number("1"), "alice"}}, all[1].Rows)
+	}
+
+	_, err = sqlSet.GetExpectation("users", "Missing")
+	require.ErrorIs(t, err, sqlset.ErrExpectationNotFound)
+	require.ErrorIs(t, err, sqlset.ErrNotFound)
+
+	// Merged sets report their new keys.
+	merged := &sqlset.SQLSet{}
+	require.NoError(t, merged.MergeWithPrefix(sqlSet, "vendor"))
+	assert.Equal(t, sqlset.QueryKey{SetID: "vendor.users", QueryID: "GetUser"}, merged.Expectations()[0].Key)
+}
+
+func TestGetExpectation_DisabledFeature(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"users.sql": {Data: []byte(`--SQL:GetUser
+--when: v2
+SELECT id FROM users_v2 WHERE id = $1
+--end
+--EXPECT:GetUser
+id
+--end`)},
+	}
+
+	sqlSet, err := sqlset.New(fsys)
+	require.NoError(t, err)
+	assert.Empty(t, sqlSet.Expectations())
+
+	sqlSet, err = sqlset.New(fsys, sqlset.WithFeatures("v2"))
+	require.NoError(t, err)
+	assert.Len(t, sqlSet.Expectations(), 1)
+}
+
+func TestDirectoryMetaInheritance(t *testing.T) {
+	t.Parallel()
+
+	sets, err := sqlset.New(fstest.MapFS{
+		"_meta.json":             {Data: []byte(`{"owner": "@acme/platform", "dialect": "postgres", "tags": ["core"]}`)},
+		"users.sql":              {Data: []byte("--SQL:GetUser\nSELECT 1;\n--end\n")},
+		"billing/_set.sql":       {Data: []byte("--META\n{\"owner\": \"@acme/payments\"}\n--end\n")},
+		"billing/invoices.sql":   {Data: []byte("--SQL:GetInvoice\nSELECT 2;\n--end\n")},
+		"billing/legacy/old.sql": {Data: []byte("--META\n{\"dialect\": \"mysql\", \"tags\": []}\n--end\n--SQL:Get\nSELECT 3;\n--end\n")},
+	})
+	require.NoError(t, err)
+
+	metas := make(map[string]sqlset.QuerySetMeta)
+	for _, meta := range sets.GetSetsMetas() {
+		metas[meta.ID] = meta
+	}
+
+	require.Len(t, metas, 3)
+	assert.Equal(t, sqlset.QuerySetMeta{
+		ID: "users", Name: "users", Owner: "@acme/platform", Dialect: sqlset.DialectPostgres, Tags: []string{"core"},
+	}, metas["users"])
+	assert.Equal(t, sqlset.QuerySetMeta{
+		ID: "invoices", Name: "invoices", Owner: "@acme/payments", Dialect: sqlset.DialectPostgres, Tags: []string{"core"},
+	}, metas["invoices"])
+	assert.Equal(t, sqlset.QuerySetMeta{
+		ID: "old", Name: "old", Owner: "@acme/payments", Dialect: sqlset.DialectMySQL, Tags: []string{},
+	}, metas["old"])
+}
+
+func TestNew_FilenameCase(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"UserAccounts.sql":   &fstest.MapFile{Data: []byte("--SQL:Get\nSELECT 1;\n--end")},
+		"Ärzte.SQL":          &fstest.MapFile{Data: []byte("--SQL:Get\nSELECT 2;\n--end")},
+		"notes.txt":          &fstest.MapFile{Data: []byte("--SQL:Get\n")},
+		"sub/OrderItems.Sql": &fstest.MapFile{Data: []byte("--SQL:Get\nSELECT 3;\n--end")},
+	}
+
+	tests := []struct {
+		name     string
+		opts     []sqlset.Option
+		expected []string
+	}{
+		{
+			name:     "lower-cased by default",
+			expected: []string{"useraccounts", "ärzte", "orderitems"},
+		},
+		{
+			name:     "preserved",
+			opts:     []sqlset.Option{sqlset.WithPreserveFilenameCase()},
+			expected: []string{"UserAccounts", "Ärzte", "OrderItems"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			sqlSet, err := sqlset.New(fsys, test.opts...)
+			require.NoError(t, err)
+
+			var ids []string
+			for _, meta := range sqlSet.GetSetsMetas() {
+				ids = append(ids, meta.ID)
+			}
+
+			assert.ElementsMatch(t, test.expected, ids)
+		})
+	}
+}
+
+func TestNewWithRoot(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"queries/users.sql":          {Data: []byte("--SQL:GetUser\nSELECT 1;\n--end\n")},
+		"queries/billing/_meta.json": {Data: []byte(`{"owner": "payments"}`)},
+		"queries/billing/bills.sql":  {Data: []byte("--SQL:GetBill\nSELECT 2;\n--end\n")},
+		"other/skipped.sql":          {Data: []byte("--SQL:Skipped\nSELECT 3;\n--end\n")},
+	}
+
+	sets, err := sqlset.NewWithRoot(fsys, "queries")
+	require.NoError(t, err)
+	assert.Len(t, sets.GetSetsMetas(), 2)
+	assert.Equal(t, "SELECT 2;", sets.MustGet("bills", "GetBill"))
+
+	src, err := sets.QuerySource("bills", "GetBill")
+	require.NoError(t, err)
+	assert.Equal(t, "billing/bills.sql", src.File)
+
+	_, err = sqlset.NewWithRoot(fsys, "missing")
+	require.ErrorIs(t, err, fs.ErrNotExist)
+
+	_, err = sqlset.NewWithRoot(fsys, "queries/users.sql")
+	require.ErrorIs(t, err, sqlset.ErrNotDir)
+
+	_, err = sqlset.NewWithRoot(fsys, "../queries")
+	require.Error(t, err)
+}
+
+// walkLog records the directories read by the walk.
+type walkLog struct {
+	fstest.MapFS
+
+	dirs []string
+}
+
+func (w *walkLog) ReadDir(name string) ([]fs.DirEntry, error) {
+	w.dirs = append(w.dirs, name)
+
+	return w.MapFS.ReadDir(name)
+}
+
+func TestWithOnly(t *testing.T) {
+	t.Parallel()
+
+	valid := &fstest.MapFile{Data: []byte("--SQL:Get\nSELECT 1;\n--end")}
+	broken := &fstest.MapFile{Data: []byte("--SQL:Get\nunfinished")}
+
+	fsys := &walkLog{MapFS: fstest.MapFS{
+		"users.sql":                 valid,
+		"users_admin.sql":           valid,
+		"orders.sql":                broken,
+		"billing/invoices.sql":      valid,
+		"billing/legacy/old.sql":    valid,
+		"billing/legacy/draft.sql":  broken,
+		"reports/daily.sql":         broken,
+		"reports/nested/weekly.sql": broken,
+	}}
+
+	sqlSet, err := sqlset.New(fsys,
+		sqlset.WithOnly("users*.sql", "billing/**"),
+		sqlset.WithIgnore("draft.sql"),
+	)
+	require.NoError(t, err)
+
+	var ids []string
+	for _, meta := range sqlSet.GetSetsMetas() {
+		ids = append(ids, meta.ID)
+	}
+
+	assert.ElementsMatch(t, []string{"users", "users_admin", "invoices", "old"}, ids)
+
+	// Directories are pruned unless a base name pattern may match at any depth.
+	assert.Contains(t, fsys.dirs, "reports/nested")
+
+	fsys.dirs = nil
+
+	_, err = sqlset.New(fsys, sqlset.WithOnly("billing/**"), sqlset.WithIgnore("draft.sql"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{".", "billing", "billing/legacy"}, fsys.dirs)
+
+	// A pattern matching a directory selects all its files.
+	sqlSet, err = sqlset.New(fsys.MapFS, sqlset.WithOnly("billing/legacy"), sqlset.WithIgnore("draft.sql"))
+	require.NoError(t, err)
+	assert.Equal(t, "old", sqlSet.GetSetsMetas()[0].ID)
+	assert.Len(t, sqlSet.GetSetsMetas(), 1)
+
+	_, err = sqlset.New(fsys.MapFS, sqlset.WithOnly("[users"))
+	require.ErrorIs(t, err, path.ErrBadPattern)
+}
+
+func TestWithIgnore(t *testing.T) {
+	t.Parallel()
+
+	valid := &fstest.MapFile{Data: []byte("--SQL:Get\nSELECT 1;\n--end")}
+	scratch := &fstest.MapFile{Data: []byte("--SQL:Get\nunfinished")}
+
+	fsys := fstest.MapFS{
+		"users.sql":                valid,
+		"users_draft.sql":          scratch,
+		"reports/orders_draft.sql": scratch,
+		"reports/orders.sql":       valid,
+		"archive/old.sql":          scratch,
+		"archive/2023/older.sql":   scratch,
+		"legacy/archive/kept.sql":  valid,
+		"tmp/scratch/pad.sql":      scratch,
+	}
+
+	_, err := sqlset.New(fsys)
+	require.ErrorIs(t, err, sqlset.ErrInvalidSyntax)
+
+	sqlSet, err := sqlset.New(fsys, sqlset.WithIgnore("*_draft.sql", "archive/**"), sqlset.WithIgnore("**/scratch"))
+	require.NoError(t, err)
+
+	var ids []string
+	for _, meta := range sqlSet.GetSetsMetas() {
+		ids = append(ids, meta.ID)
+	}
+
+	assert.ElementsMatch(t, []string{"users", "orders", "kept"}, ids)
+}
+
+func TestWithIgnore_WhenInvalidPattern_ExpectError(t *testing.T) {
+	t.Parallel()
+
+	_, err := sqlset.New(fstest.MapFS{"users.sql": &fstest.MapFile{}}, sqlset.WithIgnore("[users"))
+	require.ErrorIs(t, err, path.ErrBadPattern)
+}
+
+func TestNewFromModules(t *testing.T) {
+	t.Parallel()
+
+	sets, err := sqlset.NewFromModules(map[string]fs.FS{
+		"billing": fstest.MapFS{
+			"invoices.sql": {Data: []byte("--SQL:Get\nSELECT 1;\n--end\n")},
+		},
+		"users": fstest.MapFS{
+			"invoices.sql":     {Data: []byte("--SQL:Get\nSELECT 2;\n--end\n")},
+			"admin/groups.sql": {Data: []byte("--META: {\"id\": \"roles\"}\n--SQL:List\nSELECT 3;\n--end\n")},
+		},
+	})
+	require.NoError(t, err)
+
+	ids := make([]string, 0, 3)
+	for _, meta := range sets.GetSetsMetas() {
+		ids = append(ids, meta.ID)
+	}
+
+	assert.ElementsMatch(t, []string{"billing/invoices", "users/invoices", "users/roles"}, ids)
+	assert.Equal(t, "SELECT 1;", sets.MustGet("billing/invoices", "Get"))
+	assert.Equal(t, "SELECT 2;", sets.MustGet("users/invoices.Get"))
+	assert.Equal(t, "SELECT 3;", sets.MustGet("users/roles", "List"))
+
+	src, err := sets.QuerySource("users/roles", "List")
+	require.NoError(t, err)
+	assert.Equal(t, "users/admin/groups.sql", src.File)
+
+	_, err = sqlset.NewFromModules(map[string]fs.FS{"": fstest.MapFS{}})
+	require.ErrorIs(t, err, sqlset.ErrArgumentEmpty)
+
+	_, err = sqlset.NewFromModules(map[string]fs.FS{"../billing": fstest.MapFS{}})
+	require.ErrorIs(t, err, fs.ErrInvalid)
+}
+
+// unreadableFS fails to open or list the given paths with fs.ErrPermission.
+type unreadableFS struct {
+	fstest.MapFS
+
+	denied map[string]bool
+}
+
+func (f unreadableFS) Open(name string) (fs.File, error) {
+	if f.denied[name] {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+	}
+
+	return f.MapFS.Open(name)
+}
+
+func (f unreadableFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if f.denied[name] {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrPermission}
+	}
+
+	return f.MapFS.ReadDir(name)
+}
+
+func TestWithSkipUnreadable(t *testing.T) {
+	t.Parallel()
+
+	valid := &fstest.MapFile{Data: []byte("--SQL:Get\nSELECT 1;\n--end")}
+
+	fsys := unreadableFS{
+		MapFS: fstest.MapFS{
+			"users.sql":         valid,
+			"broken_link.sql":   valid,
+			"locked/orders.sql": valid,
+		},
+		denied: map[string]bool{"broken_link.sql": true, "locked": true},
+	}
+
+	_, err := sqlset.New(fsys)
+	require.ErrorIs(t, err, fs.ErrPermission)
+
+	sqlSet, err := sqlset.New(fsys, sqlset.WithSkipUnreadable())
+	require.NoError(t, err)
+
+	assert.Equal(t, "SELECT 1;", sqlSet.MustGet("users", "Get"))
+	assert.Len(t, sqlSet.GetSetsMetas(), 1)
+
+	skipped := sqlSet.SkippedFiles()
+	require.Len(t, skipped, 2)
+	assert.Equal(t, "broken_link.sql", skipped[0].Path)
+	assert.Equal(t, "locked", skipped[1].Path)
+
+	for _, s := range skipped {
+		assert.ErrorIs(t, s.Err, fs.ErrPermission)
+	}
+}
+
+func TestWithDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		data    string
+		line    int
+		state   string
+		snippet string
+	}{
+		{
+			name:  "unexpected block",
+			data:  "--SQL:GetUser\nSELECT 1\nFROM users\n--SQL:ListUsers\nSELECT 2\n--end\n",
+			line:  4,
+			state: `inside SQL block "GetUser" opened at line 1`,
+			snippet: "  2 | SELECT 1\n" +
+				"  3 | FROM users\n" +
+				"> 4 | --SQL:ListUsers\n" +
+				"  5 | SELECT 2\n" +
+				"  6 | --end\n",
+		},
+		{
+			name:  "unterminated literal",
+			data:  "--SQL:GetUser\nSELECT 'oops\n",
+			line:  2,
+			state: `inside SQL block "GetUser" opened at line 1, in an unterminated string literal`,
+			snippet: "  1 | --SQL:GetUser\n" +
+				"> 2 | SELECT 'oops\n",
+		},
+		{
+			name:  "set metadata",
+			data:  "--META: {\"name\": 1}\n--SQL:GetUser\nSELECT 1\n--end\n",
+			line:  1,
+			state: "outside of a block",
+			snippet: "> 1 | --META: {\"name\": 1}\n" +
+				"  2 | --SQL:GetUser\n" +
+				"  3 | SELECT 1\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := sqlset.New(fstest.MapFS{"users.sql": {Data: []byte(tt.data)}}, sqlset.WithDiagnostics(2))
+			require.ErrorIs(t, err, sqlset.ErrInvalidSyntax)
+
+			var parseErr *sqlset.ParseError
+
+			require.True(t, errors.As(err, &parseErr))
+			assert.Equal(t, "users.sql", parseErr.File)
+			assert.Equal(t, tt.line, parseErr.Line)
+			assert.Equal(t, tt.state, parseErr.State)
+			assert.Equal(t, tt.snippet, parseErr.Snippet)
+			assert.Contains(t, parseErr.Diagnostic(), tt.snippet)
+		})
+	}
+
+	_, err := sqlset.New(fstest.MapFS{"users.sql": {Data: []byte("--SQL:GetUser\n--SQL:Other\n")}})
+	require.ErrorIs(t, err, sqlset.ErrInvalidSyntax)
+
+	var parseErr *sqlset.ParseError
+	assert.False(t, errors.As(err, &parseErr))
+}
+
+func TestWithWarningHandler(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"users.sql": &fstest.MapFile{Data: []byte(`--SQL:GetUser
+-- TODO: select the columns explicitly.
+SELECT * FROM users WHERE id = $1;
+--end
+SELECT 'stray';
+
+--SQL:Disabled
+-- SELECT * FROM users;
+--end
+
+--SQL:ListUsers
+SELECT * FROM users; -- todo is not a marker in lower case
+--end`)},
+	}
+
+	var warnings []string
+
+	sqlSet, err := sqlset.New(fsys, sqlset.WithWarningHandler(func(w sqlset.Warning) {
+		warnings = append(warnings, w.String())
+	}))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"users.sql:2: TODO marker",
+		"users.sql:5: content outside of a block is ignored",
+		"users.sql:7: query Disabled has an empty body",
+	}, warnings)
+
+	// Warnings do not change the result.
+	withoutHandler, err := sqlset.New(fsys)
+	require.NoError(t, err)
+	assert.Equal(t, withoutHandler.MustGet("users", "GetUser"), sqlSet.MustGet("users", "GetUser"))
+}
+
+func TestNamingPolicyCheck(t *testing.T) {
+	t.Parallel()
+
+	prefixes := []string{"Get", "List", "Create"}
+
+	tests := []struct {
+		name    string
+		policy  sqlset.NamingPolicy
+		queryID string
+		valid   bool
+	}{
+		{name: "pascal", policy: sqlset.NamingPolicy{Style: sqlset.PascalCase}, queryID: "GetUserByID", valid: true},
+		{name: "pascal lower", policy: sqlset.NamingPolicy{Style: sqlset.PascalCase}, queryID: "getUser"},
+		{name: "pascal underscore", policy: sqlset.NamingPolicy{Style: sqlset.PascalCase}, queryID: "Get_User"},
+		{name: "camel", policy: sqlset.NamingPolicy{Style: sqlset.CamelCase}, queryID: "getUser2", valid: true},
+		{name: "camel upper", policy: sqlset.NamingPolicy{Style: sqlset.CamelCase}, queryID: "GetUser"},
+		{name: "snake", policy: sqlset.NamingPolicy{Style: sqlset.SnakeCase}, queryID: "get_user_by_id", valid: true},
+		{name: "snake upper", policy: sqlset.NamingPolicy{Style: sqlset.SnakeCase}, queryID: "get_User"},
+		{name: "snake double underscore", policy: sqlset.NamingPolicy{Style: sqlset.SnakeCase}, queryID: "get__user"},
+		{name: "prefix", policy: sqlset.NamingPolicy{Prefixes: prefixes}, queryID: "ListOrders", valid: true},
+		{name: "prefix snake", policy: sqlset.NamingPolicy{Prefixes: prefixes}, queryID: "create_order", valid: true},
+		{name: "prefix only", policy: sqlset.NamingPolicy{Prefixes: prefixes}, queryID: "Get", valid: true},
+		{name: "prefix not a word", policy: sqlset.NamingPolicy{Prefixes: prefixes}, queryID: "Getaway"},
+		{name: "no prefix", policy: sqlset.NamingPolicy{Prefixes: prefixes}, queryID: "FetchUser"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tt.policy.Check(tt.queryID)
+			if tt.valid {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, sqlset.ErrNamingPolicy)
+			}
+		})
+	}
+}
+
+func TestWithNamingPolicy(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"users.sql": {Data: []byte("--SQL:GetUser\nSELECT 1;\n--end\n--SQL:list_users\nSELECT 2;\n--end\n")},
+	}
+	policy := sqlset.NamingPolicy{Style: sqlset.PascalCase, Prefixes: []string{"Get", "List"}}
+
+	var warnings []sqlset.Warning
+
+	sets, err := sqlset.New(fsys, sqlset.WithNamingPolicy(policy), sqlset.WithWarningHandler(func(w sqlset.Warning) {
+		warnings = append(warnings, w)
+	}))
+	require.NoError(t, err)
+
+	ids, err := sets.GetQueryIDs("users")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"GetUser", "list_users"}, ids)
+	require.Len(t, warnings, 1)
+	assert.Equal(t, "users.sql:4: naming policy violated: list_users is not PascalCase", warnings[0].String())
+
+	policy.Strict = true
+
+	_, err = sqlset.New(fsys, sqlset.WithNamingPolicy(policy))
+	require.ErrorIs(t, err, sqlset.ErrNamingPolicy)
+	assert.Contains(t, err.Error(), "users.sql")
+}
+
+func TestNew_JoinedErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := sqlset.New(fstest.MapFS{
+		"billing.sql": {Data: []byte("--META\n{\"dialect\": \"oracle\"}\n--end\n--SQL:Get\nSELECT 1;\n--end\n")},
+		"orders.sql":  {Data: []byte("--SQL:Get\nSELECT 1;\n--SQL:List\nSELECT 2;\n--end\n")},
+		"users.sql": {Data: []byte("--SQL:Get\n--META\n{\"retry\": {\"attempts\": 0}, \"route\": \"any\"}\n--end\n" +
+			"SELECT 1;\n--end\n")},
+		"valid.sql": {Data: []byte("--SQL:Get\nSELECT 1;\n--end\n")},
+	})
+	require.ErrorIs(t, err, sqlset.ErrInvalidSyntax)
+	require.ErrorIs(t, err, sqlset.ErrUnknownDialect)
+
+	msg := err.Error()
+	assert.Contains(t, msg, "parse billing.sql: ")
+	assert.Contains(t, msg, `unknown dialect "oracle"`)
+	assert.Contains(t, msg, "parse orders.sql: ")
+	assert.Contains(t, msg, "parse users.sql: ")
+	assert.Contains(t, msg, "retry attempts 0, at least 1 expected")
+	assert.Contains(t, msg, `unknown route "any"`)
+	assert.NotContains(t, msg, "valid.sql")
+}
+
+func TestNew_Duplicate(t *testing.T) {
+	t.Parallel()
+
+	_, err := sqlset.New(fstest.MapFS{
+		"users.sql": {Data: []byte("--SQL:Get\nSELECT 1;\n--end\n--SQL:Get\nSELECT 2;\n--end\n")},
+	}, sqlset.WithDuplicateHandler(func(_, _ sqlset.QueryRef) sqlset.Resolution {
+		return sqlset.RejectDuplicate
+	}))
+	require.ErrorIs(t, err, sqlset.ErrDuplicate)
+	require.ErrorIs(t, err, sqlset.ErrQueryExists)
+}
+
+func TestWithDuplicateHandler(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"local/users.sql": &fstest.MapFile{Data: []byte(`--SQL:GetUser
+SELECT id, name FROM users WHERE id = $1;
+--end`)},
+		"vendor/users.sql": &fstest.MapFile{Data: []byte(`--META
+{"name": "Vendor users"}
+--end
+--SQL:GetUser
+SELECT * FROM users WHERE id = $1;
+--end
+--SQL:ListUsers
+SELECT * FROM users;
+--end`)},
+	}
+
+	t.Run("without handler the last file wins", func(t *testing.T) {
+		t.Parallel()
+
+		sqlSet, err := sqlset.New(fsys)
+		require.NoError(t, err)
+
+		_, ok := sqlSet.TryGet("users", "GetUser")
+		assert.True(t, ok)
+
+		_, ok = sqlSet.TryGet("users", "ListUsers")
+		assert.True(t, ok)
+
+		ids, err := sqlSet.GetQueryIDs("users")
+		require.NoError(t, err)
+		assert.Len(t, ids, 2)
+		assert.Equal(t, "SELECT * FROM users WHERE id = $1;", sqlSet.MustGet("users", "GetUser"))
+	})
+
+	tests := []struct {
+		name       string
+		resolution sqlset.Resolution
+		want       string
+	}{
+		{name: "use incoming", resolution: sqlset.UseIncoming, want: "SELECT * FROM users WHERE id = $1;"},
+		{name: "keep existing", resolution: sqlset.KeepExisting, want: "SELECT id, name FROM users WHERE id = $1;"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls []sqlset.QueryRef
+
+			sqlSet, err := sqlset.New(fsys, sqlset.WithDuplicateHandler(func(existing, incoming sqlset.QueryRef) sqlset.Resolution {
+				calls = append(calls, existing, incoming)
+
+				return tt.resolution
+			}))
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.want, sqlSet.MustGet("users", "GetUser"))
+			assert.Equal(t, "SELECT * FROM users;", sqlSet.MustGet("users", "ListUsers"))
+
+			require.Len(t, calls, 2)
+			assert.Equal(t, sqlset.QueryKey{SetID: "users", QueryID: "GetUser"}, calls[0].Key)
+			assert.Equal(t, "local/users.sql", calls[0].Source.File)
+			assert.Equal(t, "vendor/users.sql", calls[1].Source.File)
+			assert.Equal(t, 4, calls[1].Source.Line)
+
+			// The metadata of the first file is kept.
+			assert.Equal(t, []sqlset.QuerySetMeta{{ID: "users", Name: "users"}}, sqlSet.GetSetsMetas())
+		})
+	}
+
+	t.Run("reject", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := sqlset.New(fsys, sqlset.WithDuplicateHandler(func(_, _ sqlset.QueryRef) sqlset.Resolution {
+			return sqlset.RejectDuplicate
+		}))
+		require.ErrorIs(t, err, sqlset.ErrQueryExists)
+		require.ErrorIs(t, err, sqlset.ErrAlreadyExists)
+		assert.Contains(t, err.Error(), "users.GetUser")
+	})
+
+	t.Run("within a file", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := sqlset.New(fstest.MapFS{
+			"q.sql": &fstest.MapFile{Data: []byte("--SQL:One\nSELECT 1;\n--end\n--SQL:One\nSELECT 2;\n--end")},
+		}, sqlset.WithDuplicateHandler(func(existing, incoming sqlset.QueryRef) sqlset.Resolution {
+			assert.Equal(t, 1, existing.Source.Line)
+			assert.Equal(t, 4, incoming.Source.Line)
+
+			return sqlset.RejectDuplicate
+		}))
+		require.ErrorIs(t, err, sqlset.ErrQueryExists)
+	})
+}
+
+func TestNewWithOverrides(t *testing.T) {
+	t.Parallel()
+
+	base := fstest.MapFS{
+		"users.sql": &fstest.MapFile{Data: []byte(`--META
+{"name": "Users"}
+--end
+--SQL:GetUser
+SELECT * FROM users WHERE id = $1;
+--end
+--SQL:ListUsers
+SELECT * FROM users;
+--end`)},
+	}
+
+	overrides := fstest.MapFS{
+		"users.sql": &fstest.MapFile{Data: []byte(`--SQL:ListUsers
+SELECT * FROM users WHERE tenant_id = 42;
+--end
+--SQL:CountUsers
+SELECT count(*) FROM users;
+--end`)},
+		"reports.sql": &fstest.MapFile{Data: []byte(`--SQL:Daily
+SELECT 1;
+--end`)},
+	}
+
+	sqlSet, err := sqlset.NewWithOverrides(base, overrides)
+	require.NoError(t, err)
+
+	assert.Equal(t, "SELECT * FROM users WHERE id = $1;", sqlSet.MustGet("users", "GetUser"))
+	assert.Equal(t, "SELECT * FROM users WHERE tenant_id = 42;", sqlSet.MustGet("users", "ListUsers"))
+	assert.Equal(t, "SELECT count(*) FROM users;", sqlSet.MustGet("users", "CountUsers"))
+	assert.Equal(t, "SELECT 1;", sqlSet.MustGet("reports", "Daily"))
+
+	assert.Equal(t, []sqlset.QueryKey{{SetID: "users", QueryID: "ListUsers"}}, sqlSet.Overridden())
+
+	meta, err := sqlSet.GetMetaLocalized("users", "")
+	require.NoError(t, err)
+	assert.Equal(t, "Users", meta.Name)
+
+	file, _ := sqlSet.Source("users", "ListUsers")
+	assert.Equal(t, "users.sql", file)
+
+	t.Run("invalid overrides", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := sqlset.NewWithOverrides(base, fstest.MapFS{
+			"users.sql": &fstest.MapFile{Data: []byte("--SQL:ListUsers\nSELECT 1;")},
+		})
+		require.ErrorIs(t, err, sqlset.ErrInvalidSyntax)
+		assert.Contains(t, err.Error(), "overrides")
+	})
+}
+
+func TestWithKeySeparator(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"users.sql": &fstest.MapFile{Data: []byte("--SQL:legacy.get_user\nSELECT 1;\n--end")},
+		"posts.sql": &fstest.MapFile{Data: []byte("--SQL:GetPost\nSELECT 2;\n--end")},
+	}
+
+	t.Run("custom separator", func(t *testing.T) {
+		t.Parallel()
+
+		sqlSet, err := sqlset.New(fsys, sqlset.WithKeySeparator(":"))
+		require.NoError(t, err)
+
+		assert.Equal(t, "SELECT 1;", sqlSet.MustGet("users:legacy.get_user"))
+		assert.Equal(t, "SELECT 1;", sqlSet.MustGet("users", "legacy.get_user"))
+		assert.Equal(t, "SELECT 2;", sqlSet.MustGet("posts:GetPost"))
+		assert.Equal(t, "SELECT 2;", sqlSet.Freeze().MustGet("posts:GetPost"))
+
+		_, err = sqlSet.Get("posts.GetPost")
+		require.ErrorIs(t, err, sqlset.ErrRequiredArgMissing)
+	})
+
+	t.Run("default separator with dotted query IDs", func(t *testing.T) {
+		t.Parallel()
+
+		sqlSet, err := sqlset.New(fsys)
+		require.NoError(t, err)
+
+		assert.Equal(t, "SELECT 1;", sqlSet.MustGet("users.legacy.get_user"))
+	})
+}
+
+func TestSQLSet_TryGet(t *testing.T) {
+	t.Parallel()
+
+	sqlSet, err := sqlset.New(fstest.MapFS{
+		"postgres.sql": &fstest.MapFile{Data: []byte("--SQL:Upsert\nINSERT INTO kv VALUES ($1, $2) ON CONFLICT (k) DO UPDATE SET v = $2;\n--end")},
+	})
+	require.NoError(t, err)
+
+	q, ok := sqlSet.TryGet("postgres", "Upsert")
+	assert.True(t, ok)
+	assert.Equal(t, "INSERT INTO kv VALUES ($1, $2) ON CONFLICT (k) DO UPDATE SET v = $2;", q)
+
+	q, ok = sqlSet.TryGet("postgres", "Missing")
+	assert.False(t, ok)
+	assert.Empty(t, q)
+
+	_, ok = sqlSet.TryGet("mysql", "Upsert")
+	assert.False(t, ok)
+
+	assert.Equal(t, "SELECT 1", sqlSet.GetOr("postgres", "Missing", "SELECT 1"))
+	assert.Equal(t, "REPLACE INTO kv VALUES (?, ?)", sqlSet.GetOr("mysql", "Upsert", "REPLACE INTO kv VALUES (?, ?)"))
+	assert.Contains(t, sqlSet.GetOr("postgres", "Upsert", "fallback"), "ON CONFLICT")
+}
+
+func TestSQLSet_Describe(t *testing.T) {
+	t.Parallel()
+
+	sqlSet, err := sqlset.New(fstest.MapFS{
+		"users.sql": &fstest.MapFile{Data: []byte(`--SQL:GetUser
+--PARAMS: id bigint
+--META: {"command": "one", "tags": ["auth"]}
+SELECT * FROM users WHERE id = $1;
+--end`)},
+	})
+	require.NoError(t, err)
+
+	q, err := sqlSet.Describe("users", "GetUser")
+	require.NoError(t, err)
+	assert.Equal(t, sqlset.Query{
+		Key:    sqlset.QueryKey{SetID: "users", QueryID: "GetUser"},
+		SQL:    "SELECT * FROM users WHERE id = $1;",
+		Meta:   sqlset.QueryMeta{Command: "one", Tags: []string{"auth"}},
+		Params: []sqlset.QueryParam{{Name: "id", Type: "bigint"}},
+		Source: sqlset.Source{File: "users.sql", Line: 1, EndLine: 5},
+	}, q)
+
+	// The returned values are copies.
+	q.Meta.Tags[0] = "changed"
+	q.Params[0].Name = "changed"
+
+	q, err = sqlSet.Describe("users", "GetUser")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"auth"}, q.Meta.Tags)
+	assert.Equal(t, "id", q.Params[0].Name)
+
+	_, err = sqlSet.Describe("users", "Missing")
+	require.ErrorIs(t, err, sqlset.ErrQueryNotFound)
+}
+
+func TestSource(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"sub/users.sql": &fstest.MapFile{Data: []byte(`--META
+{"name": "Users"}
+--end
+
+--SQL:GetUser
+SELECT id
+FROM users;
+--end
+
+-- Raw block.
+--SQLRAW:GetRaw
+SELECT 1;
+--end
+`)},
+	}
+
+	sqlSet, err := sqlset.New(fsys)
+	require.NoError(t, err)
+
+	file, line := sqlSet.Source("users", "GetUser")
+	assert.Equal(t, "sub/users.sql", file)
+	assert.Equal(t, 5, line)
+
+	src, err := sqlSet.QuerySource("users", "GetRaw")
+	require.NoError(t, err)
+	assert.Equal(t, sqlset.Source{File: "sub/users.sql", Line: 11, EndLine: 13}, src)
+
+	file, line = sqlSet.Source("users", "Unknown")
+	assert.Empty(t, file)
+	assert.Zero(t, line)
+
+	_, err = sqlSet.QuerySource("users", "Unknown")
+	require.ErrorIs(t, err, sqlset.ErrQueryNotFound)
+
+	t.Run("implicit end", func(t *testing.T) {
+		t.Parallel()
+
+		sqlSet, err := sqlset.New(fstest.MapFS{
+			"q.sql": &fstest.MapFile{Data: []byte("-- name: One\nSELECT 1;\n\n-- name: Two\nSELECT 2;\n")},
+		}, sqlset.WithDotSQLCompat())
+		require.NoError(t, err)
+
+		src, err := sqlSet.QuerySource("q", "One")
+		require.NoError(t, err)
+		assert.Equal(t, sqlset.Source{File: "q.sql", Line: 1, EndLine: 3}, src)
+
+		src, err = sqlSet.QuerySource("q", "Two")
+		require.NoError(t, err)
+		assert.Equal(t, sqlset.Source{File: "q.sql", Line: 4, EndLine: 5}, src)
+	})
+
+	t.Run("bundle", func(t *testing.T) {
+		t.Parallel()
+
+		data, err := sqlSet.MarshalBundle()
+		require.NoError(t, err)
+
+		loaded, err := sqlset.NewFromBundle(data)
+		require.NoError(t, err)
+
+		file, line := loaded.Source("users", "GetUser")
+		assert.Equal(t, "sub/users.sql", file)
+		assert.Equal(t, 5, line)
+	})
+
+	t.Run("registered in code", func(t *testing.T) {
+		t.Parallel()
+
+		qs := sqlset.NewQuerySet(sqlset.QuerySetMeta{ID: "code"})
+		require.NoError(t, qs.Register("One", "SELECT 1;"))
+
+		sqlSet := &sqlset.SQLSet{}
+		require.NoError(t, sqlSet.AddSet(qs))
+
+		src, err := sqlSet.QuerySource("code", "One")
+		require.NoError(t, err)
+		assert.Zero(t, src)
+	})
+}
+
+func TestSQLSet_LastModified(t *testing.T) {
+	t.Parallel()
+
+	older := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+
+	sqlSet, err := sqlset.New(fstest.MapFS{
+		"users.sql": &fstest.MapFile{Data: []byte("--SQL:GetUser\nSELECT 1;\n--end"), ModTime: older},
+		"posts.sql": &fstest.MapFile{Data: []byte("--SQL:GetPost\nSELECT 2;\n--end"), ModTime: newer},
+	})
+	require.NoError(t, err)
+
+	modTime, err := sqlSet.LastModified("users")
+	require.NoError(t, err)
+	assert.Equal(t, older, modTime)
+
+	assert.Equal(t, newer, sqlSet.ModTime())
+	assert.Equal(t, newer, sqlSet.Freeze().ModTime())
+
+	_, err = sqlSet.LastModified("unknown")
+	require.ErrorIs(t, err, sqlset.ErrQuerySetNotFound)
+
+	data, err := sqlSet.MarshalBundle()
+	require.NoError(t, err)
+
+	loaded, err := sqlset.NewFromBundle(data)
+	require.NoError(t, err)
+
+	modTime, err = loaded.LastModified("posts")
+	require.NoError(t, err)
+	assert.True(t, newer.Equal(modTime))
+
+	// Sets built in code have no modification time.
+	qs := sqlset.NewQuerySet(sqlset.QuerySetMeta{ID: "code"})
+	require.NoError(t, sqlSet.AddSet(qs))
+
+	modTime, err = sqlSet.LastModified("code")
+	require.NoError(t, err)
+	assert.True(t, modTime.IsZero())
+}
+
+func TestSQLSet_AddSet(t *testing.T) {
+	t.Parallel()
+
+	sqlSet, err := sqlset.New(testdataValidMulti)
+	require.NoError(t, err)
+
+	fileSets := len(sqlSet.GetSetsMetas())
+
+	models := sqlset.NewQuerySet(sqlset.QuerySetMeta{ID: "models", Description: "Generated from the models"})
+	require.NoError(t, models.Register("GetAccount", "SELECT * FROM accounts WHERE id = $1"))
+	require.NoError(t, models.Register("CountAccounts", "SELECT count(*) FROM accounts"))
+	require.ErrorIs(t, models.Register("", "SELECT 1"), sqlset.ErrArgumentEmpty)
+	require.ErrorIs(t, models.Register("Blank", " \n"), sqlset.ErrEmptyQuery)
+
+	require.NoError(t, sqlSet.AddSet(models))
+
+	// Registered after adding, not visible in the SQLSet.
+	require.NoError(t, models.Register("Late", "SELECT 1"))
+
+	assert.Len(t, sqlSet.GetSetsMetas(), fileSets+1)
+	assert.Equal(t, "SELECT * FROM accounts WHERE id = $1", sqlSet.MustGet("models.GetAccount"))
+
+	ids, err := sqlSet.GetQueryIDs("models")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"CountAccounts", "GetAccount"}, ids)
+
+	meta, err := sqlSet.GetMetaLocalized("models", "")
+	require.NoError(t, err)
+	assert.Equal(t, sqlset.QuerySetMeta{ID: "models", Name: "models", Description: "Generated from the models"}, meta)
+
+	require.ErrorIs(t, sqlSet.AddSet(models), sqlset.ErrQuerySetExists)
+	require.ErrorIs(t, sqlSet.AddSet(models), sqlset.ErrDuplicate)
+	require.ErrorIs(t, sqlSet.AddSet(sqlset.NewQuerySet(sqlset.QuerySetMeta{})), sqlset.ErrArgumentEmpty)
+}
+
+func TestSQLSet_AddSet_ModTime(t *testing.T) {
+	t.Parallel()
+
+	modTime := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	qs := sqlset.NewQuerySet(sqlset.QuerySetMeta{ID: "users"})
+	require.NoError(t, qs.Register("Get", "SELECT 1"))
+	sqlset.SetModTime(qs, modTime)
+
+	var sqlSet sqlset.SQLSet
+	require.NoError(t, sqlSet.AddSet(qs))
+
+	lastModified, err := sqlSet.LastModified("users")
+	require.NoError(t, err)
+	assert.Equal(t, modTime, lastModified)
+	assert.Equal(t, modTime, sqlSet.ModTime())
+}
+
+func TestSQLSet_AddSet_ZeroValue(t *testing.T) {
+	t.Parallel()
+
+	qs := sqlset.NewQuerySet(sqlset.QuerySetMeta{ID: "users", Name: "Users"})
+	require.NoError(t, qs.Register("Get", "SELECT 1"))
+
+	var sqlSet sqlset.SQLSet
+	require.NoError(t, sqlSet.AddSet(qs))
+
+	assert.Equal(t, "SELECT 1", sqlSet.MustGet("Get"))
+	assert.Equal(t, []sqlset.QuerySetMeta{{ID: "users", Name: "Users"}}, sqlSet.GetSetsMetas())
+}
+
+func TestMergeWithPrefix(t *testing.T) {
+	t.Parallel()
+
+	newSets := func(t *testing.T) (*sqlset.SQLSet, *sqlset.SQLSet) {
+		t.Helper()
+
+		local, err := sqlset.New(fstest.MapFS{
+			"users.sql": &fstest.MapFile{Data: []byte("--SQL:GetUser\nSELECT 'local';\n--end")},
+		})
+		require.NoError(t, err)
+
+		vendor, err := sqlset.New(fstest.MapFS{
+			"users.sql": &fstest.MapFile{Data: []byte("--META\n{\"name\": \"Vendor users\"}\n--end\n--SQL:GetUser\nSELECT 'vendor';\n--end")},
+			"audit.sql": &fstest.MapFile{Data: []byte("--SQL:Log\nSELECT 'audit';\n--end")},
+		})
+		require.NoError(t, err)
+
+		return local, vendor
+	}
+
+	t.Run("merge", func(t *testing.T) {
+		t.Parallel()
+
+		local, vendor := newSets(t)
+		require.NoError(t, local.MergeWithPrefix(vendor, "vendor"))
+
+		assert.Equal(t, "SELECT 'local';", local.MustGet("users", "GetUser"))
+		assert.Equal(t, "SELECT 'local';", local.MustGet("users.GetUser"))
+		assert.Equal(t, "SELECT 'vendor';", local.MustGet("vendor.users", "GetUser"))
+		assert.Equal(t, "SELECT 'vendor';", local.MustGet("vendor.users.GetUser"))
+		assert.Equal(t, "SELECT 'audit';", local.MustGet("vendor.audit.Log"))
+
+		meta, err := local.GetMetaLocalized("vendor.users", "")
+		require.NoError(t, err)
+		assert.Equal(t, sqlset.QuerySetMeta{ID: "vendor.users", Name: "Vendor users"}, meta)
+
+		// The vendor pack is untouched.
+		assert.Equal(t, "SELECT 'vendor';", vendor.MustGet("users", "GetUser"))
+		assert.Len(t, vendor.GetSetsMetas(), 2)
+
+		_, err = local.Get("vendor.missing.GetUser")
+		require.ErrorIs(t, err, sqlset.ErrQuerySetNotFound)
+	})
+
+	t.Run("collision", func(t *testing.T) {
+		t.Parallel()
+
+		local, vendor := newSets(t)
+		require.NoError(t, local.MergeWithPrefix(vendor, "vendor"))
+
+		err := local.MergeWithPrefix(vendor, "vendor")
+		require.ErrorIs(t, err, sqlset.ErrQuerySetExists)
+		assert.Len(t, local.GetSetsMetas(), 3)
+	})
+
+	t.Run("empty prefix", func(t *testing.T) {
+		t.Parallel()
+
+		local, vendor := newSets(t)
+		require.ErrorIs(t, local.MergeWithPrefix(vendor, ""), sqlset.ErrArgumentEmpty)
+	})
+}
+
+type tenantKey struct{}
+
+func TestWithRewriter(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"users.sql": &fstest.MapFile{Data: []byte(`--SQL:ListUsers
+--META: {"soft_delete_table": "u"}
+SELECT * FROM {{schema}}.users u;
+--end`)},
+	}
+
+	var keys []sqlset.QueryKey
+
+	sqlSet, err := sqlset.New(fsys,
+		sqlset.WithSoftDelete(sqlset.SoftDeleteCondition("deleted_at")),
+		sqlset.WithRewriter(func(ctx context.Context, key sqlset.QueryKey, sql string) (string, error) {
+			keys = append(keys, key)
+
+			schema, _ := ctx.Value(tenantKey{}).(string)
+			if schema == "" {
+				schema = "public"
+			}
+
+			return strings.ReplaceAll(sql, "{{schema}}", schema), nil
+		}),
+		sqlset.WithRewriter(func(_ context.Context, _ sqlset.QueryKey, sql string) (string, error) {
+			return "/* app */ " + sql, nil
+		}),
+	)
+	require.NoError(t, err)
+
+	ctx := context.WithValue(t.Context(), tenantKey{}, "tenant_42")
+
+	q, err := sqlSet.GetContext(ctx, "users.ListUsers")
+	require.NoError(t, err)
+	assert.Equal(t, "/* app */ SELECT * FROM tenant_42.users u WHERE u.deleted_at IS NULL;", q)
+
+	q, err = sqlSet.Get("ListUsers")
+	require.NoError(t, err)
+	assert.Equal(t, "/* app */ SELECT * FROM public.users u WHERE u.deleted_at IS NULL;", q)
+
+	assert.Equal(t, []sqlset.QueryKey{
+		{SetID: "users", QueryID: "ListUsers"},
+		{SetID: "users", QueryID: "ListUsers"},
+	}, keys)
+}
+
+func TestWithRewriter_WhenRewriterFails_ExpectError(t *testing.T) {
+	t.Parallel()
+
+	errTenant := errors.New("no tenant")
+
+	sqlSet, err := sqlset.New(testdataValidMulti,
+		sqlset.WithRewriter(func(context.Context, sqlset.QueryKey, string) (string, error) {
+			return "", errTenant
+		}),
+	)
+	require.NoError(t, err)
+
+	metas := sqlSet.GetSetsMetas()
+	require.NotEmpty(t, metas)
+
+	ids, err := sqlSet.GetQueryIDs(metas[0].ID)
+	require.NoError(t, err)
+
+	_, err = sqlSet.GetContext(t.Context(), metas[0].ID, ids[0])
+	require.ErrorIs(t, err, errTenant)
+	assert.Panics(t, func() { sqlSet.MustGet(metas[0].ID, ids[0]) })
+}
+
+func TestSafeProvider(t *testing.T) {
+	t.Parallel()
+
+	src := sqlset.ProviderFunc(func(setID, queryID string) (string, error) {
+		switch queryID {
+		case "GetUser":
+			return "SELECT 1;", nil
+		case "Panic":
+			panic("boom")
+		default:
+			return "", sqlset.ErrQueryNotFound
+		}
+	})
+
+	var reported []string
+
+	p := sqlset.NewSafeProvider(src, func(key string, err error) {
+		reported = append(reported, key+": "+err.Error())
+	})
+
+	assert.Equal(t, "SELECT 1;", p.MustGet("users", "GetUser"))
+	assert.Empty(t, reported)
+
+	assert.NotPanics(t, func() {
+		assert.Empty(t, p.MustGet("users", "Missing"))
+		assert.Empty(t, p.MustGet("users.Panic"))
+	})
+
+	_, err := p.Get("users", "Panic")
+	require.ErrorIs(t, err, sqlset.ErrProviderPanic)
+	assert.Contains(t, err.Error(), "boom")
+
+	assert.Equal(t, []string{
+		"users.Missing: query not found",
+		"users.Panic: provider panicked: boom",
+		"users.Panic: provider panicked: boom",
+	}, reported)
+}
+
+func TestOrderBy(t *testing.T) {
+	t.Parallel()
+
+	allowed := []string{"name", "created_at"}
+
+	tests := []struct {
+		name      string
+		column    string
+		dir       sqlset.Direction
+		expected  string
+		expectErr bool
+	}{
+		{name: "default direction", column: "name", expected: "SELECT * FROM users ORDER BY name ASC"},
+		{name: "descending", column: "created_at", dir: sqlset.Desc, expected: "SELECT * FROM users ORDER BY created_at DESC"},
+		{name: "column not allowed", column: "password", expectErr: true},
+		{name: "injection", column: "name; DROP TABLE users", expectErr: true},
+		{name: "invalid direction", column: "name", dir: "DESC; DROP TABLE users", expectErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			q, err := sqlset.OrderBy("SELECT * FROM users;", test.column, allowed, test.dir)
+			if test.expectErr {
+				require.ErrorIs(t, err, sqlset.ErrSortNotAllowed)
+				assert.Empty(t, q)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, q)
+		})
+	}
+}
+
+func TestSQLSet_GetOrdered(t *testing.T) {
+	t.Parallel()
+
+	sqlSet, err := sqlset.New(fstest.MapFS{
+		"users.sql": &fstest.MapFile{Data: []byte(`--SQL:ListUsers
+--META: {"sortable": ["name", "created_at"]}
+SELECT * FROM users
+--end
+
+--SQL:CountUsers
+SELECT count(*) FROM users
+--end`)},
+	})
+	require.NoError(t, err)
+
+	q, err := sqlSet.GetOrdered("name", sqlset.Desc, "users.ListUsers")
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM users ORDER BY name DESC", q)
+
+	_, err = sqlSet.GetOrdered("id", sqlset.Asc, "users", "ListUsers")
+	require.ErrorIs(t, err, sqlset.ErrSortNotAllowed)
+
+	_, err = sqlSet.GetOrdered("name", sqlset.Asc, "users", "CountUsers")
+	require.ErrorIs(t, err, sqlset.ErrSortNotAllowed)
+}
+
+func TestSQLSet_Search(t *testing.T) {
+	t.Parallel()
+
+	sqlSet, err := sqlset.New(testdataValidMulti)
+	require.NoError(t, err)
+
+	expected := []sqlset.SearchResult{
+		{Key: sqlset.QueryKey{SetID: "test2", QueryID: "query1"}, Line: 1, Text: "SELECT '1' FROM test;"},
+		{Key: sqlset.QueryKey{SetID: "test2", QueryID: "query2"}, Line: 1, Text: "SELECT '2' FROM test;"},
+	}
+
+	t.Run("substring", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Equal(t, expected, sqlSet.Search("from TEST"))
+	})
+
+	t.Run("regexp", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Equal(t, expected, sqlSet.SearchRegexp(regexp.MustCompile(`^SELECT '\d'`)))
+	})
+
+	t.Run("no matches", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Empty(t, sqlSet.Search("orders"))
+	})
+}
+
+func TestStats(t *testing.T) {
+	t.Parallel()
+
+	long := "SELECT\r\n" + strings.Repeat("x,\r\n", 1000) + "1;"
+
+	sqlSet, err := sqlset.New(fstest.MapFS{
+		"small.sql": &fstest.MapFile{Data: []byte("--SQL:One\nSELECT 1;\n--end\n--SQL:Two\nSELECT 2;\n--end")},
+		"large.sql": &fstest.MapFile{Data: []byte("--SQL:Long\n" + long + "\n--end")},
+	})
+	require.NoError(t, err)
+
+	footprint := sqlSet.MemoryFootprint()
+	require.Len(t, footprint, 2)
+	assert.Greater(t, footprint["small"], len("SELECT 1;SELECT 2;"))
+	assert.Greater(t, footprint["large"], len(long))
+	assert.Greater(t, footprint["large"], footprint["small"])
+
+	stats := sqlSet.Stats()
+	assert.Equal(t, 2, stats.Sets)
+	assert.Equal(t, 3, stats.Queries)
+	assert.Equal(t, footprint["small"]+footprint["large"], stats.Bytes)
+	assert.Equal(t, footprint, stats.Footprint)
+}
+
+func TestSQLSet_Inventory(t *testing.T) {
+	t.Parallel()
+
+	sqlSet, err := sqlset.New(fstest.MapFS{
+		"users.sql": &fstest.MapFile{Data: []byte(`--SQL:ListUsers
+--META: {"tags": ["reporting", "billing"], "deprecated": true}
+SELECT * FROM users;
+--end
+--SQL:GetUser
+SELECT * FROM users WHERE id = $1;
+--end`)},
+	})
+	require.NoError(t, err)
+
+	hash := func(sql string) string {
+		sum := sha256.Sum256([]byte(sql))
+
+		return hex.EncodeToString(sum[:])
+	}
+
+	assert.Equal(t, []sqlset.InventoryEntry{
+		{
+			Key:  sqlset.QueryKey{SetID: "users", QueryID: "GetUser"},
+			Hash: hash("SELECT * FROM users WHERE id = $1;"),
+			Size: 34,
+		},
+		{
+			Key:        sqlset.QueryKey{SetID: "users", QueryID: "ListUsers"},
+			Hash:       hash("SELECT * FROM users;"),
+			Size:       20,
+			Tags:       []string{"reporting", "billing"},
+			Deprecated: true,
+		},
+	}, sqlSet.Inventory())
+
+	meta, err := sqlSet.GetQueryMeta("users", "ListUsers")
+	require.NoError(t, err)
+	assert.Equal(t, sqlset.QueryMeta{Tags: []string{"reporting", "billing"}, Deprecated: true}, meta)
+}
@@ -0,0 +1,275 @@
+// Package sqlsetcompat provides thin adapters between sqlset and the query libraries
+// it often replaces or complements, without depending on them, to migrate incrementally
+// in both directions:
+//
+//   - DotSQL exposes a catalog with the method set of a dotsql.DotSql,
+//     WriteDotSQL and ReadDotSQL convert between the catalogs and dotsql files.
+//   - Expr adapts a stored query to the squirrel Sqlizer interface, to seed builders
+//     with base queries.
+package sqlsetcompat
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/istovpets/sqlset"
+)
+
+// Catalog is the interface the adapters use to read the queries.
+// *sqlset.SQLSet and *sqlset.Snapshot implement it.
+type Catalog interface {
+	sqlset.SQLQueriesProvider
+	sqlset.SQLSetsProvider
+}
+
+// The interfaces of the databases the DotSQL methods run on, as in dotsql.
+// *sql.DB, *sql.Conn and *sql.Tx implement them.
+type (
+	Preparer interface {
+		Prepare(query string) (*sql.Stmt, error)
+	}
+	PreparerContext interface {
+		PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	}
+	Queryer interface {
+		Query(query string, args ...any) (*sql.Rows, error)
+	}
+	QueryerContext interface {
+		QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	}
+	QueryRower interface {
+		QueryRow(query string, args ...any) *sql.Row
+	}
+	QueryRowerContext interface {
+		QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	}
+	Execer interface {
+		Exec(query string, args ...any) (sql.Result, error)
+	}
+	ExecerContext interface {
+		ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	}
+)
+
+// DotSQL has the method set of a dotsql.DotSql, so that the code written against it
+// can run the queries of a catalog, declaring the dotsql methods it uses as an interface.
+// The query names are the "setID.queryID" keys:
+//
+//	type queries interface {
+//		Query(db sqlsetcompat.Queryer, name string, args ...any) (*sql.Rows, error)
+//	}
+//
+//	var q queries = sqlsetcompat.NewDotSQL(sqlSet) // was dotsql.LoadFromFile("queries.sql")
+//
+//	rows, err := q.Query(db, "users.ListUsers")
+type DotSQL struct {
+	catalog Catalog
+}
+
+// NewDotSQL returns a dotsql adapter of catalog.
+func NewDotSQL(catalog Catalog) *DotSQL {
+	return &DotSQL{catalog: catalog}
+}
+
+// Raw returns the query text.
+func (d *DotSQL) Raw(name string) (string, error) {
+	return d.catalog.Get(name)
+}
+
+// QueryMap returns the text of all queries by key.
+func (d *DotSQL) QueryMap() map[string]string {
+	queries := make(map[string]string)
+
+	for _, meta := range d.catalog.GetSetsMetas() {
+		ids, err := d.catalog.GetQueryIDs(meta.ID)
+		if err != nil {
+			continue
+		}
+
+		for _, id := range ids {
+			if q, err := d.catalog.Get(meta.ID, id); err == nil {
+				queries[meta.ID+"."+id] = q
+			}
+		}
+	}
+
+	return queries
+}
+
+// Prepare prepares the query on db.
+func (d *DotSQL) Prepare(db Preparer, name string) (*sql.Stmt, error) {
+	return d.PrepareContext(context.Background(), contextless{prepare: db}, name)
+}
+
+// PrepareContext prepares the query on db.
+func (d *DotSQL) PrepareContext(ctx context.Context, db PreparerContext, name string) (*sql.Stmt, error) {
+	q, err := d.catalog.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.PrepareContext(ctx, q)
+}
+
+// Query runs the query on db.
+func (d *DotSQL) Query(db Queryer, name string, args ...any) (*sql.Rows, error) {
+	return d.QueryContext(context.Background(), contextless{query: db}, name, args...)
+}
+
+// QueryContext runs the query on db.
+func (d *DotSQL) QueryContext(ctx context.Context, db QueryerContext, name string, args ...any) (*sql.Rows, error) {
+	q, err := d.catalog.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.QueryContext(ctx, q, args...)
+}
+
+// QueryRow runs the query on db, expecting at most one row.
+func (d *DotSQL) QueryRow(db QueryRower, name string, args ...any) (*sql.Row, error) {
+	return d.QueryRowContext(context.Background(), contextless{queryRow: db}, name, args...)
+}
+
+// QueryRowContext runs the query on db, expecting at most one row.
+func (d *DotSQL) QueryRowContext(
+	ctx context.Context, db QueryRowerContext, name string, args ...any,
+) (*sql.Row, error) {
+	q, err := d.catalog.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.QueryRowContext(ctx, q, args...), nil
+}
+
+// Exec runs the query on db without returning rows.
+func (d *DotSQL) Exec(db Execer, name string, args ...any) (sql.Result, error) {
+	return d.ExecContext(context.Background(), contextless{exec: db}, name, args...)
+}
+
+// ExecContext runs the query on db without returning rows.
+func (d *DotSQL) ExecContext(ctx context.Context, db ExecerContext, name string, args ...any) (sql.Result, error) {
+	q, err := d.catalog.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.ExecContext(ctx, q, args...)
+}
+
+// contextless adapts the databases without context methods to the context interfaces.
+type contextless struct {
+	prepare  Preparer
+	query    Queryer
+	queryRow QueryRower
+	exec     Execer
+}
+
+func (c contextless) PrepareContext(_ context.Context, query string) (*sql.Stmt, error) {
+	return c.prepare.Prepare(query)
+}
+
+func (c contextless) QueryContext(_ context.Context, query string, args ...any) (*sql.Rows, error) {
+	return c.query.Query(query, args...)
+}
+
+func (c contextless) QueryRowContext(_ context.Context, query string, args ...any) *sql.Row {
+	return c.queryRow.QueryRow(query, args...)
+}
+
+func (c contextless) ExecContext(_ context.Context, query string, args ...any) (sql.Result, error) {
+	return c.exec.Exec(query, args...)
+}
+
+// dotsqlName is the prefix of the lines naming the queries of dotsql files.
+const dotsqlName = "-- name:"
+
+// WriteDotSQL writes all queries of catalog in the dotsql format, named by their keys,
+// so that they can be loaded with dotsql.LoadFromString or dotsql.LoadFromFile.
+func WriteDotSQL(w io.Writer, catalog Catalog) error {
+	queries := NewDotSQL(catalog).QueryMap()
+
+	var keys []string
+
+	for _, meta := range catalog.GetSetsMetas() {
+		ids, err := catalog.GetQueryIDs(meta.ID)
+		if err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			if _, ok := queries[meta.ID+"."+id]; ok {
+				keys = append(keys, meta.ID+"."+id)
+			}
+		}
+	}
+
+	slices.Sort(keys)
+
+	var sb strings.Builder
+
+	for i, key := range keys {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+
+		fmt.Fprintf(&sb, "%s %s\n%s\n", dotsqlName, key, strings.ReplaceAll(queries[key], "\r\n", "\n"))
+	}
+
+	_, err := io.WriteString(w, sb.String())
+
+	return err
+}
+
+// ReadDotSQL reads the queries of a dotsql file into a query set with the given ID,
+// to be added to an SQLSet with AddSet. The query IDs are the dotsql names.
+func ReadDotSQL(r io.Reader, setID string) (*sqlset.QuerySet, error) {
+	qs := sqlset.NewQuerySet(sqlset.QuerySetMeta{ID: setID})
+
+	var (
+		name  string
+		lines []string
+	)
+
+	register := func() error {
+		if name == "" {
+			return nil
+		}
+
+		return qs.Register(name, strings.TrimSpace(strings.Join(lines, "\n")))
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		next, ok := strings.CutPrefix(strings.TrimSpace(line), dotsqlName)
+		if !ok {
+			lines = append(lines, line)
+
+			continue
+		}
+
+		if err := register(); err != nil {
+			return nil, err
+		}
+
+		name, lines = strings.TrimSpace(next), nil
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read dotsql: %w", err)
+	}
+
+	if err := register(); err != nil {
+		return nil, err
+	}
+
+	return qs, nil
+}
@@ -0,0 +1,155 @@
+package sqlsetcompat_test
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/istovpets/sqlset"
+	"github.com/istovpets/sqlset/sqlsetcompat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCatalog(t *testing.T) *sqlset.SQLSet {
+	t.Helper()
+
+	users := sqlset.NewQuerySet(sqlset.QuerySetMeta{ID: "users"})
+	require.NoError(t, users.Register("GetUser", "SELECT * FROM users WHERE id = ?;"))
+	require.NoError(t, users.Register("ActiveUsers", "SELECT * FROM users WHERE active"))
+
+	posts := sqlset.NewQuerySet(sqlset.QuerySetMeta{ID: "posts"})
+	require.NoError(t, posts.Register("DeletePost", "DELETE FROM posts\nWHERE id = ?;"))
+
+	sqlSet := &sqlset.SQLSet{}
+	require.NoError(t, sqlSet.AddSet(users))
+	require.NoError(t, sqlSet.AddSet(posts))
+
+	return sqlSet
+}
+
+type fakeDB struct {
+	query string
+	args  []any
+}
+
+func (db *fakeDB) ExecContext(_ context.Context, query string, args ...any) (sql.Result, error) {
+	db.query, db.args = query, args
+
+	return nil, nil
+}
+
+func (db *fakeDB) Query(query string, args ...any) (*sql.Rows, error) {
+	db.query, db.args = query, args
+
+	return nil, nil
+}
+
+func TestDotSQL(t *testing.T) {
+	t.Parallel()
+
+	d := sqlsetcompat.NewDotSQL(newCatalog(t))
+
+	q, err := d.Raw("users.GetUser")
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM users WHERE id = ?;", q)
+
+	_, err = d.Raw("users.Missing")
+	require.ErrorIs(t, err, sqlset.ErrQueryNotFound)
+
+	assert.Equal(t, map[string]string{
+		"users.GetUser":     "SELECT * FROM users WHERE id = ?;",
+		"users.ActiveUsers": "SELECT * FROM users WHERE active",
+		"posts.DeletePost":  "DELETE FROM posts\nWHERE id = ?;",
+	}, d.QueryMap())
+
+	db := &fakeDB{}
+
+	_, err = d.ExecContext(context.Background(), db, "posts.DeletePost", 7)
+	require.NoError(t, err)
+	assert.Equal(t, "DELETE FROM posts\nWHERE id = ?;", db.query)
+	assert.Equal(t, []any{7}, db.args)
+
+	_, err = d.Query(db, "users.GetUser", 1) //nolint:rowserrcheck,sqlclosecheck
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM users WHERE id = ?;", db.query)
+	assert.Equal(t, []any{1}, db.args)
+
+	_, err = d.Query(db, "users.Missing") //nolint:rowserrcheck,sqlclosecheck
+	require.ErrorIs(t, err, sqlset.ErrQueryNotFound)
+}
+
+func TestDotSQLFormat(t *testing.T) {
+	t.Parallel()
+
+	var sb strings.Builder
+	require.NoError(t, sqlsetcompat.WriteDotSQL(&sb, newCatalog(t)))
+
+	expected := "-- name: posts.DeletePost\nDELETE FROM posts\nWHERE id = ?;\n\n" +
+		"-- name: users.ActiveUsers\nSELECT * FROM users WHERE active\n\n" +
+		"-- name: users.GetUser\nSELECT * FROM users WHERE id = ?;\n"
+	assert.Equal(t, expected, sb.String())
+
+	qs, err := sqlsetcompat.ReadDotSQL(strings.NewReader("-- comment\n"+expected), "legacy")
+	require.NoError(t, err)
+
+	sqlSet := &sqlset.SQLSet{}
+	require.NoError(t, sqlSet.AddSet(qs))
+
+	q, err := sqlSet.Get("legacy", "posts.DeletePost")
+	require.NoError(t, err)
+	assert.Equal(t, "DELETE FROM posts\nWHERE id = ?;", q)
+
+	ids, err := sqlSet.GetQueryIDs("legacy")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"posts.DeletePost", "users.ActiveUsers", "users.GetUser"}, ids)
+}
+
+func TestExpr(t *testing.T) {
+	t.Parallel()
+
+	catalog := newCatalog(t)
+
+	tests := []struct {
+		name         string
+		sqlizer      sqlsetcompat.Sqlizer
+		expectedSQL  string
+		expectedArgs []any
+		expectedErr  error
+	}{
+		{
+			name:         "query",
+			sqlizer:      sqlsetcompat.Expr(catalog, "users.GetUser", 1),
+			expectedSQL:  "SELECT * FROM users WHERE id = ?",
+			expectedArgs: []any{1},
+		},
+		{
+			name:        "cte",
+			sqlizer:     sqlsetcompat.CTE(catalog, "active", "users.ActiveUsers"),
+			expectedSQL: "WITH active AS (SELECT * FROM users WHERE active)",
+		},
+		{
+			name:        "missing",
+			sqlizer:     sqlsetcompat.Expr(catalog, "users.Missing"),
+			expectedErr: sqlset.ErrQueryNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			q, args, err := test.sqlizer.ToSql()
+			if test.expectedErr != nil {
+				require.ErrorIs(t, err, test.expectedErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedSQL, q)
+			assert.Equal(t, test.expectedArgs, args)
+		})
+	}
+}
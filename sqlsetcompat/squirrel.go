@@ -0,0 +1,58 @@
+package sqlsetcompat
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/istovpets/sqlset"
+)
+
+// Sqlizer is the squirrel.Sqlizer interface, implemented by the squirrel builders
+// and accepted by them, e.g. by Where, PrefixExpr and FromSelect.
+type Sqlizer interface {
+	ToSql() (string, []any, error)
+}
+
+// Expr returns the stored query of key with its arguments as a Sqlizer, to seed
+// squirrel builders with base queries:
+//
+//	sq.Select("*").FromSelect(sqlsetcompat.Expr(sqlSet, "users.ActiveUsers"), "u")
+//
+// squirrel renumbers "?" placeholders only, so the queries combined with builders
+// should use "?" and the builder set the placeholder format of the database.
+// The query is looked up when the builder renders it, a missing query failing ToSql,
+// and its trailing semicolon is dropped to nest it in the builder's statement.
+func Expr(p sqlset.SQLQueriesProvider, key string, args ...any) Sqlizer {
+	return expr{provider: p, key: key, args: args}
+}
+
+// CTE is like Expr but wraps the query as a common table expression named name,
+// to be prepended to a builder with PrefixExpr:
+//
+//	sq.Select("*").From("active").PrefixExpr(sqlsetcompat.CTE(sqlSet, "active", "users.ActiveUsers"))
+func CTE(p sqlset.SQLQueriesProvider, name, key string, args ...any) Sqlizer {
+	return expr{provider: p, key: key, args: args, cte: name}
+}
+
+type expr struct {
+	provider sqlset.SQLQueriesProvider
+	key      string
+	args     []any
+	cte      string
+}
+
+// ToSql returns the query and its arguments.
+func (e expr) ToSql() (string, []any, error) { //nolint:revive // squirrel's method name.
+	q, err := e.provider.Get(e.key)
+	if err != nil {
+		return "", nil, err
+	}
+
+	q = strings.TrimRight(strings.TrimSpace(q), ";")
+
+	if e.cte != "" {
+		q = fmt.Sprintf("WITH %s AS (%s)", e.cte, q)
+	}
+
+	return q, e.args, nil
+}
@@ -0,0 +1,81 @@
+package sqlsetdb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// TxBeginner is implemented by *sql.DB and *sql.Conn.
+type TxBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// ApplyResult is the outcome of a query run by Apply.
+type ApplyResult struct {
+	Key string `json:"key"`
+	// RowsAffected is the number of rows changed by the query, -1 if the driver does not report it.
+	RowsAffected int64 `json:"rows_affected"`
+}
+
+// Apply runs the queries of keys, given as "setID.queryID", in order in a single transaction,
+// e.g. the steps of an operational runbook stored as .sql files. All queries are looked up
+// before the transaction starts. A failing query rolls the transaction back.
+// In dry-run mode the queries are executed and then rolled back, so the affected
+// row counts can be reviewed without changing anything:
+//
+//	results, err := sqlsetdb.Apply(ctx, db, sqlSet, []string{"fix.BackfillEmails", "fix.DropStale"}, true)
+func Apply(
+	ctx context.Context, db TxBeginner, catalog QueryProvider, keys []string, dryRun bool,
+) ([]ApplyResult, error) {
+	queries := make([]string, len(keys))
+
+	for i, key := range keys {
+		q, err := catalog.GetContext(ctx, key)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+
+		queries[i] = q
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin: %w", err)
+	}
+
+	results := make([]ApplyResult, 0, len(keys))
+
+	for i, query := range queries {
+		res, err := tx.ExecContext(ctx, query)
+		if err != nil {
+			return results, errors.Join(fmt.Errorf("%s: %w", keys[i], err), rollback(tx))
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			n = -1
+		}
+
+		results = append(results, ApplyResult{Key: keys[i], RowsAffected: n})
+	}
+
+	if dryRun {
+		return results, rollback(tx)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return results, fmt.Errorf("commit: %w", err)
+	}
+
+	return results, nil
+}
+
+func rollback(tx *sql.Tx) error {
+	if err := tx.Rollback(); err != nil {
+		return fmt.Errorf("rollback: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package sqlsetdb_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/istovpets/sqlset"
+	"github.com/istovpets/sqlset/sqlsetdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRunbook(t *testing.T) *sqlset.SQLSet {
+	t.Helper()
+
+	sqlSet, err := sqlset.New(fstest.MapFS{
+		"fix.sql": &fstest.MapFile{Data: []byte(`--SQL:Backfill
+UPDATE users SET email = lower(email);
+--end
+
+--SQL:Cleanup
+DELETE FROM sessions WHERE expired;
+--end
+
+--SQL:Broken
+DELETE FROM missing_table;
+--end`)},
+	})
+	require.NoError(t, err)
+
+	return sqlSet
+}
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		dryRun bool
+		end    string
+	}{
+		{name: "commit", end: "COMMIT"},
+		{name: "dry run", dryRun: true, end: "ROLLBACK"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fake, db := newFakeDB(t)
+
+			results, err := sqlsetdb.Apply(context.Background(), db, newRunbook(t),
+				[]string{"fix.Backfill", "fix.Cleanup"}, tt.dryRun)
+			require.NoError(t, err)
+			assert.Equal(t, []sqlsetdb.ApplyResult{
+				{Key: "fix.Backfill", RowsAffected: 1},
+				{Key: "fix.Cleanup", RowsAffected: 1},
+			}, results)
+			assert.Equal(t, []string{
+				"BEGIN",
+				"UPDATE users SET email = lower(email);",
+				"DELETE FROM sessions WHERE expired;",
+				tt.end,
+			}, fake.statements())
+		})
+	}
+}
+
+func TestApply_WhenQueryFails_ExpectRollback(t *testing.T) {
+	t.Parallel()
+
+	errMissing := errors.New(`relation "missing_table" does not exist`)
+
+	fake, db := newFakeDB(t)
+	fake.fail = func(query string) error {
+		if strings.Contains(query, "missing_table") {
+			return errMissing
+		}
+
+		return nil
+	}
+
+	results, err := sqlsetdb.Apply(context.Background(), db, newRunbook(t),
+		[]string{"fix.Backfill", "fix.Broken", "fix.Cleanup"}, false)
+	require.ErrorIs(t, err, errMissing)
+	assert.ErrorContains(t, err, "fix.Broken")
+	assert.Len(t, results, 1)
+	assert.Equal(t, []string{"BEGIN", "UPDATE users SET email = lower(email);", "ROLLBACK"}, fake.statements())
+
+	_, err = sqlsetdb.Apply(context.Background(), db, newRunbook(t), []string{"fix.Missing"}, false)
+	require.ErrorIs(t, err, sqlset.ErrQueryNotFound)
+}
@@ -0,0 +1,97 @@
+package sqlsetdb
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/istovpets/sqlset"
+)
+
+// BenchQuery is a query run by Bench with its arguments.
+type BenchQuery struct {
+	Key  sqlset.QueryKey
+	Args []any
+}
+
+// BenchResult holds the latency distribution of a benchmarked query.
+type BenchResult struct {
+	Key  sqlset.QueryKey `json:"key"`
+	Runs int             `json:"runs"`
+	Min  time.Duration   `json:"min"`
+	P50  time.Duration   `json:"p50"`
+	P90  time.Duration   `json:"p90"`
+	P99  time.Duration   `json:"p99"`
+	Max  time.Duration   `json:"max"`
+}
+
+// Bench runs every query runs times in a row and returns the latency percentiles
+// per query, in the order of queries. The latency of a run includes reading all
+// the result rows. Bench stops at the first failing query.
+func Bench(ctx context.Context, db Queryer, catalog QueryProvider, runs int, queries ...BenchQuery) ([]BenchResult, error) {
+	if runs <= 0 {
+		return nil, fmt.Errorf("runs %d: %w", runs, sqlset.ErrInvalidArgCount)
+	}
+
+	results := make([]BenchResult, 0, len(queries))
+
+	for _, bq := range queries {
+		q, err := catalog.GetContext(ctx, bq.Key.SetID, bq.Key.QueryID)
+		if err != nil {
+			return nil, err
+		}
+
+		latencies := make([]time.Duration, runs)
+
+		for i := range latencies {
+			start := time.Now()
+
+			if err := run(ctx, db, q, bq.Args); err != nil {
+				return nil, fmt.Errorf("%s: %w", bq.Key, err)
+			}
+
+			latencies[i] = time.Since(start)
+		}
+
+		slices.Sort(latencies)
+
+		results = append(results, BenchResult{
+			Key:  bq.Key,
+			Runs: runs,
+			Min:  latencies[0],
+			P50:  percentile(latencies, 0.5),
+			P90:  percentile(latencies, 0.9),
+			P99:  percentile(latencies, 0.99),
+			Max:  latencies[runs-1],
+		})
+	}
+
+	return results, nil
+}
+
+func run(ctx context.Context, db Queryer, query string, args []any) error {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	for rows.Next() {
+	}
+
+	if err := rows.Err(); err != nil {
+		_ = rows.Close()
+
+		return err
+	}
+
+	return rows.Close()
+}
+
+// percentile returns the nearest-rank percentile p of the sorted latencies.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	i := int(math.Ceil(p*float64(len(sorted)))) - 1
+
+	return sorted[max(i, 0)]
+}
@@ -0,0 +1,64 @@
+package sqlsetdb_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/istovpets/sqlset"
+	"github.com/istovpets/sqlset/sqlsetdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBench(t *testing.T) {
+	t.Parallel()
+
+	fake, db := newFakeDB(t)
+	fake.rows = func(string, []driver.Value) ([]string, [][]driver.Value) {
+		return []string{"id"}, [][]driver.Value{{int64(1)}, {int64(2)}}
+	}
+
+	key := sqlset.QueryKey{SetID: "users", QueryID: "GetUser"}
+
+	results, err := sqlsetdb.Bench(context.Background(), db, newTestSet(t), 10,
+		sqlsetdb.BenchQuery{Key: key, Args: []any{1}},
+	)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+
+	r := results[0]
+	assert.Equal(t, key, r.Key)
+	assert.Equal(t, 10, r.Runs)
+	assert.LessOrEqual(t, r.Min, r.P50)
+	assert.LessOrEqual(t, r.P50, r.P90)
+	assert.LessOrEqual(t, r.P90, r.P99)
+	assert.LessOrEqual(t, r.P99, r.Max)
+	assert.Len(t, fake.statements(), 10)
+}
+
+func TestBench_WhenQueryFails_ExpectError(t *testing.T) {
+	t.Parallel()
+
+	errMissing := errors.New(`relation "missing_table" does not exist`)
+
+	fake, db := newFakeDB(t)
+	fake.fail = func(query string) error {
+		if strings.Contains(query, "missing_table") {
+			return errMissing
+		}
+
+		return nil
+	}
+
+	_, err := sqlsetdb.Bench(context.Background(), db, newTestSet(t), 3,
+		sqlsetdb.BenchQuery{Key: sqlset.QueryKey{SetID: "users", QueryID: "GetLegacy"}},
+	)
+	require.ErrorIs(t, err, errMissing)
+	assert.ErrorContains(t, err, "users.GetLegacy")
+
+	_, err = sqlsetdb.Bench(context.Background(), db, newTestSet(t), 0)
+	require.ErrorIs(t, err, sqlset.ErrInvalidArgCount)
+}
@@ -0,0 +1,134 @@
+package sqlsetdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/istovpets/sqlset"
+)
+
+// ErrMissingCapability is returned when the database lacks an extension required
+// by the queries, see CheckCapabilities.
+var ErrMissingCapability = errors.New("missing capability")
+
+// extensionsQuery lists the installed PostgreSQL extensions.
+const extensionsQuery = "SELECT extname FROM pg_extension"
+
+// CapabilityCatalog is the interface CheckCapabilities uses to read the queries and their metadata.
+// *sqlset.SQLSet and *sqlset.Snapshot implement it.
+type CapabilityCatalog interface {
+	Catalog
+	GetQueryMeta(setID, queryID string) (sqlset.QueryMeta, error)
+}
+
+// Capabilities returns the capabilities declared by the queries of the catalog
+// with "capabilities" metadata, with the sorted keys of the queries requiring them.
+func Capabilities(catalog CapabilityCatalog) (map[string][]sqlset.QueryKey, error) {
+	all, err := keys(catalog)
+	if err != nil {
+		return nil, err
+	}
+
+	capabilities := make(map[string][]sqlset.QueryKey)
+
+	for _, key := range all {
+		meta, err := catalog.GetQueryMeta(key.SetID, key.QueryID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, name := range meta.Capabilities {
+			capabilities[name] = append(capabilities[name], key)
+		}
+	}
+
+	for _, keys := range capabilities {
+		slices.SortFunc(keys, func(a, b sqlset.QueryKey) int {
+			return strings.Compare(a.String(), b.String())
+		})
+	}
+
+	return capabilities, nil
+}
+
+// CheckCapabilities verifies that the PostgreSQL extensions declared by the queries
+// of the catalog are installed, e.g. at startup, so a missing extension fails
+// the deployment rather than the first request running the query:
+//
+//	--SQL:Nearby
+//	--META: {"capabilities": ["postgis"]}
+//	SELECT id FROM places WHERE ST_DWithin(location, $1, $2)
+//	--end
+//
+//	if err := sqlsetdb.CheckCapabilities(ctx, db, sqlSet); err != nil {
+//		log.Fatal(err)
+//	}
+//
+// Every missing extension is reported with the queries requiring it, the errors joined
+// and wrapping ErrMissingCapability.
+func CheckCapabilities(ctx context.Context, db Queryer, catalog CapabilityCatalog) error {
+	capabilities, err := Capabilities(catalog)
+	if err != nil {
+		return err
+	}
+
+	if len(capabilities) == 0 {
+		return nil
+	}
+
+	installed, err := extensions(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+
+	for _, name := range slices.Sorted(maps.Keys(capabilities)) {
+		if installed[name] {
+			continue
+		}
+
+		required := make([]string, len(capabilities[name]))
+		for i, key := range capabilities[name] {
+			required[i] = key.String()
+		}
+
+		errs = append(errs, fmt.Errorf("%s: %w, required by %s", name, ErrMissingCapability, strings.Join(required, ", ")))
+	}
+
+	return errors.Join(errs...)
+}
+
+// extensions returns the names of the installed extensions.
+func extensions(ctx context.Context, db Queryer) (map[string]bool, error) {
+	rows, err := db.QueryContext(ctx, extensionsQuery)
+	if err != nil {
+		return nil, fmt.Errorf("list extensions: %w", err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	installed := make(map[string]bool)
+
+	for rows.Next() {
+		var name string
+
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("list extensions: %w", err)
+		}
+
+		installed[name] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list extensions: %w", err)
+	}
+
+	return installed, nil
+}
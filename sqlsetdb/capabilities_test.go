@@ -0,0 +1,70 @@
+package sqlsetdb_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+	"testing/fstest"
+
+	"github.com/istovpets/sqlset"
+	"github.com/istovpets/sqlset/sqlsetdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckCapabilities(t *testing.T) {
+	t.Parallel()
+
+	sqlSet, err := sqlset.New(fstest.MapFS{
+		"places.sql": &fstest.MapFile{Data: []byte(`--SQL:Nearby
+--META: {"capabilities": ["postgis"]}
+SELECT id FROM places WHERE ST_DWithin(location, $1, $2);
+--end
+
+--SQL:Within
+--META: {"capabilities": ["postgis", "pg_trgm"]}
+SELECT id FROM places WHERE ST_Within(location, $1) AND name % $2;
+--end`)},
+		"users.sql": &fstest.MapFile{Data: []byte(`--SQL:CreateUser
+--META: {"capabilities": ["pgcrypto"]}
+INSERT INTO users (password) VALUES (crypt($1, gen_salt('bf')));
+--end`)},
+	})
+	require.NoError(t, err)
+
+	capabilities, err := sqlsetdb.Capabilities(sqlSet)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]sqlset.QueryKey{
+		"pg_trgm":  {{SetID: "places", QueryID: "Within"}},
+		"pgcrypto": {{SetID: "users", QueryID: "CreateUser"}},
+		"postgis":  {{SetID: "places", QueryID: "Nearby"}, {SetID: "places", QueryID: "Within"}},
+	}, capabilities)
+
+	installed := func(names ...string) func(string, []driver.Value) ([]string, [][]driver.Value) {
+		return func(string, []driver.Value) ([]string, [][]driver.Value) {
+			rows := make([][]driver.Value, len(names))
+			for i, name := range names {
+				rows[i] = []driver.Value{name}
+			}
+
+			return []string{"extname"}, rows
+		}
+	}
+
+	fake, db := newFakeDB(t)
+	fake.rows = installed("plpgsql", "pgcrypto")
+
+	err = sqlsetdb.CheckCapabilities(context.Background(), db, sqlSet)
+	require.ErrorIs(t, err, sqlsetdb.ErrMissingCapability)
+	assert.EqualError(t, err, "pg_trgm: missing capability, required by places.Within\n"+
+		"postgis: missing capability, required by places.Nearby, places.Within")
+	assert.Equal(t, []string{"SELECT extname FROM pg_extension"}, fake.statements())
+
+	fake.rows = installed("plpgsql", "pgcrypto", "postgis", "pg_trgm")
+	require.NoError(t, sqlsetdb.CheckCapabilities(context.Background(), db, sqlSet))
+
+	// Nothing is queried for catalogs without capabilities.
+	fake, db = newFakeDB(t)
+	require.NoError(t, sqlsetdb.CheckCapabilities(context.Background(), db, newTestSet(t)))
+	assert.Empty(t, fake.statements())
+}
@@ -0,0 +1,279 @@
+package sqlsetdb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/istovpets/sqlset"
+)
+
+// DB is implemented by *sql.DB and *sql.Conn.
+type DB interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// MetaCatalog is the interface Executor uses to read queries and their metadata.
+// *sqlset.SQLSet and *sqlset.Snapshot implement it.
+type MetaCatalog interface {
+	GetContext(ctx context.Context, ids ...string) (string, error)
+	GetQueryMeta(setID, queryID string) (sqlset.QueryMeta, error)
+}
+
+// Executor executes the catalog queries, retrying the queries declaring
+// a retry policy on transient errors with exponential backoff, see sqlset.RetryPolicy.
+// Only idempotent queries should declare one. As a failed transaction must be rolled back,
+// the Executor runs on a *sql.DB or *sql.Conn, outside transactions; see InTx for running queries in one.
+// It is safe for concurrent use if its pools are.
+//
+// Queries with the "replica" route run on the replica pool, given with WithReplica,
+// all other queries on the primary pool:
+//
+//	exec := sqlsetdb.NewExecutor(primary, sqlSet, sqlsetdb.WithReplica(replica))
+//
+//	rows, err := exec.QueryContext(ctx, sqlset.QueryKey{SetID: "accounts", QueryID: "GetBalance"}, id)
+type Executor struct {
+	db      DB
+	replica DB
+	catalog MetaCatalog
+
+	backoff    time.Duration
+	maxBackoff time.Duration
+	startSpan  StartSpan
+	settings   SessionSettings
+	// inTx is set for the Executor running in a transaction, see InTx.
+	inTx bool
+}
+
+// Span describes a query execution for tracing, see WithTracing.
+type Span struct {
+	// Name is the span_name of the query metadata, the query key if not declared.
+	Name string
+	Key  sqlset.QueryKey
+	// Statement is the query text, with the literals masked for the queries
+	// marked "sanitized" in the metadata, see sqlset.Redact.
+	Statement string
+	Route     sqlset.Route
+}
+
+// StartSpan starts a span for a query execution and returns the context of the span,
+// passed to the database, and the function ending it with the execution error.
+// It adapts a tracer, e.g. an OpenTelemetry one:
+//
+//	func(ctx context.Context, span sqlsetdb.Span) (context.Context, func(error)) {
+//		ctx, s := tracer.Start(ctx, span.Name, trace.WithAttributes(
+//			semconv.DBQueryText(span.Statement),
+//		))
+//
+//		return ctx, func(err error) {
+//			if err != nil {
+//				s.RecordError(err)
+//			}
+//			s.End()
+//		}
+//	}
+type StartSpan func(ctx context.Context, span Span) (context.Context, func(err error))
+
+// ExecutorOption configures an Executor.
+type ExecutorOption func(*Executor)
+
+// WithBackoff sets the delay before the first retry, doubled for every next retry up to limit.
+// The default is 50ms up to 2s.
+func WithBackoff(initial, limit time.Duration) ExecutorOption {
+	return func(e *Executor) {
+		e.backoff, e.maxBackoff = initial, limit
+	}
+}
+
+// WithReplica sets the pool of the queries routed to a replica, see sqlset.RouteReplica.
+// Without it, they run on the primary pool.
+func WithReplica(db DB) ExecutorOption {
+	return func(e *Executor) {
+		e.replica = db
+	}
+}
+
+// WithTracing makes the Executor trace every execution with a span started by start,
+// retries included, see StartSpan.
+func WithTracing(start StartSpan) ExecutorOption {
+	return func(e *Executor) {
+		e.startSpan = start
+	}
+}
+
+// NewExecutor returns an Executor running the queries of catalog on db, the primary pool.
+func NewExecutor(db DB, catalog MetaCatalog, opts ...ExecutorOption) *Executor {
+	e := &Executor{
+		db:         db,
+		catalog:    catalog,
+		backoff:    50 * time.Millisecond,
+		maxBackoff: 2 * time.Second,
+	}
+
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	return e
+}
+
+// QueryContext executes a query returning rows. Only the execution is retried,
+// errors reading the rows are returned by the rows.
+func (e *Executor) QueryContext(ctx context.Context, key sqlset.QueryKey, args ...any) (*sql.Rows, error) {
+	var rows *sql.Rows
+
+	err := e.do(ctx, key, func(ctx context.Context, db DB, query string) error {
+		var err error
+
+		rows, err = db.QueryContext(ctx, query, args...)
+
+		return err
+	})
+
+	return rows, err
+}
+
+// ExecContext executes a query without returning rows.
+func (e *Executor) ExecContext(ctx context.Context, key sqlset.QueryKey, args ...any) (sql.Result, error) {
+	var result sql.Result
+
+	err := e.do(ctx, key, func(ctx context.Context, db DB, query string) error {
+		var err error
+
+		result, err = db.ExecContext(ctx, query, args...)
+
+		return err
+	})
+
+	return result, err
+}
+
+// do runs the query of key with run on the pool of its route,
+// retrying it as declared by its retry policy.
+func (e *Executor) do(
+	ctx context.Context, key sqlset.QueryKey, run func(ctx context.Context, db DB, query string) error,
+) (err error) {
+	query, err := e.catalog.GetContext(ctx, key.SetID, key.QueryID)
+	if err != nil {
+		return err
+	}
+
+	meta, err := e.catalog.GetQueryMeta(key.SetID, key.QueryID)
+	if err != nil {
+		return err
+	}
+
+	policy := meta.Retry
+	if policy == nil || e.inTx {
+		policy = &sqlset.RetryPolicy{Attempts: 1}
+	}
+
+	db := e.db
+	if meta.Route == sqlset.RouteReplica && e.replica != nil {
+		db = e.replica
+	}
+
+	if e.startSpan != nil {
+		var end func(error)
+
+		ctx, end = e.startSpan(ctx, newSpan(key, query, meta))
+
+		defer func() {
+			end(err)
+		}()
+	}
+
+	if e.settings != nil {
+		if err := e.applySettings(ctx, db); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+	}
+
+	delay := e.backoff
+
+	for attempt := 1; ; attempt++ {
+		err = run(ctx, db, query)
+		if err == nil || attempt >= policy.Attempts || !retryable(err, policy.On) {
+			break
+		}
+
+		if err := sleep(ctx, delay); err != nil {
+			return fmt.Errorf("%s: retry: %w", key, err)
+		}
+
+		delay = min(delay*2, e.maxBackoff)
+	}
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+
+	return nil
+}
+
+func newSpan(key sqlset.QueryKey, query string, meta sqlset.QueryMeta) Span {
+	span := Span{Name: meta.SpanName, Key: key, Statement: query, Route: meta.Route}
+
+	if span.Name == "" {
+		span.Name = key.String()
+	}
+
+	if meta.Sanitized {
+		span.Statement = sqlset.Redact(query, sqlset.DefaultRedactionProfile)
+	}
+
+	return span
+}
+
+// conditions maps the SQLSTATE codes of the transient PostgreSQL errors to their condition names.
+var conditions = map[string]string{
+	"40001": "serialization_failure",
+	"40P01": "deadlock_detected",
+	"55P03": "lock_not_available",
+	"08000": "connection_exception",
+	"08003": "connection_does_not_exist",
+	"08006": "connection_failure",
+	"57P01": "admin_shutdown",
+}
+
+// defaultRetryOn are the conditions retried when a retry policy does not list any.
+var defaultRetryOn = []string{"serialization_failure", "deadlock_detected"}
+
+// retryable reports whether err matches one of the conditions, by SQLSTATE code or condition name.
+// The SQLSTATE code is read from errors implementing SQLState() string, as the pgx and pq errors do.
+func retryable(err error, on []string) bool {
+	var stateErr interface{ SQLState() string }
+
+	if !errors.As(err, &stateErr) {
+		return false
+	}
+
+	if len(on) == 0 {
+		on = defaultRetryOn
+	}
+
+	code := stateErr.SQLState()
+	if slices.Contains(on, code) {
+		return true
+	}
+
+	name, ok := conditions[code]
+
+	return ok && slices.Contains(on, name)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
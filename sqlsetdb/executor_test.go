@@ -0,0 +1,280 @@
+package sqlsetdb_test
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/istovpets/sqlset"
+	"github.com/istovpets/sqlset/sqlsetdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stateError is a driver error with an SQLSTATE code, like the pgx and pq errors.
+type stateError string
+
+func (e stateError) Error() string {
+	return "sqlstate " + string(e)
+}
+
+func (e stateError) SQLState() string {
+	return string(e)
+}
+
+func TestExecutor(t *testing.T) {
+	t.Parallel()
+
+	sqlSet, err := sqlset.New(fstest.MapFS{
+		"accounts.sql": &fstest.MapFile{Data: []byte(`--SQL:GetBalance
+--META: {"retry": {"attempts": 3, "on": ["serialization_failure"]}}
+SELECT balance FROM accounts WHERE id = $1;
+--end
+
+--SQL:Debit
+UPDATE accounts SET balance = balance - $2 WHERE id = $1;
+--end
+
+--SQL:Touch
+--META: {"retry": {"attempts": 5}}
+UPDATE accounts SET touched_at = now();
+--end`)},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		query    string
+		failures int
+		code     string
+		calls    int
+		wantErr  bool
+	}{
+		{name: "retried until success", query: "GetBalance", failures: 2, code: "40001", calls: 3},
+		{name: "attempts exhausted", query: "GetBalance", failures: 5, code: "40001", calls: 3, wantErr: true},
+		{name: "condition not listed", query: "GetBalance", failures: 1, code: "40P01", calls: 1, wantErr: true},
+		{name: "no policy", query: "Debit", failures: 1, code: "40001", calls: 1, wantErr: true},
+		{name: "default conditions", query: "Touch", failures: 1, code: "40P01", calls: 2},
+		{name: "not transient", query: "Touch", failures: 1, code: "23505", calls: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls atomic.Int32
+
+			fake, db := newFakeDB(t)
+			fake.fail = func(string) error {
+				if int(calls.Add(1)) <= tt.failures {
+					return stateError(tt.code)
+				}
+
+				return nil
+			}
+
+			exec := sqlsetdb.NewExecutor(db, sqlSet, sqlsetdb.WithBackoff(time.Millisecond, 2*time.Millisecond))
+
+			_, err := exec.ExecContext(context.Background(), sqlset.QueryKey{SetID: "accounts", QueryID: tt.query}, 1)
+			if tt.wantErr {
+				require.ErrorIs(t, err, stateError(tt.code))
+				assert.ErrorContains(t, err, "accounts."+tt.query)
+			} else {
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.calls, int(calls.Load()))
+		})
+	}
+
+	t.Run("query", func(t *testing.T) {
+		t.Parallel()
+
+		var calls atomic.Int32
+
+		fake, db := newFakeDB(t)
+		fake.fail = func(string) error {
+			if calls.Add(1) == 1 {
+				return stateError("40001")
+			}
+
+			return nil
+		}
+
+		exec := sqlsetdb.NewExecutor(db, sqlSet, sqlsetdb.WithBackoff(time.Millisecond, time.Millisecond))
+
+		rows, err := exec.QueryContext(context.Background(), sqlset.QueryKey{SetID: "accounts", QueryID: "GetBalance"}, 1)
+		require.NoError(t, err)
+		require.NoError(t, rows.Close())
+		assert.Equal(t, int32(2), calls.Load())
+	})
+
+	t.Run("canceled backoff", func(t *testing.T) {
+		t.Parallel()
+
+		fake, db := newFakeDB(t)
+		fake.fail = func(string) error {
+			return stateError("40001")
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		exec := sqlsetdb.NewExecutor(db, sqlSet, sqlsetdb.WithBackoff(time.Hour, time.Hour))
+
+		_, err := exec.ExecContext(ctx, sqlset.QueryKey{SetID: "accounts", QueryID: "GetBalance"})
+		require.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestExecutor_Route(t *testing.T) {
+	t.Parallel()
+
+	sqlSet, err := sqlset.New(fstest.MapFS{
+		"reports.sql": &fstest.MapFile{Data: []byte(`--SQL:Daily
+--META: {"route": "replica"}
+SELECT day, sum(total) FROM orders GROUP BY day;
+--end
+
+--SQL:Refresh
+--META: {"route": "primary"}
+REFRESH MATERIALIZED VIEW daily_totals;
+--end
+
+--SQL:Archive
+DELETE FROM orders WHERE created_at < $1;
+--end`)},
+	})
+	require.NoError(t, err)
+
+	primaryFake, primary := newFakeDB(t)
+	replicaFake, replica := newFakeDB(t)
+
+	exec := sqlsetdb.NewExecutor(primary, sqlSet, sqlsetdb.WithReplica(replica))
+
+	for _, id := range []string{"Daily", "Refresh", "Archive"} {
+		_, err := exec.ExecContext(context.Background(), sqlset.QueryKey{SetID: "reports", QueryID: id})
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, []string{"SELECT day, sum(total) FROM orders GROUP BY day;"}, replicaFake.statements())
+	assert.Len(t, primaryFake.statements(), 2)
+
+	// Without a replica pool every query runs on the primary.
+	fallbackFake, fallback := newFakeDB(t)
+
+	_, err = sqlsetdb.NewExecutor(fallback, sqlSet).ExecContext(context.Background(),
+		sqlset.QueryKey{SetID: "reports", QueryID: "Daily"})
+	require.NoError(t, err)
+	assert.Len(t, fallbackFake.statements(), 1)
+
+	_, err = sqlset.New(fstest.MapFS{
+		"reports.sql": &fstest.MapFile{Data: []byte("--SQL:Get\n--META: {\"route\": \"standby\"}\nSELECT 1;\n--end\n")},
+	})
+	require.ErrorIs(t, err, sqlset.ErrInvalidSyntax)
+}
+
+func TestExecutor_Tracing(t *testing.T) {
+	t.Parallel()
+
+	sqlSet, err := sqlset.New(fstest.MapFS{
+		"users.sql": &fstest.MapFile{Data: []byte(`--SQL:FindByEmail
+--META: {"span_name": "users.lookup", "sanitized": true}
+SELECT id FROM users WHERE email = $1 AND status = 'active';
+--end
+
+--SQL:Count
+--META: {"retry": {"attempts": 2}}
+SELECT count(*) FROM users;
+--end`)},
+	})
+	require.NoError(t, err)
+
+	type ctxKey struct{}
+
+	var (
+		spans []sqlsetdb.Span
+		ended []error
+	)
+
+	var calls atomic.Int32
+
+	fake, db := newFakeDB(t)
+	fake.fail = func(query string) error {
+		if query == "SELECT count(*) FROM users;" && calls.Add(1) == 1 {
+			return stateError("40001")
+		}
+
+		return nil
+	}
+
+	exec := sqlsetdb.NewExecutor(db, sqlSet,
+		sqlsetdb.WithBackoff(time.Millisecond, time.Millisecond),
+		sqlsetdb.WithTracing(func(ctx context.Context, span sqlsetdb.Span) (context.Context, func(error)) {
+			spans = append(spans, span)
+
+			return context.WithValue(ctx, ctxKey{}, span.Name), func(err error) {
+				ended = append(ended, err)
+			}
+		}),
+	)
+
+	_, err = exec.ExecContext(context.Background(), sqlset.QueryKey{SetID: "users", QueryID: "FindByEmail"}, "a@b.c")
+	require.NoError(t, err)
+
+	_, err = exec.ExecContext(context.Background(), sqlset.QueryKey{SetID: "users", QueryID: "Count"})
+	require.NoError(t, err)
+
+	_, err = exec.ExecContext(context.Background(), sqlset.QueryKey{SetID: "users", QueryID: "Missing"})
+	require.Error(t, err)
+
+	assert.Equal(t, []sqlsetdb.Span{
+		{
+			Name:      "users.lookup",
+			Key:       sqlset.QueryKey{SetID: "users", QueryID: "FindByEmail"},
+			Statement: "SELECT id FROM users WHERE email = $1 AND status = ?;",
+		},
+		{
+			Name:      "users.Count",
+			Key:       sqlset.QueryKey{SetID: "users", QueryID: "Count"},
+			Statement: "SELECT count(*) FROM users;",
+		},
+	}, spans)
+	assert.Equal(t, []error{nil, nil}, ended)
+	assert.Len(t, fake.statements(), 2)
+}
+
+type requestIDKey struct{}
+
+func TestExecutor_Context(t *testing.T) {
+	t.Parallel()
+
+	sqlSet, err := sqlset.New(fstest.MapFS{
+		"accounts.sql": &fstest.MapFile{Data: []byte(`--SQL:GetBalance
+/* request_id={{ctx "request_id"}} */ SELECT balance FROM tenant.accounts WHERE id = $1;
+--end`)},
+	}, sqlset.WithContextKeys(map[string]any{"request_id": requestIDKey{}}), sqlset.WithRewriter(func(ctx context.Context, _ sqlset.QueryKey, sql string) (string, error) {
+		tenant, _ := ctx.Value(tenantKey{}).(string)
+
+		return strings.ReplaceAll(sql, "tenant.", tenant+"."), nil
+	}))
+	require.NoError(t, err)
+
+	fake, db := newFakeDB(t)
+
+	ctx := context.WithValue(context.Background(), tenantKey{}, "acme")
+	ctx = context.WithValue(ctx, requestIDKey{}, "3f2a-77 --")
+
+	exec := sqlsetdb.NewExecutor(db, sqlSet)
+
+	rows, err := exec.QueryContext(ctx, sqlset.QueryKey{SetID: "accounts", QueryID: "GetBalance"}, 1)
+	require.NoError(t, err)
+	require.NoError(t, rows.Close())
+
+	assert.Equal(t, []string{
+		"/* request_id=3f2a77 */ SELECT balance FROM acme.accounts WHERE id = $1;",
+	}, fake.statements())
+}
@@ -0,0 +1,205 @@
+package sqlsetdb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/istovpets/sqlset"
+)
+
+// ErrUnexpectedResult is returned when the result of a query does not match
+// its --EXPECT block, see CheckExpectations.
+var ErrUnexpectedResult = errors.New("unexpected result")
+
+// ExpectationCatalog is the interface CheckExpectations uses to read the queries and their expected results.
+// *sqlset.SQLSet and *sqlset.Snapshot implement it.
+type ExpectationCatalog interface {
+	QueryProvider
+	Expectations() []sqlset.Expectation
+}
+
+// typeAliases maps the SQL type names to the names PostgreSQL drivers report.
+var typeAliases = map[string]string{
+	"bigint":                      "int8",
+	"integer":                     "int4",
+	"int":                         "int4",
+	"smallint":                    "int2",
+	"boolean":                     "bool",
+	"real":                        "float4",
+	"double precision":            "float8",
+	"decimal":                     "numeric",
+	"character varying":           "varchar",
+	"character":                   "bpchar",
+	"char":                        "bpchar",
+	"timestamp with time zone":    "timestamptz",
+	"timestamp without time zone": "timestamp",
+	"time with time zone":         "timetz",
+	"time without time zone":      "time",
+}
+
+// CheckExpectations runs every query of the catalog declaring an --EXPECT block
+// and checks the shape of its result: the column names in order, the column types
+// when declared and reported by the driver, and the expected first rows. Run in CI
+// against a test database, it catches schema drift before deploy:
+//
+//	tx, err := db.BeginTx(ctx, nil)
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	defer tx.Rollback()
+//
+//	if err := sqlsetdb.CheckExpectations(ctx, tx, sqlSet, map[string][]any{"users.GetUser": {1}}); err != nil {
+//		t.Fatal(err)
+//	}
+//
+// The queries run with the arguments of args, keyed by query key, so a transaction rolled back
+// afterwards keeps the database unchanged. All mismatches are returned joined, each prefixed
+// with the query key and wrapping ErrUnexpectedResult.
+func CheckExpectations(ctx context.Context, db Queryer, catalog ExpectationCatalog, args map[string][]any) error {
+	var errs []error
+
+	for _, e := range catalog.Expectations() {
+		q, err := catalog.GetContext(ctx, e.Key.SetID, e.Key.QueryID)
+		if err != nil {
+			errs = append(errs, err)
+
+			continue
+		}
+
+		if err := checkExpectation(ctx, db, q, e, args[e.Key.String()]); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", e.Key, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func checkExpectation(ctx context.Context, db Queryer, query string, e sqlset.Expectation, args []any) error {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	columns, err := rows.ColumnTypes()
+	if err != nil {
+		return err
+	}
+
+	if err := checkColumns(columns, e.Columns); err != nil {
+		return err
+	}
+
+	for i, expected := range e.Rows {
+		if !rows.Next() {
+			if err := rows.Err(); err != nil {
+				return err
+			}
+
+			return fmt.Errorf("%w: got %d rows, want at least %d", ErrUnexpectedResult, i, len(e.Rows))
+		}
+
+		values := make([]any, len(columns))
+		dest := make([]any, len(columns))
+
+		for j := range values {
+			dest[j] = &values[j]
+		}
+
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+
+		for j, value := range values {
+			got, want := resultValue(value), expectedValue(expected[j])
+			if got != want {
+				return fmt.Errorf("%w: row %d: column %s is %s, want %s",
+					ErrUnexpectedResult, i+1, e.Columns[j].Name, got, want)
+			}
+		}
+	}
+
+	return rows.Err()
+}
+
+// checkColumns compares the result columns with the declared ones.
+func checkColumns(columns []*sql.ColumnType, expected []sqlset.QueryParam) error {
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = column.Name()
+	}
+
+	want := make([]string, len(expected))
+	for i, column := range expected {
+		want[i] = column.Name
+	}
+
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		return fmt.Errorf("%w: got columns %s, want %s",
+			ErrUnexpectedResult, strings.Join(names, ", "), strings.Join(want, ", "))
+	}
+
+	for i, column := range expected {
+		got := columns[i].DatabaseTypeName()
+		if column.Type == "" || got == "" {
+			continue
+		}
+
+		if normalizeType(got) != normalizeType(column.Type) {
+			return fmt.Errorf("%w: column %s is %s, want %s", ErrUnexpectedResult, column.Name, got, column.Type)
+		}
+	}
+
+	return nil
+}
+
+// normalizeType returns the lowercase type name without modifiers such as (255),
+// with the standard names mapped to the PostgreSQL internal ones, e.g. bigint to int8.
+func normalizeType(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+
+	if i := strings.IndexByte(name, '('); i >= 0 {
+		j := strings.LastIndexByte(name, ')')
+		if j > i {
+			name = strings.TrimSpace(name[:i] + name[j+1:])
+		}
+	}
+
+	name = strings.Join(strings.Fields(name), " ")
+
+	if alias, ok := typeAliases[name]; ok {
+		return alias
+	}
+
+	return name
+}
+
+// resultValue formats a scanned value for comparison, NULL for nil.
+func resultValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// expectedValue formats an expected value for comparison, NULL for nil.
+func expectedValue(value any) string {
+	if value == nil {
+		return "NULL"
+	}
+
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,116 @@
+package sqlsetdb_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+	"testing/fstest"
+
+	"github.com/istovpets/sqlset"
+	"github.com/istovpets/sqlset/sqlsetdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckExpectations(t *testing.T) {
+	t.Parallel()
+
+	sqlSet, err := sqlset.New(fstest.MapFS{
+		"users.sql": &fstest.MapFile{Data: []byte(`--SQL:GetUser
+SELECT id, name, email FROM users WHERE id = $1
+--end
+--EXPECT:GetUser
+id bigint, name varchar(100), email
+1,alice,NULL
+--end
+
+--SQL:ListUsers
+SELECT id, name FROM users
+--end
+--EXPECT:ListUsers
+[{"id": 1, "name": "alice"}, {"id": 2, "name": "bob"}]
+--end
+
+--SQL:CountUsers
+SELECT count(*) FROM users
+--end`)},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		columns  []string
+		types    []string
+		values   [][]driver.Value
+		expected string
+	}{
+		{
+			name:    "match",
+			columns: []string{"id", "name", "email"},
+			types:   []string{"INT8", "VARCHAR", "TEXT"},
+			values:  [][]driver.Value{{int64(1), []byte("alice"), nil}, {int64(2), []byte("bob"), nil}},
+		},
+		{
+			name:    "no types reported",
+			columns: []string{"id", "name", "email"},
+			values:  [][]driver.Value{{int64(1), "alice", nil}, {int64(2), "bob", nil}},
+		},
+		{
+			name:    "renamed column",
+			columns: []string{"id", "full_name", "email"},
+			values:  [][]driver.Value{{int64(1), "alice", nil}, {int64(2), "bob", nil}},
+			expected: "users.GetUser: unexpected result: got columns id, full_name, email, want id, name, email\n" +
+				"users.ListUsers: unexpected result: got columns id, full_name, want id, name",
+		},
+		{
+			name:     "changed type",
+			columns:  []string{"id", "name", "email"},
+			types:    []string{"INT4", "VARCHAR", "TEXT"},
+			values:   [][]driver.Value{{int64(1), "alice", nil}, {int64(2), "bob", nil}},
+			expected: "users.GetUser: unexpected result: column id is INT4, want bigint",
+		},
+		{
+			name:    "changed rows",
+			columns: []string{"id", "name", "email"},
+			values:  [][]driver.Value{{int64(1), "alice", "alice@example.com"}},
+			expected: "users.GetUser: unexpected result: row 1: column email is alice@example.com, want NULL\n" +
+				"users.ListUsers: unexpected result: got 1 rows, want at least 2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fake, db := newFakeDB(t)
+			fake.types = func(string) []string {
+				return tt.types
+			}
+			fake.rows = func(query string, _ []driver.Value) ([]string, [][]driver.Value) {
+				if query != "SELECT id, name FROM users" {
+					return tt.columns, tt.values
+				}
+
+				// ListUsers selects the first two columns.
+				values := make([][]driver.Value, len(tt.values))
+				for i, row := range tt.values {
+					values[i] = row[:2]
+				}
+
+				return tt.columns[:2], values
+			}
+
+			err := sqlsetdb.CheckExpectations(context.Background(), db, sqlSet, map[string][]any{
+				"users.GetUser": {1},
+			})
+			if tt.expected == "" {
+				require.NoError(t, err)
+
+				return
+			}
+
+			require.ErrorIs(t, err, sqlsetdb.ErrUnexpectedResult)
+			assert.EqualError(t, err, tt.expected)
+		})
+	}
+}
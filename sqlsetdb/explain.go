@@ -0,0 +1,76 @@
+package sqlsetdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ExplainMode selects how ExplainAll validates queries.
+type ExplainMode int
+
+const (
+	// ModePrepare prepares every query on the server. It validates table and column
+	// references without binding arguments, so it works for parameterized queries.
+	ModePrepare ExplainMode = iota
+	// ModeExplain runs "EXPLAIN <query>" for every query. Queries with placeholders
+	// usually fail in this mode as no arguments are bound.
+	ModeExplain
+)
+
+// ExplainAll validates every query of the catalog against a live database
+// and returns all failures joined into a single error, each prefixed with the query key.
+// Nothing is executed: queries are either prepared or explained, depending on mode.
+//
+// Example in a CI test:
+//
+//	if err := sqlsetdb.ExplainAll(ctx, db, sqlSet, sqlsetdb.ModePrepare); err != nil {
+//		t.Fatal(err)
+//	}
+func ExplainAll(ctx context.Context, db Queryer, catalog Catalog, mode ExplainMode) error {
+	all, err := keys(catalog)
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].String() < all[j].String()
+	})
+
+	var errs []error
+
+	for _, key := range all {
+		q, err := catalog.GetContext(ctx, key.SetID, key.QueryID)
+		if err != nil {
+			return err
+		}
+
+		if err := explain(ctx, db, q, mode); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func explain(ctx context.Context, db Queryer, query string, mode ExplainMode) error {
+	switch mode {
+	case ModePrepare:
+		stmt, err := db.PrepareContext(ctx, query)
+		if err != nil {
+			return err
+		}
+
+		return stmt.Close()
+	case ModeExplain:
+		rows, err := db.QueryContext(ctx, "EXPLAIN "+query)
+		if err != nil {
+			return err
+		}
+
+		return rows.Close()
+	default:
+		return fmt.Errorf("%d: unknown explain mode", mode)
+	}
+}
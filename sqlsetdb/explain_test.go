@@ -0,0 +1,70 @@
+package sqlsetdb_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/istovpets/sqlset"
+	"github.com/istovpets/sqlset/sqlsetdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSet(t *testing.T) *sqlset.SQLSet {
+	t.Helper()
+
+	sqlSet, err := sqlset.New(fstest.MapFS{
+		"users.sql": &fstest.MapFile{Data: []byte(`--SQL:GetUser
+SELECT id FROM users WHERE id = $1;
+--end
+
+--SQL:GetLegacy
+SELECT id FROM missing_table;
+--end`)},
+	})
+	require.NoError(t, err)
+
+	return sqlSet
+}
+
+func TestExplainAll(t *testing.T) {
+	t.Parallel()
+
+	errMissing := errors.New(`relation "missing_table" does not exist`)
+
+	t.Run("prepare", func(t *testing.T) {
+		t.Parallel()
+
+		fake, db := newFakeDB(t)
+		fake.fail = func(query string) error {
+			if strings.Contains(query, "missing_table") {
+				return errMissing
+			}
+
+			return nil
+		}
+
+		err := sqlsetdb.ExplainAll(context.Background(), db, newTestSet(t), sqlsetdb.ModePrepare)
+
+		require.ErrorIs(t, err, errMissing)
+		assert.EqualError(t, err, `users.GetLegacy: relation "missing_table" does not exist`)
+		assert.Empty(t, fake.statements())
+	})
+
+	t.Run("explain", func(t *testing.T) {
+		t.Parallel()
+
+		fake, db := newFakeDB(t)
+
+		err := sqlsetdb.ExplainAll(context.Background(), db, newTestSet(t), sqlsetdb.ModeExplain)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"EXPLAIN SELECT id FROM missing_table;",
+			"EXPLAIN SELECT id FROM users WHERE id = $1;",
+		}, fake.statements())
+	})
+}
@@ -0,0 +1,179 @@
+package sqlsetdb_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+)
+
+// setConfig is the statement Executor runs for the session settings, recorded as SET LOCAL.
+const setConfig = "SELECT set_config($1, $2, true)"
+
+// fakeDB is an in-memory database/sql driver recording the statements it runs.
+type fakeDB struct {
+	mu  sync.Mutex
+	log []string
+
+	// fail returns an error for the statements that must fail on prepare.
+	fail func(query string) error
+	// rows returns the result set for a query.
+	rows func(query string, args []driver.Value) ([]string, [][]driver.Value)
+	// types returns the database type names of the result columns of a query.
+	types func(query string) []string
+}
+
+func newFakeDB(t *testing.T) (*fakeDB, *sql.DB) {
+	t.Helper()
+
+	f := &fakeDB{}
+	db := sql.OpenDB(f)
+
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	return f, db
+}
+
+func (f *fakeDB) record(entry string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.log = append(f.log, entry)
+}
+
+func (f *fakeDB) statements() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]string(nil), f.log...)
+}
+
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) {
+	return &fakeConn{db: f}, nil
+}
+
+func (f *fakeDB) Driver() driver.Driver {
+	return f
+}
+
+func (f *fakeDB) Open(string) (driver.Conn, error) {
+	return &fakeConn{db: f}, nil
+}
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	if c.db.fail != nil {
+		if err := c.db.fail(query); err != nil {
+			return nil, err
+		}
+	}
+
+	return &fakeStmt{db: c.db, query: query}, nil
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	c.db.record("BEGIN")
+
+	return &fakeTx{db: c.db}, nil
+}
+
+type fakeTx struct {
+	db *fakeDB
+}
+
+func (tx *fakeTx) Commit() error {
+	tx.db.record("COMMIT")
+
+	return nil
+}
+
+func (tx *fakeTx) Rollback() error {
+	tx.db.record("ROLLBACK")
+
+	return nil
+}
+
+type fakeStmt struct {
+	db    *fakeDB
+	query string
+}
+
+func (s *fakeStmt) Close() error {
+	return nil
+}
+
+func (s *fakeStmt) NumInput() int {
+	return -1
+}
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if s.query == setConfig {
+		s.db.record(fmt.Sprintf("SET LOCAL %v = %v", args[0], args[1]))
+
+		return driver.RowsAffected(1), nil
+	}
+
+	s.db.record(s.query)
+
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.db.record(s.query)
+
+	rows := &fakeRows{}
+	if s.db.rows != nil {
+		rows.columns, rows.values = s.db.rows(s.query, args)
+	}
+
+	if s.db.types != nil {
+		rows.types = s.db.types(s.query)
+	}
+
+	return rows, nil
+}
+
+type fakeRows struct {
+	columns []string
+	types   []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.columns
+}
+
+func (r *fakeRows) ColumnTypeDatabaseTypeName(index int) string {
+	if index < len(r.types) {
+		return r.types[index]
+	}
+
+	return ""
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+
+	return nil
+}
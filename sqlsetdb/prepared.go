@@ -0,0 +1,218 @@
+package sqlsetdb
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/istovpets/sqlset"
+)
+
+// Profile holds the number of executions per query key ("setID.queryID").
+// It is exported from production with PreparedSet.Usage and JSON encoding,
+// and loaded at startup to prepare the most used queries, see PreparedSet.Warmup.
+type Profile map[string]int64
+
+// ReadProfile decodes a JSON-encoded profile.
+func ReadProfile(r io.Reader) (Profile, error) {
+	var p Profile
+
+	if err := json.NewDecoder(r).Decode(&p); err != nil {
+		return nil, fmt.Errorf("decode profile: %w", err)
+	}
+
+	return p, nil
+}
+
+// Top returns the keys of the n most executed queries, most executed first.
+// Keys with the same count are sorted by key. A non-positive n returns all keys.
+func (p Profile) Top(n int) []string {
+	keys := make([]string, 0, len(p))
+	for key := range p {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if p[keys[i]] != p[keys[j]] {
+			return p[keys[i]] > p[keys[j]]
+		}
+
+		return keys[i] < keys[j]
+	})
+
+	if n > 0 && n < len(keys) {
+		keys = keys[:n]
+	}
+
+	return keys
+}
+
+// PreparedSet executes the catalog queries as prepared statements,
+// preparing every query on its first use and counting the executions.
+// It is safe for concurrent use.
+//
+// Preparing thousands of statements at startup is slow, so Warmup prepares
+// only the most used queries of a profile recorded in production:
+//
+//	ps := sqlsetdb.NewPreparedSet(db, sqlSet)
+//	defer ps.Close()
+//
+//	if err := ps.Warmup(ctx, profile, 200); err != nil {
+//		return err
+//	}
+type PreparedSet struct {
+	db      Queryer
+	catalog QueryProvider
+
+	mu    sync.Mutex
+	stmts map[string]*sql.Stmt
+	usage Profile
+}
+
+// NewPreparedSet returns a PreparedSet preparing the queries of catalog on db.
+func NewPreparedSet(db Queryer, catalog QueryProvider) *PreparedSet {
+	return &PreparedSet{
+		db:      db,
+		catalog: catalog,
+		stmts:   make(map[string]*sql.Stmt),
+		usage:   make(Profile),
+	}
+}
+
+// Warmup prepares the n most executed queries of profile, all of them if n is not positive.
+// Queries of the profile missing from the catalog, e.g. removed since it was recorded,
+// are skipped. Failing queries do not stop the warmup, their errors are joined.
+func (p *PreparedSet) Warmup(ctx context.Context, profile Profile, n int) error {
+	var errs []error
+
+	for _, key := range profile.Top(n) {
+		if _, err := p.stmt(ctx, key); err != nil && !errors.Is(err, sqlset.ErrNotFound) {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Stmt returns the prepared statement of a query, preparing it on first use,
+// and counts an execution. The ids are the same as for sqlset.SQLSet.Get.
+// The statement is owned by the PreparedSet and must not be closed.
+func (p *PreparedSet) Stmt(ctx context.Context, ids ...string) (*sql.Stmt, error) {
+	key := strings.Join(ids, ".")
+
+	stmt, err := p.stmt(ctx, key, ids...)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	p.usage[key]++
+	p.mu.Unlock()
+
+	return stmt, nil
+}
+
+// QueryContext executes a query returning rows, see Stmt.
+func (p *PreparedSet) QueryContext(ctx context.Context, key string, args ...any) (*sql.Rows, error) {
+	stmt, err := p.Stmt(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return stmt.QueryContext(ctx, args...)
+}
+
+// ExecContext executes a query without returning rows, see Stmt.
+func (p *PreparedSet) ExecContext(ctx context.Context, key string, args ...any) (sql.Result, error) {
+	stmt, err := p.Stmt(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return stmt.ExecContext(ctx, args...)
+}
+
+// Prepared returns the number of prepared statements.
+func (p *PreparedSet) Prepared() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return len(p.stmts)
+}
+
+// Usage returns the executions per query counted since the PreparedSet was created.
+func (p *PreparedSet) Usage() Profile {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	usage := make(Profile, len(p.usage))
+	for key, n := range p.usage {
+		usage[key] = n
+	}
+
+	return usage
+}
+
+// Close closes all prepared statements.
+func (p *PreparedSet) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var errs []error
+
+	for key, stmt := range p.stmts {
+		if err := stmt.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+		}
+
+		delete(p.stmts, key)
+	}
+
+	return errors.Join(errs...)
+}
+
+// stmt returns the statement of key, preparing it if needed. The ids default to key.
+// Statements are prepared without holding the lock, a statement prepared concurrently
+// for the same key is closed.
+func (p *PreparedSet) stmt(ctx context.Context, key string, ids ...string) (*sql.Stmt, error) {
+	p.mu.Lock()
+	stmt, ok := p.stmts[key]
+	p.mu.Unlock()
+
+	if ok {
+		return stmt, nil
+	}
+
+	if len(ids) == 0 {
+		ids = []string{key}
+	}
+
+	query, err := p.catalog.GetContext(ctx, ids...)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt, err = p.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("prepare %s: %w", key, err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if existing, ok := p.stmts[key]; ok {
+		_ = stmt.Close()
+
+		return existing, nil
+	}
+
+	p.stmts[key] = stmt
+
+	return stmt, nil
+}
@@ -0,0 +1,120 @@
+package sqlsetdb_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/istovpets/sqlset"
+	"github.com/istovpets/sqlset/sqlsetdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfileTop(t *testing.T) {
+	t.Parallel()
+
+	p := sqlsetdb.Profile{"a.One": 5, "a.Two": 10, "b.One": 5, "b.Two": 1}
+
+	assert.Equal(t, []string{"a.Two", "a.One", "b.One"}, p.Top(3))
+	assert.Equal(t, []string{"a.Two", "a.One", "b.One", "b.Two"}, p.Top(0))
+
+	read, err := sqlsetdb.ReadProfile(strings.NewReader(`{"a.One": 5, "a.Two": 10}`))
+	require.NoError(t, err)
+	assert.Equal(t, sqlsetdb.Profile{"a.One": 5, "a.Two": 10}, read)
+
+	_, err = sqlsetdb.ReadProfile(strings.NewReader(`[`))
+	require.Error(t, err)
+}
+
+func TestPreparedSet(t *testing.T) {
+	t.Parallel()
+
+	sqlSet, err := sqlset.New(fstest.MapFS{
+		"users.sql": &fstest.MapFile{Data: []byte(`--SQL:GetUser
+SELECT id FROM users WHERE id = $1;
+--end
+--SQL:ListUsers
+SELECT id FROM users;
+--end
+--SQL:CountUsers
+SELECT count(*) FROM users;
+--end
+--SQL:Broken
+SELECT id FROM missing_table;
+--end`)},
+	})
+	require.NoError(t, err)
+
+	errMissing := errors.New(`relation "missing_table" does not exist`)
+
+	var (
+		mu       sync.Mutex
+		prepared []string
+	)
+
+	fake, db := newFakeDB(t)
+	fake.fail = func(query string) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		prepared = append(prepared, query)
+
+		if strings.Contains(query, "missing_table") {
+			return errMissing
+		}
+
+		return nil
+	}
+
+	ctx := context.Background()
+
+	ps := sqlsetdb.NewPreparedSet(db, sqlSet)
+	t.Cleanup(func() {
+		require.NoError(t, ps.Close())
+	})
+
+	profile := sqlsetdb.Profile{
+		"users.ListUsers": 900,
+		"users.GetUser":   1200,
+		"users.Removed":   500,
+		"users.Broken":    300,
+		"users.Count":     1,
+	}
+
+	require.NoError(t, ps.Warmup(ctx, profile, 3))
+	assert.Equal(t, 2, ps.Prepared())
+
+	err = ps.Warmup(ctx, profile, 0)
+	require.ErrorIs(t, err, errMissing)
+	assert.Contains(t, err.Error(), "users.Broken")
+	assert.Equal(t, 2, ps.Prepared())
+
+	// Warmup does not count as usage, the rest is prepared lazily.
+	assert.Empty(t, ps.Usage())
+
+	rows, err := ps.QueryContext(ctx, "users.CountUsers")
+	require.NoError(t, err)
+	require.NoError(t, rows.Close())
+
+	_, err = ps.ExecContext(ctx, "users.GetUser", 1)
+	require.NoError(t, err)
+
+	stmt, err := ps.Stmt(ctx, "users", "GetUser")
+	require.NoError(t, err)
+	assert.NotNil(t, stmt)
+
+	assert.Equal(t, 3, ps.Prepared())
+	assert.Equal(t, sqlsetdb.Profile{"users.CountUsers": 1, "users.GetUser": 2}, ps.Usage())
+	assert.Len(t, fake.statements(), 2)
+
+	mu.Lock()
+	assert.Len(t, prepared, 4)
+	mu.Unlock()
+
+	_, err = ps.Stmt(ctx, "users", "Unknown")
+	require.ErrorIs(t, err, sqlset.ErrQueryNotFound)
+}
@@ -0,0 +1,204 @@
+package sqlsetdb
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/istovpets/sqlset"
+)
+
+// Result is a result set read into memory by ResultCache.
+// Cached results are shared between callers and must not be modified.
+type Result struct {
+	Columns []string
+	Rows    [][]any
+}
+
+// ResultCache is an Executor decorator caching the results of the queries declaring
+// a "cache_ttl" in their metadata, keyed by the query fingerprint and the arguments.
+// Other queries are always executed. Errors are not cached.
+// It is safe for concurrent use.
+//
+//	cache := sqlsetdb.NewResultCache(sqlsetdb.NewExecutor(db, sqlSet))
+//
+//	res, err := cache.Query(ctx, sqlset.QueryKey{SetID: "countries", QueryID: "List"})
+type ResultCache struct {
+	exec *Executor
+	now  func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]resultEntry
+	// sweepAt is the number of entries at which the expired ones are removed.
+	sweepAt int
+}
+
+type resultEntry struct {
+	result  *Result
+	expires time.Time
+}
+
+// minSweep is the smallest number of entries swept for expired results.
+const minSweep = 1024
+
+// NewResultCache returns a result cache for the queries run by exec.
+func NewResultCache(exec *Executor) *ResultCache {
+	return &ResultCache{
+		exec:    exec,
+		now:     time.Now,
+		entries: make(map[string]resultEntry),
+		sweepAt: minSweep,
+	}
+}
+
+// Query returns the result of the query, from the cache if it is fresh.
+func (c *ResultCache) Query(ctx context.Context, key sqlset.QueryKey, args ...any) (*Result, error) {
+	meta, err := c.exec.catalog.GetQueryMeta(key.SetID, key.QueryID)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := time.Duration(meta.CacheTTL)
+	if ttl <= 0 {
+		return c.query(ctx, key, args)
+	}
+
+	query, err := c.exec.catalog.GetContext(ctx, key.SetID, key.QueryID)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheKey, err := resultKey(query, args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", key, err)
+	}
+
+	c.mu.Lock()
+	e, ok := c.entries[cacheKey]
+	c.mu.Unlock()
+
+	if ok && c.now().Before(e.expires) {
+		return e.result, nil
+	}
+
+	result, err := c.query(ctx, key, args)
+	if err != nil {
+		return nil, err
+	}
+
+	c.store(cacheKey, resultEntry{result: result, expires: c.now().Add(ttl)})
+
+	return result, nil
+}
+
+// InvalidateAll removes all results from the cache.
+func (c *ResultCache) InvalidateAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[string]resultEntry)
+	c.sweepAt = minSweep
+}
+
+// Len returns the number of cached results, including the expired ones not removed yet.
+func (c *ResultCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.entries)
+}
+
+func (c *ResultCache) query(ctx context.Context, key sqlset.QueryKey, args []any) (*Result, error) {
+	rows, err := c.exec.QueryContext(ctx, key, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", key, err)
+	}
+
+	result := &Result{Columns: columns}
+
+	for rows.Next() {
+		row := make([]any, len(columns))
+		dest := make([]any, len(columns))
+
+		for i := range row {
+			dest[i] = &row[i]
+		}
+
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+
+		result.Rows = append(result.Rows, row)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", key, err)
+	}
+
+	return result, nil
+}
+
+// store caches the entry, removing the expired entries when the cache doubled since the last sweep.
+func (c *ResultCache) store(key string, e resultEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = e
+
+	if len(c.entries) < c.sweepAt {
+		return
+	}
+
+	now := c.now()
+
+	for k, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, k)
+		}
+	}
+
+	c.sweepAt = max(2*len(c.entries), minSweep)
+}
+
+// resultKey returns the cache key of a query run with args: the SHA-256 of the query text
+// and the arguments normalized by their driver values, so e.g. an int and an int64 share it.
+func resultKey(query string, args []any) (string, error) {
+	var sb strings.Builder
+
+	sb.WriteString(query)
+
+	for _, arg := range args {
+		if valuer, ok := arg.(driver.Valuer); ok {
+			v, err := valuer.Value()
+			if err != nil {
+				return "", fmt.Errorf("argument value: %w", err)
+			}
+
+			arg = v
+		}
+
+		if v, err := driver.DefaultParameterConverter.ConvertValue(arg); err == nil {
+			arg = v
+		}
+
+		fmt.Fprintf(&sb, "\x00%T:%v", arg, arg)
+	}
+
+	sum := sha256.Sum256([]byte(sb.String()))
+
+	return hex.EncodeToString(sum[:]), nil
+}
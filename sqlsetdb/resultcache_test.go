@@ -0,0 +1,86 @@
+package sqlsetdb_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/istovpets/sqlset"
+	"github.com/istovpets/sqlset/sqlsetdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResultCache(t *testing.T) {
+	t.Parallel()
+
+	sqlSet, err := sqlset.New(fstest.MapFS{
+		"countries.sql": &fstest.MapFile{Data: []byte(`--SQL:Get
+--META: {"cache_ttl": "1h"}
+SELECT code, name FROM countries WHERE code = $1;
+--end
+
+--SQL:Short
+--META: {"cache_ttl": "1ms"}
+SELECT code FROM countries;
+--end
+
+--SQL:Live
+SELECT code FROM countries;
+--end`)},
+	})
+	require.NoError(t, err)
+
+	fake, db := newFakeDB(t)
+	fake.rows = func(_ string, args []driver.Value) ([]string, [][]driver.Value) {
+		return []string{"code", "name"}, [][]driver.Value{{"NL", "Netherlands"}}
+	}
+
+	cache := sqlsetdb.NewResultCache(sqlsetdb.NewExecutor(db, sqlSet))
+	ctx := context.Background()
+	get := sqlset.QueryKey{SetID: "countries", QueryID: "Get"}
+
+	res, err := cache.Query(ctx, get, "NL")
+	require.NoError(t, err)
+	assert.Equal(t, &sqlsetdb.Result{
+		Columns: []string{"code", "name"},
+		Rows:    [][]any{{"NL", "Netherlands"}},
+	}, res)
+
+	// Arguments are normalized: int and int64 share the cache entry.
+	_, err = cache.Query(ctx, get, "NL")
+	require.NoError(t, err)
+	_, err = cache.Query(ctx, get, 1)
+	require.NoError(t, err)
+	_, err = cache.Query(ctx, get, int64(1))
+	require.NoError(t, err)
+	assert.Len(t, fake.statements(), 2)
+	assert.Equal(t, 2, cache.Len())
+
+	short := sqlset.QueryKey{SetID: "countries", QueryID: "Short"}
+
+	_, err = cache.Query(ctx, short)
+	require.NoError(t, err)
+	time.Sleep(5 * time.Millisecond)
+	_, err = cache.Query(ctx, short)
+	require.NoError(t, err)
+	assert.Len(t, fake.statements(), 4)
+
+	live := sqlset.QueryKey{SetID: "countries", QueryID: "Live"}
+
+	for range 2 {
+		_, err = cache.Query(ctx, live)
+		require.NoError(t, err)
+	}
+
+	assert.Len(t, fake.statements(), 6)
+	assert.Equal(t, 3, cache.Len())
+
+	cache.InvalidateAll()
+	assert.Zero(t, cache.Len())
+
+	_, err = cache.Query(ctx, sqlset.QueryKey{SetID: "countries", QueryID: "Missing"})
+	require.ErrorIs(t, err, sqlset.ErrQueryNotFound)
+}
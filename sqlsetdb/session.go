@@ -0,0 +1,93 @@
+package sqlsetdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// ErrNoTransaction is returned when an Executor with session settings runs a query
+// outside a transaction, see Executor.InTx.
+var ErrNoTransaction = errors.New("session settings require a transaction")
+
+// setConfig sets a run-time parameter for the current transaction only, like SET LOCAL,
+// but with the name and value bound as arguments.
+const setConfig = "SELECT set_config($1, $2, true)"
+
+// SessionSettings returns the PostgreSQL run-time parameters set before every query,
+// derived from the context, e.g. the tenant of the request read by the row-level security policies:
+//
+//	func(ctx context.Context) (map[string]string, error) {
+//		tenant, ok := ctx.Value(tenantKey{}).(string)
+//		if !ok {
+//			return nil, errors.New("no tenant")
+//		}
+//
+//		return map[string]string{"app.current_tenant": tenant}, nil
+//	}
+type SessionSettings func(ctx context.Context) (map[string]string, error)
+
+// WithSessionSettings makes the Executor set the parameters returned by settings, as SET LOCAL does,
+// before every query, so the row-level security policies apply uniformly to the catalog queries.
+// As the parameters last until the end of the transaction, the queries must run in one, see Executor.InTx.
+func WithSessionSettings(settings SessionSettings) ExecutorOption {
+	return func(e *Executor) {
+		e.settings = settings
+	}
+}
+
+// InTx runs fn in a transaction begun on the primary pool, which must implement TxBeginner.
+// The transaction is committed if fn returns nil and rolled back otherwise.
+// The Executor passed to fn runs all queries in the transaction, without retries:
+//
+//	err := exec.InTx(ctx, func(tx *sqlsetdb.Executor) error {
+//		_, err := tx.ExecContext(ctx, sqlset.QueryKey{SetID: "invoices", QueryID: "Void"}, id)
+//
+//		return err
+//	})
+func (e *Executor) InTx(ctx context.Context, fn func(tx *Executor) error) error {
+	beginner, ok := e.db.(TxBeginner)
+	if !ok {
+		return fmt.Errorf("begin: %T does not support transactions", e.db)
+	}
+
+	tx, err := beginner.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+
+	txExec := *e
+	txExec.db, txExec.replica, txExec.inTx = tx, nil, true
+
+	if err := fn(&txExec); err != nil {
+		return errors.Join(err, rollback(tx))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+
+	return nil
+}
+
+// applySettings sets the session settings on db for the current transaction, in the order of their names.
+func (e *Executor) applySettings(ctx context.Context, db DB) error {
+	if !e.inTx {
+		return ErrNoTransaction
+	}
+
+	settings, err := e.settings(ctx)
+	if err != nil {
+		return fmt.Errorf("session settings: %w", err)
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(settings)) {
+		if _, err := db.ExecContext(ctx, setConfig, name, settings[name]); err != nil {
+			return fmt.Errorf("set %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package sqlsetdb_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/istovpets/sqlset"
+	"github.com/istovpets/sqlset/sqlsetdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type tenantKey struct{}
+
+func TestExecutor_SessionSettings(t *testing.T) {
+	t.Parallel()
+
+	sqlSet, err := sqlset.New(fstest.MapFS{
+		"invoices.sql": &fstest.MapFile{Data: []byte(`--SQL:List
+--META: {"retry": {"attempts": 3}}
+SELECT * FROM invoices;
+--end
+
+--SQL:Void
+UPDATE invoices SET voided = true WHERE id = $1;
+--end`)},
+	})
+	require.NoError(t, err)
+
+	errNoTenant := errors.New("no tenant")
+
+	settings := func(ctx context.Context) (map[string]string, error) {
+		tenant, ok := ctx.Value(tenantKey{}).(string)
+		if !ok {
+			return nil, errNoTenant
+		}
+
+		return map[string]string{"app.current_tenant": tenant, "app.role": "billing"}, nil
+	}
+
+	list := sqlset.QueryKey{SetID: "invoices", QueryID: "List"}
+	void := sqlset.QueryKey{SetID: "invoices", QueryID: "Void"}
+
+	t.Run("in transaction", func(t *testing.T) {
+		t.Parallel()
+
+		fake, db := newFakeDB(t)
+		exec := sqlsetdb.NewExecutor(db, sqlSet, sqlsetdb.WithSessionSettings(settings))
+		ctx := context.WithValue(context.Background(), tenantKey{}, "acme")
+
+		err := exec.InTx(ctx, func(tx *sqlsetdb.Executor) error {
+			rows, err := tx.QueryContext(ctx, list)
+			if err != nil {
+				return err
+			}
+
+			if err := rows.Close(); err != nil {
+				return err
+			}
+
+			_, err = tx.ExecContext(ctx, void, 1)
+
+			return err
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"BEGIN",
+			"SET LOCAL app.current_tenant = acme",
+			"SET LOCAL app.role = billing",
+			"SELECT * FROM invoices;",
+			"SET LOCAL app.current_tenant = acme",
+			"SET LOCAL app.role = billing",
+			"UPDATE invoices SET voided = true WHERE id = $1;",
+			"COMMIT",
+		}, fake.statements())
+	})
+
+	t.Run("settings error", func(t *testing.T) {
+		t.Parallel()
+
+		fake, db := newFakeDB(t)
+		exec := sqlsetdb.NewExecutor(db, sqlSet, sqlsetdb.WithSessionSettings(settings))
+
+		err := exec.InTx(context.Background(), func(tx *sqlsetdb.Executor) error {
+			_, err := tx.ExecContext(context.Background(), void, 1)
+
+			return err
+		})
+		require.ErrorIs(t, err, errNoTenant)
+		assert.Equal(t, []string{"BEGIN", "ROLLBACK"}, fake.statements())
+	})
+
+	t.Run("outside transaction", func(t *testing.T) {
+		t.Parallel()
+
+		fake, db := newFakeDB(t)
+		exec := sqlsetdb.NewExecutor(db, sqlSet, sqlsetdb.WithSessionSettings(settings))
+
+		_, err := exec.ExecContext(context.WithValue(context.Background(), tenantKey{}, "acme"), void, 1)
+		require.ErrorIs(t, err, sqlsetdb.ErrNoTransaction)
+		assert.ErrorContains(t, err, "invoices.Void")
+		assert.Empty(t, fake.statements())
+	})
+}
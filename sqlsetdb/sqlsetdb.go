@@ -0,0 +1,48 @@
+// Package sqlsetdb provides database/sql helpers for the queries stored in an SQLSet.
+package sqlsetdb
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/istovpets/sqlset"
+)
+
+// Catalog is the interface the helpers use to read queries.
+// *sqlset.SQLSet implements it.
+type Catalog interface {
+	sqlset.SQLSetsProvider
+	QueryProvider
+}
+
+// QueryProvider is the interface the helpers use to look queries up with the context
+// of the call, which the rewriters and context references receive.
+// *sqlset.SQLSet, *sqlset.Snapshot and *sqlset.SwappableProvider implement it.
+type QueryProvider interface {
+	sqlset.SQLQueriesProvider
+	GetContext(ctx context.Context, ids ...string) (string, error)
+}
+
+// Queryer is implemented by *sql.DB, *sql.Conn and *sql.Tx.
+type Queryer interface {
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// keys returns the keys of all queries in the catalog.
+func keys(catalog Catalog) ([]sqlset.QueryKey, error) {
+	var result []sqlset.QueryKey
+
+	for _, meta := range catalog.GetSetsMetas() {
+		ids, err := catalog.GetQueryIDs(meta.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, id := range ids {
+			result = append(result, sqlset.QueryKey{SetID: meta.ID, QueryID: id})
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,83 @@
+// Package sqlsetexpvar provides an SQLQueriesProvider decorator publishing
+// lookup and miss counts per query key with expvar, for the teams that want
+// query usage metrics without third-party dependencies.
+//
+// The counters are served as JSON at /debug/vars, which Prometheus can scrape
+// with an expvar exporter:
+//
+//	{"sqlset": {"lookups": {"users.GetUser": 42}, "misses": {"users.Removed": 1}}}
+package sqlsetexpvar
+
+import (
+	"expvar"
+	"strings"
+
+	"github.com/istovpets/sqlset"
+)
+
+// Provider is an SQLQueriesProvider decorator counting the lookups and misses per key.
+// The key is the Get arguments joined with ".", e.g. "users.GetUser".
+// It is safe for concurrent use if the source is.
+type Provider struct {
+	src     sqlset.SQLQueriesProvider
+	lookups *expvar.Map
+	misses  *expvar.Map
+}
+
+// New returns a decorator of src publishing its counters under the expvar map name.
+// A map already published under name is reused, so several providers can share it.
+// It panics if name is published with another type.
+func New(src sqlset.SQLQueriesProvider, name string) *Provider {
+	var m *expvar.Map
+
+	if v := expvar.Get(name); v != nil {
+		m = v.(*expvar.Map) //nolint:forcetypeassert
+	} else {
+		m = expvar.NewMap(name)
+	}
+
+	return NewWithMap(src, m)
+}
+
+// NewWithMap returns a decorator of src storing its counters in m,
+// which does not need to be published, e.g. to nest it in another map.
+func NewWithMap(src sqlset.SQLQueriesProvider, m *expvar.Map) *Provider {
+	return &Provider{
+		src:     src,
+		lookups: submap(m, "lookups"),
+		misses:  submap(m, "misses"),
+	}
+}
+
+// Get returns the query from the source and counts the lookup, and the miss if it fails.
+func (p *Provider) Get(ids ...string) (string, error) {
+	key := strings.Join(ids, ".")
+
+	p.lookups.Add(key, 1)
+
+	q, err := p.src.Get(ids...)
+	if err != nil {
+		p.misses.Add(key, 1)
+
+		return "", err
+	}
+
+	return q, nil
+}
+
+// MustGet is like Get but panics if the query cannot be returned.
+func (p *Provider) MustGet(ids ...string) string {
+	return sqlset.Must(p.Get(ids...))
+}
+
+// submap returns the map stored in m under key, creating it if needed.
+func submap(m *expvar.Map, key string) *expvar.Map {
+	if v, ok := m.Get(key).(*expvar.Map); ok {
+		return v
+	}
+
+	v := new(expvar.Map)
+	m.Set(key, v)
+
+	return v
+}
@@ -0,0 +1,60 @@
+package sqlsetexpvar_test
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+
+	"github.com/istovpets/sqlset"
+	"github.com/istovpets/sqlset/sqlsetexpvar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSource(t *testing.T) *sqlset.SQLSet {
+	t.Helper()
+
+	qs := sqlset.NewQuerySet(sqlset.QuerySetMeta{ID: "users"})
+	require.NoError(t, qs.Register("GetUser", "SELECT 1;"))
+
+	sqlSet := &sqlset.SQLSet{}
+	require.NoError(t, sqlSet.AddSet(qs))
+
+	return sqlSet
+}
+
+func TestProvider(t *testing.T) {
+	t.Parallel()
+
+	m := new(expvar.Map)
+	p := sqlsetexpvar.NewWithMap(newSource(t), m)
+
+	assert.Equal(t, "SELECT 1;", p.MustGet("users", "GetUser"))
+	assert.Equal(t, "SELECT 1;", p.MustGet("users.GetUser"))
+
+	_, err := p.Get("users", "Removed")
+	require.ErrorIs(t, err, sqlset.ErrQueryNotFound)
+
+	var got map[string]map[string]int64
+	require.NoError(t, json.Unmarshal([]byte(m.String()), &got))
+	assert.Equal(t, map[string]map[string]int64{
+		"lookups": {"users.GetUser": 2, "users.Removed": 1},
+		"misses":  {"users.Removed": 1},
+	}, got)
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	src := newSource(t)
+
+	a := sqlsetexpvar.New(src, "sqlsetexpvar_test")
+	b := sqlsetexpvar.New(src, "sqlsetexpvar_test")
+
+	a.MustGet("users", "GetUser")
+	b.MustGet("users", "GetUser")
+
+	lookups, ok := expvar.Get("sqlsetexpvar_test").(*expvar.Map).Get("lookups").(*expvar.Map)
+	require.True(t, ok)
+	assert.Equal(t, "2", lookups.Get("users.GetUser").String())
+}
@@ -0,0 +1,195 @@
+// Package sqlsetfmt provides a dependency-free SQL formatter for sqlset.WithFormatter.
+// It upper-cases the keywords, collapses the whitespace and starts every top-level clause
+// on a new line, with AND and OR conditions indented:
+//
+//	SELECT id, name
+//	FROM users u
+//	LEFT JOIN orders o ON o.user_id = u.id
+//	WHERE u.active
+//	  AND o.id IS NULL
+//
+// Literals, quoted identifiers and comments are kept as written. Any other formatter
+// with the func(sql string) string signature can be used instead.
+package sqlsetfmt
+
+import "strings"
+
+// keywords are the words upper-cased by Format.
+var keywords = wordSet("select from where and or not in is null insert into values update set delete " +
+	"join left right inner outer full cross natural on using as group by order having limit offset fetch " +
+	"returning with recursive union intersect except all distinct case when then else end between like " +
+	"ilike exists asc desc nulls conflict do nothing over partition lateral true false")
+
+// clauses are the words starting a new line at the top level.
+var clauses = wordSet("select from where group order having limit offset fetch union intersect except " +
+	"values set returning join left right inner full cross natural with insert update delete")
+
+// joinModifiers are the words after which a clause word continues the same clause, e.g. LEFT JOIN.
+var joinModifiers = wordSet("left right inner outer full cross natural union intersect except all delete do")
+
+func wordSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range strings.Fields(words) {
+		set[strings.ToUpper(w)] = true
+	}
+
+	return set
+}
+
+// Format returns sql formatted, see the package documentation.
+//
+//nolint:gocognit,gocyclo
+func Format(sql string) string {
+	var (
+		sb      strings.Builder
+		depth   int
+		prev    string // previous word, upper-cased
+		space   bool   // whitespace pending before the next token
+		between bool   // BETWEEN seen, its AND does not start a condition
+	)
+
+	newline := func(indent string) {
+		if sb.Len() > 0 && !strings.HasSuffix(sb.String(), "\n") {
+			sb.WriteByte('\n')
+		}
+
+		if sb.Len() > 0 {
+			sb.WriteString(indent)
+		}
+
+		space = false
+	}
+
+	for i := 0; i < len(sql); {
+		c := sql[i]
+
+		var token string
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			space = true
+			i++
+
+			continue
+		case strings.HasPrefix(sql[i:], "--"):
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				end = len(sql) - i
+			}
+
+			token = strings.TrimRight(sql[i:i+end], " \t\r")
+		case strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				end = len(sql) - i - 4
+			}
+
+			token = sql[i : i+end+4]
+		case c == '\'' || c == '"':
+			end := i + 1
+
+			for end < len(sql) {
+				if sql[end] == c {
+					if end+1 < len(sql) && sql[end+1] == c {
+						end += 2
+
+						continue
+					}
+
+					break
+				}
+
+				end++
+			}
+
+			token = sql[i:min(end+1, len(sql))]
+		case c == '$' && dollarQuote(sql[i:]) != "":
+			tag := dollarQuote(sql[i:])
+
+			end := strings.Index(sql[i+len(tag):], tag)
+			if end < 0 {
+				token = sql[i:]
+			} else {
+				token = sql[i : i+2*len(tag)+end]
+			}
+		case isWordByte(c):
+			end := i + 1
+			for end < len(sql) && (isWordByte(sql[end]) || sql[end] == '$') {
+				end++
+			}
+
+			token = sql[i:end]
+		default:
+			token = sql[i : i+1]
+		}
+
+		i += len(token)
+
+		word := strings.ToUpper(token)
+		if !isWordByte(c) || c >= '0' && c <= '9' {
+			word = ""
+		}
+
+		switch {
+		case word != "" && depth == 0 && clauses[word] && !joinModifiers[prev] && !(word == "SET" && prev == "UPDATE"):
+			newline("")
+		case word == "BETWEEN":
+			between = true
+		case (word == "AND" || word == "OR") && depth == 0:
+			if between && word == "AND" {
+				between = false
+			} else {
+				newline("  ")
+			}
+		}
+
+		if space && sb.Len() > 0 && !strings.HasSuffix(sb.String(), "\n") && !strings.HasSuffix(sb.String(), "(") &&
+			token != ")" && token != "," {
+			sb.WriteByte(' ')
+		}
+
+		space = false
+
+		if keywords[word] {
+			sb.WriteString(word)
+		} else {
+			sb.WriteString(token)
+		}
+
+		switch token {
+		case "(":
+			depth++
+		case ")":
+			depth = max(depth-1, 0)
+		}
+
+		if strings.HasPrefix(token, "--") {
+			newline("")
+		}
+
+		if word != "" {
+			prev = word
+		}
+	}
+
+	return strings.TrimRight(sb.String(), " \n")
+}
+
+// dollarQuote returns the dollar-quote tag opening s, "$$" or "$tag$", or an empty string.
+func dollarQuote(s string) string {
+	for j := 1; j < len(s); j++ {
+		switch {
+		case s[j] == '$':
+			return s[:j+1]
+		case s[j] == '_' || 'a' <= s[j] && s[j] <= 'z' || 'A' <= s[j] && s[j] <= 'Z' || j > 1 && '0' <= s[j] && s[j] <= '9':
+		default:
+			return ""
+		}
+	}
+
+	return ""
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c >= 0x80
+}
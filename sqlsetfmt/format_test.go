@@ -0,0 +1,74 @@
+package sqlsetfmt_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/istovpets/sqlset"
+	"github.com/istovpets/sqlset/sqlsetfmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		sql      string
+		expected string
+	}{
+		{
+			name: "select",
+			sql:  "select id, name from users u\r\n  left join orders o on o.user_id = u.id where u.active and o.id is null or u.age between 1 and 2 order by name limit 10",
+			expected: "SELECT id, name\nFROM users u\nLEFT JOIN orders o ON o.user_id = u.id\nWHERE u.active\n  AND o.id IS NULL\n  OR u.age BETWEEN 1 AND 2\n" +
+				"ORDER BY name\nLIMIT 10",
+		},
+		{
+			name:     "subquery stays inline",
+			sql:      "select * from users where id in (select user_id from orders where total > $1)",
+			expected: "SELECT *\nFROM users\nWHERE id IN (SELECT user_id FROM orders WHERE total > $1)",
+		},
+		{
+			name:     "literals and comments are kept",
+			sql:      "-- list users\nselect 'from  where', \"Select\" /* and */ from t where x = $$ and $$",
+			expected: "-- list users\nSELECT 'from  where', \"Select\" /* and */\nFROM t\nWHERE x = $$ and $$",
+		},
+		{
+			name:     "insert and upsert",
+			sql:      "insert into users (id, name) values ($1, $2) on conflict (id) do update set name = excluded.name returning id",
+			expected: "INSERT INTO users (id, name)\nVALUES ($1, $2) ON CONFLICT (id) DO UPDATE SET name = excluded.name\nRETURNING id",
+		},
+		{
+			name:     "update and delete",
+			sql:      "update users set name = $1 where id = $2; delete from users where id = $1",
+			expected: "UPDATE users\nSET name = $1\nWHERE id = $2;\nDELETE FROM users\nWHERE id = $1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.expected, sqlsetfmt.Format(tt.sql))
+			assert.Equal(t, tt.expected, sqlsetfmt.Format(tt.expected), "idempotent")
+		})
+	}
+}
+
+func TestWithFormatter(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"users.sql": {Data: []byte("--SQL:GetUser\nselect * from users where id = $1\n--end\n--SQLRAW:Raw\nselect 1\n--end\n")},
+	}
+
+	sets, err := sqlset.New(fsys, sqlset.WithFormatter(sqlsetfmt.Format))
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT *\nFROM users\nWHERE id = $1", sets.MustGet("users", "GetUser"))
+	assert.Equal(t, "select 1", sets.MustGet("users", "Raw"))
+
+	sets, err = sqlset.New(fsys, sqlset.WithRewriter(sqlset.FormatRewriter(sqlsetfmt.Format)))
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT 1", sets.MustGet("users", "Raw"))
+}
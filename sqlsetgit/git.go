@@ -0,0 +1,98 @@
+// Package sqlsetgit loads query sets as of a git revision by running the git command,
+// so that tooling can diff or run the catalog of any commit, e.g. for changelogs:
+//
+//	old, err := sqlsetgit.New(ctx, ".", "v1.4.0", "queries")
+//	if err != nil {
+//		return err
+//	}
+//
+//	changes := sqlset.Diff(old, current)
+package sqlsetgit
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os/exec"
+	"path"
+	"strings"
+
+	"github.com/istovpets/sqlset"
+)
+
+// New loads the query sets of dir, relative to the repository directory repo,
+// as of the tree-ish rev, e.g. a commit, tag or branch. See sqlset.New for the options.
+func New(ctx context.Context, repo, rev, dir string, opts ...sqlset.Option) (*sqlset.SQLSet, error) {
+	fsys, err := FS(ctx, repo, rev, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return sqlset.New(fsys, opts...)
+}
+
+// FS returns the files of dir, relative to the repository directory repo, as of the tree-ish rev.
+// The files have the commit time as their modification time.
+func FS(ctx context.Context, repo, rev, dir string) (fs.FS, error) {
+	if rev == "" {
+		return nil, fmt.Errorf("rev: %w", sqlset.ErrArgumentEmpty)
+	}
+
+	args := []string{"-C", repo, "archive", "--format=tar", rev}
+
+	dir = path.Clean(strings.TrimPrefix(dir, "./"))
+	if dir != "." {
+		args = append(args, "--", dir)
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("git archive %s: %w: %s", rev, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return readTar(&stdout, dir)
+}
+
+// readTar reads the regular files under dir of a tar archive into memory.
+func readTar(r io.Reader, dir string) (fs.FS, error) {
+	fsys := memFS{}
+	tr := tar.NewReader(r)
+
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return fsys, nil
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("read archive: %w", err)
+		}
+
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := hdr.Name
+		if dir != "." {
+			var ok bool
+			if name, ok = strings.CutPrefix(name, dir+"/"); !ok {
+				continue
+			}
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", hdr.Name, err)
+		}
+
+		fsys[name] = &memFile{data: data, mode: fs.FileMode(hdr.Mode).Perm(), modTime: hdr.ModTime}
+	}
+}
@@ -0,0 +1,96 @@
+package sqlsetgit_test
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/istovpets/sqlset"
+	"github.com/istovpets/sqlset/sqlsetgit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// initRepo creates a repository with two commits changing queries/users.sql.
+func initRepo(t *testing.T) string {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	repo := t.TempDir()
+
+	git := func(args ...string) {
+		t.Helper()
+
+		cmd := exec.Command("git", append([]string{"-C", repo}, args...)...)
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+			"GIT_AUTHOR_DATE=2024-01-02T03:04:05Z", "GIT_COMMITTER_DATE=2024-01-02T03:04:05Z",
+		)
+
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+
+	write := func(content string) {
+		t.Helper()
+
+		require.NoError(t, os.MkdirAll(filepath.Join(repo, "queries"), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(repo, "queries", "users.sql"), []byte(content), 0o644))
+	}
+
+	git("init", "-q")
+	write("--SQL:GetUser\nSELECT 1;\n--end\n")
+	git("add", ".")
+	git("commit", "-q", "-m", "first")
+	git("tag", "v1")
+	write("--SQL:GetUser\nSELECT 2;\n--end\n--SQL:ListUsers\nSELECT 3;\n--end\n")
+	git("commit", "-q", "-am", "second")
+
+	return repo
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	repo := initRepo(t)
+	ctx := context.Background()
+
+	old, err := sqlsetgit.New(ctx, repo, "v1", "queries")
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT 1;", old.MustGet("users", "GetUser"))
+	assert.Equal(t, "2024-01-02T03:04:05Z", old.ModTime().UTC().Format("2006-01-02T15:04:05Z"))
+
+	current, err := sqlsetgit.New(ctx, repo, "HEAD", "./queries/")
+	require.NoError(t, err)
+
+	changes := sqlset.Diff(old, current)
+	assert.Equal(t, []sqlset.QueryKey{{SetID: "users", QueryID: "ListUsers"}}, changes.Added)
+	require.Len(t, changes.Modified, 1)
+
+	fsys, err := sqlsetgit.FS(ctx, repo, "HEAD~1", ".")
+	require.NoError(t, err)
+
+	data, err := fs.ReadFile(fsys, "queries/users.sql")
+	require.NoError(t, err)
+	assert.Equal(t, "--SQL:GetUser\nSELECT 1;\n--end\n", string(data))
+}
+
+func TestNewErrors(t *testing.T) {
+	t.Parallel()
+
+	repo := initRepo(t)
+
+	_, err := sqlsetgit.New(context.Background(), repo, "", "queries")
+	require.ErrorIs(t, err, sqlset.ErrArgumentEmpty)
+
+	_, err = sqlsetgit.New(context.Background(), repo, "no-such-rev", "queries")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "git archive no-such-rev")
+}
@@ -0,0 +1,151 @@
+package sqlsetgit
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// memFS is a read-only in-memory file system holding the files of a revision by path.
+// The directories of the files exist implicitly.
+type memFS map[string]*memFile
+
+// memFile is a file of a memFS.
+type memFile struct {
+	data    []byte
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+// implicitDir is the file of the directories of a memFS.
+var implicitDir = &memFile{mode: fs.ModeDir | 0o555}
+
+// Open opens the file or directory name.
+func (m memFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	if f, ok := m[name]; ok {
+		return &memReader{memInfo: memInfo{name: path.Base(name), file: f}, Reader: bytes.NewReader(f.data)}, nil
+	}
+
+	entries := m.readDir(name)
+	if len(entries) == 0 && name != "." {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	return &memDir{memInfo: memInfo{name: path.Base(name), file: implicitDir}, entries: entries}, nil
+}
+
+// readDir returns the entries of the directory dir sorted by name.
+func (m memFS) readDir(dir string) []fs.DirEntry {
+	prefix := dir + "/"
+	if dir == "." {
+		prefix = ""
+	}
+
+	var entries []fs.DirEntry
+
+	seen := make(map[string]bool)
+
+	for name, f := range m {
+		rest, ok := strings.CutPrefix(name, prefix)
+		if !ok || rest == "" {
+			continue
+		}
+
+		child, _, nested := strings.Cut(rest, "/")
+		if seen[child] {
+			continue
+		}
+
+		seen[child] = true
+
+		if nested {
+			f = implicitDir
+		}
+
+		entries = append(entries, fs.FileInfoToDirEntry(memInfo{name: child, file: f}))
+	}
+
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+
+	return entries
+}
+
+// memInfo describes a file of a memFS.
+type memInfo struct {
+	name string
+	file *memFile
+}
+
+func (i memInfo) Name() string {
+	return i.name
+}
+
+func (i memInfo) Size() int64 {
+	return int64(len(i.file.data))
+}
+
+func (i memInfo) Mode() fs.FileMode {
+	return i.file.mode
+}
+
+func (i memInfo) ModTime() time.Time {
+	return i.file.modTime
+}
+
+func (i memInfo) IsDir() bool {
+	return i.file.mode.IsDir()
+}
+
+func (i memInfo) Sys() any {
+	return nil
+}
+
+func (i memInfo) Stat() (fs.FileInfo, error) {
+	return i, nil
+}
+
+func (i memInfo) Close() error {
+	return nil
+}
+
+// memReader is an open file of a memFS.
+type memReader struct {
+	memInfo
+	*bytes.Reader
+}
+
+// memDir is an open directory of a memFS.
+type memDir struct {
+	memInfo
+	entries []fs.DirEntry
+}
+
+func (d *memDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: fs.ErrInvalid}
+}
+
+// ReadDir returns the next n entries of the directory, all the remaining ones if n <= 0.
+func (d *memDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if n > 0 && len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+
+	if n <= 0 || n > len(d.entries) {
+		n = len(d.entries)
+	}
+
+	entries := d.entries[:n]
+	d.entries = d.entries[n:]
+
+	return entries, nil
+}
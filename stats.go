@@ -0,0 +1,109 @@
+package sqlset
+
+import (
+	"slices"
+	"unsafe"
+)
+
+// mapEntryOverhead approximates the cost of a map entry beyond its key and value:
+// the control byte of the slot and the free slots kept below the load factor.
+const mapEntryOverhead = 8
+
+// Stats holds the size of a SQLSet, see (*SQLSet).Stats.
+type Stats struct {
+	// Sets is the number of query sets.
+	Sets int `json:"sets"`
+	// Queries is the number of queries in all sets.
+	Queries int `json:"queries"`
+	// Bytes is the estimated memory held by all sets.
+	Bytes int `json:"bytes"`
+	// Footprint is the estimated memory held by each set, by set ID, see MemoryFootprint.
+	Footprint map[string]int `json:"footprint"`
+}
+
+// Stats returns the number of sets and queries and their estimated memory footprint.
+func (s *SQLSet) Stats() Stats {
+	stats := Stats{
+		Sets:      len(s.sets),
+		Footprint: s.MemoryFootprint(),
+	}
+
+	for _, qs := range s.sets {
+		stats.Queries += len(qs.queries)
+	}
+
+	for _, n := range stats.Footprint {
+		stats.Bytes += n
+	}
+
+	return stats
+}
+
+// MemoryFootprint estimates the bytes held by each set, by set ID: the lengths
+// of the query bodies, IDs and metadata plus the size of the structures and maps
+// holding them. It is an estimate for budgeting large catalogs, not an exact measure:
+// e.g. strings shared between sets are counted once for every set.
+func (s *SQLSet) MemoryFootprint() map[string]int {
+	result := make(map[string]int, len(s.sets))
+
+	for setID, qs := range s.sets {
+		result[setID] = qs.footprint(setID)
+	}
+
+	return result
+}
+
+func (qs QuerySet) footprint(setID string) int {
+	n := stringSize(setID) + int(unsafe.Sizeof(qs)) + mapEntryOverhead + qs.meta.footprint()
+
+	for id, q := range qs.queries {
+		n += stringSize(id) + mapEntryOverhead + q.footprint()
+	}
+
+	return n
+}
+
+func (q query) footprint() int {
+	n := int(unsafe.Sizeof(q)) + len(q.sql) + len(q.hints) +
+		len(q.meta.Command) + len(q.meta.SoftDeleteTable) + len(q.meta.Route) + len(q.meta.SpanName) +
+		len(q.source.File)
+
+	for _, param := range slices.Concat(q.params, q.returns) {
+		n += int(unsafe.Sizeof(param)) + len(param.Name) + len(param.Type)
+	}
+
+	if q.meta.Retry != nil {
+		n += int(unsafe.Sizeof(*q.meta.Retry))
+		for _, value := range q.meta.Retry.On {
+			n += stringSize(value)
+		}
+	}
+
+	for _, value := range slices.Concat(q.meta.Sortable, q.meta.Tags, q.meta.Aliases, q.meta.Requires,
+		q.meta.Capabilities) {
+		n += stringSize(value)
+	}
+
+	return n
+}
+
+func (m QuerySetMeta) footprint() int {
+	n := len(m.ID) + len(m.Name) + len(m.Description) + len(m.Owner) + len(m.Dialect)
+
+	for _, tag := range m.Tags {
+		n += stringSize(tag)
+	}
+
+	for _, values := range []map[string]string{m.NameI18n, m.DescriptionI18n} {
+		for lang, value := range values {
+			n += stringSize(lang) + stringSize(value) + mapEntryOverhead
+		}
+	}
+
+	return n
+}
+
+// stringSize returns the size of a string header and its bytes.
+func stringSize(s string) int {
+	return int(unsafe.Sizeof(s)) + len(s)
+}
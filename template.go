@@ -0,0 +1,84 @@
+package sqlset
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// templateExt is the extension of files whose queries are templates.
+const templateExt = ".sql.tmpl"
+
+// compileTemplates parses the queries of a template set with text/template,
+// with the standard functions for the set dialect and those of WithTemplateFuncs.
+// Sets are templates when loaded from a .sql.tmpl file or marked with
+// "template": true in the metadata.
+func compileTemplates(cfg *config, qs *QuerySet) error {
+	if !qs.meta.Template {
+		return nil
+	}
+
+	funcs := templateFuncs(qs.meta.Dialect)
+	if cfg.contextKeys != nil {
+		funcs["ctx"] = contextRefFunc(cfg.contextKeys)
+	}
+
+	for name, fn := range cfg.templateFuncs {
+		funcs[name] = fn
+	}
+
+	for id, q := range qs.queries {
+		tmpl, err := template.New(id).Option("missingkey=error").Funcs(funcs).Parse(q.sql)
+		if err != nil {
+			return fmt.Errorf("line %d: %w: template %s: %s", q.source.Line, ErrInvalidSyntax, id, err.Error())
+		}
+
+		q.tmpl = tmpl
+		qs.queries[id] = q
+	}
+
+	return nil
+}
+
+// GetRendered returns a template query rendered with data, then rewritten as with Get.
+// Template queries are the queries of .sql.tmpl files and of sets marked with
+// "template": true in the metadata; they can only be fetched with GetRendered,
+// Get and its variants return ErrTemplateQuery for them. Other queries are returned as is.
+//
+//	sql, err := sqlSet.GetRendered(map[string]any{"Archived": true}, "reports", "ListOrders")
+func (s *SQLSet) GetRendered(data any, ids ...string) (string, error) {
+	return s.GetRenderedContext(context.Background(), data, ids...)
+}
+
+// GetRenderedContext is like GetRendered but passes ctx to the rewriters, see WithRewriter.
+func (s *SQLSet) GetRenderedContext(ctx context.Context, data any, ids ...string) (string, error) {
+	key, q, err := s.lookup(ids...)
+	if err != nil {
+		return "", err
+	}
+
+	sql := q.sql
+
+	if q.tmpl != nil {
+		var sb strings.Builder
+
+		if err := q.tmpl.Execute(&sb, data); err != nil {
+			return "", fmt.Errorf("%s: render: %w", key, err)
+		}
+
+		sql = sb.String()
+	}
+
+	return s.apply(ctx, key, q, sql)
+}
+
+// IsTemplate reports whether the query is a template, see GetRendered.
+func (s *SQLSet) IsTemplate(setID, queryID string) (bool, error) {
+	q, err := s.findQuery(setID, queryID)
+	if err != nil {
+		return false, err
+	}
+
+	return q.tmpl != nil, nil
+}
@@ -0,0 +1,176 @@
+package sqlset_test
+
+import (
+	"testing"
+	"testing/fstest"
+	"text/template"
+
+	"github.com/istovpets/sqlset"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetRendered(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"reports.sql.tmpl": {Data: []byte(
+			"--SQL:ListOrders\nSELECT * FROM orders\n{{if .Archived}}WHERE archived{{end}}\n--end\n",
+		)},
+		"flagged.sql": {Data: []byte(
+			"--META: {\"template\": true}\n--SQL:Count\nSELECT count(*) FROM {{.Table}}\n--end\n",
+		)},
+		"users.sql": {Data: []byte("--SQL:GetUser\nSELECT 1;\n--end\n")},
+	}
+
+	sets, err := sqlset.New(fsys)
+	require.NoError(t, err)
+
+	sql, err := sets.GetRendered(map[string]any{"Archived": true}, "reports", "ListOrders")
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM orders\r\nWHERE archived", sql)
+
+	sql, err = sets.GetRendered(map[string]any{"Table": "users"}, "flagged.Count")
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT count(*) FROM users", sql)
+
+	sql, err = sets.GetRendered(nil, "users", "GetUser")
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT 1;", sql)
+
+	_, err = sets.GetRendered(map[string]any{}, "flagged", "Count")
+	require.Error(t, err)
+
+	_, err = sets.Get("reports", "ListOrders")
+	require.ErrorIs(t, err, sqlset.ErrTemplateQuery)
+
+	_, ok := sets.TryGet("reports", "ListOrders")
+	assert.False(t, ok)
+
+	isTemplate, err := sets.IsTemplate("reports", "ListOrders")
+	require.NoError(t, err)
+	assert.True(t, isTemplate)
+
+	metas := sets.GetSetsMetas()
+	for _, meta := range metas {
+		assert.Equal(t, meta.ID != "users", meta.Template, meta.ID)
+	}
+
+	bundle, err := sets.MarshalBundle()
+	require.NoError(t, err)
+
+	loaded, err := sqlset.NewFromBundle(bundle)
+	require.NoError(t, err)
+
+	sql, err = loaded.Freeze().GetRendered(map[string]any{"Archived": false}, "reports", "ListOrders")
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM orders\r\n", sql)
+}
+
+func TestTemplateInvalidSyntax(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"reports.sql.tmpl": {Data: []byte("--SQL:ListOrders\nSELECT {{if .A}}\n--end\n")},
+	}
+
+	_, err := sqlset.New(fsys)
+	require.ErrorIs(t, err, sqlset.ErrInvalidSyntax)
+	assert.Contains(t, err.Error(), "line 1")
+}
+
+func TestTemplateFuncs(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"_meta.json": {Data: []byte(`{"dialect": "mysql"}`)},
+		"pg/events.sql.tmpl": {Data: []byte(`--META: {"dialect": "postgres"}
+--SQL:List
+SELECT {{ident "order"}}, {{now}} FROM {{ident .Table}} WHERE id IN ({{csv .IDs}}) AND name = {{literal .Name}}
+--end
+
+--SQL:Insert
+INSERT INTO {{tenantSchema .Tenant}}.events VALUES ({{placeholders 3}}), ({{placeholders 3 4}})
+--end
+`)},
+		"reports.sql.tmpl": {Data: []byte(`--SQL:List
+SELECT {{ident "order"}}, {{now}}, {{now "sqlite"}} FROM t WHERE id IN ({{placeholders 2}})
+--end
+
+--SQL:Search
+SELECT * FROM t WHERE name = {{literal .Search}} OR tag IN ({{csv .Tags}})
+--end
+`)},
+		"mssql.sql.tmpl": {Data: []byte(`--META: {"dialect": "sqlserver"}
+--SQL:List
+SELECT {{ident "a]b"}} FROM t WHERE id = {{placeholders 1}}
+--end
+`)},
+	}
+
+	funcs := template.FuncMap{
+		"tenantSchema": func(tenant string) string { return "tenant_" + tenant },
+	}
+
+	sqlSet, err := sqlset.New(fsys, sqlset.WithTemplateFuncs(funcs))
+	require.NoError(t, err)
+
+	data := map[string]any{
+		"Table": "public.users", "IDs": []int{1, 2}, "Name": "O'Brien", "Tenant": "acme",
+		"Search": `\' OR 1=1 -- `, "Tags": []string{`a\`, "b"},
+	}
+
+	tests := []struct {
+		key      string
+		expected string
+	}{
+		{
+			key:      "events.List",
+			expected: `SELECT "order", now() FROM "public"."users" WHERE id IN (1, 2) AND name = 'O''Brien'`,
+		},
+		{
+			key:      "events.Insert",
+			expected: "INSERT INTO tenant_acme.events VALUES ($1, $2, $3), ($4, $5, $6)",
+		},
+		{
+			key:      "reports.List",
+			expected: "SELECT `order`, NOW(), CURRENT_TIMESTAMP FROM t WHERE id IN (?, ?)",
+		},
+		{
+			key:      "reports.Search",
+			expected: `SELECT * FROM t WHERE name = '\\'' OR 1=1 -- ' OR tag IN ('a\\', 'b')`,
+		},
+		{
+			key:      "mssql.List",
+			expected: "SELECT [a]]b] FROM t WHERE id = @p1",
+		},
+	}
+
+	for _, test := range tests {
+		sql, err := sqlSet.GetRendered(data, test.key)
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, sql, test.key)
+	}
+
+	_, err = sqlSet.GetRendered(map[string]any{"Table": "t", "IDs": []int{}, "Name": nil}, "events.List")
+	require.ErrorIs(t, err, sqlset.ErrArgumentEmpty)
+
+	// Without the custom function, the query does not compile.
+	_, err = sqlset.New(fsys)
+	require.ErrorIs(t, err, sqlset.ErrInvalidSyntax)
+	assert.Contains(t, err.Error(), `function "tenantSchema" not defined`)
+
+	// The bundled templates are compiled with the custom functions too.
+	bundle, err := sqlSet.MarshalBundle()
+	require.NoError(t, err)
+
+	_, err = sqlset.NewFromBundle(bundle)
+	require.ErrorIs(t, err, sqlset.ErrInvalidBundle)
+
+	loaded, err := sqlset.NewFromBundle(bundle, sqlset.WithTemplateFuncs(funcs))
+	require.NoError(t, err)
+
+	sql, err := loaded.GetRendered(data, "mssql.List")
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT [a]]b] FROM t WHERE id = @p1", sql)
+}
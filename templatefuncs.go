@@ -0,0 +1,188 @@
+package sqlset
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// WithTemplateFuncs adds functions to the template queries, see GetRendered.
+// They extend the standard functions, replacing those of the same name:
+//
+//	sqlset.New(fsys, sqlset.WithTemplateFuncs(template.FuncMap{
+//		"tenantSchema": func(tenant string) string { return "tenant_" + tenant },
+//	}))
+func WithTemplateFuncs(funcs template.FuncMap) Option {
+	return func(cfg *config) {
+		if cfg.templateFuncs == nil {
+			cfg.templateFuncs = make(template.FuncMap, len(funcs))
+		}
+
+		for name, fn := range funcs {
+			cfg.templateFuncs[name] = fn
+		}
+	}
+}
+
+// templateFuncs returns the standard template functions for the dialect of a set,
+// DialectPostgres if empty:
+//
+//   - ident quotes an identifier, e.g. "order" or `order`, each part of a qualified one.
+//   - literal renders a value as an SQL literal: quoted strings, numbers, TRUE/FALSE and NULL.
+//     The strings double their quotes, and their backslashes for MySQL, which treats them as escapes.
+//   - csv renders the elements of a slice as comma-separated literals, e.g. for IN lists.
+//   - placeholders renders n comma-separated placeholders, numbered from an optional start.
+//   - now renders the current timestamp function of the set dialect or of the given one.
+func templateFuncs(dialect Dialect) template.FuncMap {
+	return template.FuncMap{
+		"ident": func(name string) string {
+			return quoteIdent(dialect, name)
+		},
+		"literal": func(v any) (string, error) {
+			return sqlLiteral(dialect, v)
+		},
+		"csv": func(values any) (string, error) {
+			return csvLiterals(dialect, values)
+		},
+		"placeholders": func(n int, start ...int) (string, error) {
+			return placeholderList(dialect, n, start...)
+		},
+		"now": func(d ...Dialect) string {
+			if len(d) > 0 {
+				return nowFunc(d[0])
+			}
+
+			return nowFunc(dialect)
+		},
+	}
+}
+
+// quoteIdent quotes every part of a possibly qualified identifier, doubling the quotes inside.
+func quoteIdent(dialect Dialect, name string) string {
+	open, closing := `"`, `"`
+
+	switch dialect {
+	case DialectMySQL:
+		open, closing = "`", "`"
+	case DialectSQLServer:
+		open, closing = "[", "]"
+	}
+
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = open + strings.ReplaceAll(part, closing, closing+closing) + closing
+	}
+
+	return strings.Join(parts, ".")
+}
+
+// sqlLiteral renders v as an SQL literal of the dialect.
+func sqlLiteral(dialect Dialect, v any) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "NULL", nil
+	case string:
+		if dialect == DialectMySQL {
+			v = strings.ReplaceAll(v, `\`, `\\`)
+		}
+
+		return "'" + strings.ReplaceAll(v, "'", "''") + "'", nil
+	case bool:
+		if v {
+			return "TRUE", nil
+		}
+
+		return "FALSE", nil
+	case time.Time:
+		return "'" + v.Format(time.RFC3339Nano) + "'", nil
+	case fmt.Stringer:
+		return sqlLiteral(dialect, v.String())
+	}
+
+	rv := reflect.ValueOf(v)
+
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'g', -1, 64), nil
+	case reflect.String:
+		return sqlLiteral(dialect, rv.String())
+	case reflect.Bool:
+		return sqlLiteral(dialect, rv.Bool())
+	default:
+		return "", fmt.Errorf("literal: unsupported type %T", v)
+	}
+}
+
+// csvLiterals renders the elements of a slice or array as comma-separated literals of the dialect.
+func csvLiterals(dialect Dialect, values any) (string, error) {
+	rv := reflect.ValueOf(values)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return "", fmt.Errorf("csv: %T is not a slice", values)
+	}
+
+	if rv.Len() == 0 {
+		return "", fmt.Errorf("csv: %w", ErrArgumentEmpty)
+	}
+
+	literals := make([]string, rv.Len())
+
+	for i := range rv.Len() {
+		lit, err := sqlLiteral(dialect, rv.Index(i).Interface())
+		if err != nil {
+			return "", fmt.Errorf("csv: %w", err)
+		}
+
+		literals[i] = lit
+	}
+
+	return strings.Join(literals, ", "), nil
+}
+
+// placeholderList renders n placeholders of the dialect: $1, $2 for PostgreSQL, @p1, @p2
+// for SQL Server and ?, ? otherwise.
+func placeholderList(dialect Dialect, n int, start ...int) (string, error) {
+	first := 1
+	if len(start) > 0 {
+		first = start[0]
+	}
+
+	if n < 1 || first < 1 {
+		return "", fmt.Errorf("placeholders: %d from %d: %w", n, first, ErrInvalidArgCount)
+	}
+
+	parts := make([]string, n)
+
+	for i := range parts {
+		switch dialect {
+		case "", DialectPostgres:
+			parts[i] = "$" + strconv.Itoa(first+i)
+		case DialectSQLServer:
+			parts[i] = "@p" + strconv.Itoa(first+i)
+		default:
+			parts[i] = "?"
+		}
+	}
+
+	return strings.Join(parts, ", "), nil
+}
+
+// nowFunc returns the current timestamp function of the dialect.
+func nowFunc(dialect Dialect) string {
+	switch dialect {
+	case DialectMySQL:
+		return "NOW()"
+	case DialectSQLite:
+		return "CURRENT_TIMESTAMP"
+	case DialectSQLServer:
+		return "SYSDATETIME()"
+	default:
+		return "now()"
+	}
+}
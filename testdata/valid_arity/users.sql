@@ -0,0 +1,25 @@
+--SQL:Declared
+--PARAMS: id int, name text
+SELECT id FROM users WHERE id = $1 AND name = $2 AND id = $1;
+--end
+
+--SQL:Positional
+SELECT id::text, '$9', $$ $8 $$ FROM users WHERE id = $2 OR id = $1; -- $7
+--end
+
+--SQL:Named
+--PARAMS: id, name
+SELECT id FROM users WHERE id = :id AND (name = :name OR :name IS NULL) AND created_at > '12:30';
+--end
+
+--SQL:Anonymous
+SELECT id FROM users WHERE id = ? AND name = ? /* ? */;
+--end
+
+--SQL:JSONBOperator
+SELECT id FROM users WHERE attrs ? 'admin' AND id = $1;
+--end
+
+--SQL:NoArgs
+SELECT count(*) FROM users;
+--end
\ No newline at end of file
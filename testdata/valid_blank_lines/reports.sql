@@ -0,0 +1,20 @@
+--SQL:Totals
+
+WITH paid AS (
+    SELECT user_id, sum(amount) AS total FROM payments GROUP BY user_id
+),
+
+refunded AS (
+    SELECT user_id, sum(amount) AS total FROM refunds GROUP BY user_id
+)
+
+SELECT p.user_id, p.total - coalesce(r.total, 0)
+FROM paid p LEFT JOIN refunded r USING (user_id);
+
+--end
+
+--SQL:Literal
+SELECT 'first
+
+third';
+--end
\ No newline at end of file
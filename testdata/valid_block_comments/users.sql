@@ -0,0 +1,16 @@
+/* Disabled for now:
+--SQL:DeleteUser
+DELETE FROM users WHERE id = $1;
+--end
+*/
+
+--SQL:GetUser
+SELECT id /* , name
+--end */ FROM users;
+--end
+
+--SQL:ListUsers
+/* outer /* nested */
+--SQL:Hidden
+*/ SELECT '/*' AS open FROM users; -- /*
+--end
\ No newline at end of file
@@ -0,0 +1,15 @@
+-- META
+{"name": "Users"}
+
+-- name: create-users-table
+CREATE TABLE users (id int, name text);
+
+--name: GetUser
+--HINTS: /*+ IndexScan(users) */
+-- end of the story: this line is a comment
+SELECT id, name
+FROM users
+WHERE id = $1;
+
+-- name: ListUsers
+SELECT id, name FROM users;
\ No newline at end of file
@@ -0,0 +1,18 @@
+--SQL:ListUsers
+--when: new_search
+SELECT * FROM users WHERE search @@ $1;
+--end
+
+--SQL:ListUsers
+--when: !new_search
+SELECT * FROM users WHERE name ILIKE $1;
+--end
+
+--SQL:Experimental
+--when: new_search, beta
+SELECT ${undefined};
+--end
+
+--SQL:GetUser
+SELECT * FROM users WHERE id = $1;
+--end
\ No newline at end of file
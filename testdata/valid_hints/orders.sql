@@ -0,0 +1,19 @@
+--SQL:ListOrders
+--HINTS
+/*+
+  SeqScan(orders)
+*/
+--end
+SELECT * FROM orders;
+--end
+
+--SQL:GetOrder
+--HINTS: /*+ IndexScan(orders) */
+SELECT * FROM orders WHERE id = $1;
+--end
+
+--SQL:CountOrders
+--HINTS_OLD: /*+ SeqScan(orders) */
+--HINTSabc
+SELECT count(*) FROM orders;
+--end
\ No newline at end of file
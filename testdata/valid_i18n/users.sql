@@ -0,0 +1,12 @@
+--META
+{
+    "name": "Users",
+    "description": "User management",
+    "name_i18n": {"de": "Benutzer", "fr": "Utilisateurs"},
+    "description_i18n": {"de": "Benutzerverwaltung"}
+}
+--end
+
+--SQL:GetUser
+SELECT id FROM users WHERE id = $1;
+--end
@@ -0,0 +1,19 @@
+--META: {"name": "Authors"}
+
+--SQL:GetAuthor
+--META: {"command": "one"}
+SELECT * FROM authors WHERE id = $1;
+--end
+
+--SQL:ListAuthors
+--META
+{
+    "command": "many"
+}
+--end
+SELECT * FROM authors;
+--end
+
+--SQL:DeleteAuthor
+DELETE FROM authors WHERE id = $1;
+--end
\ No newline at end of file
@@ -0,0 +1,24 @@
+--SQL:CreateTouch
+CREATE FUNCTION touch() RETURNS trigger AS $$
+BEGIN
+-- keep the update time
+--end of the comment
+NEW.updated_at := now();
+RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+--end
+
+--SQL:CreateTagged
+CREATE FUNCTION one() RETURNS int AS $body$
+SELECT 1; -- $$
+--end
+$body$ LANGUAGE sql;
+--end
+
+--SQL:Literal
+SELECT 'it''s
+--end
+multi-line', "odd""
+--SQL:name";
+--end
\ No newline at end of file
@@ -0,0 +1,16 @@
+--SQLRAW:CreateAudit
+CREATE FUNCTION audit() RETURNS trigger AS $$
+    BEGIN
+        -- comments are kept
+        --SQL:NotADirective
+
+        INSERT INTO audit_log VALUES (NEW.*); -- '
+        RETURN NEW;
+    --end is not the end line
+    END;
+$$ LANGUAGE plpgsql;
+--end
+
+--SQL:CountAudit
+SELECT count(*) FROM audit_log;
+--end
\ No newline at end of file
@@ -0,0 +1,73 @@
+package sqlset
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is the version of the library, checked against the min_sqlset_version
+// of query packs, see ReadPack.
+const Version = "1.0.0"
+
+// versionLess reports whether the semantic version a is older than b. The versions are
+// MAJOR.MINOR.PATCH with an optional "v" prefix and pre-release suffix, a pre-release
+// being older than its release. Missing MINOR and PATCH components are 0.
+func versionLess(a, b string) (bool, error) {
+	va, err := parseVersion(a)
+	if err != nil {
+		return false, err
+	}
+
+	vb, err := parseVersion(b)
+	if err != nil {
+		return false, err
+	}
+
+	for i := range va.core {
+		if va.core[i] != vb.core[i] {
+			return va.core[i] < vb.core[i], nil
+		}
+	}
+
+	switch {
+	case va.pre == vb.pre:
+		return false, nil
+	case va.pre == "":
+		return false, nil
+	case vb.pre == "":
+		return true, nil
+	default:
+		return va.pre < vb.pre, nil
+	}
+}
+
+type semver struct {
+	core [3]int
+	pre  string
+}
+
+func parseVersion(s string) (semver, error) {
+	var v semver
+
+	// The build metadata is ignored.
+	release, _, _ := strings.Cut(strings.TrimPrefix(s, "v"), "+")
+	core, pre, _ := strings.Cut(release, "-")
+	v.pre = pre
+
+	parts := strings.Split(core, ".")
+	if len(parts) > len(v.core) {
+		return semver{}, fmt.Errorf("invalid version %q", s)
+	}
+
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return semver{}, fmt.Errorf("invalid version %q", s)
+		}
+
+		v.core[i] = n
+	}
+
+	return v, nil
+}
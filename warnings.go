@@ -0,0 +1,55 @@
+package sqlset
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// todoMarker matches the markers of unfinished queries.
+var todoMarker = regexp.MustCompile(`\b(TODO|FIXME|XXX)\b`)
+
+// Warning is a non-fatal issue found while loading the files
+// or the use of a deprecated query alias, see WithWarningHandler.
+type Warning struct {
+	// File is the path of the file within the loaded file system.
+	File string
+	// Line is the line of the issue, starting at 1.
+	Line    int
+	Message string
+}
+
+// String returns the warning in the "file:line: message" form.
+func (w Warning) String() string {
+	return fmt.Sprintf("%s:%d: %s", w.File, w.Line, w.Message)
+}
+
+// WithWarningHandler makes New report the issues that do not fail loading to handle:
+// content outside of blocks, which is ignored, queries with an empty body, e.g. commented out,
+// and TODO, FIXME or XXX markers. CI can fail on warnings while production ignores them.
+// The set keeps the handler to report every lookup by a deprecated query alias,
+// so it must be safe for concurrent use:
+//
+//	var (
+//		mu       sync.Mutex
+//		warnings []sqlset.Warning
+//	)
+//
+//	_, err := sqlset.New(fsys, sqlset.WithWarningHandler(func(w sqlset.Warning) {
+//		mu.Lock()
+//		defer mu.Unlock()
+//
+//		warnings = append(warnings, w)
+//	}))
+func WithWarningHandler(handle func(Warning)) Option {
+	return func(cfg *config) {
+		cfg.warnings = handle
+	}
+}
+
+func (cfg *config) warn(file string, line int, format string, args ...any) {
+	if cfg.warnings == nil {
+		return
+	}
+
+	cfg.warnings(Warning{File: file, Line: line, Message: fmt.Sprintf(format, args...)})
+}